@@ -0,0 +1,279 @@
+package main
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// adminConfig holds state for the admin UI; credentials come from the "admin" auth rule
+type adminConfig struct {
+	configPath string // local config file the running config was loaded from, which settings are persisted to (optional)
+}
+
+// adminTemplate renders all admin pages; the page field selects the content block
+var adminTemplate = template.Must(template.New("admin").Funcs(template.FuncMap{
+	"age":   calculatePostAge,
+	"lines": func(values []string) string { return strings.Join(values, "\n") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>hntop-rss admin</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; margin: 24px; color: #333; }
+nav a { margin-right: 12px; color: #ff6600; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #e5e5e5; vertical-align: top; }
+textarea { width: 100%; font-family: monospace; }
+.notice { padding: 8px; background: #f6f6ef; border-left: 4px solid #ff6600; }
+</style>
+</head>
+<body>
+<nav><a href="/admin">Items &amp; settings</a><a href="/admin/opengraph">OpenGraph cache</a></nav>
+{{if .Notice}}<p class="notice">{{.Notice}}</p>{{end}}
+{{if eq .Page "opengraph"}}
+<h2>OpenGraph cache</h2>
+<table>
+<tr><th>URL</th><th>Title</th><th>Success</th><th>Fetched</th><th>Expires</th></tr>
+{{range .OpenGraph}}<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{.Title}}</td><td>{{if .FetchSuccess}}true{{else}}false{{with .ErrorClass}} ({{.}}){{end}}{{end}}</td><td>{{.FetchedAt.Format "2006-01-02 15:04"}}</td><td>{{.ExpiresAt.Format "2006-01-02 15:04"}}</td></tr>
+{{end}}</table>
+{{else}}
+<h2>Refresh</h2>
+<form method="post" action="/admin/refresh"><button type="submit">Fetch front page and update stats now</button></form>
+
+<h2>Settings</h2>
+<form method="post" action="/admin/settings">
+<p><label>Minimum points <input type="number" name="min_points" value="{{.Filter.MinPoints}}" min="0"></label>
+<label>Item limit <input type="number" name="limit" value="{{.Filter.Limit}}" min="1" max="100"></label>
+<em>These come from -min-points and -limit and reset on restart.</em></p>
+<p><label>Flamewar ratio <input type="number" name="flamewar_ratio" value="{{.Config.Flamewar.Ratio}}" step="0.1" min="0"></label>
+<label><input type="checkbox" name="flamewar_exclude" {{if .Config.Flamewar.Exclude}}checked{{end}}> Exclude flamewars</label></p>
+<p><label>Watched entities (one <code>Name = pattern</code> per line)<br>
+<textarea name="entities" rows="8">{{.Entities}}</textarea></label></p>
+<p><label>Watchlist (one keyword or regular expression per line)<br>
+<textarea name="watchlist" rows="4">{{lines .Config.Watchlist}}</textarea></label></p>
+<p><label>Blocked domains (one per line)<br>
+<textarea name="blocked_domains" rows="4">{{lines .Config.BlockedDomains}}</textarea></label></p>
+<p><label>Blocked title keywords (one per line)<br>
+<textarea name="blocked_keywords" rows="4">{{lines .Config.BlockedKeywords}}</textarea></label></p>
+<p><button type="submit">Save</button>{{if not .Persisted}} <em>Changes are kept in memory only; they are saved only when the configuration was loaded from a local file (-config).</em>{{end}}</p>
+</form>
+
+<h2>Current items</h2>
+<table>
+<tr><th>Title</th><th>Points</th><th>Comments</th><th>Author</th><th>Age</th></tr>
+{{range .Items}}<tr><td><a href="{{.CommentsLink}}">{{.Title}}</a></td><td>{{.Points}}</td><td>{{.CommentCount}}</td><td>{{.Author}}</td><td>{{age .CreatedAt}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>`))
+
+// adminPage is the data passed to adminTemplate
+type adminPage struct {
+	Page      string
+	Notice    string
+	Filter    ItemFilter
+	Config    DomainConfig
+	Entities  string
+	Persisted bool
+	Items     []HackerNewsItem
+	OpenGraph []OpenGraphCache
+}
+
+// registerAdminRoutes adds the authenticated admin UI routes to the mux.
+// The routes are never registered without an admin auth rule.
+func (s *feedServer) registerAdminRoutes(mux *http.ServeMux) {
+	if _, ok := s.auth["admin"]; !ok {
+		slog.Warn("Admin UI requires an admin auth rule, not enabling it")
+		return
+	}
+
+	mux.Handle("GET /admin", compressHandler(s.requireAdmin(http.HandlerFunc(s.handleAdmin))))
+	mux.Handle("GET /admin/opengraph", compressHandler(s.requireAdmin(http.HandlerFunc(s.handleAdminOpenGraph))))
+	mux.Handle("POST /admin/settings", compressHandler(s.requireAdmin(http.HandlerFunc(s.handleAdminSettings))))
+	mux.Handle("POST /admin/refresh", compressHandler(s.requireAdmin(http.HandlerFunc(s.handleAdminRefresh))))
+}
+
+// requireAdmin enforces the admin auth rule and rejects form posts that don't come from the admin UI
+func (s *feedServer) requireAdmin(next http.Handler) http.Handler {
+	return s.auth.requireAuth("admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && !sameOriginPost(r) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// sameOriginPost reports whether a POST names this host as its origin. Browsers send Origin on
+// form posts, and Referer when Origin is missing; a request with neither can't be shown to come
+// from the admin UI, so it is rejected too.
+func sameOriginPost(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" || source == "null" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false
+	}
+	u, err := url.Parse(source)
+	return err == nil && u.Host != "" && u.Host == r.Host
+}
+
+// renderAdmin executes the admin template
+func renderAdmin(w http.ResponseWriter, page adminPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := adminTemplate.Execute(w, page); err != nil {
+		slog.Error("Failed to render admin page", "error", err)
+	}
+}
+
+// handleAdmin shows current items and editable settings
+func (s *feedServer) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	filter, categoryMapper := s.settings()
+	config := categoryMapper.Config()
+	items, err := getFilteredItems(s.db, filter)
+	if err != nil {
+		slog.Error("Failed to load items", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	renderAdmin(w, adminPage{
+		Page:      "items",
+		Notice:    r.URL.Query().Get("notice"),
+		Filter:    filter,
+		Config:    config,
+		Entities:  formatEntities(config.Entities),
+		Persisted: s.admin.configPath != "",
+		Items:     items,
+	})
+}
+
+// handleAdminOpenGraph lists OpenGraph cache entries
+func (s *feedServer) handleAdminOpenGraph(w http.ResponseWriter, r *http.Request) {
+	entries, err := listOpenGraphCache(s.db, 200)
+	if err != nil {
+		slog.Error("Failed to list OpenGraph cache", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	renderAdmin(w, adminPage{Page: "opengraph", OpenGraph: entries})
+}
+
+// handleAdminSettings updates thresholds, watch settings and blocklists, persisting all but the
+// flag-based thresholds if a config file is in use
+func (s *feedServer) handleAdminSettings(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	filter, categoryMapper := s.settings()
+	config := categoryMapper.Config()
+
+	minPoints, err := strconv.Atoi(r.FormValue("min_points"))
+	if err != nil || minPoints < 0 {
+		http.Error(w, "invalid min_points", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	ratio, err := strconv.ParseFloat(r.FormValue("flamewar_ratio"), 64)
+	if err != nil || ratio < 0 {
+		http.Error(w, "invalid flamewar_ratio", http.StatusBadRequest)
+		return
+	}
+
+	filter.MinPoints = minPoints
+	filter.Limit = limit
+	config.Flamewar = FlamewarConfig{Ratio: ratio, Exclude: r.FormValue("flamewar_exclude") != ""}
+	config.Entities = parseEntities(r.FormValue("entities"))
+	config.Watchlist = parseLines(r.FormValue("watchlist"))
+	config.BlockedDomains = parseLines(r.FormValue("blocked_domains"))
+	config.BlockedKeywords = parseLines(r.FormValue("blocked_keywords"))
+
+	notice := "Settings updated"
+	if s.admin.configPath != "" {
+		if err := saveConfigToFile(s.admin.configPath, &config); err != nil {
+			slog.Error("Failed to save config", "error", err, "path", s.admin.configPath)
+			notice = "Settings updated but could not be saved: " + err.Error()
+		} else {
+			// Thresholds come from flags, which the config file doesn't hold
+			notice = "Settings saved to " + s.admin.configPath + "; minimum points and item limit apply until restart"
+		}
+	}
+
+	s.updateSettings(filter, NewCategoryMapper(&config))
+	slog.Info("Admin settings updated", "minPoints", minPoints, "limit", limit, "entities", len(config.Entities))
+	http.Redirect(w, r, "/admin?notice="+url.QueryEscape(notice), http.StatusSeeOther)
+}
+
+// handleAdminRefresh starts a background fetch of the front page and stats update
+func (s *feedServer) handleAdminRefresh(w http.ResponseWriter, r *http.Request) {
+	if !s.refreshing.CompareAndSwap(false, true) {
+		http.Redirect(w, r, "/admin?notice="+url.QueryEscape("A refresh is already running"), http.StatusSeeOther)
+		return
+	}
+
+	go func() {
+		defer s.refreshing.Store(false)
+		slog.Info("Manual refresh started")
+		s.refresh("admin")
+		slog.Info("Manual refresh completed")
+	}()
+
+	http.Redirect(w, r, "/admin?notice="+url.QueryEscape("Refresh started"), http.StatusSeeOther)
+}
+
+// formatEntities renders entity configuration as "Name = pattern" lines
+func formatEntities(entities []EntityConfig) string {
+	var lines []string
+	for _, entity := range entities {
+		for _, pattern := range entity.Patterns {
+			lines = append(lines, entity.Name+" = "+pattern)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseLines returns the non-empty, trimmed lines of a textarea
+func parseLines(text string) []string {
+	var values []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+// parseEntities parses "Name = pattern" lines, merging patterns for the same name
+func parseEntities(text string) []EntityConfig {
+	var entities []EntityConfig
+	index := make(map[string]int)
+
+	for _, line := range strings.Split(text, "\n") {
+		name, pattern, ok := strings.Cut(line, "=")
+		name, pattern = strings.TrimSpace(name), strings.TrimSpace(pattern)
+		if !ok || name == "" || pattern == "" {
+			continue
+		}
+
+		if i, exists := index[name]; exists {
+			entities[i].Patterns = append(entities[i].Patterns, pattern)
+			continue
+		}
+		index[name] = len(entities)
+		entities = append(entities, EntityConfig{Name: name, Patterns: []string{pattern}})
+	}
+	return entities
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupAdminServer(t *testing.T) *feedServer {
+	server := setupTestServer(t)
+	server.auth["admin"] = authRule{scheme: "basic", username: "admin", password: "secret"}
+	server.admin = &adminConfig{configPath: filepath.Join(t.TempDir(), "config.json")}
+	return server
+}
+
+func adminRequest(method, target string, form url.Values) *http.Request {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	req.SetBasicAuth("admin", "secret")
+	if method == http.MethodPost {
+		req.Header.Set("Origin", "http://"+req.Host)
+	}
+	return req
+}
+
+func TestAdmin_RequiresAuth(t *testing.T) {
+	server := setupAdminServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.SetBasicAuth("admin", "wrong")
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with wrong password, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_DisabledWithoutConfig(t *testing.T) {
+	server := setupTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, adminRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when admin UI is disabled, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_ShowsItemsAndOpenGraph(t *testing.T) {
+	server := setupAdminServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, adminRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "GitHub Project") {
+		t.Error("Admin page should list current items")
+	}
+
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/opengraph", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "https://github.com/user/repo") {
+		t.Error("OpenGraph page should list cache entries")
+	}
+}
+
+func TestAdmin_UpdateSettings(t *testing.T) {
+	server := setupAdminServer(t)
+
+	form := url.Values{
+		"min_points":       {"100"},
+		"limit":            {"10"},
+		"flamewar_ratio":   {"2"},
+		"flamewar_exclude": {"on"},
+		"entities":         {"Rust = \\brust\\b\nRust = rust-lang\nBad line"},
+		"watchlist":        {"postgres\n\n  sqlite  "},
+		"blocked_domains":  {"wsj.com"},
+		"blocked_keywords": {"crypto\nweb3"},
+	}
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/settings", form))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("Expected redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	filter, mapper := server.settings()
+	if filter.MinPoints != 100 || filter.Limit != 10 {
+		t.Errorf("Expected updated thresholds, got %+v", filter)
+	}
+	if mapper.FlamewarRatio() != 2 || !mapper.ExcludeFlamewars() {
+		t.Error("Expected updated flamewar settings")
+	}
+	if len(mapper.MatchEntities("Rust is great", "")) != 1 {
+		t.Error("Expected updated entity watch list")
+	}
+	if len(mapper.MatchWatchlist("SQLite internals", "")) != 1 || mapper.DomainAllowed("https://wsj.com/x") || !mapper.TitleBlocked("Web3 is back") {
+		t.Error("Expected updated watchlist and blocklists")
+	}
+	if location := rec.Header().Get("Location"); !strings.Contains(location, "until+restart") {
+		t.Errorf("Expected the notice to name the runtime-only settings, got %s", location)
+	}
+
+	saved, err := loadConfigFromFile(server.admin.configPath)
+	if err != nil {
+		t.Fatalf("Expected settings to be persisted: %v", err)
+	}
+	if len(saved.Entities) != 1 || len(saved.Entities[0].Patterns) != 2 {
+		t.Errorf("Unexpected persisted entities: %+v", saved.Entities)
+	}
+	if len(saved.Watchlist) != 2 || saved.Watchlist[1] != "sqlite" || len(saved.BlockedDomains) != 1 || len(saved.BlockedKeywords) != 2 {
+		t.Errorf("Unexpected persisted lists: %+v %+v %+v", saved.Watchlist, saved.BlockedDomains, saved.BlockedKeywords)
+	}
+	if len(saved.CategoryDomains) == 0 {
+		t.Error("Persisted config should keep existing domain mappings")
+	}
+}
+
+func TestAdmin_RejectsInvalidAndCrossOrigin(t *testing.T) {
+	server := setupAdminServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/settings", url.Values{"min_points": {"x"}}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid form, got %d", rec.Code)
+	}
+
+	req := adminRequest(http.MethodPost, "/admin/refresh", url.Values{})
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for cross-origin post, got %d", rec.Code)
+	}
+
+	req = adminRequest(http.MethodPost, "/admin/refresh", url.Values{})
+	req.Header.Del("Origin")
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a post without Origin or Referer, got %d", rec.Code)
+	}
+
+	req = adminRequest(http.MethodPost, "/admin/refresh", url.Values{})
+	req.Header.Del("Origin")
+	req.Header.Set("Referer", "https://evil.example/admin")
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-origin Referer, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_AcceptsSameOriginReferer(t *testing.T) {
+	server := setupAdminServer(t)
+	server.refreshing.Store(true)
+
+	req := adminRequest(http.MethodPost, "/admin/refresh", url.Values{})
+	req.Header.Del("Origin")
+	req.Header.Set("Referer", "http://"+req.Host+"/admin")
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("Expected a post with a same-origin Referer to be accepted, got %d", rec.Code)
+	}
+}
+
+func TestLoadConfig_RecordsConfigFile(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"category_domains": {"Remote": ["example.com"]}}`))
+	}))
+	defer remote.Close()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"category_domains": {"Local": ["example.org"]}}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if file := LoadConfig(path, remote.URL).ConfigFile(); file != path {
+		t.Errorf("Expected config file %s, got %q", path, file)
+	}
+
+	// A local file that fails to parse falls back to the remote config, which must never be saved over it
+	if err := os.WriteFile(path, []byte(`{"category_domains": `), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if file := LoadConfig(path, remote.URL).ConfigFile(); file != "" {
+		t.Errorf("Expected no config file for a remote fallback, got %q", file)
+	}
+}
+
+func TestSaveConfigToFile_ReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := saveConfigToFile(path, &DomainConfig{Watchlist: []string{"rust"}}); err != nil {
+		t.Fatalf("saveConfigToFile failed: %v", err)
+	}
+	saved, err := loadConfigFromFile(path)
+	if err != nil || len(saved.Watchlist) != 1 {
+		t.Fatalf("Expected the saved config, got %+v, %v", saved, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode to be kept, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestAdmin_RefreshAlreadyRunning(t *testing.T) {
+	server := setupAdminServer(t)
+	server.refreshing.Store(true)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, adminRequest(http.MethodPost, "/admin/refresh", url.Values{}))
+	if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "already") {
+		t.Errorf("Expected redirect noting a running refresh, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestParseAndFormatEntities(t *testing.T) {
+	entities := parseEntities("PostgreSQL = postgres(ql)?\n\nRust = \\brust\\b\nPostgreSQL = pg_.*=x")
+	if len(entities) != 2 {
+		t.Fatalf("Expected 2 entities, got %d", len(entities))
+	}
+	if entities[0].Patterns[1] != "pg_.*=x" {
+		t.Errorf("Expected pattern with '=' to be preserved, got %q", entities[0].Patterns[1])
+	}
+
+	roundTrip := parseEntities(formatEntities(entities))
+	if len(roundTrip) != 2 || len(roundTrip[0].Patterns) != 2 {
+		t.Errorf("Expected entities to survive a format/parse round trip, got %+v", roundTrip)
+	}
+}
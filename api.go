@@ -11,17 +11,20 @@ import (
 	"time"
 )
 
-// fetchHackerNewsItems retrieves current front page items from Algolia API
-func fetchHackerNewsItems() []HackerNewsItem {
-	slog.Debug("Fetching Hacker News items from Algolia API")
-	res, err := http.Get("https://hn.algolia.com/api/v1/search_by_date?tags=front_page&hitsPerPage=100")
+// fetchHackerNewsItems retrieves the current items of the story set from Algolia API
+func fetchHackerNewsItems(fetch FetchConfig, timeout time.Duration) []HackerNewsItem {
+	slog.Debug("Fetching Hacker News items from Algolia API", "tags", fetch.Tags, "endpoint", fetch.Endpoint, "timeout", timeout)
+	client := &http.Client{Timeout: timeout}
+	res, err := client.Get(fetch.url())
 	if err != nil {
-		slog.Error("Failed to fetch Hacker News items", "error", err)
+		fetchErr := recordFetchError(fetchSourceAlgolia, err)
+		slog.Error("Failed to fetch Hacker News items", "error", err, "class", fetchErr.Class)
 		return nil
 	}
 	defer func() { _ = res.Body.Close() }()
 
 	if res.StatusCode != 200 {
+		recordFetchError(fetchSourceAlgolia, httpStatusError(res.StatusCode))
 		slog.Error("HTTP status code error", "code", res.StatusCode, "status", res.Status)
 		return nil
 	}
@@ -37,48 +40,61 @@ func fetchHackerNewsItems() []HackerNewsItem {
 	slog.Debug("Processing Algolia response", "hitCount", len(algoliaResp.Hits))
 
 	for _, hit := range algoliaResp.Hits {
-		// Parse the ISO 8601 timestamp
-		createdAt, err := time.Parse(time.RFC3339, hit.CreatedAt)
+		item, err := itemFromHit(hit, now)
 		if err != nil {
-			slog.Warn("Failed to parse timestamp, using current time", "error", err, "timestamp", hit.CreatedAt)
-			createdAt = now
+			slog.Warn("Skipping story", "hn_id", hit.ObjectID, "error", err)
+			continue
 		}
-
-		// Generate comments link from object ID
-		commentsLink := fmt.Sprintf("https://news.ycombinator.com/item?id=%s", hit.ObjectID)
-
-		// Use numeric fields directly
-		points := hit.Points
-		commentCount := hit.NumComments
-
 		slog.Debug("Found item",
-			"title", hit.Title,
-			"link", hit.URL,
-			"commentsLink", commentsLink,
-			"points", points,
-			"comments", commentCount,
-			"author", hit.Author,
-			"createdAt", createdAt)
-
-		items = append(items, HackerNewsItem{
-			ItemID:       hit.ObjectID,
-			Title:        hit.Title,
-			Link:         hit.URL,
-			CommentsLink: commentsLink,
-			Points:       points,
-			CommentCount: commentCount,
-			Author:       hit.Author,
-			CreatedAt:    createdAt,
-			UpdatedAt:    now,
-		})
+			"title", item.Title,
+			"link", item.Link,
+			"commentsLink", item.CommentsLink,
+			"points", item.Points,
+			"comments", item.CommentCount,
+			"author", item.Author,
+			"createdAt", item.CreatedAt)
+		items = append(items, item)
 	}
 
 	slog.Debug("Finished processing items", "totalItems", len(items))
 	return items
 }
 
+// itemFromHit converts an Algolia search hit into an item last updated at now. It fails for hits
+// with an invalid article URL, which callers leave out so they never reach the feed or the
+// OpenGraph cache; hits without a URL (Ask HN and the like) are fine.
+func itemFromHit(hit AlgoliaHit, now time.Time) (HackerNewsItem, error) {
+	// Parse the ISO 8601 timestamp
+	createdAt, err := time.Parse(time.RFC3339, hit.CreatedAt)
+	if err != nil {
+		slog.Warn("Failed to parse timestamp, using current time", "error", err, "timestamp", hit.CreatedAt)
+		createdAt = now
+	}
+
+	link := hit.URL
+	if link != "" {
+		link, err = normalizeArticleURL(link)
+		if err != nil {
+			return HackerNewsItem{}, fmt.Errorf("invalid article URL %q: %w", hit.URL, err)
+		}
+	}
+
+	return HackerNewsItem{
+		ItemID:       hit.ObjectID,
+		Title:        hit.Title,
+		Link:         link,
+		CommentsLink: fmt.Sprintf("https://news.ycombinator.com/item?id=%s", hit.ObjectID),
+		Points:       hit.Points,
+		CommentCount: hit.NumComments,
+		Author:       hit.Author,
+		CreatedAt:    createdAt,
+		UpdatedAt:    now,
+		Type:         storyTypeFromTags(hit.Tags),
+	}, nil
+}
+
 // updateItemStats updates item statistics using concurrent API calls to Algolia
-func updateItemStats(db *sql.DB, items []HackerNewsItem, recentlyUpdated map[string]bool) {
+func updateItemStats(db *sql.DB, items []HackerNewsItem, recentlyUpdated map[string]bool, timeout time.Duration) {
 	slog.Debug("Updating item stats", "itemCount", len(items))
 	skippedCount := 0
 
@@ -120,7 +136,10 @@ func updateItemStats(db *sql.DB, items []HackerNewsItem, recentlyUpdated map[str
 		go func() {
 			defer wg.Done()
 			for item := range workChan {
-				update := fetchItemStats(item.ItemID)
+				update := statsUpdate{itemID: item.ItemID, err: errPanicked}
+				safely("item stats", func() {
+					update = fetchItemStats(item.ItemID, timeout)
+				}, itemLogAttrs(item)...)
 				resultChan <- update
 			}
 		}()
@@ -140,17 +159,16 @@ func updateItemStats(db *sql.DB, items []HackerNewsItem, recentlyUpdated map[str
 
 	// Process results and update database
 	updatedCount := 0
-	deletedCount := 0
+	deadCount := 0
 	for update := range resultChan {
 		if update.err != nil {
 			if update.isDeadItem {
-				// Delete the dead item from database
-				_, err := db.Exec(`DELETE FROM items WHERE item_hn_id = ?`, update.itemID)
-				if err != nil {
-					slog.Warn("Failed to delete dead item from database", "error", err, "hn_id", update.itemID)
+				// Keep the dead item with its last-known stats for the graveyard feed
+				if err := markItemDead(db, update.itemID, time.Now()); err != nil {
+					slog.Warn("Failed to mark dead item", "error", err, "hn_id", update.itemID)
 				} else {
-					slog.Info("Deleted dead item from database", "hn_id", update.itemID)
-					deletedCount++
+					slog.Info("Marked item as dead", "hn_id", update.itemID)
+					deadCount++
 				}
 			} else {
 				slog.Warn("Failed to fetch item stats from Algolia", "error", update.err, "hn_id", update.itemID)
@@ -159,7 +177,7 @@ func updateItemStats(db *sql.DB, items []HackerNewsItem, recentlyUpdated map[str
 		}
 
 		// Update database with current stats
-		_, err := db.Exec(`
+		_, err := execWithRetry(db, `
 			UPDATE items SET 
 				points = ?, 
 				comment_count = ?, 
@@ -176,13 +194,13 @@ func updateItemStats(db *sql.DB, items []HackerNewsItem, recentlyUpdated map[str
 		updatedCount++
 	}
 
-	slog.Debug("Completed stats update", "updated", updatedCount, "deleted", deletedCount, "skipped", skippedCount)
+	slog.Debug("Completed stats update", "updated", updatedCount, "dead", deadCount, "skipped", skippedCount)
 }
 
 // fetchItemStats retrieves current statistics for a single item from Algolia API
-func fetchItemStats(itemID string) statsUpdate {
+func fetchItemStats(itemID string, timeout time.Duration) statsUpdate {
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Fetch current stats from Algolia API
@@ -194,21 +212,22 @@ func fetchItemStats(itemID string) statsUpdate {
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return statsUpdate{itemID: itemID, err: err}
+		return statsUpdate{itemID: itemID, err: recordFetchError(fetchSourceAlgolia, err)}
 	}
 	defer func() { _ = res.Body.Close() }()
 
 	if res.StatusCode == 429 {
 		slog.Error("Rate limit exceeded (429) from Algolia API", "hn_id", itemID)
-		return statsUpdate{itemID: itemID, err: fmt.Errorf("rate limit exceeded (429)")}
+		return statsUpdate{itemID: itemID, err: recordFetchError(fetchSourceAlgolia, httpStatusError(res.StatusCode))}
 	}
 
+	// A missing item is dead or flagged rather than a failed fetch
 	if res.StatusCode == 404 {
 		return statsUpdate{itemID: itemID, isDeadItem: true, err: fmt.Errorf("item not found (dead/flagged)")}
 	}
 
 	if res.StatusCode != 200 {
-		return statsUpdate{itemID: itemID, err: fmt.Errorf("HTTP error %d", res.StatusCode)}
+		return statsUpdate{itemID: itemID, err: recordFetchError(fetchSourceAlgolia, httpStatusError(res.StatusCode))}
 	}
 
 	var algoliaItem AlgoliaHit
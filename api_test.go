@@ -103,14 +103,24 @@ func TestAlgoliaResponse_JSONParsing(t *testing.T) {
 }
 
 func TestFetchHackerNewsItems_WithMockServer(t *testing.T) {
-	// Create mock response
-	mockResponse := createTestAlgoliaResponse()
-	server := createMockServer(mockResponse)
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(createTestAlgoliaResponse())
+	}))
 	defer server.Close()
+	original := algoliaAPIURL
+	algoliaAPIURL = server.URL
+	defer func() { algoliaAPIURL = original }()
 
-	// This test would require modifying fetchHackerNewsItems to accept a URL parameter
-	// For now, we'll test the transformation logic separately
-	t.Skip("This test requires refactoring fetchHackerNewsItems to be testable")
+	items := fetchHackerNewsItems(FetchConfig{Tags: "show_hn", Endpoint: "search"}, 5*time.Second)
+	if requested != "/search?hitsPerPage=100&tags=show_hn" {
+		t.Errorf("Unexpected request %q", requested)
+	}
+	if len(items) != 2 || items[0].ItemID != "12345" || items[1].Points != 120 {
+		t.Errorf("Unexpected items: %+v", items)
+	}
 }
 
 func TestHackerNewsItemTransformation(t *testing.T) {
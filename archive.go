@@ -0,0 +1,163 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultArchiveFinalAfterHours is the item age after which its stats are considered final
+const defaultArchiveFinalAfterHours = 48
+
+// ArchiveConfig enables the append-only JSON Lines archive of every item seen
+type ArchiveConfig struct {
+	Path            string `json:"path"`              // JSONL file to append to; empty disables the archive
+	FinalAfterHours int    `json:"final_after_hours"` // item age at which the final stats are recorded (default 48)
+}
+
+// archiveRecord is one line of the archive
+type archiveRecord struct {
+	Event        string    `json:"event"` // "first_seen" or "final"
+	ItemID       string    `json:"hn_id"`
+	Title        string    `json:"title"`
+	URL          string    `json:"url,omitempty"`
+	CommentsURL  string    `json:"comments_url"`
+	Author       string    `json:"author"`
+	Points       int       `json:"points"`
+	CommentCount int       `json:"comments"`
+	CreatedAt    time.Time `json:"created_at"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// newArchiveRecord returns the archive record of an item
+func newArchiveRecord(event string, item HackerNewsItem, now time.Time) archiveRecord {
+	return archiveRecord{
+		Event:        event,
+		ItemID:       item.ItemID,
+		Title:        item.Title,
+		URL:          item.Link,
+		CommentsURL:  item.CommentsLink,
+		Author:       item.Author,
+		Points:       item.Points,
+		CommentCount: item.CommentCount,
+		CreatedAt:    item.CreatedAt.UTC(),
+		RecordedAt:   now.UTC(),
+	}
+}
+
+// appendArchive appends records to a JSON Lines file in a single write
+func appendArchive(path string, records []archiveRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode archive record: %w", err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to append to archive: %w", err)
+	}
+	return f.Close()
+}
+
+// archiveItems appends first-seen records for front page items new to the archive and final
+// records for items old enough that their stats have settled. Records are appended before the
+// archive log is updated, so a failed run repeats records rather than losing them.
+func archiveItems(db *sql.DB, config ArchiveConfig, frontPage []HackerNewsItem, now time.Time) error {
+	if config.Path == "" {
+		return nil
+	}
+
+	var firstSeen []archiveRecord
+	for _, item := range frontPage {
+		archived, err := isArchived(db, item.ItemID)
+		if err != nil {
+			return err
+		}
+		if !archived {
+			firstSeen = append(firstSeen, newArchiveRecord("first_seen", item, now))
+		}
+	}
+	if err := appendArchive(config.Path, firstSeen); err != nil {
+		return err
+	}
+	for _, record := range firstSeen {
+		if _, err := execWithRetry(db, "INSERT INTO archive_log (item_hn_id, first_seen_at) VALUES (?, ?) ON CONFLICT(item_hn_id) DO NOTHING", record.ItemID, now); err != nil {
+			return fmt.Errorf("failed to record archived item: %w", err)
+		}
+	}
+
+	finalAfter := time.Duration(config.FinalAfterHours) * time.Hour
+	if finalAfter <= 0 {
+		finalAfter = defaultArchiveFinalAfterHours * time.Hour
+	}
+	items, err := getUnfinalizedItems(db, now.Add(-finalAfter))
+	if err != nil {
+		return err
+	}
+	final := make([]archiveRecord, 0, len(items))
+	for _, item := range items {
+		final = append(final, newArchiveRecord("final", item, now))
+	}
+	if err := appendArchive(config.Path, final); err != nil {
+		return err
+	}
+	for _, record := range final {
+		if _, err := execWithRetry(db, `
+			INSERT INTO archive_log (item_hn_id, final_at) VALUES (?, ?)
+			ON CONFLICT(item_hn_id) DO UPDATE SET final_at = excluded.final_at`, record.ItemID, now); err != nil {
+			return fmt.Errorf("failed to record archived item: %w", err)
+		}
+	}
+
+	if len(firstSeen) > 0 || len(final) > 0 {
+		slog.Info("Archived items", "path", config.Path, "first_seen", len(firstSeen), "final", len(final))
+	}
+	return nil
+}
+
+// isArchived reports whether an item already has a record in the archive
+func isArchived(db *sql.DB, itemID string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM archive_log WHERE item_hn_id = ?", itemID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check archive log: %w", err)
+	}
+	return count > 0, nil
+}
+
+// getUnfinalizedItems returns items created before cutoff that have no final archive record yet.
+// Items stored before the archive was enabled are included, so every stored item ends up archived.
+func getUnfinalizedItems(db *sql.DB, cutoff time.Time) ([]HackerNewsItem, error) {
+	rows, err := db.Query("SELECT "+itemColumns+` WHERE items.created_at < ?
+		AND items.item_hn_id NOT IN (SELECT item_hn_id FROM archive_log WHERE final_at IS NOT NULL)
+		ORDER BY items.created_at`, cutoff.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []HackerNewsItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readArchive returns the records in an archive file
+func readArchive(t *testing.T, path string) []archiveRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []archiveRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record archiveRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Invalid archive line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestArchiveItems(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	config := ArchiveConfig{Path: path, FinalAfterHours: 24}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	item := HackerNewsItem{ItemID: "7", Title: "New story", Link: "https://example.com/", CommentsLink: "https://news.ycombinator.com/item?id=7", Points: 60, CommentCount: 4, Author: "dave", CreatedAt: now.Add(-time.Hour), UpdatedAt: now}
+	updateStoredItems(db, []HackerNewsItem{item})
+
+	if err := archiveItems(db, config, []HackerNewsItem{item}, now); err != nil {
+		t.Fatalf("archiveItems failed: %v", err)
+	}
+	// Seeing the item again doesn't append another first-seen record
+	if err := archiveItems(db, config, []HackerNewsItem{item}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("archiveItems failed: %v", err)
+	}
+	records := readArchive(t, path)
+	if len(records) != 1 || records[0].Event != "first_seen" || records[0].Points != 60 || records[0].Author != "dave" {
+		t.Fatalf("Expected a single first-seen record, got %+v", records)
+	}
+
+	// Once the item is old enough, its final stats are appended exactly once
+	item.Points, item.CommentCount = 420, 99
+	updateStoredItems(db, []HackerNewsItem{item})
+	later := now.Add(26 * time.Hour)
+	if err := archiveItems(db, config, nil, later); err != nil {
+		t.Fatalf("archiveItems failed: %v", err)
+	}
+	if err := archiveItems(db, config, nil, later.Add(time.Hour)); err != nil {
+		t.Fatalf("archiveItems failed: %v", err)
+	}
+	records = readArchive(t, path)
+	if len(records) != 2 {
+		t.Fatalf("Expected first-seen and final records, got %+v", records)
+	}
+	final := records[1]
+	if final.Event != "final" || final.Points != 420 || final.CommentCount != 99 || !final.RecordedAt.Equal(later) {
+		t.Errorf("Unexpected final record %+v", final)
+	}
+}
+
+func TestArchiveItems_ExistingItemsGetFinalRecords(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	updateStoredItems(db, []HackerNewsItem{{ItemID: "1", Title: "Stored before the archive", Link: "https://example.com/", Points: 100, CreatedAt: now.Add(-72 * time.Hour), UpdatedAt: now}})
+
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	if err := archiveItems(db, ArchiveConfig{Path: path}, nil, now); err != nil {
+		t.Fatalf("archiveItems failed: %v", err)
+	}
+	records := readArchive(t, path)
+	if len(records) != 1 || records[0].Event != "final" || records[0].ItemID != "1" {
+		t.Errorf("Expected a final record for the existing item, got %+v", records)
+	}
+}
+
+func TestArchiveItems_Disabled(t *testing.T) {
+	if err := archiveItems(nil, ArchiveConfig{}, []HackerNewsItem{{ItemID: "1"}}, time.Now()); err != nil {
+		t.Errorf("Expected a disabled archive to do nothing, got %v", err)
+	}
+}
+
+func TestArchiveItems_WriteFailureKeepsItemsPending(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	item := HackerNewsItem{ItemID: "9", Title: "Story", Link: "https://example.com/", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	config := ArchiveConfig{Path: filepath.Join(t.TempDir(), "missing", "archive.jsonl")}
+	if err := archiveItems(db, config, []HackerNewsItem{item}, time.Now()); err == nil {
+		t.Fatal("Expected an error when the archive can't be written")
+	}
+	if archived, _ := isArchived(db, "9"); archived {
+		t.Error("Expected the item to stay pending after a failed write")
+	}
+}
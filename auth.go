@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// authRouteGroups lists the route groups that can be protected individually
+var authRouteGroups = []string{"feed", "api", "admin"}
+
+// authRule describes how a route group is protected
+type authRule struct {
+	scheme   string // "basic" or "bearer"
+	username string
+	password string
+	token    string
+}
+
+// parseAuthRule parses "basic:user:password" or "bearer:token"
+func parseAuthRule(spec string) (authRule, error) {
+	scheme, credentials, _ := strings.Cut(spec, ":")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		username, password, ok := strings.Cut(credentials, ":")
+		if !ok || username == "" || password == "" {
+			return authRule{}, fmt.Errorf("basic auth requires basic:user:password")
+		}
+		return authRule{scheme: "basic", username: username, password: password}, nil
+	case "bearer":
+		if credentials == "" {
+			return authRule{}, fmt.Errorf("bearer auth requires bearer:token")
+		}
+		return authRule{scheme: "bearer", token: credentials}, nil
+	}
+	return authRule{}, fmt.Errorf("unknown auth scheme %q (use basic or bearer)", scheme)
+}
+
+// authorize reports whether the request carries valid credentials for the rule.
+// Bearer tokens may also be passed as a ?token= query parameter for feed readers that can't set headers.
+func (a authRule) authorize(r *http.Request) bool {
+	switch a.scheme {
+	case "basic":
+		user, password, ok := r.BasicAuth()
+		return ok &&
+			subtle.ConstantTimeCompare([]byte(user), []byte(a.username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(a.password)) == 1
+	case "bearer":
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+	}
+	return false
+}
+
+// challenge writes a 401 response with the appropriate WWW-Authenticate header
+func (a authRule) challenge(w http.ResponseWriter, realm string) {
+	if a.scheme == "basic" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q`, realm))
+	} else {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, realm))
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// authRules maps route groups to their protection; groups without a rule are public.
+// It implements flag.Value so rules can be given as repeated -auth group=scheme:credentials flags.
+type authRules map[string]authRule
+
+func (a authRules) String() string {
+	groups := make([]string, 0, len(a))
+	for group, rule := range a {
+		groups = append(groups, group+"="+rule.scheme)
+	}
+	sort.Strings(groups)
+	return strings.Join(groups, ",")
+}
+
+func (a authRules) Set(value string) error {
+	group, spec, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected group=scheme:credentials, got %q", value)
+	}
+	group = strings.ToLower(strings.TrimSpace(group))
+	known := false
+	for _, g := range authRouteGroups {
+		known = known || g == group
+	}
+	if !known {
+		return fmt.Errorf("unknown route group %q (use %s)", group, strings.Join(authRouteGroups, ", "))
+	}
+
+	rule, err := parseAuthRule(spec)
+	if err != nil {
+		return err
+	}
+	a[group] = rule
+	return nil
+}
+
+// requireAuth wraps a handler with the rule for the given route group, if any
+func (a authRules) requireAuth(group string, next http.Handler) http.Handler {
+	rule, ok := a[group]
+	if !ok {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rule.authorize(r) {
+			rule.challenge(w, "hntop-rss "+group)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseAuthRule(t *testing.T) {
+	testCases := []struct {
+		spec      string
+		shouldErr bool
+		scheme    string
+	}{
+		{"basic:user:pass:word", false, "basic"},
+		{"bearer:abc123", false, "bearer"},
+		{"BEARER:abc123", false, "bearer"},
+		{"basic:user", true, ""},
+		{"basic::pass", true, ""},
+		{"bearer:", true, ""},
+		{"digest:user:pass", true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			rule, err := parseAuthRule(tc.spec)
+			if tc.shouldErr != (err != nil) {
+				t.Fatalf("Expected error=%v, got %v", tc.shouldErr, err)
+			}
+			if rule.scheme != tc.scheme {
+				t.Errorf("Expected scheme %q, got %q", tc.scheme, rule.scheme)
+			}
+		})
+	}
+
+	rule, _ := parseAuthRule("basic:user:pass:word")
+	if rule.password != "pass:word" {
+		t.Errorf("Expected password to keep colons, got %q", rule.password)
+	}
+}
+
+func TestAuthRules_Set(t *testing.T) {
+	rules := make(authRules)
+	if err := rules.Set("feed=bearer:token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := rules.Set("api=basic:u:p"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := rules.Set("unknown=bearer:token"); err == nil {
+		t.Error("Expected error for unknown route group")
+	}
+	if err := rules.Set("bearer:token"); err == nil {
+		t.Error("Expected error for missing group")
+	}
+	if rules.String() != "api=basic,feed=bearer" {
+		t.Errorf("Unexpected String(): %q", rules.String())
+	}
+}
+
+func TestServerAuth_PerRoute(t *testing.T) {
+	server := setupTestServer(t)
+	server.auth["feed"] = authRule{scheme: "bearer", token: "feedtoken"}
+	server.auth["api"] = authRule{scheme: "basic", username: "api", password: "secret"}
+
+	testCases := []struct {
+		name     string
+		request  func() *http.Request
+		expected int
+	}{
+		{"feed without token", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		}, http.StatusUnauthorized},
+		{"feed with bearer header", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+			req.Header.Set("Authorization", "Bearer feedtoken")
+			return req
+		}, http.StatusOK},
+		{"feed with token query", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/feed.xml?token=feedtoken", nil)
+		}, http.StatusOK},
+		{"feed with wrong token", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/feed.xml?token=nope", nil)
+		}, http.StatusUnauthorized},
+		{"api without credentials", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/api/items", nil)
+		}, http.StatusUnauthorized},
+		{"api with basic auth", func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+			req.SetBasicAuth("api", "secret")
+			return req
+		}, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.routes().ServeHTTP(rec, tc.request())
+			if rec.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestServerAuth_AdminRequiresRule(t *testing.T) {
+	server := setupTestServer(t)
+	server.admin = &adminConfig{}
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected admin UI to stay disabled without an auth rule, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// authorRefreshInterval is how long cached karma is used before the author is fetched again
+const authorRefreshInterval = 24 * time.Hour
+
+// hnAPIUserURL is the official Hacker News API endpoint for a user
+var hnAPIUserURL = "https://hacker-news.firebaseio.com/v0/user/"
+
+// AuthorsConfig configures fetching submitter karma and account age
+type AuthorsConfig struct {
+	Enabled        bool `json:"enabled"`          // fetch and show submitter karma and account age
+	NewAccountDays int  `json:"new_account_days"` // tag stories submitted from accounts younger than this "New Account" (0 = no tag)
+	Related        int  `json:"related"`          // list up to this many other recent front-page submissions by the author (0 = off)
+	RelatedDays    int  `json:"related_days"`     // how far back related submissions go (default 30)
+}
+
+// defaultRelatedDays is how far back related submissions go when related_days is not set
+const defaultRelatedDays = 30
+
+// hnAuthor is the cached karma and account creation time of a submitter
+type hnAuthor struct {
+	Username  string
+	Karma     int
+	CreatedAt time.Time
+}
+
+// fetchAuthor fetches a user's karma and account creation time from the official Hacker News API
+func fetchAuthor(ctx context.Context, client *http.Client, username string) (*hnAuthor, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hnAPIUserURL+username+".json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error %d", resp.StatusCode)
+	}
+
+	// Unknown users come back as a JSON null
+	var user *struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		Karma   int    `json:"karma"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+	return &hnAuthor{Username: username, Karma: user.Karma, CreatedAt: time.Unix(user.Created, 0).UTC()}, nil
+}
+
+// refreshAuthors caches karma and account age of the submitters of the given items.
+// It does nothing unless author enrichment is enabled.
+func refreshAuthors(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	if !categoryMapper.Config().Authors.Enabled {
+		return
+	}
+
+	timeout := categoryMapper.Timeouts().Algolia
+	client := &http.Client{Timeout: timeout}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.Author == "" || seen[item.Author] {
+			continue
+		}
+		seen[item.Author] = true
+
+		fetchedAt, err := getAuthorFetchedAt(db, item.Author)
+		if err != nil {
+			slog.Warn("Failed to load author", "author", item.Author, "error", err)
+			continue
+		}
+		if time.Since(fetchedAt) < authorRefreshInterval {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var author *hnAuthor
+		err = errPanicked
+		safely("author fetch", func() {
+			author, err = fetchAuthor(ctx, client, item.Author)
+		}, itemLogAttrs(item)...)
+		cancel()
+		if err != nil {
+			slog.Debug("Failed to fetch author", "author", item.Author, "error", err)
+			continue
+		}
+
+		if err := cacheAuthor(db, author, time.Now()); err != nil {
+			slog.Warn("Failed to cache author", "author", item.Author, "error", err)
+		}
+	}
+}
+
+// isNewAccount reports whether an item was submitted from an account younger than the configured age
+func isNewAccount(item HackerNewsItem, categoryMapper *CategoryMapper) bool {
+	days := categoryMapper.Config().Authors.NewAccountDays
+	if days <= 0 || item.AuthorCreatedAt.IsZero() {
+		return false
+	}
+	return item.CreatedAt.Sub(item.AuthorCreatedAt) < time.Duration(days)*24*time.Hour
+}
+
+// formatAccountAge describes how old an account was at a given time, e.g. "3 days" or "2 years"
+func formatAccountAge(created, at time.Time) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	days := int(at.Sub(created).Hours() / 24)
+	switch {
+	case days < 1:
+		return "less than a day"
+	case days < 60:
+		return plural(days, "day")
+	case days < 730:
+		return plural(days/30, "month")
+	}
+	return plural(days/365, "year")
+}
+
+// authorDetails returns the karma and account age shown next to the author of an entry, or nothing if unknown
+func authorDetails(item HackerNewsItem) string {
+	if item.AuthorCreatedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(` <span style="color: #828282;">(%d karma, account %s old)</span>`, item.AuthorKarma, formatAccountAge(item.AuthorCreatedAt, item.CreatedAt))
+}
+
+// loadRelatedSubmissions returns the author's other recent front-page submissions for a feed entry
+func loadRelatedSubmissions(db *sql.DB, item HackerNewsItem, categoryMapper *CategoryMapper) []HackerNewsItem {
+	config := categoryMapper.Config().Authors
+	if db == nil || config.Related <= 0 || item.Author == "" {
+		return nil
+	}
+	days := config.RelatedDays
+	if days <= 0 {
+		days = defaultRelatedDays
+	}
+	related, err := getAuthorSubmissions(db, item.Author, item.ItemID, time.Now().AddDate(0, 0, -days), config.Related)
+	if err != nil {
+		slog.Debug("Failed to load related submissions", "author", item.Author, "error", err)
+	}
+	return related
+}
+
+// renderRelatedSubmissions lists other submissions by the same author, linking to their discussions
+func renderRelatedSubmissions(author string, related []HackerNewsItem) string {
+	if len(related) == 0 {
+		return ""
+	}
+	block := fmt.Sprintf(`<section aria-label="More from %[1]s" style="margin-bottom: 12px;"><strong>More from %[1]s:</strong><ul style="margin: 4px 0; padding-left: 20px;">`, html.EscapeString(author))
+	for _, other := range related {
+		block += fmt.Sprintf(`<li><a href="%s">%s</a> (%d points, %s)</li>`, other.CommentsLink, html.EscapeString(other.Title), other.Points, calculatePostAge(other.CreatedAt))
+	}
+	return block + "</ul></section>"
+}
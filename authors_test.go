@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFormatAccountAge(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		at       time.Time
+		expected string
+	}{
+		{created.Add(time.Hour), "less than a day"},
+		{created.Add(24 * time.Hour), "1 day"},
+		{created.AddDate(0, 0, 45), "45 days"},
+		{created.AddDate(0, 7, 0), "7 months"},
+		{created.AddDate(4, 1, 0), "4 years"},
+	}
+	for _, tc := range testCases {
+		if got := formatAccountAge(created, tc.at); got != tc.expected {
+			t.Errorf("formatAccountAge(%s) = %q, expected %q", tc.at, got, tc.expected)
+		}
+	}
+}
+
+func TestRefreshAuthors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/user/veteran.json":
+			_, _ = w.Write([]byte(`{"id": "veteran", "created": 1262304000, "karma": 12345}`))
+		case "/user/newbie.json":
+			_, _ = w.Write([]byte(`{"id": "newbie", "created": 1704067200, "karma": 3}`))
+		default:
+			_, _ = w.Write([]byte(`null`))
+		}
+	}))
+	defer server.Close()
+	original := hnAPIUserURL
+	hnAPIUserURL = server.URL + "/user/"
+	defer func() { hnAPIUserURL = original }()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	submitted := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Old hand", Link: "https://example.com/1", Author: "veteran", Points: 100, CreatedAt: submitted, UpdatedAt: submitted},
+		{ItemID: "2", Title: "Buy my product", Link: "https://example.com/2", Author: "newbie", Points: 90, CreatedAt: submitted, UpdatedAt: submitted},
+		{ItemID: "3", Title: "Second post", Link: "https://example.com/3", Author: "newbie", Points: 80, CreatedAt: submitted, UpdatedAt: submitted},
+		{ItemID: "4", Title: "Ghost", Link: "https://example.com/4", Author: "ghost", Points: 70, CreatedAt: submitted, UpdatedAt: submitted},
+	}
+	updateStoredItems(db, items)
+
+	mapper := NewCategoryMapper(&DomainConfig{Authors: AuthorsConfig{Enabled: true, NewAccountDays: 30}})
+	refreshAuthors(db, items, mapper)
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected one request per distinct author, got %d", got)
+	}
+
+	// Cached authors aren't fetched again until the refresh interval passes
+	refreshAuthors(db, items, mapper)
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Expected only the unknown author to be retried, got %d requests", got)
+	}
+
+	stored, err := getFilteredItems(db, ItemFilter{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]HackerNewsItem)
+	for _, item := range stored {
+		byID[item.ItemID] = item
+	}
+	if byID["1"].AuthorKarma != 12345 || byID["2"].AuthorKarma != 3 || !byID["4"].AuthorCreatedAt.IsZero() {
+		t.Fatalf("Unexpected author details: %+v", stored)
+	}
+
+	if categories := itemCategoryList(byID["2"], 50, mapper); !hasAnyCategory(categories, []string{"New Account"}) {
+		t.Errorf("Expected a New Account tag, got %v", categories)
+	}
+	if categories := itemCategoryList(byID["1"], 50, mapper); hasAnyCategory(categories, []string{"New Account"}) {
+		t.Errorf("Expected no New Account tag for an old account, got %v", categories)
+	}
+	if categories := itemCategoryList(byID["2"], 50, nil); hasAnyCategory(categories, []string{"New Account"}) {
+		t.Error("Expected no New Account tag unless configured")
+	}
+
+	entry, _ := feedEntry(db, byID["1"], 50, mapper, nil, FeedFormat{})
+	if !strings.Contains(entry.Description, "(12345 karma, account 14 years old)") {
+		t.Error("Expected karma and account age next to the author")
+	}
+
+	// Disabled enrichment makes no requests
+	refreshAuthors(db, items, NewCategoryMapper(&DomainConfig{}))
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Expected no requests when disabled, got %d", got)
+	}
+}
+
+func TestRelatedSubmissions(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "1", Title: "Current", Author: "pg", Points: 100, CommentsLink: "https://news.ycombinator.com/item?id=1", CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "Yesterday's <post>", Author: "pg", Points: 300, CommentsLink: "https://news.ycombinator.com/item?id=2", CreatedAt: now.Add(-49 * time.Hour), UpdatedAt: now},
+		{ItemID: "3", Title: "Last week", Author: "pg", Points: 80, CommentsLink: "https://news.ycombinator.com/item?id=3", CreatedAt: now.Add(-7 * 24 * time.Hour), UpdatedAt: now},
+		{ItemID: "4", Title: "Last year", Author: "pg", Points: 900, CommentsLink: "https://news.ycombinator.com/item?id=4", CreatedAt: now.AddDate(-1, 0, 0), UpdatedAt: now},
+		{ItemID: "5", Title: "Someone else", Author: "dang", Points: 90, CommentsLink: "https://news.ycombinator.com/item?id=5", CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now},
+	})
+	items, err := getFilteredItems(db, ItemFilter{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var current HackerNewsItem
+	for _, item := range items {
+		if item.ItemID == "1" {
+			current = item
+		}
+	}
+
+	mapper := NewCategoryMapper(&DomainConfig{Authors: AuthorsConfig{Related: 5}})
+	related := loadRelatedSubmissions(db, current, mapper)
+	if len(related) != 2 || related[0].ItemID != "2" || related[1].ItemID != "3" {
+		t.Fatalf("Expected the two recent submissions by pg, newest first, got %+v", related)
+	}
+
+	mapper = NewCategoryMapper(&DomainConfig{Authors: AuthorsConfig{Related: 1, RelatedDays: 400}})
+	if related := loadRelatedSubmissions(db, current, mapper); len(related) != 1 || related[0].ItemID != "2" {
+		t.Errorf("Expected the limit to apply, got %+v", related)
+	}
+
+	entry, _ := feedEntry(db, current, 50, NewCategoryMapper(&DomainConfig{Authors: AuthorsConfig{Related: 5}}), nil, FeedFormat{})
+	if !strings.Contains(entry.Description, `<strong>More from pg:</strong>`) ||
+		!strings.Contains(entry.Description, `<a href="https://news.ycombinator.com/item?id=2">Yesterday&#39;s &lt;post&gt;</a> (300 points, 2 days ago)`) {
+		t.Errorf("Expected related submissions in the entry, got %s", entry.Description)
+	}
+
+	if related := loadRelatedSubmissions(db, current, nil); related != nil {
+		t.Errorf("Expected no related submissions unless configured, got %+v", related)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// backfillHitsPerPage is Algolia's page size cap. Stories are fetched one day at a time, which
+// keeps every window well below it, so a single page per day is enough.
+const backfillHitsPerPage = 1000
+
+// backfillDateLayout is the date format of the backfill -since and -until flags
+const backfillDateLayout = "2006-01-02"
+
+// backfillParams returns the Algolia search parameters for the front-page stories submitted in [start, end)
+func backfillParams(start, end time.Time) url.Values {
+	return url.Values{
+		"tags":           {"front_page"},
+		"numericFilters": {fmt.Sprintf("created_at_i>=%d,created_at_i<%d", start.Unix(), end.Unix())},
+		"hitsPerPage":    {strconv.Itoa(backfillHitsPerPage)},
+	}
+}
+
+// backfillStories pages through Algolia one day at a time from since until until and stores every
+// past front-page story. It returns the number of stories stored and the number of days fetched.
+func backfillStories(db *sql.DB, since, until time.Time, timeout time.Duration, now time.Time) (int, int, error) {
+	client := &http.Client{}
+	stored, days := 0, 0
+	for start := since; start.Before(until); start = start.AddDate(0, 0, 1) {
+		end := start.AddDate(0, 0, 1)
+		if end.After(until) {
+			end = until
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		hits, err := searchStories(ctx, client, backfillParams(start, end))
+		cancel()
+		if err != nil {
+			return stored, days, fmt.Errorf("failed to fetch stories for %s: %w", start.Format(backfillDateLayout), err)
+		}
+		if len(hits) == backfillHitsPerPage {
+			slog.Warn("Backfill day hit the Algolia page limit, some stories may be missing", "date", start.Format(backfillDateLayout))
+		}
+
+		items := make([]HackerNewsItem, 0, len(hits))
+		for _, hit := range hits {
+			item, err := itemFromHit(hit, now)
+			if err != nil {
+				slog.Warn("Skipping story", "hn_id", hit.ObjectID, "error", err)
+				continue
+			}
+			items = append(items, item)
+		}
+		stored += len(updateStoredItems(db, items))
+		days++
+		slog.Debug("Backfilled day", "date", start.Format(backfillDateLayout), "stories", len(items))
+	}
+	return stored, days, nil
+}
+
+// runBackfill handles the backfill subcommand
+func runBackfill(args []string) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	sinceFlag := fs.String("since", "", "first day to backfill, as YYYY-MM-DD in UTC (required)")
+	untilFlag := fs.String("until", "", "day to stop backfilling before, as YYYY-MM-DD in UTC (default now)")
+	timeout := fs.Duration("timeout", defaultAlgoliaTimeout, "timeout for each day's Algolia request")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	proxy := addProxyFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+
+	if *sinceFlag == "" {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss backfill -since YYYY-MM-DD [-until YYYY-MM-DD]")
+		os.Exit(2)
+	}
+	since, err := time.Parse(backfillDateLayout, *sinceFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: invalid -since date %q\n", *sinceFlag)
+		os.Exit(2)
+	}
+	now := time.Now()
+	until := now
+	if *untilFlag != "" {
+		if until, err = time.Parse(backfillDateLayout, *untilFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "backfill: invalid -until date %q\n", *untilFlag)
+			os.Exit(2)
+		}
+	}
+	if !since.Before(until) {
+		fmt.Fprintln(os.Stderr, "backfill: -since must be before -until")
+		os.Exit(2)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	stored, days, err := backfillStories(db, since, until, *timeout, now)
+	if err != nil {
+		slog.Error("Backfill stopped", "error", err, "stored", stored, "days", days)
+		os.Exit(1)
+	}
+	fmt.Printf("Backfilled %d stories from %d days\n", stored, days)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// useTestAlgoliaBackfill points the Algolia search endpoint at a test server that answers
+// front-page queries with the stories created inside the requested window
+func useTestAlgoliaBackfill(t *testing.T, stories []AlgoliaHit) *int {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		if query.Get("tags") != "front_page" {
+			t.Errorf("tags = %q, want front_page", query.Get("tags"))
+		}
+		var from, to int64
+		if _, err := fmt.Sscanf(query.Get("numericFilters"), "created_at_i>=%d,created_at_i<%d", &from, &to); err != nil {
+			t.Errorf("unexpected numericFilters %q", query.Get("numericFilters"))
+		}
+		var hits []AlgoliaHit
+		for _, story := range stories {
+			created, _ := time.Parse(time.RFC3339, story.CreatedAt)
+			if created.Unix() >= from && created.Unix() < to {
+				hits = append(hits, story)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(AlgoliaResponse{Hits: hits})
+	}))
+	original := algoliaSearchURL
+	algoliaSearchURL = server.URL
+	t.Cleanup(func() {
+		algoliaSearchURL = original
+		server.Close()
+	})
+	return &requests
+}
+
+func TestBackfillStories(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	requests := useTestAlgoliaBackfill(t, []AlgoliaHit{
+		{ObjectID: "101", Title: "First day", URL: "https://example.com/a", Author: "alice", Points: 300, CreatedAt: "2024-01-01T08:00:00Z"},
+		{ObjectID: "102", Title: "Second day", URL: "https://example.com/b", Author: "bob", Points: 150, CreatedAt: "2024-01-02T23:59:00Z"},
+		{ObjectID: "103", Title: "Too late", URL: "https://example.com/c", Author: "carol", Points: 90, CreatedAt: "2024-01-03T00:00:00Z"},
+	})
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	stored, days, err := backfillStories(db, since, until, time.Second, until)
+	if err != nil {
+		t.Fatalf("backfillStories failed: %v", err)
+	}
+	if stored != 2 || days != 2 || *requests != 2 {
+		t.Errorf("stored %d stories from %d days in %d requests, want 2 from 2 in 2", stored, days, *requests)
+	}
+
+	item, err := getItemByID(db, "102")
+	if err != nil {
+		t.Fatalf("backfilled item missing: %v", err)
+	}
+	if item.Title != "Second day" || item.Points != 150 || !item.CreatedAt.Equal(time.Date(2024, 1, 2, 23, 59, 0, 0, time.UTC)) {
+		t.Errorf("unexpected backfilled item: %+v", item)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM items WHERE item_hn_id = '103'").Scan(&count); err != nil || count != 0 {
+		t.Errorf("story outside the window was stored (count %d, err %v)", count, err)
+	}
+}
+
+func TestBackfillStories_PartialDay(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	requests := useTestAlgoliaBackfill(t, []AlgoliaHit{
+		{ObjectID: "201", Title: "Morning", Points: 100, CreatedAt: "2024-01-01T06:00:00Z"},
+		{ObjectID: "202", Title: "Evening", Points: 100, CreatedAt: "2024-01-01T20:00:00Z"},
+	})
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stored, days, err := backfillStories(db, since, until, time.Second, until)
+	if err != nil {
+		t.Fatalf("backfillStories failed: %v", err)
+	}
+	if stored != 1 || days != 1 || *requests != 1 {
+		t.Errorf("stored %d stories from %d days in %d requests, want 1 from 1 in 1", stored, days, *requests)
+	}
+}
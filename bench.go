@@ -0,0 +1,256 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// benchFrontPageSize is the number of items upserted per operation, the size of one front page fetch
+const benchFrontPageSize = 30
+
+// benchNames lists the available benchmarks in the order they run
+var benchNames = []string{"db-upsert", "og-cache-lookup", "feed-render"}
+
+var (
+	benchWords   = []string{"Rust", "Go", "SQLite", "compiler", "database", "startup", "kernel", "browser", "privacy", "LLM", "open source", "release", "security", "design", "history"}
+	benchDomains = []string{"github.com", "example.com", "blog.example.org", "nytimes.com", "arxiv.org", "youtube.com", "medium.com", "lwn.net"}
+)
+
+// benchResult is the outcome of one benchmark
+type benchResult struct {
+	Name    string
+	Ops     int
+	Elapsed time.Duration
+	Allocs  uint64 // heap allocations over all operations
+	Bytes   uint64 // bytes allocated over all operations
+}
+
+// opsPerSec returns the operation throughput
+func (r benchResult) opsPerSec() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Ops) / r.Elapsed.Seconds()
+}
+
+// perOp divides a total by the number of operations
+func (r benchResult) perOp(total uint64) uint64 {
+	if r.Ops == 0 {
+		return 0
+	}
+	return total / uint64(r.Ops)
+}
+
+// runBenchmark runs op repeatedly for at least the given duration after one warm-up run,
+// measuring throughput and allocations
+func runBenchmark(name string, duration time.Duration, op func() error) (benchResult, error) {
+	if err := op(); err != nil {
+		return benchResult{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	result := benchResult{Name: name}
+	start := time.Now()
+	for result.Elapsed < duration {
+		if err := op(); err != nil {
+			return benchResult{}, fmt.Errorf("%s: %w", name, err)
+		}
+		result.Ops++
+		result.Elapsed = time.Since(start)
+	}
+	runtime.ReadMemStats(&after)
+	result.Allocs = after.Mallocs - before.Mallocs
+	result.Bytes = after.TotalAlloc - before.TotalAlloc
+	return result, nil
+}
+
+// syntheticItems generates a reproducible dataset of n stories created over the last days
+func syntheticItems(r *rand.Rand, n int, now time.Time) []HackerNewsItem {
+	items := make([]HackerNewsItem, n)
+	for i := range items {
+		id := fmt.Sprint(40000000 + i)
+		title := benchWords[r.IntN(len(benchWords))] + " " + benchWords[r.IntN(len(benchWords))] + " " + benchWords[r.IntN(len(benchWords))]
+		link := fmt.Sprintf("https://%s/post/%d", benchDomains[r.IntN(len(benchDomains))], i)
+		if r.IntN(10) == 0 {
+			title, link = "Ask HN: "+title, ""
+		}
+		points := 1 + r.IntN(1000)
+		items[i] = HackerNewsItem{
+			ItemID:       id,
+			Title:        title,
+			Link:         link,
+			CommentsLink: "https://news.ycombinator.com/item?id=" + id,
+			Points:       points,
+			CommentCount: r.IntN(points + 1),
+			Author:       fmt.Sprintf("user%d", r.IntN(n/4+1)),
+			CreatedAt:    now.Add(-time.Duration(r.IntN(72*60)) * time.Minute),
+			UpdatedAt:    now,
+		}
+	}
+	return items
+}
+
+// seedBenchDatabase stores the dataset and a cached OpenGraph preview for every article, so feed
+// rendering never goes to the network
+func seedBenchDatabase(db *sql.DB, items []HackerNewsItem) error {
+	updateStoredItems(db, items)
+	for _, item := range items {
+		if isTextPost(item) {
+			continue
+		}
+		ogData := &OpenGraphData{URL: item.Link, Title: item.Title, Description: "A synthetic preview of " + item.Title, SiteName: extractDomain(item.Link)}
+		if err := cacheOpenGraphData(db, ogData, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runBenchmarks benchmarks DB upserts, OpenGraph cache lookups and feed rendering against a synthetic
+// dataset of the given size in db
+func runBenchmarks(db *sql.DB, size int, seed uint64, duration time.Duration, only []string) ([]benchResult, error) {
+	r := rand.New(rand.NewPCG(seed, seed))
+	now := time.Now()
+	items := syntheticItems(r, size, now)
+	if err := seedBenchDatabase(db, items); err != nil {
+		return nil, fmt.Errorf("failed to seed database: %w", err)
+	}
+
+	var articles []string
+	for _, item := range items {
+		if !isTextPost(item) {
+			articles = append(articles, item.Link)
+		}
+	}
+	categoryMapper := NewCategoryMapper(&DomainConfig{})
+	filter := ItemFilter{Limit: 30, MinPoints: 50}
+
+	benchmarks := []struct {
+		name string
+		op   func() error
+	}{
+		{"db-upsert", func() error {
+			// Each operation updates the stats of one front page worth of items
+			start := r.IntN(max(1, len(items)-benchFrontPageSize))
+			batch := items[start:min(len(items), start+benchFrontPageSize)]
+			for i := range batch {
+				batch[i].Points++
+			}
+			updateStoredItems(db, batch)
+			return nil
+		}},
+		{"og-cache-lookup", func() error {
+			if len(articles) == 0 {
+				return nil
+			}
+			url := articles[r.IntN(len(articles))]
+			cached, err := getOpenGraphData(db, url)
+			if err == nil && cached == nil {
+				err = fmt.Errorf("no cached data for %s", url)
+			}
+			return err
+		}},
+		{"feed-render", func() error {
+			items, err := getFilteredItems(db, filter)
+			if err != nil {
+				return err
+			}
+			feedItems := prepareFeedItems(db, items, categoryMapper)
+			if generateRSSFeed(db, feedItems, filter.MinPoints, categoryMapper) == "" {
+				return fmt.Errorf("empty feed")
+			}
+			return nil
+		}},
+	}
+
+	var results []benchResult
+	for _, bench := range benchmarks {
+		if len(only) > 0 && !slices.Contains(only, bench.name) {
+			continue
+		}
+		slog.Debug("Running benchmark", "name", bench.name)
+		result, err := runBenchmark(bench.name, duration, bench.op)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// printBenchResults writes the results as an aligned table
+func printBenchResults(w io.Writer, results []benchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "benchmark\tops\tops/sec\tns/op\tallocs/op\tB/op\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%d\t%d\t\n",
+			r.Name, r.Ops, r.opsPerSec(), r.Elapsed.Nanoseconds()/int64(max(r.Ops, 1)), r.perOp(r.Allocs), r.perOp(r.Bytes))
+	}
+	_ = tw.Flush()
+}
+
+// runBench handles the bench subcommand, which benchmarks the database and rendering paths on a
+// throwaway database so results are comparable across machines
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "enable debug logging")
+	size := fs.Int("items", 1000, "number of synthetic stories in the dataset")
+	seed := fs.Uint64("seed", 1, "random seed for the synthetic dataset")
+	duration := fs.Duration("benchtime", time.Second, "how long each benchmark runs")
+	dir := fs.String("dir", "", "directory for the throwaway benchmark database (defaults to the system temp directory)")
+	run := fs.String("run", "", "comma-separated benchmarks to run: db-upsert, og-cache-lookup, feed-render (default all)")
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+
+	if *size < 1 {
+		fmt.Fprintln(os.Stderr, "bench: -items must be at least 1")
+		os.Exit(2)
+	}
+	only := splitQueryList([]string{*run})
+	for _, name := range only {
+		if !slices.Contains(benchNames, name) {
+			fmt.Fprintf(os.Stderr, "bench: unknown benchmark %q (available: %s)\n", name, strings.Join(benchNames, ", "))
+			os.Exit(2)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp(*dir, "hntop-bench-")
+	if err != nil {
+		slog.Error("Failed to create benchmark directory", "error", err)
+		os.Exit(1)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	db, err := sql.Open("sqlite", filepath.Join(tmpDir, "bench.db"))
+	if err != nil {
+		slog.Error("Failed to open benchmark database", "error", err)
+		os.Exit(1)
+	}
+	defer func() { _ = db.Close() }()
+	if err := createSchema(db); err != nil {
+		slog.Error("Failed to initialize benchmark database", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("dataset: %d items, seed %d, %s per benchmark, %s/%s\n\n", *size, *seed, *duration, runtime.GOOS, runtime.GOARCH)
+	results, err := runBenchmarks(db, *size, *seed, *duration, only)
+	printBenchResults(os.Stdout, results)
+	if err != nil {
+		slog.Error("Benchmark failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyntheticItems_Reproducible(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := syntheticItems(rand.New(rand.NewPCG(7, 7)), 100, now)
+	second := syntheticItems(rand.New(rand.NewPCG(7, 7)), 100, now)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Expected the same seed to generate the same dataset")
+	}
+	other := syntheticItems(rand.New(rand.NewPCG(8, 8)), 100, now)
+	if reflect.DeepEqual(first, other) {
+		t.Error("Expected a different seed to generate a different dataset")
+	}
+
+	textPosts := 0
+	for _, item := range first {
+		if isTextPost(item) {
+			textPosts++
+		}
+		if item.CommentCount > item.Points || item.CreatedAt.After(now) {
+			t.Errorf("Implausible item: %+v", item)
+		}
+	}
+	if textPosts == 0 || textPosts == len(first) {
+		t.Errorf("Expected a mix of articles and text posts, got %d text posts", textPosts)
+	}
+}
+
+func TestRunBenchmarks(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	results, err := runBenchmarks(db, 60, 1, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("runBenchmarks failed: %v", err)
+	}
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Name)
+		if r.Ops == 0 || r.Elapsed < 10*time.Millisecond || r.Allocs == 0 {
+			t.Errorf("Expected measurements for %s, got %+v", r.Name, r)
+		}
+	}
+	if !slices.Equal(names, benchNames) {
+		t.Errorf("Expected benchmarks %v, got %v", benchNames, names)
+	}
+
+	var out bytes.Buffer
+	printBenchResults(&out, results)
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 4 || !strings.Contains(lines[0], "ops/sec") {
+		t.Errorf("Unexpected table:\n%s", out.String())
+	}
+
+	// A subset runs only the named benchmarks
+	results, err = runBenchmarks(setupTestDB(), 10, 1, time.Millisecond, []string{"og-cache-lookup"})
+	if err != nil || len(results) != 1 || results[0].Name != "og-cache-lookup" {
+		t.Errorf("Expected only the cache lookup benchmark, got %+v (err %v)", results, err)
+	}
+}
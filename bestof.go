@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bestOfTemplate renders the year-in-review archive page
+var bestOfTemplate = template.Must(template.New("bestof").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Hacker News: the best of {{.Year}}</title>
+<link rel="alternate" type="application/atom+xml" title="Best of {{.Year}}" href="{{.FeedFile}}">
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 860px; margin: 0 auto; padding: 1rem; color: #222; }
+h1 { border-bottom: 3px solid #ff6600; padding-bottom: .3rem; }
+h2 { margin-top: 2rem; color: #ff6600; }
+h3 { margin-bottom: .3rem; font-size: 1rem; color: #555; }
+ol { margin-top: 0; padding-left: 1.5rem; }
+li { margin: .3rem 0; }
+a { color: #000; }
+.meta { color: #828282; font-size: .85rem; }
+.meta a { color: #828282; }
+nav a { margin-right: .5rem; }
+</style>
+</head>
+<body>
+<h1>Hacker News: the best of {{.Year}}</h1>
+<p>The top {{.Count}} stories of {{.Year}} by points, grouped by month and category. <a href="{{.FeedFile}}">Atom feed</a></p>
+<nav>{{range .Months}}<a href="#{{.Anchor}}">{{.Name}}</a>{{end}}</nav>
+{{range .Months}}
+<h2 id="{{.Anchor}}">{{.Name}}</h2>
+{{range .Sections}}
+<h3>{{.Name}}</h3>
+<ol>
+{{range .Stories}}<li><a href="{{.URL}}">{{.Title}}</a>{{if .Domain}} <span class="meta">({{.Domain}})</span>{{end}}<br><span class="meta">{{.Points}} points · <a href="{{.CommentsURL}}">{{.Comments}} comments</a> · by {{.Author}}</span></li>
+{{end}}
+</ol>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// bestOfMonth is one month of the year-in-review page
+type bestOfMonth struct {
+	Name     string
+	Anchor   string
+	Sections []newsletterSection
+}
+
+// bestOfPage is the data passed to bestOfTemplate
+type bestOfPage struct {
+	Year     int
+	Count    int
+	FeedFile string
+	Months   []bestOfMonth
+}
+
+// bestOfFeedInfo describes the year-in-review feed
+func bestOfFeedInfo(year int) feedInfo {
+	return feedInfo{
+		Title:       fmt.Sprintf("Hacker News: the best of %d", year),
+		Description: fmt.Sprintf("The top Hacker News stories of %d", year),
+		ID:          fmt.Sprintf("tag:news.ycombinator.com,2024:best-of-%d", year),
+	}
+}
+
+// yearBounds returns the start of the year and the start of the next one in the display time zone
+func yearBounds(year int) (time.Time, time.Time) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, displayLocation)
+	return start, start.AddDate(1, 0, 0)
+}
+
+// renderBestOf renders the year's items, which must be sorted by points, as an HTML page grouped by
+// month and then by category. Within a month, categories are ordered by their best story.
+func renderBestOf(items []HackerNewsItem, categoryMapper *CategoryMapper, year int, feedFile string) (string, error) {
+	byMonth := make(map[time.Month][]HackerNewsItem)
+	for _, item := range items {
+		month := localTime(item.CreatedAt).Month()
+		byMonth[month] = append(byMonth[month], item)
+	}
+
+	page := bestOfPage{Year: year, Count: len(items), FeedFile: feedFile}
+	for month := time.January; month <= time.December; month++ {
+		monthItems := byMonth[month]
+		if len(monthItems) == 0 {
+			continue
+		}
+
+		sections := make(map[string]*newsletterSection)
+		var order []string
+		for _, item := range monthItems {
+			name := newsletterSectionName(item, categoryMapper)
+			if _, ok := sections[name]; !ok {
+				sections[name] = &newsletterSection{Name: name}
+				order = append(order, name)
+			}
+			sections[name].Stories = append(sections[name].Stories, storyForItem(item))
+		}
+
+		entry := bestOfMonth{Name: month.String(), Anchor: fmt.Sprintf("m%02d", int(month))}
+		for _, name := range order {
+			entry.Sections = append(entry.Sections, *sections[name])
+		}
+		page.Months = append(page.Months, entry)
+	}
+
+	var buf bytes.Buffer
+	if err := bestOfTemplate.Execute(&buf, page); err != nil {
+		return "", fmt.Errorf("failed to render best-of page: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// writeBestOf writes the year-in-review page and feed to outDir and returns their paths
+func writeBestOf(db *sql.DB, outDir string, year, minPoints, limit int, categoryMapper *CategoryMapper) (string, string, error) {
+	start, end := yearBounds(year)
+	items, err := getTopItemsBetween(db, start, end, minPoints, limit)
+	if err != nil {
+		return "", "", err
+	}
+	if len(items) == 0 {
+		return "", "", fmt.Errorf("no stories stored for %d", year)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	feedFile := fmt.Sprintf("best-of-%d.xml", year)
+	page, err := renderBestOf(items, categoryMapper, year, feedFile)
+	if err != nil {
+		return "", "", err
+	}
+	pagePath := filepath.Join(outDir, fmt.Sprintf("best-of-%d.html", year))
+	if err := os.WriteFile(pagePath, []byte(page), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write best-of page: %w", err)
+	}
+
+	feed := generateFeed(db, items, minPoints, categoryMapper, bestOfFeedInfo(year))
+	feedPath := filepath.Join(outDir, feedFile)
+	if err := os.WriteFile(feedPath, []byte(feed), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write best-of feed: %w", err)
+	}
+	return pagePath, feedPath, nil
+}
+
+// runBestOf parses best-of subcommand flags and writes the year-in-review archive
+func runBestOf(args []string) {
+	fs := flag.NewFlagSet("best-of", flag.ExitOnError)
+	year := fs.Int("year", time.Now().Year()-1, "year to review")
+	outDir := fs.String("outdir", ".", "directory where the page and feed will be saved")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	minPoints := fs.Int("min-points", 0, "minimum points threshold for stories")
+	limit := fs.Int("limit", 100, "number of stories to include")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	timezone := addTimezoneFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+	setupTimezone(*timezone)
+	categoryMapper := LoadConfig(*configPath, *configURL)
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	pagePath, feedPath, err := writeBestOf(db, *outDir, *year, *minPoints, *limit, categoryMapper)
+	if err != nil {
+		slog.Error("Failed to generate best-of archive", "year", *year, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Best-of archive saved", "year", *year, "page", pagePath, "feed", feedPath)
+}
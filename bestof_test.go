@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetTopItemsBetween(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Late 2023", Link: "https://example.com/1", Points: 900, CreatedAt: time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)},
+		{ItemID: "2", Title: "January", Link: "https://example.com/2", Points: 300, CreatedAt: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{ItemID: "3", Title: "March", Link: "https://example.com/3", Points: 500, CreatedAt: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{ItemID: "4", Title: "Quiet", Link: "https://example.com/4", Points: 20, CreatedAt: time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	updateStoredItems(db, items)
+
+	start, end := yearBounds(2024)
+	got, err := getTopItemsBetween(db, start, end, 50, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].ItemID != "3" || got[1].ItemID != "2" {
+		t.Errorf("Expected items 3 and 2 by points, got %+v", got)
+	}
+}
+
+func TestRenderBestOf(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{CategoryDomains: map[string][]string{"GitHub": {"github.com"}}})
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Big <release>", Link: "https://github.com/a/b", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 900, CreatedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{ItemID: "2", Title: "Ask HN: Favorite tools?", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 400, CreatedAt: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)},
+		{ItemID: "3", Title: "An essay", Link: "https://example.com/essay", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 300, CreatedAt: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	page, err := renderBestOf(items, mapper, 2024, "best-of-2024.xml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"the best of 2024",
+		`href="best-of-2024.xml"`,
+		"Big &lt;release&gt;",
+		`<a href="https://news.ycombinator.com/item?id=2">Ask HN: Favorite tools?</a>`,
+		"<h3>Ask HN</h3>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected page to contain %q", want)
+		}
+	}
+
+	// Months are in calendar order regardless of score, categories by their best story
+	january := strings.Index(page, `<h2 id="m01">`)
+	march := strings.Index(page, `<h2 id="m03">`)
+	github := strings.Index(page, "<h3>GitHub</h3>")
+	other := strings.Index(page, "<h3>Other</h3>")
+	if january < 0 || march < 0 || january > march || github < march || other < github {
+		t.Errorf("Unexpected layout order: January=%d March=%d GitHub=%d Other=%d", january, march, github, other)
+	}
+	if strings.Contains(page, `id="m02"`) {
+		t.Error("Expected months without stories to be omitted")
+	}
+}
+
+func TestWriteBestOfNoStories(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	dir := t.TempDir()
+	if _, _, err := writeBestOf(db, dir, 2019, 0, 10, nil); err == nil {
+		t.Error("Expected an error for a year without stories")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected no files to be written, got %d", len(entries))
+	}
+}
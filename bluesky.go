@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// blueskyTimeout bounds a single Bluesky API request, including thumbnail downloads
+const blueskyTimeout = 15 * time.Second
+
+// blueskyMaxPostLength is the Bluesky post length limit. Bluesky counts graphemes; counting runes
+// is stricter for emoji sequences, so posts under this never get rejected for their length.
+const blueskyMaxPostLength = 300
+
+// blueskyMaxThumbSize is the largest image Bluesky accepts as a link card thumbnail
+const blueskyMaxThumbSize = 1_000_000
+
+// defaultBlueskyTemplate is the post text used when no template is configured. The article
+// itself is shown as a link card below the text.
+const defaultBlueskyTemplate = `{{.Title}}
+
+💬 {{.CommentsURL}}`
+
+// blueskyLinkPattern finds the URLs in post text, which Bluesky only links through facets
+var blueskyLinkPattern = regexp.MustCompile(`https?://[^\s]+`)
+
+// BlueskyConfig enables posting feed stories to a Bluesky account
+type BlueskyConfig struct {
+	Service     string `json:"service"`      // PDS URL (default https://bsky.social)
+	Handle      string `json:"handle"`       // account handle or DID
+	AppPassword string `json:"app_password"` // app password from Settings → App Passwords
+	Template    string `json:"template"`     // Go template for the post text, with the storyPost fields
+	MaxPerRun   int    `json:"max_per_run"`  // stories posted per run (default 5)
+}
+
+// blueskyClient is an AT Protocol session on the account's PDS
+type blueskyClient struct {
+	client  *http.Client
+	service string
+	did     string
+	token   string
+}
+
+// blueskyFacet marks a byte range of the post text as a link
+type blueskyFacet struct {
+	Index struct {
+		ByteStart int `json:"byteStart"`
+		ByteEnd   int `json:"byteEnd"`
+	} `json:"index"`
+	Features []blueskyFacetFeature `json:"features"`
+}
+
+type blueskyFacetFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri"`
+}
+
+// blueskyExternal is the link card of a post
+type blueskyExternal struct {
+	URI         string          `json:"uri"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Thumb       json.RawMessage `json:"thumb,omitempty"`
+}
+
+type blueskyEmbed struct {
+	Type     string          `json:"$type"`
+	External blueskyExternal `json:"external"`
+}
+
+// blueskyPost is an app.bsky.feed.post record
+type blueskyPost struct {
+	Type      string         `json:"$type"`
+	Text      string         `json:"text"`
+	CreatedAt string         `json:"createdAt"`
+	Facets    []blueskyFacet `json:"facets,omitempty"`
+	Embed     *blueskyEmbed  `json:"embed,omitempty"`
+}
+
+// blueskyLinkFacets returns link facets for the URLs in text
+func blueskyLinkFacets(text string) []blueskyFacet {
+	var facets []blueskyFacet
+	for _, loc := range blueskyLinkPattern.FindAllStringIndex(text, -1) {
+		var facet blueskyFacet
+		facet.Index.ByteStart = loc[0]
+		facet.Index.ByteEnd = loc[1]
+		facet.Features = []blueskyFacetFeature{{Type: "app.bsky.richtext.facet#link", URI: text[loc[0]:loc[1]]}}
+		facets = append(facets, facet)
+	}
+	return facets
+}
+
+// xrpc calls an XRPC procedure and decodes its JSON response into out, if given
+func (c *blueskyClient) xrpc(ctx context.Context, method, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.service+"/xrpc/"+method, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s failed (%d): %s: %s", method, resp.StatusCode, apiErr.Error, apiErr.Message)
+		}
+		return fmt.Errorf("%s failed with status code %d", method, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	return nil
+}
+
+// xrpcJSON calls an XRPC procedure with a JSON body
+func (c *blueskyClient) xrpcJSON(ctx context.Context, method string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	return c.xrpc(ctx, method, "application/json", bytes.NewReader(body), out)
+}
+
+// newBlueskySession logs in with an app password
+func newBlueskySession(ctx context.Context, config BlueskyConfig) (*blueskyClient, error) {
+	c := &blueskyClient{
+		client:  &http.Client{Timeout: blueskyTimeout},
+		service: strings.TrimSuffix(cmp.Or(config.Service, "https://bsky.social"), "/"),
+	}
+	var session struct {
+		DID       string `json:"did"`
+		AccessJwt string `json:"accessJwt"`
+	}
+	login := map[string]string{"identifier": config.Handle, "password": config.AppPassword}
+	if err := c.xrpcJSON(ctx, "com.atproto.server.createSession", login, &session); err != nil {
+		return nil, err
+	}
+	c.did = session.DID
+	c.token = session.AccessJwt
+	return c, nil
+}
+
+// uploadThumb downloads an image and uploads it as a blob for a link card thumbnail
+func (c *blueskyClient) uploadThumb(ctx context.Context, imageURL string) (json.RawMessage, error) {
+	image, contentType, err := fetchPreviewImage(ctx, c.client, imageURL, blueskyMaxThumbSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploaded struct {
+		Blob json.RawMessage `json:"blob"`
+	}
+	if err := c.xrpc(ctx, "com.atproto.repo.uploadBlob", contentType, bytes.NewReader(image), &uploaded); err != nil {
+		return nil, err
+	}
+	return uploaded.Blob, nil
+}
+
+// linkCard builds the link card of an article from its cached OpenGraph data, falling back to
+// the story title when the article has no preview
+func (c *blueskyClient) linkCard(ctx context.Context, db *sql.DB, item HackerNewsItem) *blueskyEmbed {
+	external := blueskyExternal{URI: item.Link, Title: item.Title}
+	if ogData := cachedOpenGraph(db, item.Link); ogData != nil {
+		external.Title = cmp.Or(ogData.Title, item.Title)
+		external.Description = ogData.Description
+		if ogData.Image != "" {
+			thumb, err := c.uploadThumb(ctx, ogData.Image)
+			if err != nil {
+				slog.Debug("Posting Bluesky link card without a thumbnail", "hn_id", item.ItemID, "image", ogData.Image, "error", err)
+			}
+			external.Thumb = thumb
+		}
+	}
+	return &blueskyEmbed{Type: "app.bsky.embed.external", External: external}
+}
+
+// createPost posts text with link facets and, for link posts, a link card
+func (c *blueskyClient) createPost(ctx context.Context, db *sql.DB, text string, item HackerNewsItem, now time.Time) error {
+	if n := utf8.RuneCountInString(text); n > blueskyMaxPostLength {
+		return fmt.Errorf("post is %d characters, Bluesky allows %d", n, blueskyMaxPostLength)
+	}
+	post := blueskyPost{
+		Type:      "app.bsky.feed.post",
+		Text:      text,
+		CreatedAt: now.UTC().Format(time.RFC3339),
+		Facets:    blueskyLinkFacets(text),
+	}
+	if item.Link != "" {
+		post.Embed = c.linkCard(ctx, db, item)
+	}
+	record := map[string]any{"repo": c.did, "collection": "app.bsky.feed.post", "record": post}
+	return c.xrpcJSON(ctx, "com.atproto.repo.createRecord", record, nil)
+}
+
+// renderBlueskyPost renders the post text of a story, shortening the title when the post would
+// be over the length limit
+func renderBlueskyPost(tmpl *template.Template, item HackerNewsItem) (string, error) {
+	text, err := renderStatus(tmpl, item)
+	if err != nil {
+		return "", err
+	}
+	excess := utf8.RuneCountInString(text) - blueskyMaxPostLength
+	title := []rune(item.Title)
+	if excess <= 0 || excess >= len(title) {
+		return text, nil
+	}
+	item.Title = strings.TrimSpace(string(title[:len(title)-excess-1])) + "…"
+	return renderStatus(tmpl, item)
+}
+
+// postToBluesky posts new stories when a Bluesky account is configured. The session is only
+// created once there is something to post, since logins are rate limited.
+func postToBluesky(db *sql.DB, items []HackerNewsItem, config BlueskyConfig, now time.Time) {
+	if config.Handle == "" || config.AppPassword == "" {
+		return
+	}
+	tmpl, err := parseStatusTemplate(config.Template, defaultBlueskyTemplate)
+	if err != nil {
+		slog.Warn("Invalid Bluesky template, skipping Bluesky", "error", err)
+		return
+	}
+
+	var client *blueskyClient
+	postNewStories(db, items, "Bluesky", "bluesky_posted_at", config.MaxPerRun, now, func(item HackerNewsItem) error {
+		text, err := renderBlueskyPost(tmpl, item)
+		if err != nil {
+			return fmt.Errorf("failed to render post: %w", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), blueskyTimeout)
+		defer cancel()
+		if client == nil {
+			if client, err = newBlueskySession(ctx, config); err != nil {
+				return fmt.Errorf("failed to log in: %w", err)
+			}
+		}
+		return client.createPost(ctx, db, text, item, now)
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestBlueskyLinkFacets(t *testing.T) {
+	text := "Ünïcode title\n\n💬 https://news.ycombinator.com/item?id=1"
+	facets := blueskyLinkFacets(text)
+	if len(facets) != 1 {
+		t.Fatalf("Expected one facet, got %d", len(facets))
+	}
+	start, end := facets[0].Index.ByteStart, facets[0].Index.ByteEnd
+	if text[start:end] != "https://news.ycombinator.com/item?id=1" || facets[0].Features[0].URI != text[start:end] {
+		t.Errorf("Facet covers %q, expected the discussion link", text[start:end])
+	}
+}
+
+func TestRenderBlueskyPost_ShortensLongTitles(t *testing.T) {
+	tmpl, err := parseStatusTemplate("", defaultBlueskyTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := HackerNewsItem{Title: strings.Repeat("ä", 400), CommentsLink: "https://news.ycombinator.com/item?id=1"}
+	text, err := renderBlueskyPost(tmpl, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(text); n > blueskyMaxPostLength {
+		t.Errorf("Expected the post to fit in %d characters, got %d", blueskyMaxPostLength, n)
+	}
+	if !strings.Contains(text, "ä…\n") || !strings.HasSuffix(text, item.CommentsLink) {
+		t.Errorf("Expected a shortened title and the whole discussion link, got %q", text)
+	}
+}
+
+func TestPostToBluesky(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var logins int
+	var records []map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			var login map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&login)
+			if login["identifier"] != "hntop.example.com" || login["password"] != "app-password" {
+				t.Errorf("Unexpected login %v", login)
+			}
+			logins++
+			_, _ = w.Write([]byte(`{"did": "did:plc:test", "accessJwt": "jwt"}`))
+		case "/og.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png"))
+		case "/xrpc/com.atproto.repo.uploadBlob":
+			if body, _ := io.ReadAll(r.Body); string(body) != "png" || r.Header.Get("Content-Type") != "image/png" {
+				t.Errorf("Unexpected blob %q of type %q", body, r.Header.Get("Content-Type"))
+			}
+			_, _ = w.Write([]byte(`{"blob": {"$type": "blob", "ref": {"$link": "cid"}, "mimeType": "image/png", "size": 3}}`))
+		case "/xrpc/com.atproto.repo.createRecord":
+			if r.Header.Get("Authorization") != "Bearer jwt" {
+				t.Errorf("Expected the session token, got %q", r.Header.Get("Authorization"))
+			}
+			var record map[string]json.RawMessage
+			_ = json.NewDecoder(r.Body).Decode(&record)
+			records = append(records, record)
+			_, _ = w.Write([]byte(`{"uri": "at://did:plc:test/app.bsky.feed.post/1", "cid": "cid"}`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "An article", Link: "https://example.com/article", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "Ask HN: A question", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 100, CreatedAt: now, UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+	ogData := &OpenGraphData{URL: "https://example.com/article", Title: "The article", Description: "About things", Image: server.URL + "/og.png"}
+	if err := cacheOpenGraphData(db, ogData, true); err != nil {
+		t.Fatal(err)
+	}
+
+	config := BlueskyConfig{Service: server.URL, Handle: "hntop.example.com", AppPassword: "app-password"}
+	postToBluesky(db, storiesToPublish(items), config, now)
+	postToBluesky(db, storiesToPublish(items), config, now)
+	if len(records) != 2 || logins != 1 {
+		t.Fatalf("Expected each story to be posted once with one login, got %d posts and %d logins", len(records), logins)
+	}
+
+	var post blueskyPost
+	if err := json.Unmarshal(records[0]["record"], &post); err != nil {
+		t.Fatal(err)
+	}
+	if string(records[0]["repo"]) != `"did:plc:test"` || post.Text != "An article\n\n💬 https://news.ycombinator.com/item?id=1" || len(post.Facets) != 1 {
+		t.Errorf("Unexpected post %s: %+v", records[0]["repo"], post)
+	}
+	if post.Embed == nil || post.Embed.External.URI != "https://example.com/article" || post.Embed.External.Title != "The article" ||
+		post.Embed.External.Description != "About things" || !strings.Contains(string(post.Embed.External.Thumb), `"cid"`) {
+		t.Errorf("Expected a link card from the OpenGraph data, got %+v", post.Embed)
+	}
+
+	var textPost blueskyPost
+	if err := json.Unmarshal(records[1]["record"], &textPost); err != nil {
+		t.Fatal(err)
+	}
+	if textPost.Embed != nil {
+		t.Errorf("Expected no link card for a text post, got %+v", textPost.Embed)
+	}
+}
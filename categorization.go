@@ -2,12 +2,14 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 	"time"
 )
 
-// categorizeContent analyzes content and returns applicable categories based on domain and title
-func categorizeContent(title, domain, url string, categoryMapper *CategoryMapper) []string {
+// categorizeContent analyzes content and returns applicable categories based on domain, title and story type
+func categorizeContent(title, domain, url string, storyType StoryType, categoryMapper *CategoryMapper) []string {
 	var categories []string
 
 	// Add the raw domain as a category first
@@ -22,13 +24,34 @@ func categorizeContent(title, domain, url string, categoryMapper *CategoryMapper
 		}
 	}
 
-	// Content type detection
+	// Watched entities get their own category
+	for _, entity := range categoryMapper.MatchEntities(title, url) {
+		categories = append(categories, "Watch: "+entity)
+	}
+
+	// Stories about YC companies, matched by domain or name
+	categories = append(categories, categoryMapper.ycCategories(title, domain)...)
+
+	// Job posts name the hiring company and its batch in the title
+	if storyType == StoryTypeJob {
+		for _, category := range jobCompanyCategories(title) {
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+
+	// Watchlist matches are tagged so they stand out regardless of score
+	if len(categoryMapper.MatchWatchlist(title, url)) > 0 {
+		categories = append(categories, "Watchlist")
+	}
+
+	// Content type detection, with the story type from the Algolia tags taking precedence
 	titleLower := strings.ToLower(title)
+	typeCategory := storyTypeCategory(storyType)
 	switch {
-	case strings.HasPrefix(titleLower, "show hn:"):
-		categories = append(categories, "Show HN")
-	case strings.HasPrefix(titleLower, "ask hn:"):
-		categories = append(categories, "Ask HN")
+	case typeCategory != "":
+		categories = append(categories, typeCategory)
 	case strings.Contains(titleLower, "pdf") || strings.HasSuffix(url, ".pdf"):
 		categories = append(categories, "PDF")
 	case strings.Contains(titleLower, "video"):
@@ -40,6 +63,11 @@ func categorizeContent(title, domain, url string, categoryMapper *CategoryMapper
 	return categories
 }
 
+// isTextPost reports whether an item is a text post (Ask HN, launch posts, ...) without an article URL
+func isTextPost(item HackerNewsItem) bool {
+	return strings.TrimSpace(item.Link) == ""
+}
+
 // categorizeByPoints returns a category label based on point count and threshold
 func categorizeByPoints(points int, minPoints int) string {
 	switch {
@@ -58,6 +86,28 @@ func categorizeByPoints(points int, minPoints int) string {
 	}
 }
 
+// isFlamewar reports whether an item's comment-to-point ratio exceeds the given threshold
+func isFlamewar(points, commentCount int, ratio float64) bool {
+	if points <= 0 || ratio <= 0 {
+		return false
+	}
+	return float64(commentCount)/float64(points) > ratio
+}
+
+// filterFlamewars returns the items whose comment-to-point ratio does not exceed the threshold
+func filterFlamewars(items []HackerNewsItem, ratio float64) []HackerNewsItem {
+	var filtered []HackerNewsItem
+	for _, item := range items {
+		// Pinned items were added on purpose and always stay in the feed
+		if item.PinnedAt.IsZero() && isFlamewar(item.Points, item.CommentCount, ratio) {
+			slog.Debug("Excluding flamewar item", "hn_id", item.ItemID, "points", item.Points, "comments", item.CommentCount)
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
 // calculatePostAge returns a human-readable time difference from the given time to now
 func calculatePostAge(createdAt time.Time) string {
 	now := time.Now()
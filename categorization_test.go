@@ -8,7 +8,7 @@ import (
 
 // buildExpectedCategories builds the expected categories for a given domain and title
 // based on the configuration file
-func buildExpectedCategories(domain, title, url string, categoryMapper *CategoryMapper) []string {
+func buildExpectedCategories(domain, title, url string, storyType StoryType, categoryMapper *CategoryMapper) []string {
 	var expected []string
 
 	// Add raw domain if not empty
@@ -23,12 +23,17 @@ func buildExpectedCategories(domain, title, url string, categoryMapper *Category
 		}
 	}
 
+	// Add watched entity categories
+	for _, entity := range categoryMapper.MatchEntities(title, url) {
+		expected = append(expected, "Watch: "+entity)
+	}
+
 	// Add content type categories (these are not configurable)
 	titleLower := strings.ToLower(title)
 	switch {
-	case strings.HasPrefix(titleLower, "show hn:"):
+	case storyType == StoryTypeShowHN:
 		expected = append(expected, "Show HN")
-	case strings.HasPrefix(titleLower, "ask hn:"):
+	case storyType == StoryTypeAskHN:
 		expected = append(expected, "Ask HN")
 	case strings.Contains(titleLower, "pdf") || strings.HasSuffix(url, ".pdf"):
 		expected = append(expected, "PDF")
@@ -53,10 +58,11 @@ func TestCategorizeContent(t *testing.T) {
 	}
 
 	testCases := []struct {
-		name   string
-		title  string
-		domain string
-		url    string
+		name      string
+		title     string
+		domain    string
+		url       string
+		storyType StoryType
 	}{
 		{
 			name:   "GitHub repository",
@@ -71,16 +77,18 @@ func TestCategorizeContent(t *testing.T) {
 			url:    "https://arxiv.org/abs/1234.5678",
 		},
 		{
-			name:   "Show HN post",
-			title:  "Show HN: My new project",
-			domain: "example.com",
-			url:    "https://example.com/project",
+			name:      "Show HN post",
+			title:     "Show HN: My new project",
+			domain:    "example.com",
+			url:       "https://example.com/project",
+			storyType: StoryTypeShowHN,
 		},
 		{
-			name:   "Ask HN post",
-			title:  "Ask HN: How do you learn programming?",
-			domain: "news.ycombinator.com",
-			url:    "https://news.ycombinator.com/item?id=123",
+			name:      "Ask HN post",
+			title:     "Ask HN: How do you learn programming?",
+			domain:    "news.ycombinator.com",
+			url:       "https://news.ycombinator.com/item?id=123",
+			storyType: StoryTypeAskHN,
 		},
 		{
 			name:   "PDF document",
@@ -116,8 +124,8 @@ func TestCategorizeContent(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := categorizeContent(tc.title, tc.domain, tc.url, categoryMapper)
-			expected := buildExpectedCategories(tc.domain, tc.title, tc.url, categoryMapper)
+			result := categorizeContent(tc.title, tc.domain, tc.url, tc.storyType, categoryMapper)
+			expected := buildExpectedCategories(tc.domain, tc.title, tc.url, tc.storyType, categoryMapper)
 
 			if len(result) != len(expected) {
 				t.Errorf("Expected %d categories, got %d: expected=%v, actual=%v", len(expected), len(result), expected, result)
@@ -162,6 +170,170 @@ func TestCategorizeByPoints(t *testing.T) {
 	}
 }
 
+func TestIsFlamewar(t *testing.T) {
+	testCases := []struct {
+		name     string
+		points   int
+		comments int
+		ratio    float64
+		expected bool
+	}{
+		{"calm discussion", 200, 50, 1.0, false},
+		{"exactly at ratio", 100, 100, 1.0, false},
+		{"above ratio", 100, 150, 1.0, true},
+		{"custom lower ratio", 100, 60, 0.5, true},
+		{"zero points", 0, 100, 1.0, false},
+		{"disabled ratio", 100, 500, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := isFlamewar(tc.points, tc.comments, tc.ratio)
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFilterFlamewars(t *testing.T) {
+	items := []HackerNewsItem{
+		{ItemID: "1", Points: 100, CommentCount: 20},
+		{ItemID: "2", Points: 100, CommentCount: 300},
+		{ItemID: "3", Points: 50, CommentCount: 50},
+	}
+
+	filtered := filterFlamewars(items, 1.0)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 items after filtering, got %d", len(filtered))
+	}
+	for _, item := range filtered {
+		if item.ItemID == "2" {
+			t.Error("Flamewar item should have been filtered out")
+		}
+	}
+
+	// Pinned flamewars were added on purpose and are kept
+	items[1].PinnedAt = time.Now()
+	if filtered := filterFlamewars(items, 1.0); len(filtered) != 3 {
+		t.Errorf("Expected the pinned flamewar to be kept, got %d items", len(filtered))
+	}
+}
+
+func TestFlamewarConfig(t *testing.T) {
+	var nilMapper *CategoryMapper
+	if nilMapper.FlamewarRatio() != DefaultFlamewarRatio {
+		t.Errorf("Expected default ratio for nil mapper, got %f", nilMapper.FlamewarRatio())
+	}
+	if nilMapper.ExcludeFlamewars() {
+		t.Error("Nil mapper should not exclude flamewars")
+	}
+
+	mapper := NewCategoryMapper(&DomainConfig{Flamewar: FlamewarConfig{Ratio: 2.5, Exclude: true}})
+	if mapper.FlamewarRatio() != 2.5 {
+		t.Errorf("Expected configured ratio 2.5, got %f", mapper.FlamewarRatio())
+	}
+	if !mapper.ExcludeFlamewars() {
+		t.Error("Expected flamewar exclusion to be enabled")
+	}
+}
+
+func TestCategorizeContent_WatchedEntities(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{
+		Entities: []EntityConfig{
+			{Name: "PostgreSQL", Patterns: []string{`\bpostgres(ql)?\b`}},
+			{Name: "Rust", Patterns: []string{`\brust\b`, `rust-lang\.org`}},
+			{Name: "Broken", Patterns: []string{`(unclosed`}},
+		},
+	})
+
+	testCases := []struct {
+		name     string
+		title    string
+		url      string
+		expected []string
+	}{
+		{"title match", "Why we moved to Postgres", "https://example.com/", []string{"Watch: PostgreSQL"}},
+		{"url match", "Announcing 1.80", "https://blog.rust-lang.org/1.80", []string{"Watch: Rust"}},
+		{"multiple entities", "Rust bindings for PostgreSQL", "https://example.com/", []string{"Watch: PostgreSQL", "Watch: Rust"}},
+		{"no match", "Trusty old tools", "https://example.com/", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			categories := categorizeContent(tc.title, "", tc.url, StoryTypeStory, mapper)
+			var watched []string
+			for _, cat := range categories {
+				if strings.HasPrefix(cat, "Watch: ") {
+					watched = append(watched, cat)
+				}
+			}
+			if len(watched) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, watched)
+			}
+			for i := range watched {
+				if watched[i] != tc.expected[i] {
+					t.Errorf("Expected %v, got %v", tc.expected, watched)
+				}
+			}
+		})
+	}
+}
+
+func TestCategoryAliases(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{
+		CategoryDomains: map[string][]string{
+			"Twitter": {"twitter.com"},
+			"X":       {"x.com"},
+			"YouTube": {"youtube.com"},
+		},
+		CategoryAliases: map[string]string{"twitter": "Social", "X": "Social", "YouTube": "Video"},
+	})
+
+	if got := mapper.CategoryAlias("TWITTER"); got != "Social" {
+		t.Errorf("Expected aliases to match case-insensitively, got %q", got)
+	}
+	if got := mapper.CategoryAlias("GitHub"); got != "GitHub" {
+		t.Errorf("Expected unaliased categories to be kept, got %q", got)
+	}
+
+	// A YouTube video titled "video" would otherwise be tagged Video twice
+	item := HackerNewsItem{Title: "A video about Go", Link: "https://youtube.com/watch?v=1", Points: 120}
+	categories := itemCategoryList(item, 50, mapper)
+	videos := 0
+	for _, category := range categories {
+		if category == "YouTube" {
+			t.Error("Expected YouTube to be merged into Video")
+		}
+		if category == "Video" {
+			videos++
+		}
+	}
+	if videos != 1 {
+		t.Errorf("Expected exactly one Video category, got %v", categories)
+	}
+
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Tweet", Link: "https://twitter.com/a/status/1", Points: 100},
+		{ItemID: "2", Title: "Post", Link: "https://x.com/b/status/2", Points: 100},
+		{ItemID: "3", Title: "Repo", Link: "https://github.com/c/d", Points: 100},
+	}
+	if filtered := filterItemsByCategory(items, []string{"Social"}, nil, 50, mapper); len(filtered) != 2 {
+		t.Errorf("Expected both social items for category=Social, got %d", len(filtered))
+	}
+	if filtered := filterItemsByCategory(items, []string{"Twitter"}, nil, 50, mapper); len(filtered) != 2 {
+		t.Errorf("Expected an aliased name to select the merged category, got %d", len(filtered))
+	}
+	if section := newsletterSectionName(items[1], mapper); section != "Social" {
+		t.Errorf("Expected newsletter section Social, got %q", section)
+	}
+
+	var nilMapper *CategoryMapper
+	if got := nilMapper.AliasCategories([]string{"a", "a"}); len(got) != 2 {
+		t.Errorf("Expected a nil mapper to leave categories untouched, got %v", got)
+	}
+}
+
 func TestCalculatePostAge(t *testing.T) {
 	now := time.Now()
 
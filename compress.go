@@ -0,0 +1,145 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// supportedEncodings lists response encodings in order of server preference
+var supportedEncodings = []string{"br", "gzip"}
+
+// negotiateEncoding picks the preferred supported encoding from an Accept-Encoding header.
+// Returns empty string if the client accepts none of them.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		accepted[name] = quality
+	}
+
+	best := ""
+	bestQuality := 0.0
+	for _, encoding := range supportedEncodings {
+		quality, ok := accepted[encoding]
+		if !ok {
+			quality, ok = accepted["*"]
+		}
+		if ok && quality > bestQuality {
+			best = encoding
+			bestQuality = quality
+		}
+	}
+	return best
+}
+
+// compressibleType reports whether a Content-Type is text that compresses well: HTML, XML
+// feeds, JSON and other text. Images, audio and archives are already compressed.
+func compressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json" || mediaType == "application/xml" || mediaType == "application/javascript":
+		return true
+	case strings.HasSuffix(mediaType, "+xml") || strings.HasSuffix(mediaType, "+json"):
+		return true
+	}
+	return false
+}
+
+// compressResponseWriter compresses the body written to the wrapped ResponseWriter. The decision
+// waits for the status and headers: responses without a body, partial content and
+// non-text content types pass through unchanged.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string
+	writer   io.WriteCloser
+	status   int  // status set by the handler, 0 until then
+	compress bool // whether the body is compressed, decided with the status
+}
+
+func (w *compressResponseWriter) WriteHeader(statusCode int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = statusCode
+	header := w.Header()
+	w.compress = statusCode >= 200 && statusCode != http.StatusNoContent &&
+		statusCode != http.StatusPartialContent && statusCode != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		compressibleType(header.Get("Content-Type"))
+	if !w.compress {
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
+	// Compressed responses send their headers with the first body bytes, so an empty body
+	// goes out without Content-Encoding
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.compress {
+		return w.ResponseWriter.Write(b)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if w.writer == nil {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+		w.ResponseWriter.WriteHeader(w.status)
+		switch w.encoding {
+		case "br":
+			w.writer = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+		default:
+			w.writer = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+	return w.writer.Write(b)
+}
+
+// close finishes the compressed body, or sends the headers of a response that had no body
+func (w *compressResponseWriter) close() {
+	switch {
+	case w.writer != nil:
+		_ = w.writer.Close()
+	case w.compress:
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// compressHandler wraps a handler with gzip/brotli compression negotiated via Accept-Encoding.
+// Range requests are passed through, since compressing would change the byte offsets.
+func compressHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer writer.close()
+		next.ServeHTTP(writer, r)
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip;q=0.8", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+		{"*", "br"},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			result := negotiateEncoding(tc.header)
+			if result != tc.expected {
+				t.Errorf("Expected %q for %q, got %q", tc.expected, tc.header, result)
+			}
+		})
+	}
+}
+
+func TestCompressHandler(t *testing.T) {
+	body := strings.Repeat("<entry>compressible</entry>", 100)
+	handler := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}))
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+
+	for encoding, decode := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", encoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Header().Get("Content-Encoding") != encoding {
+				t.Fatalf("Expected Content-Encoding %s, got %q", encoding, rec.Header().Get("Content-Encoding"))
+			}
+			if rec.Body.Len() >= len(body) {
+				t.Errorf("Expected compressed body to be smaller than %d bytes, got %d", len(body), rec.Body.Len())
+			}
+
+			reader, err := decode(rec.Body)
+			if err != nil {
+				t.Fatalf("Failed to create decoder: %v", err)
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Failed to decode body: %v", err)
+			}
+			if string(decoded) != body {
+				t.Error("Decoded body does not match original")
+			}
+		})
+	}
+
+	t.Run("uncompressed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Header().Get("Content-Encoding") != "" {
+			t.Error("Expected no Content-Encoding without Accept-Encoding")
+		}
+		if rec.Body.String() != body {
+			t.Error("Expected uncompressed body")
+		}
+		if rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Error("Expected Vary: Accept-Encoding header")
+		}
+	})
+}
+
+func TestCompressHandler_PassesThrough(t *testing.T) {
+	body := strings.Repeat("<entry>compressible</entry>", 100)
+	testCases := []struct {
+		name        string
+		rangeHeader string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"range request", "bytes=0-99", http.StatusOK, "text/plain", body},
+		{"partial content", "", http.StatusPartialContent, "text/plain", body},
+		{"not modified", "", http.StatusNotModified, "application/atom+xml", ""},
+		{"no content", "", http.StatusNoContent, "application/json", ""},
+		{"audio", "", http.StatusOK, "audio/mpeg", body},
+		{"no content type", "", http.StatusOK, "", body},
+		{"empty body", "", http.StatusOK, "application/json", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip, br")
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, rec.Code)
+			}
+			if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+				t.Errorf("Expected no Content-Encoding, got %q", encoding)
+			}
+			if rec.Body.String() != tc.body {
+				t.Errorf("Expected the body unchanged, got %d bytes", rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestCompressHandler_OnlyFeedAPIAndAdminRoutes(t *testing.T) {
+	server := setupTestServer(t)
+	server.podcastDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(server.podcastDir, "episode.txt"), []byte(strings.Repeat("transcript ", 200)), 0644); err != nil {
+		t.Fatalf("Failed to write podcast file: %v", err)
+	}
+	handler := server.routes()
+
+	for path, compressed := range map[string]bool{
+		"/feed.xml":            true,
+		"/api/items":           true,
+		"/podcast/episode.txt": false,
+		"/healthz":             false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Content-Encoding") == "gzip"; got != compressed {
+			t.Errorf("%s: expected compressed=%v, got Content-Encoding %q (status %d)", path, compressed, rec.Header().Get("Content-Encoding"), rec.Code)
+		}
+	}
+}
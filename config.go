@@ -8,27 +8,98 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
-	"time"
+	"text/template"
 )
 
 // DomainConfig represents the configuration structure for domain mappings
 type DomainConfig struct {
 	CategoryDomains map[string][]string `json:"category_domains"`
+	DomainFeeds     []string            `json:"domain_feeds"`     // category_domains groups that get a feed file of their own
+	BlockedDomains  []string            `json:"blocked_domains"`  // article domains, with their subdomains, never shown in feeds
+	AllowedDomains  []string            `json:"allowed_domains"`  // when set, only article domains on this list are shown
+	BlockedKeywords []string            `json:"blocked_keywords"` // case-insensitive title keywords whose stories are never shown
+	BlockedAuthors  []string            `json:"blocked_authors"`  // HN submitters whose stories are muted
+	AllowedAuthors  []string            `json:"allowed_authors"`  // when set, only stories from these submitters are shown
+	CategoryAliases map[string]string   `json:"category_aliases"` // category name -> category it is merged into
+	Flamewar        FlamewarConfig      `json:"flamewar"`
+	Entities        []EntityConfig      `json:"entities"`
+	Watchlist       []string            `json:"watchlist"` // case-insensitive keywords or regular expressions that trigger alerts
+	Notifiers       []NotifierConfig    `json:"notifiers"`
+	ThresholdAlerts []ThresholdConfig   `json:"threshold_alerts"` // notifications when stories first reach a number of points
+	Summarizer      SummarizerConfig    `json:"summarizer"`
+	Discussion      DiscussionConfig    `json:"discussion"`
+	Translation     TranslationConfig   `json:"translation"`
+	Podcast         PodcastConfig       `json:"podcast"`
+	OpenGraph       OpenGraphConfig     `json:"opengraph"`
+	Fetch           FetchConfig         `json:"fetch"` // which Algolia story set each run tracks
+	Timeouts        TimeoutsConfig      `json:"timeouts"`
+	Replication     ReplicationConfig   `json:"replication"`
+	Coordination    CoordinationConfig  `json:"coordination"`
+	Archive         ArchiveConfig       `json:"archive"`
+	TitleTemplate   string              `json:"title_template"` // Go template for entry titles, e.g. "{{.Trend}} {{.Title}}"
+	Authors         AuthorsConfig       `json:"authors"`
+	YC              YCConfig            `json:"yc"`
+	Previously      PreviouslyConfig    `json:"previously"`
+	TopComment      TopCommentConfig    `json:"top_comment"`
+	WebSub          WebSubConfig        `json:"websub"`
+	Mastodon        MastodonConfig      `json:"mastodon"`
+	Bluesky         BlueskyConfig       `json:"bluesky"`
+	SMTP            SMTPConfig          `json:"smtp"` // mail server for the digest subcommand
+	Upload          UploadConfig        `json:"upload"`
+	Linkding        LinkdingConfig      `json:"linkding"`
+	Karakeep        KarakeepConfig      `json:"karakeep"`
+	ACME            ACMEConfig          `json:"acme"` // Let's Encrypt certificates for HTTPS in serve mode
+}
+
+// EntityConfig describes a watched entity (company, project, person) and the patterns that identify it
+type EntityConfig struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"patterns"` // case-insensitive regular expressions matched against title and URL
+}
+
+// watchedEntity is a compiled EntityConfig
+type watchedEntity struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// FlamewarConfig controls detection of items with a high comment-to-point ratio
+type FlamewarConfig struct {
+	Ratio   float64 `json:"ratio"`   // comments per point above which an item is a flamewar (0 = default)
+	Exclude bool    `json:"exclude"` // drop flamewar items from the feed entirely
 }
 
 // CategoryMapper provides methods for domain categorization
 type CategoryMapper struct {
 	config           *DomainConfig
 	domainToCategory map[string]string // reverse lookup for efficient searching
+	aliases          map[string]string // lowercased category name -> merged category
+	entities         []watchedEntity
+	watchlist        []watchlistTerm
+	titleTemplate    *template.Template
+	ycCompanies      []ycCompany
+	file             string // local file the config was loaded from, or "" if it came from the remote URL
+}
+
+// watchlistTerm is a compiled watchlist entry
+type watchlistTerm struct {
+	term string
+	re   *regexp.Regexp
 }
 
 // Default configuration URL
 const DefaultConfigURL = "https://raw.githubusercontent.com/lepinkainen/hntop-rss/refs/heads/main/configs/domains.json"
 
+// DefaultFlamewarRatio is the comment-to-point ratio used when none is configured
+const DefaultFlamewarRatio = 1.0
+
 // loadConfigFromURL loads configuration from a remote URL with timeout
 func loadConfigFromURL(url string) (*DomainConfig, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	timeout := configFetchTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -37,7 +108,7 @@ func loadConfigFromURL(url string) (*DomainConfig, error) {
 	}
 
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: timeout,
 	}
 
 	resp, err := client.Do(req)
@@ -63,6 +134,48 @@ func loadConfigFromURL(url string) (*DomainConfig, error) {
 	return &config, nil
 }
 
+// saveConfigToFile writes configuration to a local file as indented JSON. It writes a temporary
+// file next to it and renames it into place, so a failed write leaves the old file intact.
+func saveConfigToFile(path string, config *DomainConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	// Keep the permissions of the existing file, which may hold secrets
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return nil
+}
+
 // loadConfigFromFile loads configuration from a local file
 func loadConfigFromFile(filepath string) (*DomainConfig, error) {
 	data, err := os.ReadFile(filepath)
@@ -85,8 +198,10 @@ func loadConfigFromFile(filepath string) (*DomainConfig, error) {
 func LoadConfig(configPath, configURL string) *CategoryMapper {
 	var config *DomainConfig
 	var err error
+	var loadedFrom string
 
-	// Try loading from local file first (if specified)
+	// Try loading from local file first (if specified, or present in the config directory)
+	configPath = resolveConfigPath(configPath)
 	if configPath != "" {
 		slog.Debug("Loading config from local file", "path", configPath)
 		config, err = loadConfigFromFile(configPath)
@@ -94,6 +209,7 @@ func LoadConfig(configPath, configURL string) *CategoryMapper {
 			slog.Warn("Failed to load local config, trying remote", "error", err)
 		} else {
 			slog.Info("Successfully loaded config from local file", "path", configPath)
+			loadedFrom = configPath
 		}
 	}
 
@@ -119,7 +235,22 @@ func LoadConfig(configPath, configURL string) *CategoryMapper {
 		return nil
 	}
 
-	return NewCategoryMapper(config)
+	if err := validateTimeouts(config.Timeouts); err != nil {
+		slog.Warn("Invalid timeout configuration, using defaults", "error", err)
+		config.Timeouts = TimeoutsConfig{}
+	}
+	if err := validateFetch(config.Fetch); err != nil {
+		slog.Warn("Invalid fetch configuration, using the front page", "error", err)
+		config.Fetch = FetchConfig{}
+	}
+	if err := validateCoordination(config.Coordination); err != nil {
+		slog.Error("Invalid coordination configuration, coordination disabled", "error", err)
+		config.Coordination = CoordinationConfig{}
+	}
+
+	mapper := NewCategoryMapper(config)
+	mapper.file = loadedFrom
+	return mapper
 }
 
 // NewCategoryMapper creates a new CategoryMapper with reverse lookup optimization
@@ -127,6 +258,7 @@ func NewCategoryMapper(config *DomainConfig) *CategoryMapper {
 	mapper := &CategoryMapper{
 		config:           config,
 		domainToCategory: make(map[string]string),
+		aliases:          make(map[string]string),
 	}
 
 	// Build reverse lookup map for efficient domain matching
@@ -136,7 +268,62 @@ func NewCategoryMapper(config *DomainConfig) *CategoryMapper {
 		}
 	}
 
-	slog.Debug("CategoryMapper initialized", "categories", len(config.CategoryDomains), "domain_mappings", len(mapper.domainToCategory))
+	// Aliases are resolved once, so "A": "B", "B": "C" merges A into B, not C
+	for name, target := range config.CategoryAliases {
+		if name = strings.TrimSpace(name); name != "" && strings.TrimSpace(target) != "" {
+			mapper.aliases[strings.ToLower(name)] = strings.TrimSpace(target)
+		}
+	}
+
+	// Compile entity watch patterns, skipping invalid ones
+	for _, entity := range config.Entities {
+		if entity.Name == "" {
+			continue
+		}
+		compiled := watchedEntity{name: entity.Name}
+		for _, pattern := range entity.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				slog.Warn("Invalid entity pattern, skipping", "entity", entity.Name, "pattern", pattern, "error", err)
+				continue
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+		if len(compiled.patterns) > 0 {
+			mapper.entities = append(mapper.entities, compiled)
+		}
+	}
+
+	// Compile watchlist terms; anything that isn't a valid regex is matched literally
+	for _, term := range config.Watchlist {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + term)
+		if err != nil {
+			re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+		}
+		mapper.watchlist = append(mapper.watchlist, watchlistTerm{term: term, re: re})
+	}
+
+	// A missing companies file still leaves the inline companies
+	companies, err := loadYCCompanies(config.YC)
+	if err != nil {
+		slog.Warn("Failed to load YC companies", "error", err)
+	}
+	mapper.ycCompanies = compileYCCompanies(companies)
+
+	// An invalid title template is ignored rather than breaking every entry title
+	if strings.TrimSpace(config.TitleTemplate) != "" {
+		tmpl, err := parseTitleTemplate(config.TitleTemplate)
+		if err != nil {
+			slog.Warn("Invalid title template, using plain titles", "template", config.TitleTemplate, "error", err)
+		} else {
+			mapper.titleTemplate = tmpl
+		}
+	}
+
+	slog.Debug("CategoryMapper initialized", "categories", len(config.CategoryDomains), "domain_mappings", len(mapper.domainToCategory), "aliases", len(mapper.aliases), "entities", len(mapper.entities), "watchlist", len(mapper.watchlist), "yc_companies", len(mapper.ycCompanies))
 	return mapper
 }
 
@@ -159,6 +346,70 @@ func (cm *CategoryMapper) GetCategoryForDomain(domain string) string {
 	return ""
 }
 
+// withAuthorLists returns the filter with the configured author block and allow lists
+func (cm *CategoryMapper) withAuthorLists(filter ItemFilter) ItemFilter {
+	if cm != nil {
+		filter.BlockedAuthors = cm.config.BlockedAuthors
+		filter.AllowedAuthors = cm.config.AllowedAuthors
+	}
+	return filter
+}
+
+// CategoryAlias returns the category a category name is merged into, or the name itself
+func (cm *CategoryMapper) CategoryAlias(name string) string {
+	if cm == nil {
+		return name
+	}
+	if target, ok := cm.aliases[strings.ToLower(name)]; ok {
+		return target
+	}
+	return name
+}
+
+// AliasCategories replaces aliased categories with the categories they are merged into,
+// dropping the duplicates that merging creates
+func (cm *CategoryMapper) AliasCategories(categories []string) []string {
+	if cm == nil || len(cm.aliases) == 0 {
+		return categories
+	}
+
+	result := make([]string, 0, len(categories))
+	seen := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		category = cm.CategoryAlias(category)
+		if key := strings.ToLower(category); !seen[key] {
+			seen[key] = true
+			result = append(result, category)
+		}
+	}
+	return result
+}
+
+// ConfigFile returns the local file the configuration was loaded from, or "" if it came from
+// the remote URL or none could be loaded
+func (cm *CategoryMapper) ConfigFile() string {
+	if cm == nil {
+		return ""
+	}
+	return cm.file
+}
+
+// Config returns a copy of the underlying configuration, or an empty one for a nil mapper
+func (cm *CategoryMapper) Config() DomainConfig {
+	if cm == nil {
+		return DomainConfig{}
+	}
+	return *cm.config
+}
+
+// TitleTemplate returns the compiled entry title template, or nil to use plain titles
+func (cm *CategoryMapper) TitleTemplate() *template.Template {
+	if cm == nil {
+		return nil
+	}
+	return cm.titleTemplate
+}
+
 // GetAllCategories returns all available categories
 func (cm *CategoryMapper) GetAllCategories() []string {
 	categories := make([]string, 0, len(cm.config.CategoryDomains))
@@ -167,3 +418,73 @@ func (cm *CategoryMapper) GetAllCategories() []string {
 	}
 	return categories
 }
+
+// MatchEntities returns the names of watched entities whose patterns match the title or URL
+func (cm *CategoryMapper) MatchEntities(title, url string) []string {
+	if cm == nil {
+		return nil
+	}
+
+	var matches []string
+	for _, entity := range cm.entities {
+		for _, re := range entity.patterns {
+			if re.MatchString(title) || re.MatchString(url) {
+				matches = append(matches, entity.name)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// MatchWatchlist returns the watchlist terms that match the title or URL
+func (cm *CategoryMapper) MatchWatchlist(title, url string) []string {
+	if cm == nil {
+		return nil
+	}
+
+	var matches []string
+	for _, w := range cm.watchlist {
+		if w.re.MatchString(title) || w.re.MatchString(url) {
+			matches = append(matches, w.term)
+		}
+	}
+	return matches
+}
+
+// Notifiers returns the configured notifiers
+func (cm *CategoryMapper) Notifiers() []NotifierConfig {
+	if cm == nil {
+		return nil
+	}
+	return cm.config.Notifiers
+}
+
+// Timeouts returns the network timeouts with defaults applied
+func (cm *CategoryMapper) Timeouts() networkTimeouts {
+	if cm == nil {
+		return defaultNetworkTimeouts()
+	}
+	return resolveTimeouts(cm.config.Timeouts)
+}
+
+// Fetch returns the configured story set with defaults applied
+func (cm *CategoryMapper) Fetch() FetchConfig {
+	if cm == nil {
+		return resolveFetch(FetchConfig{})
+	}
+	return resolveFetch(cm.config.Fetch)
+}
+
+// FlamewarRatio returns the configured flamewar comment-to-point ratio, falling back to the default
+func (cm *CategoryMapper) FlamewarRatio() float64 {
+	if cm == nil || cm.config.Flamewar.Ratio <= 0 {
+		return DefaultFlamewarRatio
+	}
+	return cm.config.Flamewar.Ratio
+}
+
+// ExcludeFlamewars reports whether flamewar items should be dropped from the feed
+func (cm *CategoryMapper) ExcludeFlamewars() bool {
+	return cm != nil && cm.config.Flamewar.Exclude
+}
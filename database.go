@@ -2,37 +2,92 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "modernc.org/sqlite" // Pure Go SQLite driver
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // dbMutex protects concurrent access to OpenGraph database operations
 var dbMutex sync.Mutex
 
-// initDB initializes and returns a SQLite database connection
+// busyRetryAttempts is the number of retries after the first attempt
+const busyRetryAttempts = 5
+
+// busyRetryBaseDelay is doubled on every retry, plus up to 100% jitter
+var busyRetryBaseDelay = 20 * time.Millisecond
+
+// isBusyError reports whether err is SQLite reporting the database as busy or locked, or
+// PostgreSQL aborting a statement over a serialization failure or deadlock
+func isBusyError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	var coded interface{ Code() int }
+	if !errors.As(err, &coded) {
+		return false
+	}
+	code := coded.Code() & 0xff // extended result codes keep the primary code in the low byte
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// withBusyRetry runs a database write, retrying with jittered exponential backoff
+// for a bounded number of attempts while the database reports it as busy
+func withBusyRetry(op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || !isBusyError(err) || attempt == busyRetryAttempts {
+			return err
+		}
+		delay := busyRetryBaseDelay << attempt
+		delay += rand.N(delay)
+		slog.Debug("Database busy, retrying", "attempt", attempt+1, "delay", delay, "error", err)
+		time.Sleep(delay)
+	}
+}
+
+// execWithRetry runs db.Exec, retrying while the database is busy
+func execWithRetry(db *sql.DB, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := withBusyRetry(func() error {
+		var err error
+		result, err = db.Exec(query, args...)
+		return err
+	})
+	return result, err
+}
+
+// initDB initializes and returns the database connection: the SQLite file in the data
+// directory, or the PostgreSQL database named by $HNTOP_DATABASE_URL
 func initDB() *sql.DB {
-	// Get the directory of the executable
-	exePath, err := os.Executable()
+	db, err := openDatabase()
 	if err != nil {
-		slog.Error("Error getting executable path", "error", err)
+		slog.Error("Failed to open database", "error", err)
 		os.Exit(1)
 	}
-	exeDir := filepath.Dir(exePath)
-	dbPath := filepath.Join(exeDir, "hackernews.db")
-	slog.Debug("Initializing database", "path", dbPath)
 
-	// Open database in the executable's directory
-	db, err := sql.Open("sqlite", dbPath) // Use "sqlite" driver name
-	if err != nil {
-		slog.Error("Failed to open database", "error", err)
+	if err := createSchema(db); err != nil {
+		slog.Error("Failed to initialize database schema", "error", err)
 		os.Exit(1)
 	}
+	slog.Debug("Database initialized successfully", "backend", backendOf(db).name())
+
+	return db
+}
+
+// createSchema creates all tables and indexes if they don't exist
+func createSchema(db *sql.DB) error {
+	backend := backendOf(db)
 
 	// Create items table if it doesn't exist
 	createItemsTable := `
@@ -46,12 +101,53 @@ func initDB() *sql.DB {
 		comment_count INTEGER DEFAULT 0,
 		author TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		pinned_at TIMESTAMP,                    -- when the item was manually added to the feed
+		canonical_url TEXT,                     -- article URL after redirects and rel=canonical, if resolved
+		story_type TEXT,                        -- story, show_hn, ask_hn, poll or job from the Algolia tags
+		dead_at TIMESTAMP,                      -- when Algolia stopped returning the item as dead or flagged
+		mastodon_posted_at TIMESTAMP,           -- when the item was posted to Mastodon
+		bluesky_posted_at TIMESTAMP,            -- when the item was posted to Bluesky
+		linkding_saved_at TIMESTAMP,            -- when the item was bookmarked in Linkding
+		karakeep_saved_at TIMESTAMP             -- when the item was bookmarked in Karakeep
 	)`
-	_, err = db.Exec(createItemsTable)
-	if err != nil {
-		slog.Error("Failed to create items table", "error", err)
-		os.Exit(1)
+	if _, err := db.Exec(backend.ddl(createItemsTable)); err != nil {
+		return fmt.Errorf("failed to create items table: %w", err)
+	}
+	if err := addColumnIfMissing(db, "items", "pinned_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "canonical_url", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "story_type", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "dead_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "mastodon_posted_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "bluesky_posted_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "linkding_saved_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "items", "karakeep_saved_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+
+	// Duplicate submissions are looked up by submitted and canonical URL
+	createItemIndexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_items_link ON items(link)",
+		"CREATE INDEX IF NOT EXISTS idx_items_canonical_url ON items(canonical_url)",
+	}
+	for _, indexSQL := range createItemIndexes {
+		if _, err := db.Exec(indexSQL); err != nil {
+			return fmt.Errorf("failed to create items index: %w", err)
+		}
 	}
 
 	// Create OpenGraph cache table if it doesn't exist
@@ -65,12 +161,22 @@ func initDB() *sql.DB {
 		site_name TEXT,
 		fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		expires_at TIMESTAMP,
-		fetch_success BOOLEAN DEFAULT TRUE
+		fetch_success BOOLEAN DEFAULT TRUE,
+		final_url TEXT,                         -- URL the fetch ended up at after redirects
+		canonical_url TEXT,                     -- rel=canonical of the page, or the final URL
+		error_class TEXT                        -- class of the failure when fetch_success is false
 	)`
-	_, err = db.Exec(createOGCacheTable)
-	if err != nil {
-		slog.Error("Failed to create opengraph_cache table", "error", err)
-		os.Exit(1)
+	if _, err := db.Exec(backend.ddl(createOGCacheTable)); err != nil {
+		return fmt.Errorf("failed to create opengraph_cache table: %w", err)
+	}
+	if err := addColumnIfMissing(db, "opengraph_cache", "final_url", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "opengraph_cache", "canonical_url", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(db, "opengraph_cache", "error_class", "TEXT"); err != nil {
+		return err
 	}
 
 	// Create indexes for opengraph_cache table
@@ -79,15 +185,231 @@ func initDB() *sql.DB {
 		"CREATE INDEX IF NOT EXISTS idx_opengraph_expires ON opengraph_cache(expires_at)",
 	}
 	for _, indexSQL := range createOGIndexes {
-		_, err = db.Exec(indexSQL)
-		if err != nil {
-			slog.Error("Failed to create opengraph_cache index", "error", err)
-			os.Exit(1)
+		if _, err := db.Exec(indexSQL); err != nil {
+			return fmt.Errorf("failed to create opengraph_cache index: %w", err)
 		}
 	}
-	slog.Debug("Database initialized successfully")
 
-	return db
+	// Create opengraph_queue table holding URLs waiting for an OpenGraph fetch, so the work survives restarts
+	createOGQueueTable := `
+	CREATE TABLE IF NOT EXISTS opengraph_queue (
+		url TEXT PRIMARY KEY,
+		priority INTEGER DEFAULT 0,             -- points of the best item linking to the URL; highest is fetched first
+		attempts INTEGER DEFAULT 0,             -- failed fetches so far
+		next_attempt_at TIMESTAMP,              -- not fetched again before this, backing off after failures
+		last_error TEXT,
+		last_error_class TEXT,                  -- fetch error class of the last failure
+		queued_at TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createOGQueueTable)); err != nil {
+		return fmt.Errorf("failed to create opengraph_queue table: %w", err)
+	}
+	if err := addColumnIfMissing(db, "opengraph_queue", "last_error_class", "TEXT"); err != nil {
+		return err
+	}
+
+	// Create feed profiles table for tokenized personalized feeds
+	createProfilesTable := `
+	CREATE TABLE IF NOT EXISTS feed_profiles (
+		token TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		keywords TEXT,                          -- JSON array of title keywords
+		categories TEXT,                        -- JSON array of categories to include
+		exclude TEXT,                           -- JSON array of categories to exclude
+		min_points INTEGER DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		format TEXT                             -- JSON object of rendering toggles
+	)`
+	if _, err := db.Exec(backend.ddl(createProfilesTable)); err != nil {
+		return fmt.Errorf("failed to create feed_profiles table: %w", err)
+	}
+	if err := addColumnIfMissing(db, "feed_profiles", "format", "TEXT"); err != nil {
+		return err
+	}
+
+	// Create runs table recording each fetch/update run
+	createRunsTable := `
+	CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		started_at TIMESTAMP,
+		finished_at TIMESTAMP,
+		fetched INTEGER DEFAULT 0,
+		updated INTEGER DEFAULT 0,
+		feed_items INTEGER DEFAULT 0,
+		error TEXT,
+		fetch_errors TEXT                       -- JSON object of failed fetches by "source/class"
+	)`
+	if _, err := db.Exec(backend.ddl(createRunsTable)); err != nil {
+		return fmt.Errorf("failed to create runs table: %w", err)
+	}
+	if err := addColumnIfMissing(db, "runs", "fetch_errors", "TEXT"); err != nil {
+		return err
+	}
+
+	// Create watchlist alerts table so each item triggers notifications only once
+	createWatchlistAlertsTable := `
+	CREATE TABLE IF NOT EXISTS watchlist_alerts (
+		item_hn_id TEXT PRIMARY KEY,
+		alerted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createWatchlistAlertsTable)); err != nil {
+		return fmt.Errorf("failed to create watchlist_alerts table: %w", err)
+	}
+
+	// Create threshold alerts table so each item triggers each threshold's notifications only once
+	createThresholdAlertsTable := `
+	CREATE TABLE IF NOT EXISTS threshold_alerts (
+		item_hn_id TEXT NOT NULL,
+		points INTEGER NOT NULL, -- the configured threshold that was crossed
+		alerted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (item_hn_id, points)
+	)`
+	if _, err := db.Exec(backend.ddl(createThresholdAlertsTable)); err != nil {
+		return fmt.Errorf("failed to create threshold_alerts table: %w", err)
+	}
+
+	// Create article summaries table keyed by article URL
+	createSummariesTable := `
+	CREATE TABLE IF NOT EXISTS summaries (
+		url TEXT PRIMARY KEY,
+		summary TEXT NOT NULL,
+		model TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createSummariesTable)); err != nil {
+		return fmt.Errorf("failed to create summaries table: %w", err)
+	}
+
+	// Create discussion summaries table keyed by item
+	createDiscussionSummariesTable := `
+	CREATE TABLE IF NOT EXISTS discussion_summaries (
+		item_hn_id TEXT PRIMARY KEY,
+		summary TEXT NOT NULL,
+		comment_count INTEGER DEFAULT 0,        -- comment count when the summary was made
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createDiscussionSummariesTable)); err != nil {
+		return fmt.Errorf("failed to create discussion_summaries table: %w", err)
+	}
+
+	// Create translations table keyed by source text and target language
+	createTranslationsTable := `
+	CREATE TABLE IF NOT EXISTS translations (
+		source_text TEXT NOT NULL,
+		target_language TEXT NOT NULL,
+		translated_text TEXT,                   -- empty when the text is already in the target language
+		source_language TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (source_text, target_language)
+	)`
+	if _, err := db.Exec(backend.ddl(createTranslationsTable)); err != nil {
+		return fmt.Errorf("failed to create translations table: %w", err)
+	}
+
+	// Create front page sightings table used to detect returning stories
+	createSightingsTable := `
+	CREATE TABLE IF NOT EXISTS item_sightings (
+		item_hn_id TEXT PRIMARY KEY,
+		last_seen_at TIMESTAMP NOT NULL,        -- last time the item was on the front page
+		last_points INTEGER DEFAULT 0,          -- points when last seen on the front page
+		last_comment_count INTEGER DEFAULT 0,
+		returned_at TIMESTAMP                   -- when the item last came back to the front page
+	)`
+	if _, err := db.Exec(backend.ddl(createSightingsTable)); err != nil {
+		return fmt.Errorf("failed to create item_sightings table: %w", err)
+	}
+
+	// Create leader lease table used to coordinate instances sharing the database
+	createLeaderLeaseTable := `
+	CREATE TABLE IF NOT EXISTS leader_lease (
+		name TEXT PRIMARY KEY,                  -- what the lease is for, e.g. "refresh"
+		holder TEXT NOT NULL,                   -- instance ID of the current leader
+		expires_at BIGINT NOT NULL              -- Unix time after which another instance may take over
+	)`
+	if _, err := db.Exec(backend.ddl(createLeaderLeaseTable)); err != nil {
+		return fmt.Errorf("failed to create leader_lease table: %w", err)
+	}
+
+	// Create archive log table tracking which items have been written to the JSONL archive
+	createArchiveLogTable := `
+	CREATE TABLE IF NOT EXISTS archive_log (
+		item_hn_id TEXT PRIMARY KEY,
+		first_seen_at TIMESTAMP,                -- when the first-seen record was appended
+		final_at TIMESTAMP                      -- when the final stats record was appended
+	)`
+	if _, err := db.Exec(backend.ddl(createArchiveLogTable)); err != nil {
+		return fmt.Errorf("failed to create archive_log table: %w", err)
+	}
+
+	// Create polls table caching whether a text post is a poll and its options
+	createPollsTable := `
+	CREATE TABLE IF NOT EXISTS polls (
+		item_hn_id TEXT PRIMARY KEY,
+		is_poll BOOLEAN NOT NULL,               -- false for text posts that turned out not to be polls
+		options TEXT,                           -- JSON array of options with their vote counts
+		fetched_at TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createPollsTable)); err != nil {
+		return fmt.Errorf("failed to create polls table: %w", err)
+	}
+
+	// Create previous_discussions table caching earlier submissions of each item's article
+	createPreviousDiscussionsTable := `
+	CREATE TABLE IF NOT EXISTS previous_discussions (
+		item_hn_id TEXT PRIMARY KEY,
+		discussions TEXT,                       -- JSON array of earlier submissions, newest first
+		fetched_at TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createPreviousDiscussionsTable)); err != nil {
+		return fmt.Errorf("failed to create previous_discussions table: %w", err)
+	}
+
+	// Create top_comments table caching the highest ranked comment of each story
+	createTopCommentsTable := `
+	CREATE TABLE IF NOT EXISTS top_comments (
+		item_hn_id TEXT PRIMARY KEY,
+		comment_hn_id TEXT NOT NULL,
+		author TEXT,
+		text TEXT,                              -- plain text of the whole comment
+		comment_count INTEGER DEFAULT 0,        -- the story's comment count when fetched; refreshed when it changes
+		fetched_at TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createTopCommentsTable)); err != nil {
+		return fmt.Errorf("failed to create top_comments table: %w", err)
+	}
+
+	// Create authors table caching submitter karma and account age
+	createAuthorsTable := `
+	CREATE TABLE IF NOT EXISTS authors (
+		username TEXT PRIMARY KEY,
+		karma INTEGER DEFAULT 0,
+		account_created_at TIMESTAMP,           -- when the HN account was created
+		fetched_at TIMESTAMP
+	)`
+	if _, err := db.Exec(backend.ddl(createAuthorsTable)); err != nil {
+		return fmt.Errorf("failed to create authors table: %w", err)
+	}
+
+	// Create stats history table with a snapshot of each item's stats per run
+	createStatsHistoryTable := `
+	CREATE TABLE IF NOT EXISTS item_stats_history (
+		item_hn_id TEXT NOT NULL,
+		recorded_at TIMESTAMP NOT NULL,         -- start of the run that took the snapshot
+		points INTEGER DEFAULT 0,
+		comment_count INTEGER DEFAULT 0,
+		PRIMARY KEY (item_hn_id, recorded_at)
+	)`
+	if _, err := db.Exec(backend.ddl(createStatsHistoryTable)); err != nil {
+		return fmt.Errorf("failed to create item_stats_history table: %w", err)
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds a column to a table created by an older version of the schema
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	return backendOf(db).addColumnIfMissing(db, table, column, definition)
 }
 
 // updateStoredItems updates the database with new items, returns map of updated item IDs
@@ -98,18 +420,21 @@ func updateStoredItems(db *sql.DB, newItems []HackerNewsItem) map[string]bool {
 	for _, item := range newItems {
 		// The 'item.CreatedAt' should be the original submission time of the HN post.
 		// The 'item.UpdatedAt' should be when it was last seen/modified by your scraper.
-		result, err := db.Exec(`
-			INSERT INTO items (item_hn_id, title, link, comments_link, points, comment_count, author, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		result, err := execWithRetry(db, `
+			INSERT INTO items (item_hn_id, title, link, comments_link, points, comment_count, author, created_at, updated_at, story_type)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
 			ON CONFLICT(item_hn_id) DO UPDATE SET
+				story_type = COALESCE(excluded.story_type, items.story_type),
 				title = excluded.title,
+				canonical_url = CASE WHEN items.link = excluded.link THEN items.canonical_url END,
 				link = excluded.link, 
 				comments_link = excluded.comments_link,
 				points = excluded.points,
 				comment_count = excluded.comment_count,
 				author = excluded.author,
-				updated_at = excluded.updated_at`, // Note: created_at is not updated on conflict
-			item.ItemID, item.Title, item.Link, item.CommentsLink, item.Points, item.CommentCount, item.Author, item.CreatedAt, item.UpdatedAt)
+				dead_at = NULL,
+				updated_at = excluded.updated_at`, // Note: created_at is not updated on conflict; a listed item is alive
+			item.ItemID, item.Title, item.Link, item.CommentsLink, item.Points, item.CommentCount, item.Author, item.CreatedAt, item.UpdatedAt, string(item.Type))
 
 		if err != nil {
 			slog.Error("Error updating item", "error", err, "hn_id", item.ItemID)
@@ -126,29 +451,370 @@ func updateStoredItems(db *sql.DB, newItems []HackerNewsItem) map[string]bool {
 	return updatedItems
 }
 
+// insertItemIfMissing stores an item unless one with the same HN ID exists, reporting whether it was added
+func insertItemIfMissing(db *sql.DB, item HackerNewsItem) (bool, error) {
+	result, err := execWithRetry(db, `
+		INSERT INTO items (item_hn_id, title, link, comments_link, points, comment_count, author, created_at, updated_at, story_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT(item_hn_id) DO NOTHING`,
+		item.ItemID, item.Title, item.Link, item.CommentsLink, item.Points, item.CommentCount, item.Author, item.CreatedAt, item.UpdatedAt, string(item.Type))
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// itemColumns selects the item fields read by scanItem, including when the item last returned to the front page
+const itemColumns = `items.item_hn_id, title, link, comments_link, points, comment_count, author, created_at, updated_at, returned_at, pinned_at, canonical_url, story_type, karma, account_created_at, items.dead_at
+	FROM items LEFT JOIN item_sightings ON item_sightings.item_hn_id = items.item_hn_id
+	LEFT JOIN authors ON authors.username = items.author`
+
+// scanItem scans a row selected with itemColumns
+func scanItem(row interface{ Scan(...any) error }) (HackerNewsItem, error) {
+	var item HackerNewsItem
+	var returnedAt, pinnedAt, accountCreatedAt, deadAt sql.NullTime
+	var canonicalURL, storyType sql.NullString
+	var karma sql.NullInt64
+	err := row.Scan(&item.ItemID, &item.Title, &item.Link, &item.CommentsLink, &item.Points, &item.CommentCount, &item.Author, &item.CreatedAt, &item.UpdatedAt, &returnedAt, &pinnedAt, &canonicalURL, &storyType, &karma, &accountCreatedAt, &deadAt)
+	item.ReturnedAt = returnedAt.Time
+	item.PinnedAt = pinnedAt.Time
+	item.CanonicalURL = canonicalURL.String
+	item.Type = StoryType(storyType.String)
+	item.AuthorKarma = int(karma.Int64)
+	item.AuthorCreatedAt = accountCreatedAt.Time
+	item.DeadAt = deadAt.Time
+	return item, err
+}
+
 // getAllItems retrieves items from database with minimum points threshold
-func getAllItems(db *sql.DB, limit int, minPoints int) []HackerNewsItem {
-	slog.Debug("Querying database for items", "limit", limit, "minPoints", minPoints)
-	rows, err := db.Query("SELECT item_hn_id, title, link, comments_link, points, comment_count, author, created_at, updated_at FROM items WHERE points > ? ORDER BY created_at DESC LIMIT ?", minPoints, limit)
+func getAllItems(db *sql.DB, limit int, minPoints int) ([]HackerNewsItem, error) {
+	return getFilteredItems(db, ItemFilter{Limit: limit, MinPoints: minPoints})
+}
+
+// getFilteredItems retrieves items from database matching the given filter, newest first.
+// Pinned items are included regardless of points and without waiting for MinAge; they count
+// as new from the time they were pinned. Dead items are left out.
+func getFilteredItems(db *sql.DB, filter ItemFilter) ([]HackerNewsItem, error) {
+	slog.Debug("Querying database for items", "limit", filter.Limit, "minPoints", filter.MinPoints, "minAge", filter.MinAge, "maxAge", filter.MaxAge)
+
+	where, args := filteredItemsWhere(filter)
+	query := "SELECT " + itemColumns + where + " ORDER BY COALESCE(items.pinned_at, created_at) DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		slog.Error("Failed to query database", "error", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to query items: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
 	var items []HackerNewsItem
 	for rows.Next() {
-		var item HackerNewsItem
-		err := rows.Scan(&item.ItemID, &item.Title, &item.Link, &item.CommentsLink, &item.Points, &item.CommentCount, &item.Author, &item.CreatedAt, &item.UpdatedAt)
+		item, err := scanItem(rows)
 		if err != nil {
 			slog.Error("Error scanning row", "error", err)
 			continue
 		}
 		items = append(items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read items: %w", err)
+	}
 
 	slog.Debug("Retrieved items from database", "count", len(items))
-	return items
+	return items, nil
+}
+
+// filteredItemsWhere returns the WHERE clause selecting the items that match the filter, with its
+// arguments; the limit is left to the caller
+func filteredItemsWhere(filter ItemFilter) (string, []any) {
+	query := " WHERE items.dead_at IS NULL AND (points > ? OR items.pinned_at IS NOT NULL)"
+	args := []any{filter.MinPoints}
+
+	if filter.MinAge > 0 {
+		query += " AND (created_at <= ? OR items.pinned_at IS NOT NULL)"
+		args = append(args, time.Now().Add(-filter.MinAge).UTC())
+	}
+
+	if filter.MaxAge > 0 {
+		query += " AND COALESCE(items.pinned_at, created_at) >= ?"
+		args = append(args, time.Now().Add(-filter.MaxAge).UTC())
+	}
+
+	// Author lists don't apply to pinned items, which were added on purpose
+	if clause, authorArgs := authorListClause("NOT IN", filter.BlockedAuthors); clause != "" {
+		query += " AND (items.pinned_at IS NOT NULL OR " + clause + ")"
+		args = append(args, authorArgs...)
+	}
+	if clause, authorArgs := authorListClause("IN", filter.AllowedAuthors); clause != "" {
+		query += " AND (items.pinned_at IS NOT NULL OR " + clause + ")"
+		args = append(args, authorArgs...)
+	}
+	return query, args
+}
+
+// countFilteredItems returns how many items match the filter, ignoring its limit
+func countFilteredItems(db *sql.DB, filter ItemFilter) (int, error) {
+	where, args := filteredItemsWhere(filter)
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM items"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return count, nil
+}
+
+// authorListClause returns a case-insensitive "items.author IN (...)" style condition for the
+// non-empty authors with its arguments, or an empty clause when there are none
+func authorListClause(op string, authors []string) (string, []any) {
+	var args []any
+	for _, author := range authors {
+		if author = strings.ToLower(strings.TrimSpace(author)); author != "" {
+			args = append(args, author)
+		}
+	}
+	if len(args) == 0 {
+		return "", nil
+	}
+	return "LOWER(items.author) " + op + " (?" + strings.Repeat(", ?", len(args)-1) + ")", args
+}
+
+// getTopItemsBetween retrieves the highest scoring live items created in [start, end), highest score first
+func getTopItemsBetween(db *sql.DB, start, end time.Time, minPoints, limit int) ([]HackerNewsItem, error) {
+	rows, err := db.Query("SELECT "+itemColumns+" WHERE items.dead_at IS NULL AND points > ? AND created_at >= ? AND created_at < ? ORDER BY points DESC LIMIT ?",
+		minPoints, start.UTC(), end.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []HackerNewsItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// markItemDead records that an item has died or been flagged. The item is kept with its last-known stats
+// but left out of the feeds; it comes back to life if it shows up on the front page again.
+func markItemDead(db *sql.DB, itemID string, now time.Time) error {
+	if _, err := execWithRetry(db, "UPDATE items SET dead_at = ? WHERE item_hn_id = ? AND dead_at IS NULL", now.UTC(), itemID); err != nil {
+		return fmt.Errorf("failed to mark item dead: %w", err)
+	}
+	return nil
+}
+
+// getDeadItems returns items that died or were flagged, most recently died first
+func getDeadItems(db *sql.DB, limit int) ([]HackerNewsItem, error) {
+	rows, err := db.Query("SELECT "+itemColumns+" WHERE items.dead_at IS NOT NULL ORDER BY items.dead_at DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []HackerNewsItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// countDeadItems returns how many items died or were flagged
+func countDeadItems(db *sql.DB) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM items WHERE dead_at IS NOT NULL").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dead items: %w", err)
+	}
+	return count, nil
+}
+
+// getOldestItemTime returns when the oldest item above minPoints was created, or the zero time if there is none
+func getOldestItemTime(db *sql.DB, minPoints int) (time.Time, error) {
+	var createdAt time.Time
+	err := db.QueryRow("SELECT created_at FROM items WHERE dead_at IS NULL AND points > ? ORDER BY created_at LIMIT 1", minPoints).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query oldest item: %w", err)
+	}
+	return createdAt, nil
+}
+
+// countItemsBetween returns the number of items above minPoints created in [start, end)
+func countItemsBetween(db *sql.DB, start, end time.Time, minPoints int) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM items WHERE dead_at IS NULL AND points > ? AND created_at >= ? AND created_at < ?",
+		minPoints, start.UTC(), end.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return count, nil
+}
+
+// pinItem marks a stored item as manually added so it is included in feeds regardless of points
+func pinItem(db *sql.DB, itemID string, now time.Time) error {
+	result, err := execWithRetry(db, "UPDATE items SET pinned_at = ? WHERE item_hn_id = ?", now.UTC(), itemID)
+	if err != nil {
+		return fmt.Errorf("failed to pin item: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("item %s is not stored", itemID)
+	}
+	return nil
+}
+
+// storeCanonicalURL records the canonical URL of every item submitted with the given link
+func storeCanonicalURL(db *sql.DB, link, canonicalURL string) error {
+	if canonicalURL == "" {
+		return nil
+	}
+	_, err := execWithRetry(db, `UPDATE items SET canonical_url = ? WHERE link = ? AND canonical_url IS DISTINCT FROM ?`, canonicalURL, link, canonicalURL)
+	if err != nil {
+		return fmt.Errorf("failed to store canonical URL: %w", err)
+	}
+	return nil
+}
+
+// getItemByID retrieves a single item by its Hacker News ID, returning nil if it doesn't exist
+func getItemByID(db *sql.DB, itemID string) (*HackerNewsItem, error) {
+	item, err := scanItem(db.QueryRow("SELECT "+itemColumns+" WHERE items.item_hn_id = ?", itemID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item: %w", err)
+	}
+	return &item, nil
+}
+
+// getArticleSubmissions returns the live stored submissions created at or after since whose
+// submitted or canonical URL is one of urls, most points first
+func getArticleSubmissions(db *sql.DB, urls []string, since time.Time) ([]HackerNewsItem, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(urls)), ", ")
+	args := make([]any, 0, 2*len(urls)+1)
+	for _, u := range urls {
+		args = append(args, u)
+	}
+	for _, u := range urls {
+		args = append(args, u)
+	}
+	args = append(args, since)
+
+	rows, err := db.Query("SELECT "+itemColumns+`
+		WHERE (link IN (`+placeholders+`) OR canonical_url IN (`+placeholders+`))
+		AND items.created_at >= ? AND items.dead_at IS NULL
+		ORDER BY points DESC, items.item_hn_id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query article submissions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []HackerNewsItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan article submission: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// recordRun stores a run record and sets its ID
+func recordRun(db *sql.DB, run *RunRecord) error {
+	var fetchErrors any
+	if len(run.FetchErrors) > 0 {
+		data, err := json.Marshal(run.FetchErrors)
+		if err != nil {
+			return fmt.Errorf("failed to encode fetch errors: %w", err)
+		}
+		fetchErrors = string(data)
+	}
+	// RETURNING rather than LastInsertId, which PostgreSQL doesn't support
+	err := withBusyRetry(func() error {
+		return db.QueryRow(`
+			INSERT INTO runs (source, started_at, finished_at, fetched, updated, feed_items, error, fetch_errors)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`,
+			run.Source, run.StartedAt, run.FinishedAt, run.Fetched, run.Updated, run.FeedItems, run.Error, fetchErrors).Scan(&run.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	return nil
+}
+
+// runColumns is the column list scanRun expects
+const runColumns = "id, source, started_at, finished_at, fetched, updated, feed_items, error, fetch_errors"
+
+// scanRun scans a row selected with runColumns into a run record
+func scanRun(row interface{ Scan(...any) error }) (RunRecord, error) {
+	var run RunRecord
+	var runError, fetchErrors sql.NullString
+	if err := row.Scan(&run.ID, &run.Source, &run.StartedAt, &run.FinishedAt, &run.Fetched, &run.Updated, &run.FeedItems, &runError, &fetchErrors); err != nil {
+		return run, err
+	}
+	run.Error = runError.String
+	if fetchErrors.Valid {
+		if err := json.Unmarshal([]byte(fetchErrors.String), &run.FetchErrors); err != nil {
+			slog.Warn("Failed to decode run fetch errors", "run", run.ID, "error", err)
+		}
+	}
+	return run, nil
+}
+
+// listRuns returns recorded runs, most recent first
+func listRuns(db *sql.DB, limit, offset int) ([]RunRecord, error) {
+	rows, err := db.Query("SELECT "+runColumns+" FROM runs ORDER BY id DESC LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []RunRecord
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			slog.Error("Error scanning run row", "error", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// getLastSuccessfulRun returns the most recent run that finished without an error, or nil if there is none
+func getLastSuccessfulRun(db *sql.DB) (*RunRecord, error) {
+	run, err := scanRun(db.QueryRow("SELECT " + runColumns + " FROM runs WHERE COALESCE(error, '') = '' ORDER BY id DESC LIMIT 1"))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query last successful run: %w", err)
+	}
+	return &run, nil
+}
+
+// countRuns returns the number of recorded runs
+func countRuns(db *sql.DB) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM runs").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count runs: %w", err)
+	}
+	return count, nil
 }
 
 // getOpenGraphData retrieves cached OpenGraph data for a URL
@@ -156,11 +822,12 @@ func getOpenGraphData(db *sql.DB, url string) (*OpenGraphCache, error) {
 	slog.Debug("Getting cached OpenGraph data", "url", url)
 
 	query := `
-		SELECT id, url, title, description, image, site_name, fetched_at, expires_at, fetch_success 
+		SELECT id, url, title, description, image, site_name, fetched_at, expires_at, fetch_success, final_url, canonical_url, error_class
 		FROM opengraph_cache 
 		WHERE url = ? AND expires_at > ?`
 
 	var cache OpenGraphCache
+	var finalURL, canonicalURL, errorClass sql.NullString
 	err := db.QueryRow(query, url, time.Now()).Scan(
 		&cache.ID,
 		&cache.URL,
@@ -171,7 +838,13 @@ func getOpenGraphData(db *sql.DB, url string) (*OpenGraphCache, error) {
 		&cache.FetchedAt,
 		&cache.ExpiresAt,
 		&cache.FetchSuccess,
+		&finalURL,
+		&canonicalURL,
+		&errorClass,
 	)
+	cache.FinalURL = finalURL.String
+	cache.CanonicalURL = canonicalURL.String
+	cache.ErrorClass = fetchErrorClass(errorClass.String)
 
 	if err == sql.ErrNoRows {
 		slog.Debug("No cached OpenGraph data found", "url", url)
@@ -186,6 +859,88 @@ func getOpenGraphData(db *sql.DB, url string) (*OpenGraphCache, error) {
 	return &cache, nil
 }
 
+// queueOpenGraphURL adds a URL to the OpenGraph queue, raising its priority if it is already queued
+func queueOpenGraphURL(db *sql.DB, url string, priority int, now time.Time) error {
+	_, err := execWithRetry(db, `
+		INSERT INTO opengraph_queue (url, priority, attempts, next_attempt_at, queued_at) VALUES (?, ?, 0, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+			priority = CASE WHEN excluded.priority > opengraph_queue.priority THEN excluded.priority ELSE opengraph_queue.priority END`,
+		url, priority, now.UTC(), now.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to queue OpenGraph fetch: %w", err)
+	}
+	return nil
+}
+
+// getDueOpenGraphQueue returns the queued URLs whose next attempt is due, highest priority first
+func getDueOpenGraphQueue(db *sql.DB, now time.Time) ([]ogQueueEntry, error) {
+	rows, err := db.Query(`
+		SELECT url, priority, attempts FROM opengraph_queue
+		WHERE next_attempt_at <= ?
+		ORDER BY priority DESC, queued_at`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OpenGraph queue: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []ogQueueEntry
+	for rows.Next() {
+		var entry ogQueueEntry
+		if err := rows.Scan(&entry.URL, &entry.Priority, &entry.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan OpenGraph queue entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// rescheduleOpenGraphQueueEntry records a failed fetch and when to try the URL again
+func rescheduleOpenGraphQueueEntry(db *sql.DB, url string, attempts int, nextAttempt time.Time, lastError *fetchError) error {
+	_, err := execWithRetry(db, "UPDATE opengraph_queue SET attempts = ?, next_attempt_at = ?, last_error = ?, last_error_class = ? WHERE url = ?",
+		attempts, nextAttempt.UTC(), lastError.Error(), string(lastError.Class), url)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule OpenGraph fetch: %w", err)
+	}
+	return nil
+}
+
+// removeOpenGraphQueueEntry removes a URL from the OpenGraph queue
+func removeOpenGraphQueueEntry(db *sql.DB, url string) error {
+	if _, err := execWithRetry(db, "DELETE FROM opengraph_queue WHERE url = ?", url); err != nil {
+		return fmt.Errorf("failed to remove OpenGraph queue entry: %w", err)
+	}
+	return nil
+}
+
+// listOpenGraphCache returns the most recently fetched OpenGraph cache entries
+func listOpenGraphCache(db *sql.DB, limit int) ([]OpenGraphCache, error) {
+	rows, err := db.Query(`
+		SELECT id, url, title, description, image, site_name, fetched_at, expires_at, fetch_success, final_url, error_class
+		FROM opengraph_cache
+		ORDER BY fetched_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OpenGraph cache: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []OpenGraphCache
+	for rows.Next() {
+		var cache OpenGraphCache
+		var title, description, image, siteName, finalURL, errorClass sql.NullString
+		err := rows.Scan(&cache.ID, &cache.URL, &title, &description, &image, &siteName, &cache.FetchedAt, &cache.ExpiresAt, &cache.FetchSuccess, &finalURL, &errorClass)
+		if err != nil {
+			slog.Error("Error scanning OpenGraph cache row", "error", err)
+			continue
+		}
+		cache.Title, cache.Description, cache.Image, cache.SiteName = title.String, description.String, image.String, siteName.String
+		cache.FinalURL = finalURL.String
+		cache.ErrorClass = fetchErrorClass(errorClass.String)
+		entries = append(entries, cache)
+	}
+
+	return entries, nil
+}
+
 // cacheOpenGraphData stores OpenGraph data in the cache
 func cacheOpenGraphData(db *sql.DB, ogData *OpenGraphData, fetchSuccess bool) error {
 	dbMutex.Lock()
@@ -202,18 +957,21 @@ func cacheOpenGraphData(db *sql.DB, ogData *OpenGraphData, fetchSuccess bool) er
 	}
 
 	query := `
-		INSERT INTO opengraph_cache (url, title, description, image, site_name, fetched_at, expires_at, fetch_success)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO opengraph_cache (url, title, description, image, site_name, fetched_at, expires_at, fetch_success, final_url, canonical_url, error_class)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL)
 		ON CONFLICT(url) DO UPDATE SET
+			final_url = excluded.final_url,
+			canonical_url = excluded.canonical_url,
 			title = excluded.title,
 			description = excluded.description,
 			image = excluded.image,
 			site_name = excluded.site_name,
 			fetched_at = excluded.fetched_at,
 			expires_at = excluded.expires_at,
-			fetch_success = excluded.fetch_success`
+			fetch_success = excluded.fetch_success,
+			error_class = excluded.error_class`
 
-	_, err := db.Exec(query,
+	_, err := execWithRetry(db, query,
 		ogData.URL,
 		ogData.Title,
 		ogData.Description,
@@ -222,6 +980,8 @@ func cacheOpenGraphData(db *sql.DB, ogData *OpenGraphData, fetchSuccess bool) er
 		time.Now(),
 		expiresAt,
 		fetchSuccess,
+		ogData.FinalURL,
+		ogData.CanonicalURL,
 	)
 
 	if err != nil {
@@ -232,11 +992,314 @@ func cacheOpenGraphData(db *sql.DB, ogData *OpenGraphData, fetchSuccess bool) er
 	return nil
 }
 
+// touchOpenGraphCache marks cached OpenGraph data as fetched at the given time without changing it
+func touchOpenGraphCache(db *sql.DB, url string, fetchedAt time.Time) error {
+	if _, err := execWithRetry(db, "UPDATE opengraph_cache SET fetched_at = ? WHERE url = ?", fetchedAt, url); err != nil {
+		return fmt.Errorf("failed to update OpenGraph cache: %w", err)
+	}
+	return nil
+}
+
+// cacheOpenGraphFailure caches a failed fetch with its error class. Failures that won't fix
+// themselves are kept as long as successful fetches, transient ones for a day.
+func cacheOpenGraphFailure(db *sql.DB, url string, fetchErr *fetchError) error {
+	expiresAt := time.Now().Add(24 * time.Hour)
+	if !fetchErr.retryable() {
+		expiresAt = time.Now().Add(7 * 24 * time.Hour)
+	}
+
+	_, err := execWithRetry(db, `
+		INSERT INTO opengraph_cache (url, title, description, image, site_name, fetched_at, expires_at, fetch_success, final_url, canonical_url, error_class)
+		VALUES (?, '', '', '', '', ?, ?, FALSE, '', '', ?)
+		ON CONFLICT(url) DO UPDATE SET
+			final_url = excluded.final_url,
+			canonical_url = excluded.canonical_url,
+			title = excluded.title,
+			description = excluded.description,
+			image = excluded.image,
+			site_name = excluded.site_name,
+			fetched_at = excluded.fetched_at,
+			expires_at = excluded.expires_at,
+			fetch_success = excluded.fetch_success,
+			error_class = excluded.error_class`,
+		url, time.Now(), expiresAt, string(fetchErr.Class))
+	if err != nil {
+		return fmt.Errorf("failed to cache OpenGraph failure: %w", err)
+	}
+	return nil
+}
+
+// getSummary returns the cached article summary for a URL, or empty string if there is none
+func getSummary(db *sql.DB, url string) (string, error) {
+	var summary string
+	err := db.QueryRow("SELECT summary FROM summaries WHERE url = ?", url).Scan(&summary)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query summary: %w", err)
+	}
+	return summary, nil
+}
+
+// cacheSummary stores an article summary generated by the given model
+func cacheSummary(db *sql.DB, url, summary, model string) error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	_, err := execWithRetry(db, `
+		INSERT INTO summaries (url, summary, model, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+			summary = excluded.summary,
+			model = excluded.model,
+			created_at = excluded.created_at`,
+		url, summary, model, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to cache summary: %w", err)
+	}
+	return nil
+}
+
+// getDiscussionSummary returns the cached discussion summary for an item, or nil if there is none
+func getDiscussionSummary(db *sql.DB, itemID string) (*discussionSummary, error) {
+	var summary discussionSummary
+	err := db.QueryRow("SELECT summary, comment_count FROM discussion_summaries WHERE item_hn_id = ?", itemID).
+		Scan(&summary.Summary, &summary.CommentCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discussion summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// cacheDiscussionSummary stores a discussion summary along with the comment count it was made at
+func cacheDiscussionSummary(db *sql.DB, itemID, summary string, commentCount int) error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	_, err := execWithRetry(db, `
+		INSERT INTO discussion_summaries (item_hn_id, summary, comment_count, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(item_hn_id) DO UPDATE SET
+			summary = excluded.summary,
+			comment_count = excluded.comment_count,
+			created_at = excluded.created_at`,
+		itemID, summary, commentCount, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to cache discussion summary: %w", err)
+	}
+	return nil
+}
+
+// getPoll returns the cached poll state of an item, or nil if it was never checked
+func getPoll(db *sql.DB, itemID string) (*hnPoll, error) {
+	var poll hnPoll
+	var options sql.NullString
+	err := db.QueryRow("SELECT is_poll, options, fetched_at FROM polls WHERE item_hn_id = ?", itemID).
+		Scan(&poll.IsPoll, &options, &poll.FetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query poll: %w", err)
+	}
+	if options.Valid && options.String != "" {
+		if err := json.Unmarshal([]byte(options.String), &poll.Options); err != nil {
+			return nil, fmt.Errorf("failed to decode poll options: %w", err)
+		}
+	}
+	return &poll, nil
+}
+
+// cachePoll stores whether an item is a poll along with its options and vote counts
+func cachePoll(db *sql.DB, itemID string, poll *hnPoll) error {
+	options, err := json.Marshal(poll.Options)
+	if err != nil {
+		return fmt.Errorf("failed to encode poll options: %w", err)
+	}
+	_, err = execWithRetry(db, `
+		INSERT INTO polls (item_hn_id, is_poll, options, fetched_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(item_hn_id) DO UPDATE SET
+			is_poll = excluded.is_poll,
+			options = excluded.options,
+			fetched_at = excluded.fetched_at`,
+		itemID, poll.IsPoll, string(options), poll.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to cache poll: %w", err)
+	}
+	return nil
+}
+
+// getPreviousDiscussionsFetchedAt returns when earlier submissions of an item were searched, or the zero time if never
+func getPreviousDiscussionsFetchedAt(db *sql.DB, itemID string) (time.Time, error) {
+	var fetchedAt sql.NullTime
+	err := db.QueryRow("SELECT fetched_at FROM previous_discussions WHERE item_hn_id = ?", itemID).Scan(&fetchedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query previous discussions: %w", err)
+	}
+	return fetchedAt.Time, nil
+}
+
+// getPreviousDiscussions returns the cached earlier submissions of an item
+func getPreviousDiscussions(db *sql.DB, itemID string) ([]previousDiscussion, error) {
+	var discussions sql.NullString
+	err := db.QueryRow("SELECT discussions FROM previous_discussions WHERE item_hn_id = ?", itemID).Scan(&discussions)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous discussions: %w", err)
+	}
+	var previous []previousDiscussion
+	if discussions.Valid && discussions.String != "" {
+		if err := json.Unmarshal([]byte(discussions.String), &previous); err != nil {
+			return nil, fmt.Errorf("failed to decode previous discussions: %w", err)
+		}
+	}
+	return previous, nil
+}
+
+// cachePreviousDiscussions stores the earlier submissions found for an item
+func cachePreviousDiscussions(db *sql.DB, itemID string, previous []previousDiscussion, fetchedAt time.Time) error {
+	discussions, err := json.Marshal(previous)
+	if err != nil {
+		return fmt.Errorf("failed to encode previous discussions: %w", err)
+	}
+	_, err = execWithRetry(db, `
+		INSERT INTO previous_discussions (item_hn_id, discussions, fetched_at) VALUES (?, ?, ?)
+		ON CONFLICT(item_hn_id) DO UPDATE SET
+			discussions = excluded.discussions,
+			fetched_at = excluded.fetched_at`,
+		itemID, string(discussions), fetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to cache previous discussions: %w", err)
+	}
+	return nil
+}
+
+// getTopComment returns the cached top comment of a story, or nil if none is cached
+func getTopComment(db *sql.DB, itemID string) (*topComment, error) {
+	var comment topComment
+	var author, text sql.NullString
+	err := db.QueryRow("SELECT comment_hn_id, author, text, comment_count, fetched_at FROM top_comments WHERE item_hn_id = ?", itemID).
+		Scan(&comment.CommentID, &author, &text, &comment.CommentCount, &comment.FetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top comment: %w", err)
+	}
+	comment.Author, comment.Text = author.String, text.String
+	return &comment, nil
+}
+
+// cacheTopComment stores the top comment of a story
+func cacheTopComment(db *sql.DB, itemID string, comment *topComment) error {
+	_, err := execWithRetry(db, `
+		INSERT INTO top_comments (item_hn_id, comment_hn_id, author, text, comment_count, fetched_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(item_hn_id) DO UPDATE SET
+			comment_hn_id = excluded.comment_hn_id,
+			author = excluded.author,
+			text = excluded.text,
+			comment_count = excluded.comment_count,
+			fetched_at = excluded.fetched_at`,
+		itemID, comment.CommentID, comment.Author, comment.Text, comment.CommentCount, comment.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to cache top comment: %w", err)
+	}
+	return nil
+}
+
+// getAuthorFetchedAt returns when an author was last fetched, or the zero time if never
+func getAuthorFetchedAt(db *sql.DB, username string) (time.Time, error) {
+	var fetchedAt sql.NullTime
+	err := db.QueryRow("SELECT fetched_at FROM authors WHERE username = ?", username).Scan(&fetchedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query author: %w", err)
+	}
+	return fetchedAt.Time, nil
+}
+
+// getAuthorSubmissions returns an author's front-page submissions created since the given time, newest first,
+// leaving out the item with excludeID
+func getAuthorSubmissions(db *sql.DB, author, excludeID string, since time.Time, limit int) ([]HackerNewsItem, error) {
+	rows, err := db.Query("SELECT "+itemColumns+" WHERE items.author = ? AND items.item_hn_id != ? AND items.dead_at IS NULL AND created_at >= ? ORDER BY created_at DESC LIMIT ?",
+		author, excludeID, since.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query author submissions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []HackerNewsItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// cacheAuthor stores an author's karma and account creation time
+func cacheAuthor(db *sql.DB, author *hnAuthor, fetchedAt time.Time) error {
+	_, err := execWithRetry(db, `
+		INSERT INTO authors (username, karma, account_created_at, fetched_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET
+			karma = excluded.karma,
+			account_created_at = excluded.account_created_at,
+			fetched_at = excluded.fetched_at`,
+		author.Username, author.Karma, author.CreatedAt, fetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to cache author: %w", err)
+	}
+	return nil
+}
+
+// getTranslation returns the cached translation of a text and whether one was cached.
+// An empty translation means the text needs no translating.
+func getTranslation(db *sql.DB, text, target string) (string, bool, error) {
+	var translated sql.NullString
+	err := db.QueryRow("SELECT translated_text FROM translations WHERE source_text = ? AND target_language = ?", text, target).Scan(&translated)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query translation: %w", err)
+	}
+	return translated.String, true, nil
+}
+
+// cacheTranslation stores the translation of a text into the target language
+func cacheTranslation(db *sql.DB, text, target, translated, sourceLanguage string) error {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	_, err := execWithRetry(db, `
+		INSERT INTO translations (source_text, target_language, translated_text, source_language, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(source_text, target_language) DO UPDATE SET
+			translated_text = excluded.translated_text,
+			source_language = excluded.source_language,
+			created_at = excluded.created_at`,
+		text, target, translated, sourceLanguage, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to cache translation: %w", err)
+	}
+	return nil
+}
+
 // cleanupExpiredOpenGraphCache removes expired OpenGraph cache entries
 func cleanupExpiredOpenGraphCache(db *sql.DB) error {
 	slog.Debug("Cleaning up expired OpenGraph cache entries")
 
-	result, err := db.Exec("DELETE FROM opengraph_cache WHERE expires_at < ?", time.Now())
+	result, err := execWithRetry(db, "DELETE FROM opengraph_cache WHERE expires_at < ?", time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to cleanup expired cache: %w", err)
 	}
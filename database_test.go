@@ -2,68 +2,64 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// testDatabaseURLEnv runs the database tests against a PostgreSQL server instead of in-memory SQLite
+const testDatabaseURLEnv = "HNTOP_TEST_DATABASE_URL"
+
 func setupTestDB() *sql.DB {
+	if url := os.Getenv(testDatabaseURLEnv); url != "" {
+		return setupPostgresTestDB(url)
+	}
+
 	db, err := sql.Open("sqlite", ":memory:")
 	if err != nil {
 		panic(err)
 	}
 
-	// Create items table schema
-	createItemsTable := `
-	CREATE TABLE IF NOT EXISTS items (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		item_hn_id TEXT NOT NULL UNIQUE,
-		title TEXT NOT NULL,
-		link TEXT NOT NULL,
-		comments_link TEXT,
-		points INTEGER DEFAULT 0,
-		comment_count INTEGER DEFAULT 0,
-		author TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
+	// Each pooled connection would get its own in-memory database
+	db.SetMaxOpenConns(1)
 
-	_, err = db.Exec(createItemsTable)
-	if err != nil {
+	if err := createSchema(db); err != nil {
 		panic(err)
 	}
 
-	// Create OpenGraph cache table schema
-	createOGCacheTable := `
-	CREATE TABLE IF NOT EXISTS opengraph_cache (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		url TEXT NOT NULL UNIQUE,
-		title TEXT,
-		description TEXT,
-		image TEXT,
-		site_name TEXT,
-		fetched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		expires_at TIMESTAMP,
-		fetch_success BOOLEAN DEFAULT TRUE
-	)`
-	_, err = db.Exec(createOGCacheTable)
+	return db
+}
+
+// setupPostgresTestDB gives each test its own schema, so tests start from empty tables
+func setupPostgresTestDB(url string) *sql.DB {
+	admin, err := openPostgres(url)
 	if err != nil {
 		panic(err)
 	}
+	defer func() { _ = admin.Close() }()
 
-	// Create indexes for opengraph_cache table
-	createOGIndexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_opengraph_url ON opengraph_cache(url)",
-		"CREATE INDEX IF NOT EXISTS idx_opengraph_expires ON opengraph_cache(expires_at)",
-	}
-	for _, indexSQL := range createOGIndexes {
-		_, err = db.Exec(indexSQL)
-		if err != nil {
-			panic(err)
-		}
+	schema := fmt.Sprintf("hntop_test_%d", rand.Uint64())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		panic(err)
 	}
 
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	db, err := openPostgres(url + separator + "search_path=" + schema)
+	if err != nil {
+		panic(err)
+	}
+	if err := createSchema(db); err != nil {
+		panic(err)
+	}
 	return db
 }
 
@@ -234,7 +230,10 @@ func TestGetAllItems_FilterByPoints(t *testing.T) {
 	updateStoredItems(db, items)
 
 	// Get items (should only return those with >50 points)
-	retrievedItems := getAllItems(db, 30, 50)
+	retrievedItems, err := getAllItems(db, 30, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(retrievedItems) != 2 {
 		t.Errorf("Expected 2 items with >50 points, got %d", len(retrievedItems))
@@ -288,7 +287,10 @@ func TestGetAllItems_OrderByCreatedAt(t *testing.T) {
 	}
 
 	updateStoredItems(db, items)
-	retrievedItems := getAllItems(db, 30, 50)
+	retrievedItems, err := getAllItems(db, 30, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(retrievedItems) != 2 {
 		t.Fatalf("Expected 2 items, got %d", len(retrievedItems))
@@ -586,3 +588,242 @@ func TestCleanupExpiredOpenGraphCache(t *testing.T) {
 		}
 	}
 }
+
+func TestGetFilteredItems_MinAge(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{
+			ItemID:       "1",
+			Title:        "Settled Article",
+			Link:         "https://example.com/settled",
+			CommentsLink: "https://news.ycombinator.com/item?id=1",
+			Points:       120,
+			Author:       "user1",
+			CreatedAt:    now.Add(-3 * time.Hour),
+			UpdatedAt:    now,
+		},
+		{
+			ItemID:       "2",
+			Title:        "Fresh Article",
+			Link:         "https://example.com/fresh",
+			CommentsLink: "https://news.ycombinator.com/item?id=2",
+			Points:       90,
+			Author:       "user2",
+			CreatedAt:    now.Add(-30 * time.Minute),
+			UpdatedAt:    now,
+		},
+	}
+
+	updateStoredItems(db, items)
+
+	retrievedItems, err := getFilteredItems(db, ItemFilter{Limit: 30, MinPoints: 50, MinAge: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retrievedItems) != 1 {
+		t.Fatalf("Expected 1 item older than min age, got %d", len(retrievedItems))
+	}
+	if retrievedItems[0].Title != "Settled Article" {
+		t.Errorf("Expected 'Settled Article', got '%s'", retrievedItems[0].Title)
+	}
+
+	retrievedItems, err = getFilteredItems(db, ItemFilter{Limit: 30, MinPoints: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retrievedItems) != 2 {
+		t.Errorf("Expected 2 items without min age, got %d", len(retrievedItems))
+	}
+}
+
+func TestGetFilteredItems_MaxAge(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{
+			ItemID:       "1",
+			Title:        "Week Old Article",
+			Link:         "https://example.com/old",
+			CommentsLink: "https://news.ycombinator.com/item?id=1",
+			Points:       400,
+			Author:       "user1",
+			CreatedAt:    now.Add(-7 * 24 * time.Hour),
+			UpdatedAt:    now,
+		},
+		{
+			ItemID:       "2",
+			Title:        "Recent Article",
+			Link:         "https://example.com/recent",
+			CommentsLink: "https://news.ycombinator.com/item?id=2",
+			Points:       90,
+			Author:       "user2",
+			CreatedAt:    now.Add(-5 * time.Hour),
+			UpdatedAt:    now,
+		},
+	}
+
+	updateStoredItems(db, items)
+
+	retrievedItems, err := getFilteredItems(db, ItemFilter{Limit: 30, MinPoints: 50, MaxAge: 48 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retrievedItems) != 1 {
+		t.Fatalf("Expected 1 item within max age, got %d", len(retrievedItems))
+	}
+	if retrievedItems[0].Title != "Recent Article" {
+		t.Errorf("Expected 'Recent Article', got '%s'", retrievedItems[0].Title)
+	}
+}
+
+func TestGetFilteredItems_AuthorLists(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "From a muted submitter", Link: "https://example.com/1", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 300, Author: "Spammer", CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "From a followed submitter", Link: "https://example.com/2", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 200, Author: "pg", CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now},
+		{ItemID: "3", Title: "From someone else", Link: "https://example.com/3", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 100, Author: "user3", CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+
+	mapper := NewCategoryMapper(&DomainConfig{BlockedAuthors: []string{"spammer", " "}})
+	blocked, err := getFilteredItems(db, mapper.withAuthorLists(ItemFilter{Limit: 30, MinPoints: 50}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocked) != 2 || blocked[0].ItemID != "2" || blocked[1].ItemID != "3" {
+		t.Errorf("Expected the muted submitter to be left out, got %+v", blocked)
+	}
+
+	allowed, err := getFilteredItems(db, ItemFilter{Limit: 30, MinPoints: 50, AllowedAuthors: []string{"PG"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allowed) != 1 || allowed[0].ItemID != "2" {
+		t.Errorf("Expected only the followed submitter, got %+v", allowed)
+	}
+
+	// Pinned items stay regardless of their submitter
+	if err := pinItem(db, "1", now); err != nil {
+		t.Fatal(err)
+	}
+	if pinned, _ := getFilteredItems(db, ItemFilter{Limit: 30, MinPoints: 50, BlockedAuthors: []string{"spammer"}}); len(pinned) != 3 {
+		t.Errorf("Expected the pinned item to stay, got %d items", len(pinned))
+	}
+}
+
+func TestCreateSchema_MigratesOpenGraphCache(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(1)
+
+	// Cache table as created before final URLs were recorded
+	if _, err := db.Exec(`CREATE TABLE opengraph_cache (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, url TEXT NOT NULL UNIQUE, title TEXT, description TEXT, image TEXT,
+		site_name TEXT, fetched_at TIMESTAMP, expires_at TIMESTAMP, fetch_success BOOLEAN DEFAULT TRUE)`); err != nil {
+		t.Fatalf("Failed to create old table: %v", err)
+	}
+
+	for range 2 {
+		if err := createSchema(db); err != nil {
+			t.Fatalf("Schema migration failed: %v", err)
+		}
+	}
+
+	if err := cacheOpenGraphData(db, &OpenGraphData{URL: "https://example.com", Title: "T", FinalURL: "https://example.com/final"}, true); err != nil {
+		t.Fatalf("Failed to cache data after migration: %v", err)
+	}
+	cached, err := getOpenGraphData(db, "https://example.com")
+	if err != nil || cached == nil || cached.FinalURL != "https://example.com/final" {
+		t.Errorf("Expected migrated cache to store the final URL, got %+v (err %v)", cached, err)
+	}
+}
+
+// codedError mimics the driver's error type for retry tests
+type codedError int
+
+func (e codedError) Error() string { return fmt.Sprintf("sqlite error %d", int(e)) }
+func (e codedError) Code() int     { return int(e) }
+
+func TestWithBusyRetry(t *testing.T) {
+	defer func(delay time.Duration) { busyRetryBaseDelay = delay }(busyRetryBaseDelay)
+	busyRetryBaseDelay = time.Millisecond
+
+	attempts := 0
+	err := withBusyRetry(func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("exec failed: %w", codedError(5)) // SQLITE_BUSY
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success after 3 attempts, got %d attempts and %v", attempts, err)
+	}
+
+	// Non-busy errors are returned right away
+	attempts = 0
+	err = withBusyRetry(func() error {
+		attempts++
+		return codedError(19) // SQLITE_CONSTRAINT
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected a single attempt for a non-busy error, got %d", attempts)
+	}
+
+	// Retries are bounded
+	attempts = 0
+	err = withBusyRetry(func() error {
+		attempts++
+		return codedError(261) // SQLITE_BUSY_RECOVERY
+	})
+	if !isBusyError(err) || attempts != busyRetryAttempts+1 {
+		t.Errorf("Expected %d attempts ending in a busy error, got %d and %v", busyRetryAttempts+1, attempts, err)
+	}
+}
+
+func TestExecWithRetry_WaitsForLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	writer, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	writer.SetMaxOpenConns(1)
+	if err := createSchema(writer); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+
+	other, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() { _ = other.Close() }()
+
+	// Hold the write lock briefly from another connection
+	tx, err := writer.Begin()
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO watchlist_alerts (item_hn_id) VALUES ('1')"); err != nil {
+		t.Fatalf("Failed to write in transaction: %v", err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = tx.Commit()
+	}()
+
+	if _, err := execWithRetry(other, "INSERT INTO watchlist_alerts (item_hn_id) VALUES ('2')"); err != nil {
+		t.Fatalf("Expected write to succeed once the lock is released, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// datasetteDatabase is the database name Datasette derives from hackernews.db
+const datasetteDatabase = "hackernews"
+
+// articleDomainSQL extracts the lowercased host of items.link, or NULL for text posts
+const articleDomainSQL = `CASE WHEN link = '' THEN NULL ELSE lower(
+	substr(
+		replace(replace(link, 'https://', ''), 'http://', ''),
+		1,
+		instr(replace(replace(link, 'https://', ''), 'http://', '') || '/', '/') - 1
+	)) END`
+
+// datasetteView is a canonical view published alongside the tables
+type datasetteView struct {
+	Name        string
+	Description string
+	Query       string
+	SortDesc    string
+}
+
+// datasetteViews are created in the order listed; later views may select from earlier ones
+var datasetteViews = []datasetteView{
+	{
+		Name:        "stories",
+		Description: "Every stored story with its article domain and when it last returned to the front page",
+		SortDesc:    "points",
+		Query: `SELECT items.item_hn_id AS hn_id, title, link AS url, comments_link AS comments_url,
+	` + articleDomainSQL + ` AS domain,
+	points, comment_count AS comments, author, created_at, updated_at, returned_at
+FROM items LEFT JOIN item_sightings ON item_sightings.item_hn_id = items.item_hn_id`,
+	},
+	{
+		Name:        "daily_history",
+		Description: "Stories stored per day of submission with their points and comments",
+		SortDesc:    "day",
+		Query: `SELECT substr(created_at, 1, 10) AS day, COUNT(*) AS stories,
+	ROUND(AVG(points), 1) AS average_points, MAX(points) AS top_points, SUM(comments) AS comments
+FROM stories GROUP BY day`,
+	},
+	{
+		Name:        "domains",
+		Description: "Article domains by number of stories, excluding text posts",
+		SortDesc:    "stories",
+		Query: `SELECT domain, COUNT(*) AS stories, SUM(points) AS total_points,
+	ROUND(AVG(points), 1) AS average_points, MIN(created_at) AS first_story, MAX(created_at) AS last_story
+FROM stories WHERE domain IS NOT NULL GROUP BY domain`,
+	},
+}
+
+// datasetteHiddenTables are internal bookkeeping tables left out of the table list
+var datasetteHiddenTables = []string{"opengraph_cache", "runs", "watchlist_alerts", "summaries", "discussion_summaries", "translations", "item_sightings", "archive_log", "leader_lease"}
+
+// datasettePrivateTables hold secrets and are denied to everyone
+var datasettePrivateTables = []string{"feed_profiles"}
+
+// createDatasetteViews (re)creates the canonical views so their definitions follow the current version
+func createDatasetteViews(db *sql.DB) error {
+	for i := len(datasetteViews) - 1; i >= 0; i-- {
+		if _, err := db.Exec("DROP VIEW IF EXISTS " + datasetteViews[i].Name); err != nil {
+			return fmt.Errorf("failed to drop view %s: %w", datasetteViews[i].Name, err)
+		}
+	}
+	for _, view := range datasetteViews {
+		if _, err := db.Exec("CREATE VIEW " + view.Name + " AS " + view.Query); err != nil {
+			return fmt.Errorf("failed to create view %s: %w", view.Name, err)
+		}
+	}
+	return nil
+}
+
+// datasetteMetadata returns the Datasette metadata describing the database
+func datasetteMetadata() map[string]any {
+	tables := map[string]any{
+		"items": map[string]any{
+			"description": "Hacker News stories as fetched from the front page, with their latest stats",
+			"sort_desc":   "points",
+			"columns": map[string]string{
+				"item_hn_id":    "Hacker News item ID",
+				"title":         "Story title",
+				"link":          "Article URL, empty for text posts",
+				"comments_link": "Hacker News discussion URL",
+				"points":        "Points at the last update",
+				"comment_count": "Comments at the last update",
+				"author":        "Submitter",
+				"created_at":    "Submission time",
+				"updated_at":    "When the stats were last updated",
+			},
+		},
+	}
+	for _, view := range datasetteViews {
+		tables[view.Name] = map[string]any{"description": view.Description, "sort_desc": view.SortDesc}
+	}
+	for _, name := range datasetteHiddenTables {
+		tables[name] = map[string]any{"hidden": true}
+	}
+	for _, name := range datasettePrivateTables {
+		tables[name] = map[string]any{"hidden": true, "allow": false}
+	}
+
+	return map[string]any{
+		"title":       defaultFeedInfo.Title,
+		"description": "Stories collected by hntop-rss from the Hacker News front page",
+		"source":      "Hacker News via the Algolia API",
+		"source_url":  "https://hn.algolia.com/api",
+		"databases": map[string]any{
+			datasetteDatabase: map[string]any{"tables": tables},
+		},
+	}
+}
+
+// writeDatasetteExport creates the views and writes metadata.json to outDir, returning its path
+func writeDatasetteExport(db *sql.DB, outDir string) (string, error) {
+	if err := createDatasetteViews(db); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(datasetteMetadata(), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	path := filepath.Join(outDir, "metadata.json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write metadata: %w", err)
+	}
+	return path, nil
+}
+
+// runExportDatasette handles the export datasette subcommand
+func runExportDatasette(args []string) {
+	fs := flag.NewFlagSet("export datasette", flag.ExitOnError)
+	outDir := fs.String("outdir", ".", "directory where metadata.json will be saved")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	// Datasette reads SQLite files, and the views use SQLite's string functions
+	if backend := backendOf(db); backend.name() != "sqlite" {
+		slog.Error("Datasette export needs the SQLite backend", "backend", backend.name())
+		os.Exit(1)
+	}
+
+	path, err := writeDatasetteExport(db, *outDir)
+	if err != nil {
+		slog.Error("Failed to export Datasette metadata", "error", err)
+		os.Exit(1)
+	}
+	dbPath, _ := databasePath()
+	fmt.Printf("Created views %s and wrote %s\nPublish with: datasette %s -m %s\n", strings.Join(datasetteViewNames(), ", "), path, dbPath, path)
+}
+
+// datasetteViewNames returns the names of the canonical views for display
+func datasetteViewNames() []string {
+	names := make([]string, len(datasetteViews))
+	for i, view := range datasetteViews {
+		names[i] = view.Name
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCreateDatasetteViews(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	day := time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC)
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "1", Title: "One", Link: "https://GitHub.com/a/b", Points: 100, CommentCount: 10, CreatedAt: day, UpdatedAt: day},
+		{ItemID: "2", Title: "Two", Link: "http://github.com", Points: 300, CommentCount: 30, CreatedAt: day.Add(time.Hour), UpdatedAt: day},
+		{ItemID: "3", Title: "Ask HN: Three", Link: "", Points: 50, CommentCount: 5, CreatedAt: day.Add(24 * time.Hour), UpdatedAt: day},
+	})
+
+	// Creating the views twice replaces them
+	for i := 0; i < 2; i++ {
+		if err := createDatasetteViews(db); err != nil {
+			t.Fatalf("createDatasetteViews failed: %v", err)
+		}
+	}
+
+	var domain string
+	if err := db.QueryRow("SELECT domain FROM stories WHERE hn_id = '1'").Scan(&domain); err != nil || domain != "github.com" {
+		t.Errorf("Expected domain github.com, got %q (%v)", domain, err)
+	}
+	var textPostDomain *string
+	if err := db.QueryRow("SELECT domain FROM stories WHERE hn_id = '3'").Scan(&textPostDomain); err != nil || textPostDomain != nil {
+		t.Errorf("Expected no domain for a text post, got %v (%v)", textPostDomain, err)
+	}
+
+	var stories, totalPoints int
+	if err := db.QueryRow("SELECT stories, total_points FROM domains WHERE domain = 'github.com'").Scan(&stories, &totalPoints); err != nil {
+		t.Fatal(err)
+	}
+	if stories != 2 || totalPoints != 400 {
+		t.Errorf("Expected 2 stories with 400 points for github.com, got %d and %d", stories, totalPoints)
+	}
+
+	rows, err := db.Query("SELECT day, stories FROM daily_history ORDER BY day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rows.Close() }()
+	var days []string
+	for rows.Next() {
+		var d string
+		var n int
+		if err := rows.Scan(&d, &n); err != nil {
+			t.Fatal(err)
+		}
+		days = append(days, d)
+	}
+	if len(days) != 2 || days[0] != "2024-02-03" || days[1] != "2024-02-04" {
+		t.Errorf("Expected two days of history, got %v", days)
+	}
+}
+
+func TestWriteDatasetteExport(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	path, err := writeDatasetteExport(db, t.TempDir())
+	if err != nil {
+		t.Fatalf("writeDatasetteExport failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var metadata struct {
+		Title     string
+		Databases map[string]struct {
+			Tables map[string]struct {
+				Description string
+				Hidden      bool
+				Allow       *bool
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Invalid metadata JSON: %v", err)
+	}
+	tables := metadata.Databases[datasetteDatabase].Tables
+	for _, name := range []string{"items", "stories", "daily_history", "domains"} {
+		if tables[name].Description == "" {
+			t.Errorf("Expected a description for %s", name)
+		}
+	}
+	if !tables["leader_lease"].Hidden {
+		t.Error("Expected internal tables to be hidden")
+	}
+	if profiles := tables["feed_profiles"]; profiles.Allow == nil || *profiles.Allow {
+		t.Error("Expected feed_profiles to be denied, since it holds secret tokens")
+	}
+}
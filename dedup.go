@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// duplicateWindow is how far back stored submissions of the same article count as other
+// discussions of it; older ones are found by the previous discussions lookup instead
+const duplicateWindow = 30 * 24 * time.Hour
+
+// normalizeSubmissionURL reduces an article URL to a comparable form for duplicate detection
+func normalizeSubmissionURL(link string) string {
+	if link == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimSpace(link))
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	path := strings.TrimSuffix(parsed.Path, "/")
+
+	normalized := host + path
+	if parsed.RawQuery != "" {
+		normalized += "?" + parsed.RawQuery
+	}
+	return normalized
+}
+
+// articleURL returns the canonical URL of an item's article, or the submitted link until it is resolved
+func articleURL(item HackerNewsItem) string {
+	if item.CanonicalURL != "" {
+		return item.CanonicalURL
+	}
+	return item.Link
+}
+
+// collapseDuplicateSubmissions merges items that point to the same article URL into a single entry.
+// Submissions of different URLs that redirect or declare the same canonical URL count as the same article.
+// The submission with the most points is kept and the others are attached as Duplicates.
+func collapseDuplicateSubmissions(items []HackerNewsItem) []HackerNewsItem {
+	var collapsed []HackerNewsItem
+	indexByURL := make(map[string]int)
+
+	for _, item := range items {
+		key := normalizeSubmissionURL(articleURL(item))
+		if key == "" {
+			collapsed = append(collapsed, item)
+			continue
+		}
+
+		idx, exists := indexByURL[key]
+		if !exists {
+			indexByURL[key] = len(collapsed)
+			collapsed = append(collapsed, item)
+			continue
+		}
+
+		slog.Debug("Collapsing duplicate submission", "url", item.Link, "hn_id", item.ItemID, "primary_hn_id", collapsed[idx].ItemID)
+
+		primary := collapsed[idx]
+		if item.Points > primary.Points {
+			// The new item becomes the primary entry, inheriting the earlier duplicates
+			item.Duplicates = append(primary.Duplicates, withoutDuplicates(primary))
+			collapsed[idx] = item
+		} else {
+			collapsed[idx].Duplicates = append(primary.Duplicates, item)
+		}
+	}
+
+	return collapsed
+}
+
+// attachStoredDuplicates adds the stored submissions of each item's article made since since to its
+// Duplicates. The feed only holds the items that pass its filters and limit, so other submissions
+// of the same article, with fewer points or older, would otherwise go unmentioned.
+func attachStoredDuplicates(db *sql.DB, items []HackerNewsItem, since time.Time) []HackerNewsItem {
+	// Skip the lookup if database is nil (for testing)
+	if db == nil {
+		return items
+	}
+
+	inFeed := make(map[string]bool, len(items))
+	for _, item := range items {
+		inFeed[item.ItemID] = true
+	}
+
+	for i, item := range items {
+		if isTextPost(item) {
+			continue
+		}
+		urls := []string{item.Link}
+		if item.CanonicalURL != "" && item.CanonicalURL != item.Link {
+			urls = append(urls, item.CanonicalURL)
+		}
+		submissions, err := getArticleSubmissions(db, urls, since)
+		if err != nil {
+			slog.Warn("Failed to look up other submissions", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		for _, other := range submissions {
+			if inFeed[other.ItemID] || slices.ContainsFunc(item.Duplicates, func(dup HackerNewsItem) bool { return dup.ItemID == other.ItemID }) {
+				continue
+			}
+			items[i].Duplicates = append(items[i].Duplicates, other)
+		}
+	}
+	return items
+}
+
+// withoutDuplicates returns a copy of the item with its duplicate list cleared
+func withoutDuplicates(item HackerNewsItem) HackerNewsItem {
+	item.Duplicates = nil
+	return item
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSubmissionURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{"identical", "https://example.com/post", "https://example.com/post", true},
+		{"scheme differs", "http://example.com/post", "https://example.com/post", true},
+		{"www prefix", "https://www.example.com/post", "https://example.com/post", true},
+		{"trailing slash", "https://example.com/post/", "https://example.com/post", true},
+		{"fragment ignored", "https://example.com/post#comments", "https://example.com/post", true},
+		{"different query", "https://example.com/?p=1", "https://example.com/?p=2", false},
+		{"different path", "https://example.com/a", "https://example.com/b", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := normalizeSubmissionURL(tc.a) == normalizeSubmissionURL(tc.b)
+			if result != tc.expected {
+				t.Errorf("Expected match=%v for %q and %q", tc.expected, tc.a, tc.b)
+			}
+		})
+	}
+
+	if normalizeSubmissionURL("") != "" {
+		t.Error("Empty URL should normalize to empty string")
+	}
+}
+
+func TestCollapseDuplicateSubmissions(t *testing.T) {
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "First", Link: "https://example.com/article", Points: 100},
+		{ItemID: "2", Title: "Other", Link: "https://other.com/", Points: 80},
+		{ItemID: "3", Title: "Second", Link: "https://www.example.com/article/", Points: 300},
+		{ItemID: "4", Title: "Ask HN: text", Link: "", Points: 90},
+		{ItemID: "5", Title: "Ask HN: another", Link: "", Points: 70},
+	}
+
+	collapsed := collapseDuplicateSubmissions(items)
+	if len(collapsed) != 4 {
+		t.Fatalf("Expected 4 items after collapsing, got %d", len(collapsed))
+	}
+
+	primary := collapsed[0]
+	if primary.ItemID != "3" {
+		t.Errorf("Expected highest-scoring submission to be primary, got %s", primary.ItemID)
+	}
+	if len(primary.Duplicates) != 1 || primary.Duplicates[0].ItemID != "1" {
+		t.Errorf("Expected item 1 as duplicate of item 3, got %+v", primary.Duplicates)
+	}
+}
+
+func TestCollapseDuplicateSubmissions_CanonicalURL(t *testing.T) {
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Short link", Link: "https://bit.example/abc", CanonicalURL: "https://example.com/article", Points: 100},
+		{ItemID: "2", Title: "Direct", Link: "https://example.com/article?utm_source=hn", CanonicalURL: "https://example.com/article", Points: 200},
+		{ItemID: "3", Title: "Unresolved", Link: "https://example.com/article", Points: 50},
+	}
+
+	collapsed := collapseDuplicateSubmissions(items)
+	if len(collapsed) != 1 || collapsed[0].ItemID != "2" || len(collapsed[0].Duplicates) != 2 {
+		t.Fatalf("Expected all submissions collapsed into item 2, got %+v", collapsed)
+	}
+
+	entry, _ := feedEntry(nil, items[0], 50, nil, nil, FeedFormat{})
+	if !strings.Contains(entry.Description, `href="https://example.com/article"`) || !strings.Contains(entry.Description, "Submitted as") {
+		t.Error("Expected the entry to link the canonical URL and keep the submitted one for reference")
+	}
+	entry, _ = feedEntry(nil, items[2], 50, nil, nil, FeedFormat{})
+	if strings.Contains(entry.Description, "Submitted as") {
+		t.Error("Expected no submitted URL note without a canonical URL")
+	}
+}
+
+func TestGenerateRSSFeed_DuplicateDiscussions(t *testing.T) {
+	items := collapseDuplicateSubmissions([]HackerNewsItem{
+		{ItemID: "1", Title: "Article", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 200, CommentCount: 10},
+		{ItemID: "2", Title: "Article again", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 60, CommentCount: 5},
+	})
+
+	rss := generateRSSFeed(nil, items, 50, nil)
+	if strings.Count(rss, "<entry>") != 1 {
+		t.Error("Duplicate submissions should produce a single entry")
+	}
+	if !strings.Contains(rss, "Also discussed") || !strings.Contains(rss, "item?id=2") {
+		t.Error("Entry should link to the other discussion")
+	}
+}
+
+func TestPrepareFeedItems_AttachesStoredDuplicates(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	stored := []HackerNewsItem{
+		{ItemID: "1", Title: "Article", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 300, CreatedAt: now.Add(-time.Hour)},
+		{ItemID: "2", Title: "Article, last week", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 20, CreatedAt: now.Add(-7 * 24 * time.Hour)},
+		{ItemID: "3", Title: "Article, long ago", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 50, CreatedAt: now.Add(-2 * duplicateWindow)},
+		{ItemID: "4", Title: "Article, flagged", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=4", Points: 10, CreatedAt: now.Add(-2 * time.Hour)},
+		{ItemID: "5", Title: "Short link", Link: "https://short.example/x", CommentsLink: "https://news.ycombinator.com/item?id=5", Points: 15, CreatedAt: now.Add(-3 * time.Hour)},
+		{ItemID: "6", Title: "Other", Link: "https://other.example/", CommentsLink: "https://news.ycombinator.com/item?id=6", Points: 200, CreatedAt: now.Add(-time.Hour)},
+	}
+	for i := range stored {
+		stored[i].UpdatedAt = now
+	}
+	updateStoredItems(db, stored)
+	if err := markItemDead(db, "4", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeCanonicalURL(db, "https://short.example/x", "https://example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the items above the feed's threshold are selected
+	items, err := getFilteredItems(db, ItemFilter{MinPoints: 100, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items = prepareFeedItems(db, items, nil)
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 feed items, got %d", len(items))
+	}
+
+	var duplicates []string
+	for _, item := range items {
+		if item.ItemID != "1" {
+			if len(item.Duplicates) != 0 {
+				t.Errorf("Expected no duplicates for item %s, got %+v", item.ItemID, item.Duplicates)
+			}
+			continue
+		}
+		for _, dup := range item.Duplicates {
+			duplicates = append(duplicates, dup.ItemID)
+		}
+	}
+	// Submissions outside the window and dead ones are left out; the canonical URL counts too
+	if strings.Join(duplicates, ",") != "2,5" {
+		t.Errorf("Expected stored submissions 2 and 5 attached to item 1, got %v", duplicates)
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/tls"
+	"database/sql"
+	"flag"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds the whole SMTP conversation of sending one digest
+const smtpTimeout = 30 * time.Second
+
+// digestPeriods maps the -period values to the time they cover
+var digestPeriods = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// SMTPConfig is the mail server and addresses the digest is sent with
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`     // 587 with STARTTLS by default, 465 for implicit TLS
+	Username string   `json:"username"` // no authentication when empty
+	Password string   `json:"password"` // defaults to $HNTOP_SMTP_PASSWORD
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// digestTemplate wraps the feed entry descriptions in an email-safe layout, like the newsletter
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f6f6ef;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color: #f6f6ef;">
+<tr><td align="center" style="padding: 24px 12px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" border="0" style="width: 600px; max-width: 100%; background-color: #ffffff; font-family: Arial, Helvetica, sans-serif; color: #333333;">
+<tr><td style="background-color: #ff6600; padding: 16px 24px;">
+<h1 style="margin: 0; font-size: 22px; color: #ffffff;">{{.Subject}}</h1>
+<p style="margin: 4px 0 0 0; font-size: 13px; color: #ffffff;">{{len .Stories}} stories</p>
+</td></tr>
+{{range .Stories}}
+<tr><td style="padding: 16px 24px; border-bottom: 1px solid #e5e5e5;">
+<h2 style="margin: 0 0 8px 0; font-size: 17px;"><a href="{{.URL}}" style="color: #000000; text-decoration: none;">{{.Title}}</a></h2>
+{{.Description}}
+</td></tr>
+{{end}}
+<tr><td style="padding: 20px 24px; font-size: 11px; color: #828282;">
+Generated by hntop-rss from the Hacker News front page.
+</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// digestStory is a story in the digest, with its feed entry description
+type digestStory struct {
+	Title       string
+	URL         string
+	CommentsURL string
+	Description template.HTML
+}
+
+// digestPage is the data passed to digestTemplate
+type digestPage struct {
+	Subject string
+	Stories []digestStory
+}
+
+// digestSubject names the digest after its period and date
+func digestSubject(period string, now time.Time) string {
+	now = localTime(now)
+	if period == "weekly" {
+		year, week := now.ISOWeek()
+		return fmt.Sprintf("Hacker News weekly digest – Week %d, %d", week, year)
+	}
+	return "Hacker News daily digest – " + now.Format("January 2, 2006")
+}
+
+// digestStories renders the feed entry of each item, using the cached OpenGraph previews
+func digestStories(db *sql.DB, items []HackerNewsItem, minPoints int, categoryMapper *CategoryMapper) []digestStory {
+	ogDataMap := make(map[string]*OpenGraphData)
+	for _, item := range items {
+		if isTextPost(item) {
+			continue
+		}
+		if ogData := cachedOpenGraph(db, item.Link); ogData != nil {
+			ogDataMap[item.Link] = ogData
+		}
+	}
+
+	stories := make([]digestStory, 0, len(items))
+	for _, item := range items {
+		entry, _ := feedEntry(db, item, minPoints, categoryMapper, ogDataMap, FeedFormat{})
+		stories = append(stories, digestStory{
+			Title:       entry.Title,
+			URL:         storyForItem(item).URL,
+			CommentsURL: item.CommentsLink,
+			Description: template.HTML(entry.Description), // built by feedEntry with escaped fields
+		})
+	}
+	return stories
+}
+
+// renderDigest renders the HTML body of a digest
+func renderDigest(subject string, stories []digestStory) (string, error) {
+	var buf bytes.Buffer
+	if err := digestTemplate.Execute(&buf, digestPage{Subject: subject, Stories: stories}); err != nil {
+		return "", fmt.Errorf("failed to render digest: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// digestPlainText is the text alternative of a digest for mail clients that don't show HTML
+func digestPlainText(subject string, stories []digestStory) string {
+	var b strings.Builder
+	b.WriteString(subject + "\n\n")
+	for _, story := range stories {
+		fmt.Fprintf(&b, "%s\n%s\n", story.Title, story.URL)
+		if story.CommentsURL != story.URL {
+			fmt.Fprintf(&b, "Discussion: %s\n", story.CommentsURL)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// buildDigestMessage builds a multipart/alternative email with plain text and HTML parts
+func buildDigestMessage(config SMTPConfig, subject, text, htmlBody string, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", htmlBody},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to encode message part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode message part: %w", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish message: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// envelopeAddress returns the bare address of a header address such as "HN <hn@example.com>"
+func envelopeAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	return parsed.Address, nil
+}
+
+// sendDigestMail delivers a message over SMTP. Port 465 uses implicit TLS; other ports upgrade
+// with STARTTLS when the server offers it, as net/smtp only sends credentials over TLS.
+func sendDigestMail(config SMTPConfig, msg []byte) error {
+	port := cmp.Or(config.Port, 587)
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: config.Host}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	c, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && port != 465 {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if config.Username != "" {
+		password := cmp.Or(config.Password, os.Getenv("HNTOP_SMTP_PASSWORD"))
+		if err := c.Auth(smtp.PlainAuth("", config.Username, password, config.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	from, err := envelopeAddress(config.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("server rejected the sender: %w", err)
+	}
+	for _, to := range config.To {
+		rcpt, err := envelopeAddress(to)
+		if err != nil {
+			return err
+		}
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("server rejected recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return c.Quit()
+}
+
+// runDigest parses digest subcommand flags and emails the top stories of the period
+func runDigest(args []string) {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	period := fs.String("period", "daily", "period the digest covers: daily or weekly")
+	output := fs.String("output", "", "write the HTML digest to this file instead of sending it")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	minPoints := fs.Int("min-points", 100, "minimum points threshold for stories")
+	limit := fs.Int("limit", 15, "maximum number of stories")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	timezone := addTimezoneFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+	setupTimezone(*timezone)
+
+	duration, ok := digestPeriods[*period]
+	if !ok {
+		slog.Error("Invalid -period, expected daily or weekly", "period", *period)
+		os.Exit(2)
+	}
+	categoryMapper := LoadConfig(*configPath, *configURL)
+	config := categoryMapper.Config().SMTP
+	if *output == "" && (config.Host == "" || config.From == "" || len(config.To) == 0) {
+		slog.Error("Sending the digest needs smtp host, from and to in the configuration")
+		os.Exit(2)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	items, err := newsletterItems(db, categoryMapper.withAuthorLists(ItemFilter{Limit: *limit, MinPoints: *minPoints}), duration, categoryMapper)
+	if err != nil {
+		slog.Error("Failed to load digest stories", "error", err)
+		os.Exit(1)
+	}
+	if len(items) == 0 {
+		slog.Warn("No stories for the digest, nothing to send", "period", *period, "min_points", *minPoints)
+		return
+	}
+
+	subject := digestSubject(*period, now)
+	stories := digestStories(db, items, *minPoints, categoryMapper)
+	htmlBody, err := renderDigest(subject, stories)
+	if err != nil {
+		slog.Error("Failed to render digest", "error", err)
+		os.Exit(1)
+	}
+
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(htmlBody), 0644); err != nil {
+			slog.Error("Error writing digest", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Digest saved", "stories", len(stories), "filename", *output)
+		return
+	}
+
+	msg, err := buildDigestMessage(config, subject, digestPlainText(subject, stories), htmlBody, now)
+	if err != nil {
+		slog.Error("Failed to build digest email", "error", err)
+		os.Exit(1)
+	}
+	if err := sendDigestMail(config, msg); err != nil {
+		slog.Error("Failed to send digest", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Digest sent", "stories", len(stories), "recipients", len(config.To))
+}
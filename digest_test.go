@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts one SMTP session without authentication and returns the envelope and message
+func fakeSMTPServer(t *testing.T) (port int, received <-chan []string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	ch := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+		var session []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL", "RCPT":
+				session = append(session, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 Go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				session = append(session, data.String())
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				ch <- session
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestDigestSubject(t *testing.T) {
+	useDisplayLocation(t, "UTC")
+	now := time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC)
+	if got := digestSubject("daily", now); got != "Hacker News daily digest – October 16, 2026" {
+		t.Errorf("Unexpected daily subject %q", got)
+	}
+	if got := digestSubject("weekly", now); got != "Hacker News weekly digest – Week 42, 2026" {
+		t.Errorf("Unexpected weekly subject %q", got)
+	}
+}
+
+func TestRenderDigest_UsesFeedEntries(t *testing.T) {
+	server := setupTestServer(t)
+	_, mapper := server.settings()
+
+	items, err := newsletterItems(server.db, ItemFilter{Limit: 2, MinPoints: 50}, 24*time.Hour, mapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stories := digestStories(server.db, items, 50, mapper)
+	if len(stories) != 2 {
+		t.Fatalf("Expected the 2 best stories, got %d", len(stories))
+	}
+
+	body, err := renderDigest("Digest", stories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := feedEntry(server.db, items[0], 50, mapper, nil, FeedFormat{})
+	if !strings.Contains(body, entry.Description) {
+		t.Error("Expected the digest to contain the feed entry description unescaped")
+	}
+	if !strings.Contains(body, `href="https://github.com/user/repo"`) || strings.Contains(body, "Blog") {
+		t.Error("Expected the best stories to be linked and the rest left out")
+	}
+	if text := digestPlainText("Digest", stories); !strings.Contains(text, "https://github.com/user/repo\nDiscussion: ") {
+		t.Errorf("Expected the text part to list links, got %q", text)
+	}
+}
+
+func TestSendDigestMail(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+	config := SMTPConfig{Host: "127.0.0.1", Port: port, From: "HN Digest <digest@example.com>", To: []string{"me@example.com", "You <you@example.com>"}}
+
+	htmlBody := `<p style="margin: 0;">` + strings.Repeat("A long line of HTML ", 100) + "</p>"
+	msg, err := buildDigestMessage(config, "Hacker News daily digest – October 16, 2026", "Plain text", htmlBody, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sendDigestMail(config, msg); err != nil {
+		t.Fatalf("sendDigestMail failed: %v", err)
+	}
+
+	session := <-received
+	if len(session) != 4 || session[0] != "MAIL FROM:<digest@example.com>" || session[1] != "RCPT TO:<me@example.com>" || session[2] != "RCPT TO:<you@example.com>" {
+		t.Fatalf("Unexpected SMTP envelope %q", session[:min(3, len(session))])
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(session[3]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "Hacker News daily digest – October 16, 2026" || parsed.Header.Get("To") != "me@example.com, You <you@example.com>" {
+		t.Errorf("Unexpected headers %v", parsed.Header)
+	}
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Expected a multipart/alternative message, got %s", mediaType)
+	}
+
+	// multipart.Reader decodes quoted-printable parts
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			break
+		}
+		content, _ := io.ReadAll(part)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(content))
+	}
+	if len(bodies) != 2 || bodies[0] != "text/plain; charset=utf-8: Plain text" || bodies[1] != "text/html; charset=utf-8: "+htmlBody {
+		t.Errorf("Unexpected message parts %q", bodies)
+	}
+	for _, line := range strings.Split(session[3], "\r\n") {
+		if len(line) > 998 {
+			t.Errorf("Message has a line of %d characters, over the SMTP limit", len(line))
+		}
+	}
+}
+
+func TestSendDigestMail_ConnectionError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	err = sendDigestMail(SMTPConfig{Host: "127.0.0.1", Port: port, From: "a@example.com", To: []string{"b@example.com"}}, []byte("x"))
+	if err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Errorf("Expected a connection error, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	defaultDiscussionComments   = 5   // top-level comments considered per story
+	defaultDiscussionGrowth     = 0.5 // relative comment growth that triggers a refresh
+	defaultDiscussionMaxItems   = 10  // discussion summaries generated per run
+	minDiscussionCommentGrowth  = 10  // absolute comment growth required before refreshing
+	maxCommentExcerptLength     = 200
+	maxDiscussionPromptComments = 20
+)
+
+// algoliaItemsURL is the Algolia endpoint returning an item with its comment tree
+var algoliaItemsURL = "https://hn.algolia.com/api/v1/items/"
+
+// DiscussionConfig configures "what HN thinks" summaries of story comments
+type DiscussionConfig struct {
+	Mode        string  `json:"mode"`         // "extractive" (top comment excerpts) or "llm" (uses the summarizer); empty disables
+	Comments    int     `json:"comments"`     // top-level comments used (0 = 5)
+	RefreshRate float64 `json:"refresh_rate"` // relative comment growth that triggers a refresh (0 = 0.5)
+	MaxItems    int     `json:"max_items"`    // new or refreshed summaries per run (0 = 10)
+}
+
+// AlgoliaComment is a node in the comment tree returned by the Algolia items endpoint
+type AlgoliaComment struct {
+	Author   string           `json:"author"`
+	Text     string           `json:"text"`
+	Children []AlgoliaComment `json:"children"`
+}
+
+// discussionSummary is a cached discussion summary and the comment count it was made at
+type discussionSummary struct {
+	Summary      string
+	CommentCount int
+}
+
+// fetchTopComments returns the top-level comments of a story, ranked by the number of replies they drew
+func fetchTopComments(ctx context.Context, client *http.Client, itemID string, limit int) ([]AlgoliaComment, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, algoliaItemsURL+itemID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error %d", resp.StatusCode)
+	}
+
+	var story AlgoliaComment
+	if err := json.NewDecoder(resp.Body).Decode(&story); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	// Deleted and dead comments have no author or text
+	var comments []AlgoliaComment
+	for _, c := range story.Children {
+		if c.Author != "" && c.Text != "" {
+			comments = append(comments, c)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return countReplies(comments[i]) > countReplies(comments[j])
+	})
+	if len(comments) > limit {
+		comments = comments[:limit]
+	}
+	return comments, nil
+}
+
+// countReplies returns the number of descendants of a comment
+func countReplies(c AlgoliaComment) int {
+	count := len(c.Children)
+	for _, child := range c.Children {
+		count += countReplies(child)
+	}
+	return count
+}
+
+// commentPlainText converts comment HTML to plain text
+func commentPlainText(commentHTML string) string {
+	doc, err := html.Parse(strings.NewReader(commentHTML))
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(nodeText(doc)), " ")
+}
+
+// extractiveDiscussionSummary builds a summary from excerpts of the top comments, one per line
+func extractiveDiscussionSummary(comments []AlgoliaComment) string {
+	var lines []string
+	for _, c := range comments {
+		text := commentPlainText(c.Text)
+		if text == "" {
+			continue
+		}
+		if runes := []rune(text); len(runes) > maxCommentExcerptLength {
+			text = strings.TrimSpace(string(runes[:maxCommentExcerptLength-1])) + "…"
+		}
+		lines = append(lines, c.Author+": "+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// discussionPrompt builds the LLM instruction for summarizing comments
+func discussionPrompt(title string, comments []AlgoliaComment) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Summarize what Hacker News commenters think about the story below in 2-3 plain sentences. Reply with the summary only.\n\nStory: %s\n\nComments:\n", title)
+	for i, c := range comments {
+		if i == maxDiscussionPromptComments {
+			break
+		}
+		fmt.Fprintf(&sb, "- %s\n", commentPlainText(c.Text))
+	}
+
+	prompt := sb.String()
+	if len(prompt) > maxArticleTextLength {
+		prompt = strings.ToValidUTF8(prompt[:maxArticleTextLength], "")
+	}
+	return prompt
+}
+
+// needsDiscussionRefresh reports whether a story has no summary yet or its comment count grew significantly
+func needsDiscussionRefresh(cached *discussionSummary, commentCount int, growth float64) bool {
+	if cached == nil {
+		return true
+	}
+	added := commentCount - cached.CommentCount
+	return added >= minDiscussionCommentGrowth && float64(added) >= float64(cached.CommentCount)*growth
+}
+
+// summarizeDiscussions creates or refreshes discussion summaries for items with comments.
+// It does nothing unless a discussion mode is configured.
+func summarizeDiscussions(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	fullConfig := categoryMapper.Config()
+	config := fullConfig.Discussion
+	if config.Mode == "" {
+		return
+	}
+
+	var llm summarizer
+	timeout := defaultSummaryTimeout
+	switch config.Mode {
+	case "extractive":
+	case "llm":
+		var err error
+		if llm, err = newSummarizer(fullConfig.Summarizer); err != nil {
+			slog.Warn("Discussion summaries need a configured summarizer, skipping", "error", err)
+			return
+		}
+		if fullConfig.Summarizer.TimeoutSeconds > 0 {
+			timeout = time.Duration(fullConfig.Summarizer.TimeoutSeconds) * time.Second
+		}
+	default:
+		slog.Warn("Unknown discussion summary mode, skipping", "mode", config.Mode)
+		return
+	}
+
+	limit := config.Comments
+	if limit <= 0 {
+		limit = defaultDiscussionComments
+	}
+	growth := config.RefreshRate
+	if growth <= 0 {
+		growth = defaultDiscussionGrowth
+	}
+	maxItems := config.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultDiscussionMaxItems
+	}
+
+	client := &http.Client{Timeout: timeout}
+	generated := 0
+	for _, item := range items {
+		if generated >= maxItems {
+			slog.Debug("Discussion summary limit reached for this run", "limit", maxItems)
+			break
+		}
+		if item.CommentCount == 0 {
+			continue
+		}
+		cached, err := getDiscussionSummary(db, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to load discussion summary", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if !needsDiscussionRefresh(cached, item.CommentCount, growth) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var summary string
+		err = errPanicked
+		safely("discussion summary", func() {
+			summary, err = buildDiscussionSummary(ctx, client, llm, item, limit)
+		}, itemLogAttrs(item)...)
+		cancel()
+		if err != nil {
+			slog.Debug("Failed to summarize discussion", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+
+		if err := cacheDiscussionSummary(db, item.ItemID, summary, item.CommentCount); err != nil {
+			slog.Warn("Failed to cache discussion summary", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		generated++
+		slog.Info("Summarized discussion", "title", item.Title, "comments", item.CommentCount, "mode", config.Mode)
+	}
+}
+
+// buildDiscussionSummary fetches an item's comments and summarizes them, using the LLM if one is given
+func buildDiscussionSummary(ctx context.Context, client *http.Client, llm summarizer, item HackerNewsItem, limit int) (string, error) {
+	comments, err := fetchTopComments(ctx, client, item.ItemID, limit)
+	if err != nil {
+		return "", err
+	}
+	if len(comments) == 0 {
+		return "", fmt.Errorf("no comments")
+	}
+
+	if llm == nil {
+		return extractiveDiscussionSummary(comments), nil
+	}
+	summary, _, err := llm.Complete(ctx, discussionPrompt(item.Title, comments))
+	return summary, err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testDiscussionJSON = `{
+	"id": 1,
+	"children": [
+		{"author": "quiet", "text": "<p>Nobody replied to me.</p>", "children": []},
+		{"author": "", "text": "", "children": [{"author": "x", "text": "reply to deleted", "children": []}, {"author": "y", "text": "another", "children": []}]},
+		{"author": "popular", "text": "<p>This is the <i>main</i> point &amp; more.</p>", "children": [
+			{"author": "a", "text": "agree", "children": [{"author": "b", "text": "me too", "children": []}]}
+		]},
+		{"author": "middle", "text": "Some reply-worthy take", "children": [{"author": "c", "text": "hmm", "children": []}]}
+	]
+}`
+
+// useTestAlgoliaItems points the Algolia items endpoint at a test server for the duration of a test
+func useTestAlgoliaItems(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	original := algoliaItemsURL
+	algoliaItemsURL = server.URL + "/items/"
+	t.Cleanup(func() {
+		algoliaItemsURL = original
+		server.Close()
+	})
+}
+
+func TestExtractiveDiscussionSummary(t *testing.T) {
+	useTestAlgoliaItems(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDiscussionJSON))
+	})
+
+	comments, err := fetchTopComments(t.Context(), http.DefaultClient, "1", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	summary := extractiveDiscussionSummary(comments)
+	expected := "popular: This is the main point & more.\nmiddle: Some reply-worthy take"
+	if summary != expected {
+		t.Errorf("Expected %q, got %q", expected, summary)
+	}
+}
+
+func TestExtractiveDiscussionSummary_Truncates(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	summary := extractiveDiscussionSummary([]AlgoliaComment{{Author: "a", Text: long}})
+	if !strings.HasSuffix(summary, "…") || len([]rune(summary)) > maxCommentExcerptLength+3 {
+		t.Errorf("Expected truncated excerpt, got %q", summary)
+	}
+}
+
+func TestNeedsDiscussionRefresh(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cached   *discussionSummary
+		comments int
+		expected bool
+	}{
+		{"no summary yet", nil, 5, true},
+		{"little growth", &discussionSummary{CommentCount: 100}, 120, false},
+		{"significant growth", &discussionSummary{CommentCount: 100}, 150, true},
+		{"large relative but tiny absolute growth", &discussionSummary{CommentCount: 4}, 10, false},
+		{"large growth from small base", &discussionSummary{CommentCount: 4}, 20, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := needsDiscussionRefresh(tc.cached, tc.comments, 0.5); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSummarizeDiscussions(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var requests atomic.Int32
+	useTestAlgoliaItems(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(testDiscussionJSON))
+	})
+
+	mapper := NewCategoryMapper(&DomainConfig{Discussion: DiscussionConfig{Mode: "extractive", Comments: 1}})
+	item := HackerNewsItem{ItemID: "1", Title: "Story", Link: "https://example.com/story", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CommentCount: 40, CreatedAt: time.Now()}
+	noComments := HackerNewsItem{ItemID: "2", Title: "Quiet", CommentCount: 0}
+
+	summarizeDiscussions(db, []HackerNewsItem{item, noComments}, mapper)
+	if requests.Load() != 1 {
+		t.Fatalf("Expected one comment fetch, got %d", requests.Load())
+	}
+
+	cached, err := getDiscussionSummary(db, "1")
+	if err != nil || cached == nil {
+		t.Fatalf("Expected cached discussion summary, got %v (err %v)", cached, err)
+	}
+	if cached.Summary != "popular: This is the main point & more." || cached.CommentCount != 40 {
+		t.Errorf("Unexpected cached summary: %+v", cached)
+	}
+
+	// Unchanged comment counts reuse the cache; significant growth refreshes it
+	summarizeDiscussions(db, []HackerNewsItem{item}, mapper)
+	if requests.Load() != 1 {
+		t.Errorf("Expected cached summary to be reused, got %d fetches", requests.Load())
+	}
+	item.CommentCount = 80
+	summarizeDiscussions(db, []HackerNewsItem{item}, mapper)
+	if requests.Load() != 2 {
+		t.Errorf("Expected refresh after comment growth, got %d fetches", requests.Load())
+	}
+
+	if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link}, false); err != nil {
+		t.Fatalf("Failed to seed OpenGraph cache: %v", err)
+	}
+	rss := generateRSSFeed(db, []HackerNewsItem{item}, 50, nil)
+	if !strings.Contains(rss, "What HN thinks") || !strings.Contains(rss, "popular: This is the main point") {
+		t.Error("Expected feed entry to include the discussion summary")
+	}
+}
+
+func TestSummarizeDiscussions_LLM(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	useTestAlgoliaItems(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDiscussionJSON))
+	})
+	var prompt string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompt = string(body)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Commenters mostly agree."}}],"usage":{"total_tokens":50}}`))
+	}))
+	defer llm.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{
+		Summarizer: SummarizerConfig{Provider: "openai", URL: llm.URL, Model: "m"},
+		Discussion: DiscussionConfig{Mode: "llm"},
+	})
+	summarizeDiscussions(db, []HackerNewsItem{{ItemID: "1", Title: "Story", CommentCount: 10}}, mapper)
+
+	cached, _ := getDiscussionSummary(db, "1")
+	if cached == nil || cached.Summary != "Commenters mostly agree." {
+		t.Fatalf("Expected LLM discussion summary, got %+v", cached)
+	}
+	if !strings.Contains(prompt, "main point") || !strings.Contains(prompt, "Nobody replied") {
+		t.Errorf("Expected prompt to include comment text, got %q", prompt)
+	}
+}
+
+func TestSummarizeDiscussions_LLMWithoutSummarizer(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var requests atomic.Int32
+	useTestAlgoliaItems(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	})
+
+	mapper := NewCategoryMapper(&DomainConfig{Discussion: DiscussionConfig{Mode: "llm"}})
+	summarizeDiscussions(db, []HackerNewsItem{{ItemID: "1", CommentCount: 10}}, mapper)
+	if requests.Load() != 0 {
+		t.Errorf("Expected no fetches without a configured summarizer, got %d", requests.Load())
+	}
+}
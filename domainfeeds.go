@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// domainFeedScanLimit bounds how many stored items are searched for a domain group's feed
+const domainFeedScanLimit = 1000
+
+// domainFeedSlug turns a domain group name into a file name part, e.g. "The Verge" -> "the-verge"
+func domainFeedSlug(group string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(group) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// domainFeedFile returns the file name of a domain group's feed, e.g. domain-github.xml
+func domainFeedFile(group string) string {
+	return "domain-" + domainFeedSlug(group) + ".xml"
+}
+
+// domainFeedInfo describes the dedicated feed of a domain group
+func domainFeedInfo(group string) feedInfo {
+	return feedInfo{
+		Title:       "Hacker News: " + group,
+		Description: "Top Hacker News stories linking to " + group,
+		ID:          "tag:news.ycombinator.com,2024:domain:" + domainFeedSlug(group),
+	}
+}
+
+// getDomainFeedItems returns stored items above the threshold whose links belong to the domain group
+func getDomainFeedItems(db *sql.DB, group string, filter ItemFilter, categoryMapper *CategoryMapper) ([]HackerNewsItem, error) {
+	scan := filter
+	scan.Limit = domainFeedScanLimit
+	items, err := getFilteredItems(db, scan)
+	if err != nil {
+		return nil, err
+	}
+	var matched []HackerNewsItem
+	for _, item := range items {
+		if categoryMapper.GetCategoryForDomain(extractDomain(item.Link)) != group {
+			continue
+		}
+		matched = append(matched, item)
+		if len(matched) == filter.Limit {
+			break
+		}
+	}
+	return prepareFeedItems(db, matched, categoryMapper), nil
+}
+
+// writeDomainFeeds writes a feed for every domain group listed in domain_feeds and returns the groups
+// that have one. Groups missing from category_domains are skipped with a warning.
+func writeDomainFeeds(db *sql.DB, outDir string, filter ItemFilter, categoryMapper *CategoryMapper) ([]string, error) {
+	config := categoryMapper.Config()
+	var groups []string
+	for _, group := range config.DomainFeeds {
+		if _, ok := config.CategoryDomains[group]; !ok || domainFeedSlug(group) == "" {
+			slog.Warn("Skipping domain feed for unknown domain group", "group", group)
+			continue
+		}
+
+		items, err := getDomainFeedItems(db, group, filter, categoryMapper)
+		if err != nil {
+			return groups, err
+		}
+		feed := generateFeed(db, items, filter.MinPoints, categoryMapper, domainFeedInfo(group))
+		filename := filepath.Join(outDir, domainFeedFile(group))
+		written, err := writeFeedFile(filename, feed)
+		if err != nil {
+			return groups, fmt.Errorf("failed to write domain feed: %w", err)
+		}
+		if written {
+			slog.Info("Domain feed saved", "group", group, "count", len(items), "filename", filename)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDomainFeedFile(t *testing.T) {
+	testCases := map[string]string{
+		"GitHub":                  "domain-github.xml",
+		"The Verge":               "domain-the-verge.xml",
+		"Dev.to":                  "domain-dev-to.xml",
+		"Harvard Business Review": "domain-harvard-business-review.xml",
+	}
+	for group, expected := range testCases {
+		if got := domainFeedFile(group); got != expected {
+			t.Errorf("domainFeedFile(%q) = %q, expected %q", group, got, expected)
+		}
+	}
+}
+
+func TestWriteDomainFeeds(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "A repository", Link: "https://github.com/user/repo", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 200, CommentCount: 10, Author: "a", CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "A paper", Link: "https://arxiv.org/abs/1234.5678", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 150, CommentCount: 20, Author: "b", CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now},
+		{ItemID: "3", Title: "Another paper", Link: "https://www.arxiv.org/abs/8765.4321", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 90, CommentCount: 5, Author: "c", CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now},
+		{ItemID: "4", Title: "A quiet paper", Link: "https://arxiv.org/abs/0000.0001", CommentsLink: "https://news.ycombinator.com/item?id=4", Points: 10, CommentCount: 0, Author: "d", CreatedAt: now.Add(-4 * time.Hour), UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+	for _, item := range items {
+		if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mapper := NewCategoryMapper(&DomainConfig{
+		CategoryDomains: map[string][]string{"GitHub": {"github.com"}, "ArXiv": {"arxiv.org"}},
+		DomainFeeds:     []string{"ArXiv", "Nowhere"},
+	})
+	outDir := t.TempDir()
+	groups, err := writeDomainFeeds(db, outDir, ItemFilter{Limit: 30, MinPoints: 50}, mapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0] != "ArXiv" {
+		t.Errorf("Expected only the ArXiv feed to be written, got %v", groups)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "domain-arxiv.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := string(data)
+	for _, want := range []string{"Hacker News: ArXiv", "A paper", "Another paper"} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected %q in the ArXiv feed", want)
+		}
+	}
+	for _, unwanted := range []string{"A repository", "A quiet paper"} {
+		if strings.Contains(feed, unwanted) {
+			t.Errorf("Expected %q to be left out of the ArXiv feed", unwanted)
+		}
+	}
+
+	// Only listed, known groups get a feed
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the ArXiv feed, got %d files", len(entries))
+	}
+}
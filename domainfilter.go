@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// domainListed reports whether host is one of the listed domains or a subdomain of one
+func domainListed(host string, domains []string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+		if domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// DomainAllowed reports whether articles from the link's domain may appear in feeds: the domain must
+// not be blocked and, when an allowlist is configured, must be on it. Text posts have no article
+// domain and are always allowed.
+func (cm *CategoryMapper) DomainAllowed(link string) bool {
+	if cm == nil || link == "" {
+		return true
+	}
+	host := extractDomain(link)
+	if domainListed(host, cm.config.BlockedDomains) {
+		return false
+	}
+	return len(cm.config.AllowedDomains) == 0 || domainListed(host, cm.config.AllowedDomains)
+}
+
+// filterBlockedDomains drops items whose article domain is blocked or missing from the allowlist
+func filterBlockedDomains(items []HackerNewsItem, categoryMapper *CategoryMapper) []HackerNewsItem {
+	var filtered []HackerNewsItem
+	for _, item := range items {
+		// Pinned items were added on purpose and always stay in the feed
+		if item.PinnedAt.IsZero() && !categoryMapper.DomainAllowed(item.Link) {
+			slog.Debug("Excluding item from filtered domain", "hn_id", item.ItemID, "domain", extractDomain(item.Link))
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
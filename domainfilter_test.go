@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDomainAllowed(t *testing.T) {
+	blocking := NewCategoryMapper(&DomainConfig{BlockedDomains: []string{"wsj.com", "www.Bloomberg.com", ""}})
+	allowing := NewCategoryMapper(&DomainConfig{AllowedDomains: []string{"github.com", "arxiv.org"}, BlockedDomains: []string{"gist.github.com"}})
+
+	testCases := []struct {
+		name     string
+		mapper   *CategoryMapper
+		link     string
+		expected bool
+	}{
+		{"blocked domain", blocking, "https://www.wsj.com/articles/x", false},
+		{"blocked subdomain", blocking, "https://markets.wsj.com/x", false},
+		{"blocked entry with www", blocking, "https://bloomberg.com/news/x", false},
+		{"lookalike domain", blocking, "https://notwsj.com/x", true},
+		{"unlisted domain", blocking, "https://example.com/x", true},
+		{"text post", blocking, "", true},
+		{"allowlisted domain", allowing, "https://github.com/user/repo", true},
+		{"not allowlisted", allowing, "https://example.com/x", false},
+		{"block wins over allow", allowing, "https://gist.github.com/x", false},
+		{"text post with allowlist", allowing, "", true},
+		{"no mapper", nil, "https://wsj.com/x", true},
+	}
+	for _, tc := range testCases {
+		if got := tc.mapper.DomainAllowed(tc.link); got != tc.expected {
+			t.Errorf("%s: DomainAllowed(%q) = %v, expected %v", tc.name, tc.link, got, tc.expected)
+		}
+	}
+}
+
+func TestPrepareFeedItems_BlockedDomains(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{BlockedDomains: []string{"wsj.com"}})
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Paywalled", Link: "https://www.wsj.com/articles/x", Points: 300},
+		{ItemID: "2", Title: "Open", Link: "https://example.com/x", Points: 200},
+		{ItemID: "3", Title: "Pinned paywalled", Link: "https://wsj.com/articles/y", Points: 10, PinnedAt: time.Now()},
+	}
+
+	prepared := prepareFeedItems(nil, items, mapper)
+	if len(prepared) != 2 || prepared[0].ItemID != "2" || prepared[1].ItemID != "3" {
+		t.Errorf("Expected the open and pinned items, got %+v", prepared)
+	}
+}
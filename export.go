@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tierThresholds are the point thresholds offered as separate feeds, matching the score categories
+var tierThresholds = []int{100, 200, 500}
+
+// opmlDocument is an OPML 2.0 subscription list
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Head    opmlHead      `xml:"head"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated"`
+}
+
+// opmlOutline is a folder when it has children, otherwise a feed subscription
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Children []opmlOutline `xml:"outline"`
+}
+
+// opmlFeed returns the outline of a single feed subscription
+func opmlFeed(title, xmlURL string) opmlOutline {
+	return opmlOutline{Text: title, Title: title, Type: "rss", XMLURL: xmlURL, HTMLURL: "https://news.ycombinator.com/"}
+}
+
+// feedVariantOptions selects which optional feeds are exported
+type feedVariantOptions struct {
+	MinPoints int
+	Profiles  []FeedProfile // personalized feeds; their URLs contain the secret token
+	Podcast   bool
+}
+
+// feedVariants returns the feeds served at baseURL grouped into folders:
+// the main feeds, point tiers, one feed per configured category and personalized profiles
+func feedVariants(baseURL string, categoryMapper *CategoryMapper, options feedVariantOptions) []opmlOutline {
+	base := strings.TrimSuffix(baseURL, "/")
+	feedURL := func(path string, query url.Values) string {
+		if len(query) == 0 {
+			return base + path
+		}
+		return base + path + "?" + query.Encode()
+	}
+
+	top := opmlOutline{Text: "Hacker News", Children: []opmlOutline{
+		opmlFeed(defaultFeedInfo.Title, feedURL("/feed.xml", nil)),
+	}}
+	if len(categoryMapper.Config().Watchlist) > 0 {
+		top.Children = append(top.Children, opmlFeed("Hacker News Watchlist", feedURL("/watchlist.xml", nil)))
+	}
+	if options.Podcast {
+		top.Children = append(top.Children, opmlFeed("Hacker News Audio Digest", feedURL("/podcast/"+podcastFeedFile, nil)))
+	}
+	outlines := []opmlOutline{top}
+
+	tiers := opmlOutline{Text: "By Points"}
+	for _, threshold := range tierThresholds {
+		if threshold <= options.MinPoints {
+			continue
+		}
+		query := url.Values{"min_points": {strconv.Itoa(threshold)}}
+		tiers.Children = append(tiers.Children, opmlFeed(fmt.Sprintf("Hacker News %d+ points", threshold), feedURL("/feed.xml", query)))
+	}
+	if len(tiers.Children) > 0 {
+		outlines = append(outlines, tiers)
+	}
+
+	categories := opmlOutline{Text: "By Category"}
+	names := make([]string, 0, len(categoryMapper.Config().CategoryDomains))
+	for name := range categoryMapper.Config().CategoryDomains {
+		names = append(names, name)
+	}
+	names = categoryMapper.AliasCategories(names)
+	sort.Strings(names)
+	for _, name := range names {
+		query := url.Values{"category": {name}}
+		categories.Children = append(categories.Children, opmlFeed("Hacker News: "+name, feedURL("/feed.xml", query)))
+	}
+	if len(categories.Children) > 0 {
+		outlines = append(outlines, categories)
+	}
+
+	profiles := opmlOutline{Text: "Personalized"}
+	for _, profile := range options.Profiles {
+		profiles.Children = append(profiles.Children, opmlFeed("Hacker News: "+profile.Name, feedURL("/feed/"+profile.Token+".xml", nil)))
+	}
+	if len(profiles.Children) > 0 {
+		outlines = append(outlines, profiles)
+	}
+
+	return outlines
+}
+
+// generatedFeedsOPMLFile is the OPML list of the feed files written by a run
+const generatedFeedsOPMLFile = "feeds.opml"
+
+// generatedFeed is a feed file written to the output directory
+type generatedFeed struct {
+	Title string
+	File  string
+}
+
+// publicFeedURL returns the URL of a feed file in an output directory served at baseURL
+func publicFeedURL(baseURL, file string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + file
+}
+
+// generatedFeedOutlines returns the feed files written to a directory served at baseURL: the
+// top-level feeds in one folder and the domain group feeds in another
+func generatedFeedOutlines(baseURL string, feeds []generatedFeed, domainGroups []string) []opmlOutline {
+	top := opmlOutline{Text: "Hacker News"}
+	for _, feed := range feeds {
+		top.Children = append(top.Children, opmlFeed(feed.Title, publicFeedURL(baseURL, feed.File)))
+	}
+	outlines := []opmlOutline{top}
+
+	domains := opmlOutline{Text: "By Domain"}
+	for _, group := range domainGroups {
+		domains.Children = append(domains.Children, opmlFeed(domainFeedInfo(group).Title, publicFeedURL(baseURL, domainFeedFile(group))))
+	}
+	if len(domains.Children) > 0 {
+		outlines = append(outlines, domains)
+	}
+	return outlines
+}
+
+// writeGeneratedFeedsOPML writes feeds.opml listing the feed files of a run, so the whole set can be
+// imported into a reader at once. Nothing is written for a run that produced a single feed.
+func writeGeneratedFeedsOPML(outDir, baseURL string, feeds []generatedFeed, domainGroups []string, now time.Time) error {
+	if len(feeds)+len(domainGroups) < 2 {
+		return nil
+	}
+	opml, err := renderOPML("Hacker News feeds", generatedFeedOutlines(baseURL, feeds, domainGroups), now)
+	if err != nil {
+		return err
+	}
+	if _, err := writeFeedFile(filepath.Join(outDir, generatedFeedsOPMLFile), opml); err != nil {
+		return fmt.Errorf("failed to write feed list: %w", err)
+	}
+	return nil
+}
+
+// renderOPML renders outlines as an OPML 2.0 document
+func renderOPML(title string, outlines []opmlOutline, now time.Time) (string, error) {
+	doc := opmlDocument{
+		Version: "2.0",
+		Head:    opmlHead{Title: title, DateCreated: now.UTC().Format(time.RFC1123Z)},
+		Body:    outlines,
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode OPML: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// parseBaseURL validates the public base URL feeds are served from
+func parseBaseURL(baseURL string) (string, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("base URL must be an absolute http or https URL, got %q", baseURL)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("base URL must not have a query or fragment")
+	}
+	return parsed.String(), nil
+}
+
+// runExport handles the export subcommand
+func runExport(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss export <opml|datasette> [options] | export -format parquet [options]")
+		os.Exit(2)
+	}
+
+	if strings.HasPrefix(args[0], "-") {
+		runExportData(args)
+		return
+	}
+
+	switch args[0] {
+	case "opml":
+		runExportOPML(args[1:])
+	case "datasette":
+		runExportDatasette(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss export <opml|datasette> [options] | export -format parquet [options]")
+		os.Exit(2)
+	}
+}
+
+// runExportOPML handles the export opml subcommand
+func runExportOPML(args []string) {
+	fs := flag.NewFlagSet("export opml", flag.ExitOnError)
+	baseURL := fs.String("base-url", "", "public URL the feeds are served from, e.g. https://hn.example.com (required)")
+	output := fs.String("output", "", "file to write the OPML to (defaults to stdout)")
+	minPoints := fs.Int("min-points", 50, "minimum points threshold of the default feed; only higher tiers are exported")
+	profiles := fs.Bool("profiles", false, "include personalized profile feeds (their URLs contain the secret token)")
+	podcast := fs.Bool("podcast", false, "include the podcast feed served by serve -podcast-dir")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+
+	if *baseURL == "" {
+		fmt.Fprintln(os.Stderr, "export opml: -base-url is required")
+		os.Exit(2)
+	}
+	base, err := parseBaseURL(*baseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export opml: %v\n", err)
+		os.Exit(2)
+	}
+
+	categoryMapper := LoadConfig(*configPath, *configURL)
+
+	options := feedVariantOptions{MinPoints: *minPoints, Podcast: *podcast}
+	if *profiles {
+		options.Profiles = loadExportProfiles()
+	}
+
+	opml, err := renderOPML(defaultFeedInfo.Title, feedVariants(base, categoryMapper, options), time.Now())
+	if err != nil {
+		slog.Error("Failed to render OPML", "error", err)
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		fmt.Print(opml)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(opml), 0644); err != nil {
+		slog.Error("Failed to write OPML", "path", *output, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("OPML written", "path", *output)
+}
+
+// loadExportProfiles returns the stored feed profiles, exiting on database errors
+func loadExportProfiles() []FeedProfile {
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	profiles, err := listFeedProfiles(db)
+	if err != nil {
+		slog.Error("Failed to list profiles", "error", err)
+		os.Exit(1)
+	}
+	return profiles
+}
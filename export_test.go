@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collectFeedURLs returns the xmlUrl of every feed outline
+func collectFeedURLs(outlines []opmlOutline) []string {
+	var urls []string
+	for _, outline := range outlines {
+		if outline.XMLURL != "" {
+			urls = append(urls, outline.XMLURL)
+		}
+		urls = append(urls, collectFeedURLs(outline.Children)...)
+	}
+	return urls
+}
+
+func TestFeedVariants(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{
+		CategoryDomains: map[string][]string{"Code": {"github.com"}, "Big News": {"bbc.co.uk"}},
+		Watchlist:       []string{"golang"},
+	})
+	options := feedVariantOptions{
+		MinPoints: 150,
+		Profiles:  []FeedProfile{{Name: "Go", Token: "abc123"}},
+		Podcast:   true,
+	}
+
+	urls := collectFeedURLs(feedVariants("https://hn.example.com/", mapper, options))
+	want := []string{
+		"https://hn.example.com/feed.xml",
+		"https://hn.example.com/watchlist.xml",
+		"https://hn.example.com/podcast/podcast.xml",
+		"https://hn.example.com/feed.xml?min_points=200",
+		"https://hn.example.com/feed.xml?min_points=500",
+		"https://hn.example.com/feed.xml?category=Big+News",
+		"https://hn.example.com/feed.xml?category=Code",
+		"https://hn.example.com/feed/abc123.xml",
+	}
+	if strings.Join(urls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected feed URLs:\ngot  %v\nwant %v", urls, want)
+	}
+}
+
+func TestFeedVariants_NoConfig(t *testing.T) {
+	outlines := feedVariants("https://hn.example.com/hn", nil, feedVariantOptions{MinPoints: 50})
+
+	urls := collectFeedURLs(outlines)
+	if len(urls) != 4 || urls[0] != "https://hn.example.com/hn/feed.xml" {
+		t.Errorf("Expected the main feed and three tiers under the base path, got %v", urls)
+	}
+	for _, outline := range outlines {
+		if outline.Text == "By Category" || outline.Text == "Personalized" {
+			t.Errorf("Expected no empty %q folder", outline.Text)
+		}
+	}
+}
+
+func TestRenderOPML(t *testing.T) {
+	outlines := feedVariants("https://hn.example.com", nil, feedVariantOptions{})
+	opml, err := renderOPML("Test & Feeds", outlines, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("renderOPML failed: %v", err)
+	}
+
+	var doc opmlDocument
+	if err := xml.Unmarshal([]byte(opml), &doc); err != nil {
+		t.Fatalf("OPML is not valid XML: %v", err)
+	}
+	if doc.Version != "2.0" || doc.Head.Title != "Test & Feeds" {
+		t.Errorf("Unexpected head: version %q, title %q", doc.Version, doc.Head.Title)
+	}
+	if doc.Head.DateCreated != "Tue, 02 Jan 2024 03:04:05 +0000" {
+		t.Errorf("Expected an RFC 822 creation date, got %q", doc.Head.DateCreated)
+	}
+	if len(collectFeedURLs(doc.Body)) != len(collectFeedURLs(outlines)) {
+		t.Error("Expected all feeds to round-trip through the OPML document")
+	}
+	if !strings.Contains(opml, `type="rss"`) {
+		t.Error("Expected feed outlines to have type rss")
+	}
+}
+
+func TestParseBaseURL(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{"https://hn.example.com", false},
+		{"http://localhost:8080/hn/", false},
+		{"hn.example.com", true},
+		{"ftp://hn.example.com", true},
+		{"https://hn.example.com/?x=1", true},
+		{"https://", true},
+	}
+	for _, tt := range tests {
+		_, err := parseBaseURL(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBaseURL(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteGeneratedFeedsOPML(t *testing.T) {
+	outDir := t.TempDir()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(outDir, generatedFeedsOPMLFile)
+
+	// A single feed needs no subscription list
+	mainFeed := []generatedFeed{{Title: defaultFeedInfo.Title, File: feedFileName}}
+	if err := writeGeneratedFeedsOPML(outDir, "https://hn.example.com/feeds/", mainFeed, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no feed list for a single feed, got %v", err)
+	}
+
+	feeds := append(mainFeed, generatedFeed{Title: graveyardFeedInfo.Title, File: graveyardFeedFile})
+	if err := writeGeneratedFeedsOPML(outDir, "https://hn.example.com/feeds/", feeds, []string{"The Verge"}, now); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Invalid OPML: %v", err)
+	}
+	if len(doc.Body) != 2 || len(doc.Body[0].Children) != 2 || doc.Body[1].Text != "By Domain" {
+		t.Fatalf("Unexpected outline structure: %+v", doc.Body)
+	}
+	if got := doc.Body[0].Children[1].XMLURL; got != "https://hn.example.com/feeds/graveyard.xml" {
+		t.Errorf("Graveyard feed URL = %q", got)
+	}
+	if got := doc.Body[1].Children[0]; got.XMLURL != "https://hn.example.com/feeds/domain-the-verge.xml" || got.Title != "Hacker News: The Verge" {
+		t.Errorf("Unexpected domain feed outline: %+v", got)
+	}
+
+	// A later run listing the same feeds leaves the file alone
+	if err := writeGeneratedFeedsOPML(outDir, "https://hn.example.com/feeds/", feeds, []string{"The Verge"}, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(data) {
+		t.Error("Expected an unchanged feed list not to be rewritten")
+	}
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"context"
 	"database/sql"
 	"encoding/xml"
 	"fmt"
+	"html"
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gorilla/feeds"
@@ -38,15 +40,16 @@ type CustomAtomEntry struct {
 }
 
 type CustomAtomFeed struct {
-	XMLName  xml.Name           `xml:"feed"`
-	Xmlns    string             `xml:"xmlns,attr"`
-	Title    string             `xml:"title"`
-	Id       string             `xml:"id"`
-	Updated  string             `xml:"updated"`
-	Link     *feeds.AtomLink    `xml:"link,omitempty"`
-	Author   *feeds.AtomAuthor  `xml:"author,omitempty"`
-	Subtitle string             `xml:"subtitle,omitempty"`
-	Rights   string             `xml:"rights,omitempty"`
+	XMLName  xml.Name          `xml:"feed"`
+	Xmlns    string            `xml:"xmlns,attr"`
+	Title    string            `xml:"title"`
+	Id       string            `xml:"id"`
+	Updated  string            `xml:"updated"`
+	Links    []feeds.AtomLink  `xml:"link"`
+	Author   *feeds.AtomAuthor `xml:"author,omitempty"`
+	Subtitle string            `xml:"subtitle,omitempty"`
+	Rights   string            `xml:"rights,omitempty"`
+	Archive  *feedHistoryArchive
 	Entries  []*CustomAtomEntry `xml:"entry"`
 }
 
@@ -60,12 +63,15 @@ func convertToCustomAtom(feed *feeds.Feed, itemCategories map[string][]string) *
 		Title:    standardAtomFeed.Title,
 		Id:       standardAtomFeed.Id,
 		Updated:  standardAtomFeed.Updated,
-		Link:     standardAtomFeed.Link,
 		Author:   standardAtomFeed.Author,
 		Subtitle: standardAtomFeed.Subtitle,
 		Rights:   standardAtomFeed.Rights,
 	}
 
+	if standardAtomFeed.Link != nil {
+		customFeed.Links = append(customFeed.Links, *standardAtomFeed.Link)
+	}
+
 	// Convert entries with categories
 	for _, entry := range standardAtomFeed.Entries {
 		customEntry := &CustomAtomEntry{
@@ -97,120 +103,75 @@ func convertToCustomAtom(feed *feeds.Feed, itemCategories map[string][]string) *
 	return customFeed
 }
 
-// fetchOpenGraphConcurrently fetches OpenGraph data for multiple URLs using a worker pool
-func fetchOpenGraphConcurrently(db *sql.DB, fetcher *OpenGraphFetcher, urls []string, maxWorkers int) map[string]*OpenGraphData {
-	if len(urls) == 0 {
-		return make(map[string]*OpenGraphData)
-	}
-
-	// Create channels for work distribution and result collection
-	urlJobs := make(chan string, len(urls))
-	results := make(chan struct {
-		url  string
-		data *OpenGraphData
-	}, len(urls))
-
-	// Start worker goroutines
-	for i := 0; i < maxWorkers; i++ {
-		go func() {
-			for url := range urlJobs {
-				ogData := getOpenGraphWithFallback(db, fetcher, url)
-				results <- struct {
-					url  string
-					data *OpenGraphData
-				}{url: url, data: ogData}
-			}
-		}()
-	}
+// domainRegex extracts the host part of an article URL
+var domainRegex = regexp.MustCompile(`^https?://([^/]+)`)
 
-	// Send all URLs to workers
-	for _, url := range urls {
-		urlJobs <- url
+// extractDomain returns the host of an article link, or empty string if none
+func extractDomain(link string) string {
+	if matches := domainRegex.FindStringSubmatch(link); len(matches) > 1 {
+		return matches[1]
 	}
-	close(urlJobs)
-
-	// Collect results
-	resultMap := make(map[string]*OpenGraphData)
-	for i := 0; i < len(urls); i++ {
-		result := <-results
-		resultMap[result.url] = result.data
-	}
-
-	return resultMap
+	return ""
 }
 
-// getOpenGraphWithFallback fetches OpenGraph data with caching and fallback
-func getOpenGraphWithFallback(db *sql.DB, fetcher *OpenGraphFetcher, url string) *OpenGraphData {
-	// Skip OpenGraph fetching if database is nil (for testing)
-	if db == nil {
-		return nil
+// itemCategoryList returns all categories for an item: content, points and engagement based,
+// with the configured category aliases applied
+func itemCategoryList(item HackerNewsItem, minPoints int, categoryMapper *CategoryMapper) []string {
+	categories := categorizeContent(item.Title, extractDomain(item.Link), item.Link, itemStoryType(item), categoryMapper)
+	if isTextPost(item) {
+		categories = append(categories, "Text Post")
 	}
-
-	// First check cache
-	cached, err := getOpenGraphData(db, url)
-	if err != nil {
-		slog.Warn("Error getting cached OpenGraph data", "error", err, "url", url)
-	}
-
-	// Return cached data if available and successful
-	if cached != nil && cached.FetchSuccess {
-		return &OpenGraphData{
-			URL:         cached.URL,
-			Title:       cached.Title,
-			Description: cached.Description,
-			Image:       cached.Image,
-			SiteName:    cached.SiteName,
-		}
+	// Job posts can't be voted on, so a points tier would say nothing
+	if itemStoryType(item) != StoryTypeJob {
+		categories = append(categories, categorizeByPoints(item.Points, minPoints))
 	}
-
-	// Skip fetching if we have a recent failed attempt
-	if cached != nil && !cached.FetchSuccess {
-		slog.Debug("Skipping OpenGraph fetch due to recent failure", "url", url)
-		return nil
+	if isFlamewar(item.Points, item.CommentCount, categoryMapper.FlamewarRatio()) {
+		categories = append(categories, "Flamewar")
 	}
-
-	// Fetch fresh data
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	ogData, err := fetcher.FetchOpenGraph(ctx, url)
-	fetchSuccess := err == nil && ogData != nil
-
-	if err != nil {
-		slog.Debug("Failed to fetch OpenGraph data", "error", err, "url", url)
-		// Cache the failure to avoid repeated attempts
-		if ogData == nil {
-			ogData = &OpenGraphData{URL: url}
-		}
-	} else if ogData != nil {
-		cleanOpenGraphData(ogData)
-		slog.Debug("Successfully fetched OpenGraph data", "url", url, "title", ogData.Title)
+	if !item.ReturnedAt.IsZero() {
+		categories = append(categories, "Returning")
 	}
-
-	// Cache the result (success or failure)
-	if ogData != nil {
-		if err := cacheOpenGraphData(db, ogData, fetchSuccess); err != nil {
-			slog.Warn("Failed to cache OpenGraph data", "error", err, "url", url)
-		}
+	if isNewAccount(item, categoryMapper) {
+		categories = append(categories, "New Account")
 	}
-
-	if fetchSuccess {
-		return ogData
+	if !item.DeadAt.IsZero() {
+		categories = append(categories, "Flagged")
 	}
-
-	return nil
+	return categoryMapper.AliasCategories(categories)
 }
 
 // generateRSSFeed creates an Atom RSS feed from the provided items with OpenGraph data
 func generateRSSFeed(db *sql.DB, items []HackerNewsItem, minPoints int, categoryMapper *CategoryMapper) string {
-	slog.Debug("Generating RSS feed", "itemCount", len(items))
+	return generateFeed(db, items, minPoints, categoryMapper, defaultFeedInfo)
+}
+
+// feedInfo holds the feed-level metadata of a generated feed
+type feedInfo struct {
+	Title       string
+	Description string
+	ID          string
+	Format      FeedFormat
+	Links       []feeds.AtomLink // extra feed-level links, such as the RFC 5005 paging links
+	Archive     bool             // marks the document as an RFC 5005 archive document
+}
+
+// defaultFeedInfo describes the main top stories feed
+var defaultFeedInfo = feedInfo{
+	Title:       "Hacker News Top Stories",
+	Description: "High-quality Hacker News stories, updated regularly",
+	ID:          "tag:news.ycombinator.com,2024:feed",
+}
+
+// generateFeed creates an Atom feed of the items with the given feed metadata
+func generateFeed(db *sql.DB, items []HackerNewsItem, minPoints int, categoryMapper *CategoryMapper, info feedInfo) string {
+	slog.Debug("Generating RSS feed", "feed", info.ID, "itemCount", len(items))
 	now := time.Now()
 
 	feed := &feeds.Feed{
-		Title:       "Hacker News Top Stories",
-		Description: "High-quality Hacker News stories, updated regularly",
+		Title:       info.Title,
+		Description: info.Description,
 		Link:        &feeds.Link{Href: "https://news.ycombinator.com/", Rel: "self", Type: "text/html"},
-		Id:          "tag:news.ycombinator.com,2024:feed",
+		Id:          info.ID,
 		Created:     now,
 		Updated:     now,
 	}
@@ -218,166 +179,319 @@ func generateRSSFeed(db *sql.DB, items []HackerNewsItem, minPoints int, category
 	// Track categories for each item (using CommentsLink as the ID)
 	itemCategories := make(map[string][]string)
 
-	domainRegex := regexp.MustCompile(`^https?://([^/]+)`)
+	// Previews are fetched by refresh runs; rendering only reads what they cached
+	ogDataMap := cachedOpenGraphPreviews(db, items)
+	slog.Debug("Loaded OpenGraph data", "previews", len(ogDataMap))
 
-	// Initialize OpenGraph fetcher
-	ogFetcher := NewOpenGraphFetcher()
-	slog.Debug("Initialized OpenGraph fetcher")
-
-	// Collect all URLs that need OpenGraph data
-	var urlsToFetch []string
 	for _, item := range items {
-		if item.Link != "" {
-			urlsToFetch = append(urlsToFetch, item.Link)
+		var rssItem *feeds.Item
+		var categories []string
+		if !safely("feed entry", func() {
+			rssItem, categories = feedEntry(db, item, minPoints, categoryMapper, ogDataMap, info.Format)
+		}, itemLogAttrs(item)...) {
+			continue
 		}
+
+		// Store categories for this item (using the same ID as the rssItem)
+		itemCategories[item.CommentsLink] = categories
+
+		feed.Items = append(feed.Items, rssItem)
 	}
 
-	// Fetch OpenGraph data concurrently for all URLs
-	slog.Debug("Fetching OpenGraph data concurrently", "urlCount", len(urlsToFetch))
-	ogDataMap := fetchOpenGraphConcurrently(db, ogFetcher, urlsToFetch, 5) // Use 5 workers to match semaphore limit
-	slog.Debug("Completed concurrent OpenGraph fetching")
+	// Generate custom Atom feed with proper categories
+	customAtomFeed := convertToCustomAtom(feed, itemCategories)
+	customAtomFeed.Links = append(customAtomFeed.Links, info.Links...)
+	// A feed with its own self link, such as a WebSub topic, links the Hacker News front page as alternate
+	if slices.ContainsFunc(info.Links, func(link feeds.AtomLink) bool { return link.Rel == "self" }) {
+		customAtomFeed.Links[0].Rel = "alternate"
+	}
+	if info.Archive {
+		customAtomFeed.Archive = &feedHistoryArchive{}
+	}
 
-	for _, item := range items {
-		// Extract domain from the article link
-		domain := ""
-		if matches := domainRegex.FindStringSubmatch(item.Link); len(matches) > 1 {
-			domain = matches[1]
+	// Convert to XML
+	xmlData, err := xml.MarshalIndent(customAtomFeed, "", "  ")
+	if err != nil {
+		slog.Error("Failed to generate RSS feed", "error", err)
+		os.Exit(1)
+	}
+
+	// Add XML header, and the stylesheet browsers render the feed with
+	rss := xml.Header + stylesheetInstruction() + string(xmlData)
+
+	slog.Debug("RSS feed generated successfully", "feedSize", len(rss))
+	return rss
+}
+
+// feedEntry builds the feed entry for an item along with its categories
+func feedEntry(db *sql.DB, item HackerNewsItem, minPoints int, categoryMapper *CategoryMapper, ogDataMap map[string]*OpenGraphData, format FeedFormat) (*feeds.Item, []string) {
+	domain := extractDomain(articleURL(item))
+	categories := itemCategoryList(item, minPoints, categoryMapper)
+	flamewar := isFlamewar(item.Points, item.CommentCount, categoryMapper.FlamewarRatio())
+
+	// Calculate post age, with the exact time in the display time zone
+	postAge := calculatePostAge(item.CreatedAt)
+	posted := localTime(item.CreatedAt)
+
+	// Calculate engagement ratio
+	engagementRatio := float64(item.CommentCount) / float64(item.Points)
+	engagementText := ""
+	if flamewar {
+		engagementText = format.icon("⚔️") + "Flamewar"
+	} else if engagementRatio > 0.5 {
+		engagementText = format.icon("🔥") + "High engagement"
+	} else if engagementRatio > 0.3 {
+		engagementText = format.icon("💬") + "Good discussion"
+	}
+
+	// Get pre-fetched OpenGraph data for the article
+	var ogPreview string
+	if !isTextPost(item) {
+		ogData := ogDataMap[item.Link]
+		if ogData != nil && (ogData.Title != "" || ogData.Description != "") {
+			ogPreview = fmt.Sprintf(`<section aria-label="Article preview" style="margin-bottom: 16px; padding: 12px; background: #f9f9f9; border-radius: 6px; border-left: 3px solid #007acc;">
+				<h4 style="margin: 0 0 8px 0; color: #007acc; font-size: 14px;">%sArticle Preview</h4>
+				%s
+				%s
+				%s
+			</section>`,
+				format.icon("📄"),
+				func() string {
+					if ogData.Title != "" && ogData.Title != item.Title {
+						return fmt.Sprintf(`<p style="margin: 0 0 6px 0; font-weight: bold; color: #333;">%s</p>`, html.EscapeString(ogData.Title))
+					}
+					return ""
+				}(),
+				func() string {
+					if ogData.Description != "" {
+						return fmt.Sprintf(`<p style="margin: 0 0 6px 0; color: #666; line-height: 1.4; font-size: 13px;">%s</p>`, html.EscapeString(ogData.Description))
+					}
+					return ""
+				}(),
+				func() string {
+					if ogData.Image != "" {
+						return fmt.Sprintf(`<img src="%s" alt="%s" style="max-width: 100%%; height: auto; border-radius: 4px; margin-top: 8px;" loading="lazy">`,
+							html.EscapeString(ogData.Image), html.EscapeString(ogImageAlt(ogData, item.Title, domain)))
+					}
+					return ""
+				}())
+		}
+	}
+
+	// Text posts have no article, so the source and article link point to the HN post itself
+	source, articleLink, articleLabel := domain, articleURL(item), "Read article on "+strings.TrimPrefix(domain, "www.")
+	if isTextPost(item) {
+		source, articleLink, articleLabel = "news.ycombinator.com (text post)", item.CommentsLink, "Read post on Hacker News"
+	}
+
+	// Polls show their options where an article preview would go
+	pollBlock := ""
+	if poll := loadPoll(db, item); poll != nil {
+		source, articleLabel = "news.ycombinator.com (poll)", "Vote on Hacker News"
+		pollBlock = renderPoll(poll, format)
+	}
+
+	// Changes since the previous run show which discussions are still active
+	delta, hasDelta := loadStatsDelta(db, item)
+
+	// Translated title, keeping the original visible when it is replaced
+	title, originalTitle := translatedTitle(db, item.Title, categoryMapper)
+	title = renderTitle(format.titleTemplate(categoryMapper.TitleTemplate()), titleData{
+		Title:    title,
+		Points:   item.Points,
+		Comments: item.CommentCount,
+		Domain:   strings.TrimPrefix(domain, "www."),
+		Trend:    trendMarker(item.Points, delta, hasDelta),
+	})
+	originalTitleBlock := ""
+	if originalTitle != "" {
+		originalTitleBlock = fmt.Sprintf(`<p style="margin: 0 0 8px 0; color: #828282;"><em>Original title:</em> %s</p>`, html.EscapeString(originalTitle))
+	}
+
+	// Cached LLM summary of the article, if summarization is enabled
+	summaryBlock := ""
+	if db != nil && !isTextPost(item) {
+		summary, err := getSummary(db, item.Link)
+		if err != nil {
+			slog.Debug("Failed to load summary", "url", item.Link, "error", err)
 		}
+		if summary != "" {
+			summaryBlock = fmt.Sprintf(`<section aria-label="Summary" style="margin-bottom: 16px; padding: 12px; background: #f4f8f4; border-radius: 6px; border-left: 3px solid #2e7d32;">
+				<h4 style="margin: 0 0 8px 0; color: #2e7d32; font-size: 14px;">%sSummary</h4>
+				<p style="margin: 0; color: #333; line-height: 1.4; font-size: 13px;">%s</p>
+			</section>`, format.icon("📝"), html.EscapeString(summary))
+		}
+	}
 
-		// Generate categories
-		categories := categorizeContent(item.Title, domain, item.Link, categoryMapper)
-		pointCategory := categorizeByPoints(item.Points, minPoints)
-		categories = append(categories, pointCategory)
-
-		// Calculate post age
-		postAge := calculatePostAge(item.CreatedAt)
-
-		// Calculate engagement ratio
-		engagementRatio := float64(item.CommentCount) / float64(item.Points)
-		engagementText := ""
-		if engagementRatio > 0.5 {
-			engagementText = "🔥 High engagement"
-		} else if engagementRatio > 0.3 {
-			engagementText = "💬 Good discussion"
+	// Cached summary of the HN discussion, if enabled
+	discussionBlock := ""
+	if db != nil && item.CommentCount > 0 {
+		discussion, err := getDiscussionSummary(db, item.ItemID)
+		if err != nil {
+			slog.Debug("Failed to load discussion summary", "hn_id", item.ItemID, "error", err)
 		}
+		if discussion != nil {
+			discussionBlock = fmt.Sprintf(`<section aria-label="What HN thinks" style="margin-bottom: 16px; padding: 12px; background: #fdf6ec; border-radius: 6px; border-left: 3px solid #ff6600;">
+				<h4 style="margin: 0 0 8px 0; color: #ff6600; font-size: 14px;">%sWhat HN thinks</h4>
+				<p style="margin: 0; color: #333; line-height: 1.4; font-size: 13px;">%s</p>
+			</section>`, format.icon("🗣️"), strings.ReplaceAll(html.EscapeString(discussion.Summary), "\n", "<br>"))
+		}
+	}
 
-		// Get pre-fetched OpenGraph data for the article
-		var ogPreview string
-		if item.Link != "" {
-			ogData := ogDataMap[item.Link]
-			if ogData != nil && (ogData.Title != "" || ogData.Description != "") {
-				ogPreview = fmt.Sprintf(`<div style="margin-bottom: 16px; padding: 12px; background: #f9f9f9; border-radius: 6px; border-left: 3px solid #007acc;">
-					<h4 style="margin: 0 0 8px 0; color: #007acc; font-size: 14px;">📄 Article Preview</h4>
-					%s
-					%s
-					%s
-				</div>`,
-					func() string {
-						if ogData.Title != "" && ogData.Title != item.Title {
-							return fmt.Sprintf(`<p style="margin: 0 0 6px 0; font-weight: bold; color: #333;">%s</p>`, ogData.Title)
-						}
-						return ""
-					}(),
-					func() string {
-						if ogData.Description != "" {
-							return fmt.Sprintf(`<p style="margin: 0 0 6px 0; color: #666; line-height: 1.4; font-size: 13px;">%s</p>`, ogData.Description)
-						}
-						return ""
-					}(),
-					func() string {
-						if ogData.Image != "" {
-							return fmt.Sprintf(`<img src="%s" alt="Article image" style="max-width: 100%%; height: auto; border-radius: 4px; margin-top: 8px;" loading="lazy">`, ogData.Image)
-						}
-						return ""
-					}())
-			}
+	// Quoted top comment, if enabled
+	topCommentBlock := renderTopComment(loadTopComment(db, item, categoryMapper), categoryMapper.Config().TopComment.Length, format)
+
+	// Link to other discussions of the same article
+	otherDiscussions := ""
+	if len(item.Duplicates) > 0 {
+		otherDiscussions = `<section aria-label="Also discussed" style="margin-bottom: 12px;"><strong>Also discussed:</strong><ul style="margin: 4px 0; padding-left: 20px;">`
+		for _, dup := range item.Duplicates {
+			otherDiscussions += fmt.Sprintf(`<li><a href="%s">%s</a> (%d points, %d comments)</li>`, dup.CommentsLink, html.EscapeString(dup.Title), dup.Points, dup.CommentCount)
 		}
+		otherDiscussions += "</ul></section>"
+	}
 
-		// Enhanced HTML description with categories
-		categoryTags := ""
-		if len(categories) > 0 {
-			categoryTags = "<div style=\"margin-bottom: 8px; line-height: 1.8;\">"
-			for i, cat := range categories {
-				// Add space between tags for better RSS reader compatibility
-				if i > 0 {
-					categoryTags += " "
-				}
-				categoryTags += fmt.Sprintf("<span style=\"display: inline-block; background: #e5e5e5; color: #666; padding: 3px 8px; border-radius: 12px; font-size: 12px; margin-right: 6px; margin-bottom: 2px; white-space: nowrap;\">%s</span>", cat)
+	// Earlier submissions of the same article, whose threads are often better for reposted classics
+	otherDiscussions += renderPreviousDiscussions(loadPreviousDiscussions(db, item, categoryMapper))
+
+	// Other recent submissions by the same author, if enabled
+	otherDiscussions += renderRelatedSubmissions(item.Author, loadRelatedSubmissions(db, item, categoryMapper))
+
+	// Category tags as a list, so screen readers announce how many there are
+	categoryTags := ""
+	if len(categories) > 0 {
+		categoryTags = `<ul aria-label="Categories" style="list-style: none; margin: 0 0 8px 0; padding: 0; line-height: 1.8;">`
+		for i, cat := range categories {
+			// Add space between tags for better RSS reader compatibility
+			if i > 0 {
+				categoryTags += " "
 			}
-			categoryTags += "</div>"
+			categoryTags += fmt.Sprintf("<li style=\"display: inline-block; background: #e5e5e5; color: #666; padding: 3px 8px; border-radius: 12px; font-size: 12px; margin-right: 6px; margin-bottom: 2px; white-space: nowrap;\">%s</li>", html.EscapeString(cat))
 		}
+		categoryTags += "</ul>"
+	}
 
-		description := fmt.Sprintf(`<div style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.5;">
-			<div style="margin-bottom: 12px; padding: 8px; background-color: #f6f6ef; border-left: 4px solid #ff6600;">
-				<strong style="color: #ff6600;">%d points</strong> • 
-				<strong style="color: #666;">%d comments</strong> • 
-				<span style="color: #828282;">%s</span>
-				%s
-			</div>
-			
-			%s
-			
-			%s
-			
-			<div style="margin-bottom: 8px;">
-				<strong>Source:</strong> <code style="background: #f4f4f4; padding: 2px 4px; border-radius: 3px;">%s</code>
-			</div>
-			
-			<div style="margin-bottom: 12px;">
-				<strong>Author:</strong> <span style="color: #666;">%s</span>
-			</div>
-			
-			<div style="margin-top: 16px; padding-top: 12px; border-top: 1px solid #e5e5e5;">
-				<a href="%s" style="display: inline-block; padding: 6px 12px; background-color: #ff6600; color: white; text-decoration: none; border-radius: 4px; margin-right: 8px;">💬 HN Discussion</a>
-				<a href="%s" style="display: inline-block; padding: 6px 12px; background-color: #666; color: white; text-decoration: none; border-radius: 4px;">📖 Read Article</a>
-			</div>
-		</div>`,
-			item.Points,
-			item.CommentCount,
-			postAge,
-			func() string {
-				if engagementText != "" {
-					return " • " + engagementText
-				}
-				return ""
-			}(),
-			categoryTags,
-			ogPreview,
-			domain,
-			item.Author,
-			item.CommentsLink,
-			item.Link)
-
-		rssItem := &feeds.Item{
-			Title: item.Title,
-			Link:  &feeds.Link{Href: item.CommentsLink, Rel: "alternate", Type: "text/html"},
-			Id:    item.CommentsLink,
-			Author: &feeds.Author{
-				Name: item.Author,
-			},
-			Description: description,
-			Created:     item.CreatedAt,
+	// Points, comments and age with the engagement label
+	statsLine := ""
+	if !format.NoEngagement {
+		if engagementText != "" {
+			engagementText = " • " + engagementText
 		}
+		// The points change gets its own line below, so only the comment change is shown inline
+		sparkline, commentsDelta := "", ""
+		if hasDelta {
+			commentsDelta = formatDelta(delta.Comments)
+		}
+		if trend := loadSparkline(db, item); trend != "" {
+			sparkline = " " + trend
+		}
+		statsLine = fmt.Sprintf(`<p style="margin: 0 0 12px 0; padding: 8px; background-color: #f6f6ef; border-left: 4px solid #ff6600;">
+			<strong style="color: #ff6600;">%d points%s</strong> • 
+			<strong style="color: #666;">%d comments%s</strong> • 
+			<time datetime="%s" title="Posted %s" style="color: #828282;">%s</time>
+			%s
+		</p>`, item.Points, sparkline, item.CommentCount, commentsDelta,
+			posted.Format(time.RFC3339), posted.Format("2006-01-02 15:04 MST"), postAge, engagementText)
+		statsLine += renderDeltaLine(delta, hasDelta)
+	}
 
-		// Store categories for this item (using the same ID as the rssItem)
-		itemCategories[item.CommentsLink] = categories
+	description := fmt.Sprintf(`<article style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.5;">
+		%s
+		
+		%s
+		
+		%s
+		
+		%s
+		
+		%s
+		
+		%s
+		
+		%s
+		
+		<p style="margin: 0 0 8px 0;">
+			<strong>Source:</strong> <code style="background: #f4f4f4; padding: 2px 4px; border-radius: 3px;">%s</code>%s
+		</p>
+		
+		<p style="margin: 0 0 12px 0;">
+			<strong>Author:</strong> <span style="color: #666;">%s</span>%s
+		</p>
+		
+		%s
+	</article>`,
+		statsLine,
+		renderDeadNote(item, format)+originalTitleBlock,
+		categoryTags,
+		summaryBlock,
+		ogPreview+pollBlock,
+		discussionBlock+topCommentBlock,
+		otherDiscussions,
+		source,
+		submittedURLNote(item),
+		html.EscapeString(item.Author),
+		authorDetails(item),
+		format.entryLinks(item.CommentsLink, discussionLabel(item.CommentCount), articleLink, articleLabel))
+
+	if format.NoEmoji {
+		title = strings.TrimSpace(stripEmoji(title))
+		description = stripEmoji(description)
+	}
 
-		feed.Items = append(feed.Items, rssItem)
+	rssItem := &feeds.Item{
+		Title: title,
+		Link:  &feeds.Link{Href: item.CommentsLink, Rel: "alternate", Type: "text/html"},
+		Id:    item.CommentsLink,
+		Author: &feeds.Author{
+			Name: item.Author,
+		},
+		Description: description,
+		Created:     item.CreatedAt,
+		Updated:     item.ReturnedAt, // returning stories resurface in readers that sort by update time
 	}
 
-	// Generate custom Atom feed with proper categories
-	customAtomFeed := convertToCustomAtom(feed, itemCategories)
+	return rssItem, categories
+}
 
-	// Convert to XML
-	xmlData, err := xml.MarshalIndent(customAtomFeed, "", "  ")
-	if err != nil {
-		slog.Error("Failed to generate RSS feed", "error", err)
-		os.Exit(1)
+// submittedURLNote links the URL an item was submitted with when it differs from the canonical article URL
+func submittedURLNote(item HackerNewsItem) string {
+	if item.CanonicalURL == "" || normalizeSubmissionURL(item.CanonicalURL) == normalizeSubmissionURL(item.Link) {
+		return ""
+	}
+	return fmt.Sprintf(`<br><small style="color: #828282;">Submitted as <a href="%s" style="color: #828282;">%s</a></small>`,
+		html.EscapeString(item.Link), html.EscapeString(item.Link))
+}
+
+// discussionLabel returns the text of the link to the HN discussion
+func discussionLabel(commentCount int) string {
+	switch commentCount {
+	case 0:
+		return "Discuss on Hacker News"
+	case 1:
+		return "HN discussion (1 comment)"
 	}
+	return fmt.Sprintf("HN discussion (%d comments)", commentCount)
+}
 
-	// Add XML header
-	rss := xml.Header + string(xmlData)
+// ogImageAlt describes an OpenGraph image for screen readers using the page title and site name,
+// since the image itself is rarely described by the page
+func ogImageAlt(ogData *OpenGraphData, itemTitle, domain string) string {
+	title := ogData.Title
+	if title == "" {
+		title = itemTitle
+	}
+	site := ogData.SiteName
+	if site == "" {
+		site = strings.TrimPrefix(domain, "www.")
+	}
 
-	slog.Debug("RSS feed generated successfully", "feedSize", len(rss))
-	return rss
+	switch {
+	case title != "" && site != "":
+		return fmt.Sprintf("Preview image for “%s” from %s", title, site)
+	case title != "":
+		return fmt.Sprintf("Preview image for “%s”", title)
+	case site != "":
+		return "Preview image from " + site
+	}
+	return "Article preview image"
 }
@@ -65,6 +65,75 @@ func TestGenerateRSSFeed_SingleItem(t *testing.T) {
 	}
 }
 
+func TestGenerateRSSFeed_TextPost(t *testing.T) {
+	items := []HackerNewsItem{
+		{
+			ItemID:       "777",
+			Title:        "Ask HN: What are you working on?",
+			CommentsLink: "https://news.ycombinator.com/item?id=777",
+			Points:       120,
+			CommentCount: 300,
+			Author:       "asker",
+			CreatedAt:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	rss := generateRSSFeed(nil, items, 50, nil)
+
+	if !strings.Contains(rss, `term="Text Post"`) {
+		t.Error("Text posts should get a Text Post category")
+	}
+	if !strings.Contains(rss, "news.ycombinator.com (text post)") {
+		t.Error("Text posts should not have a blank source")
+	}
+	if strings.Contains(rss, "Read article") || !strings.Contains(rss, "Read post on Hacker News") {
+		t.Error("Text posts should link to the post instead of an article")
+	}
+	if strings.Contains(rss, `href=&#34;&#34;`) || strings.Contains(rss, `href=""`) {
+		t.Error("Text posts should not contain empty links")
+	}
+}
+
+func TestFeedEntry_Accessibility(t *testing.T) {
+	item := HackerNewsItem{
+		ItemID:       "42",
+		Title:        "Rust & Go",
+		Link:         "https://www.example.com/post",
+		CommentsLink: "https://news.ycombinator.com/item?id=42",
+		Points:       200,
+		CommentCount: 1,
+		Author:       "writer",
+		CreatedAt:    time.Now().Add(-2 * time.Hour),
+	}
+	ogDataMap := map[string]*OpenGraphData{
+		item.Link: {URL: item.Link, Title: "Rust <and> Go", Description: "A comparison", Image: "https://example.com/og.png", SiteName: "Example Blog"},
+	}
+
+	entry, _ := feedEntry(nil, item, 50, nil, ogDataMap, FeedFormat{})
+	for _, want := range []string{
+		`alt="Preview image for “Rust &lt;and&gt; Go” from Example Blog"`,
+		`<article `,
+		`<section aria-label="Article preview"`,
+		`<ul aria-label="Categories"`,
+		`<span aria-hidden="true">💬</span> HN discussion (1 comment)`,
+		`Read article on example.com`,
+	} {
+		if !strings.Contains(entry.Description, want) {
+			t.Errorf("Expected entry to contain %q", want)
+		}
+	}
+	if strings.Contains(entry.Description, "Article image") || strings.Contains(entry.Description, "<div") {
+		t.Error("Expected no generic alt text or styled divs")
+	}
+
+	// Without a site name or OG title the alt text falls back to the story and domain
+	ogDataMap[item.Link] = &OpenGraphData{URL: item.Link, Description: "A comparison", Image: "https://example.com/og.png"}
+	entry, _ = feedEntry(nil, item, 50, nil, ogDataMap, FeedFormat{})
+	if !strings.Contains(entry.Description, `alt="Preview image for “Rust &amp; Go” from example.com"`) {
+		t.Error("Expected alt text built from the item title and domain")
+	}
+}
+
 func TestGenerateRSSFeed_MultipleItems(t *testing.T) {
 	items := []HackerNewsItem{
 		{
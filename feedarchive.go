@@ -0,0 +1,123 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/feeds"
+)
+
+// feedArchiveLimit is the most stories kept in one monthly archive document, highest points first
+const feedArchiveLimit = 500
+
+// feedHistoryArchive marks a feed document as an RFC 5005 archive document, which never changes
+type feedHistoryArchive struct {
+	XMLName xml.Name `xml:"http://purl.org/syndication/history/1.0 archive"`
+}
+
+// feedArchive is a completed month of stories published as an archive document
+type feedArchive struct {
+	Month time.Time // start of the month in the display time zone
+	File  string
+}
+
+// feedArchiveFile returns the file name of the archive document of a month, like hackernews-archive-2024-05.xml
+func feedArchiveFile(name string, month time.Time) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-archive-%s%s", strings.TrimSuffix(name, ext), month.Format("2006-01"), ext)
+}
+
+// monthStart returns the start of the month of t in the display time zone
+func monthStart(t time.Time) time.Time {
+	t = localTime(t)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, displayLocation)
+}
+
+// listFeedArchives returns the completed months, oldest first, that have stories above minPoints. The
+// current month stays in the live feed until it is over, so archive documents never change once written.
+func listFeedArchives(db *sql.DB, name string, minPoints int, now time.Time) ([]feedArchive, error) {
+	oldest, err := getOldestItemTime(db, minPoints)
+	if err != nil || oldest.IsZero() {
+		return nil, err
+	}
+
+	var archives []feedArchive
+	current := monthStart(now)
+	for month := monthStart(oldest); month.Before(current); month = month.AddDate(0, 1, 0) {
+		count, err := countItemsBetween(db, month, month.AddDate(0, 1, 0), minPoints)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			archives = append(archives, feedArchive{Month: month, File: feedArchiveFile(name, month)})
+		}
+	}
+	return archives, nil
+}
+
+// feedArchiveInfo describes the archive document of a month, linking to the live feed and the previous archive
+func feedArchiveInfo(name string, archive feedArchive, prev *feedArchive) feedInfo {
+	info := feedInfo{
+		Title:       fmt.Sprintf("%s: %s", defaultFeedInfo.Title, archive.Month.Format("January 2006")),
+		Description: fmt.Sprintf("Hacker News top stories submitted in %s", archive.Month.Format("January 2006")),
+		ID:          fmt.Sprintf("%s:archive-%s", defaultFeedInfo.ID, archive.Month.Format("2006-01")),
+		Archive:     true,
+		Links:       []feeds.AtomLink{{Href: name, Rel: "current", Type: "application/atom+xml"}},
+	}
+	if prev != nil {
+		info.Links = append(info.Links, prevArchiveLink(*prev))
+	}
+	return info
+}
+
+// prevArchiveLink returns the rel="prev-archive" link to an archive document
+func prevArchiveLink(archive feedArchive) feeds.AtomLink {
+	return feeds.AtomLink{Href: archive.File, Rel: "prev-archive", Type: "application/atom+xml"}
+}
+
+// writeFeedArchives writes the archive documents of the months that don't have one in outDir yet and
+// returns how many were written. Existing documents are left alone, since archives are immutable.
+func writeFeedArchives(db *sql.DB, outDir, name string, archives []feedArchive, minPoints int, categoryMapper *CategoryMapper) (int, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	written := 0
+	for i, archive := range archives {
+		path := filepath.Join(outDir, archive.File)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return written, fmt.Errorf("failed to check archive %s: %w", archive.File, err)
+		}
+
+		items, err := getTopItemsBetween(db, archive.Month, archive.Month.AddDate(0, 1, 0), minPoints, feedArchiveLimit)
+		if err != nil {
+			return written, err
+		}
+		// Archives list the month's stories newest first, like the live feed
+		slices.SortStableFunc(items, func(a, b HackerNewsItem) int { return cmp.Compare(b.CreatedAt.Unix(), a.CreatedAt.Unix()) })
+
+		var prev *feedArchive
+		if i > 0 {
+			prev = &archives[i-1]
+		}
+		document := generateFeed(db, items, minPoints, categoryMapper, feedArchiveInfo(name, archive, prev))
+		if _, err := writeFeedFile(path, document); err != nil {
+			return written, fmt.Errorf("failed to write archive %s: %w", archive.File, err)
+		}
+		slog.Info("Feed archive saved", "month", archive.Month.Format("2006-01"), "count", len(items), "filename", path)
+		written++
+	}
+	return written, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedArchiveFile(t *testing.T) {
+	month := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	if got := feedArchiveFile("hackernews.xml", month); got != "hackernews-archive-2024-05.xml" {
+		t.Errorf("Unexpected archive file name %s", got)
+	}
+}
+
+func TestFeedArchives(t *testing.T) {
+	useDisplayLocation(t, "UTC")
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	march := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "1", Title: "March Story", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 200, CreatedAt: march, UpdatedAt: march},
+		{ItemID: "2", Title: "Quiet March Story", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 10, CreatedAt: march, UpdatedAt: march},
+		{ItemID: "3", Title: "May Story", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 300, CreatedAt: march.AddDate(0, 2, 0), UpdatedAt: march},
+		{ItemID: "4", Title: "June Story", CommentsLink: "https://news.ycombinator.com/item?id=4", Points: 400, CreatedAt: march.AddDate(0, 3, 0), UpdatedAt: march},
+	})
+
+	// April has no stories and June is still in progress
+	now := time.Date(2024, time.June, 20, 0, 0, 0, 0, time.UTC)
+	archives, err := listFeedArchives(db, "hackernews.xml", 50, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].File != "hackernews-archive-2024-03.xml" || archives[1].File != "hackernews-archive-2024-05.xml" {
+		t.Fatalf("Unexpected archives: %+v", archives)
+	}
+
+	outDir := t.TempDir()
+	written, err := writeFeedArchives(db, outDir, "hackernews.xml", archives, 50, nil)
+	if err != nil || written != 2 {
+		t.Fatalf("Expected 2 archives written, got %d (%v)", written, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "hackernews-archive-2024-03.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	march2024 := string(data)
+	if !strings.Contains(march2024, "March Story") || strings.Contains(march2024, "Quiet March Story") || strings.Contains(march2024, "May Story") {
+		t.Error("Expected only the month's stories above the threshold in its archive")
+	}
+	if !strings.Contains(march2024, `<archive xmlns="http://purl.org/syndication/history/1.0"></archive>`) {
+		t.Error("Expected the archive document to be marked as an archive")
+	}
+	if !strings.Contains(march2024, `rel="current"`) || strings.Contains(march2024, `rel="prev-archive"`) {
+		t.Error("Expected the oldest archive to link to the live feed and no earlier archive")
+	}
+
+	data, err = os.ReadFile(filepath.Join(outDir, "hackernews-archive-2024-05.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `href="hackernews-archive-2024-03.xml" rel="prev-archive"`) {
+		t.Error("Expected the May archive to link to the March archive")
+	}
+
+	// Written archives are immutable
+	path := filepath.Join(outDir, "hackernews-archive-2024-03.xml")
+	if err := os.WriteFile(path, []byte("kept"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := writeFeedArchives(db, outDir, "hackernews.xml", archives, 50, nil); err != nil || written != 0 {
+		t.Errorf("Expected existing archives to be kept, wrote %d (%v)", written, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "kept" {
+		t.Error("Expected the existing archive to stay untouched")
+	}
+}
+
+func TestGenerateFeed_PrevArchiveLink(t *testing.T) {
+	info := defaultFeedInfo
+	info.Links = append(info.Links, prevArchiveLink(feedArchive{File: "hackernews-archive-2024-05.xml"}))
+	feed := generateFeed(nil, nil, 50, nil, info)
+	if !strings.Contains(feed, `href="hackernews-archive-2024-05.xml" rel="prev-archive"`) {
+		t.Errorf("Expected a prev-archive link in the live feed:\n%s", feed)
+	}
+	if strings.Contains(feed, "syndication/history") {
+		t.Error("The live feed must not be marked as an archive")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedFeed is a rendered feed variant kept in memory
+type cachedFeed struct {
+	body      string
+	etag      string
+	version   string // data version the feed was rendered from
+	expiresAt time.Time
+}
+
+// feedCache caches rendered feed variants keyed by their filter parameters.
+// Entries are invalidated when they expire or when the server's data version changes.
+type feedCache struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]cachedFeed
+}
+
+// newFeedCache creates a feed cache with the given maximum entry lifetime
+func newFeedCache(ttl time.Duration) *feedCache {
+	return &feedCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedFeed),
+	}
+}
+
+// get returns a cached feed if it exists, hasn't expired and matches the current data version
+func (c *feedCache) get(key, version string) (cachedFeed, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists {
+		return cachedFeed{}, false
+	}
+	if time.Now().After(entry.expiresAt) || entry.version != version {
+		delete(c.entries, key)
+		return cachedFeed{}, false
+	}
+
+	slog.Debug("Serving cached feed", "key", key)
+	return entry, true
+}
+
+// put stores a rendered feed and evicts stale entries, returning the stored entry. The ETag
+// combines the data version with a hash of the query key, so it changes exactly when the data does.
+// It is weak because the same feed is sent as identity, gzip and brotli, which differ byte for byte.
+func (c *feedCache) put(key, version, body string) cachedFeed {
+	sum := sha256.Sum256([]byte(key))
+	entry := cachedFeed{
+		body:      body,
+		etag:      `W/"` + version + "-" + hex.EncodeToString(sum[:8]) + `"`,
+		version:   version,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	if c.ttl <= 0 {
+		return entry
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) || e.version != version {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry
+	return entry
+}
+
+// invalidate drops all cached feeds
+func (c *feedCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]cachedFeed)
+	slog.Debug("Feed cache invalidated")
+}
+
+// cacheKey returns a canonical key for the query so equivalent requests share a cache entry
+func (q feedQuery) cacheKey() string {
+	types := make([]string, len(q.types))
+	for i, t := range q.types {
+		types[i] = string(t)
+	}
+	return fmt.Sprintf("watchlist=%t|dead=%t|points=%d|limit=%d|minAge=%s|maxAge=%s|include=%s|exclude=%s|keywords=%s|types=%s|format=%s",
+		q.watchlist, q.dead, q.filter.MinPoints, q.filter.Limit, q.filter.MinAge, q.filter.MaxAge,
+		canonicalList(q.include), canonicalList(q.exclude), canonicalList(q.keywords), canonicalList(types), q.format)
+}
+
+// canonicalList lowercases, sorts and joins list values
+func canonicalList(values []string) string {
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = strings.ToLower(v)
+	}
+	slices.Sort(normalized)
+	return strings.Join(slices.Compact(normalized), ",")
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the weak comparison
+// RFC 9110 specifies for it: W/ prefixes are ignored and any listed tag or * matches.
+func etagMatches(ifNoneMatch, etag string) bool {
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
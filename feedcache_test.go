@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedCache_VersionInvalidation(t *testing.T) {
+	cache := newFeedCache(time.Minute)
+	cache.put("key", "v1", "body")
+
+	if _, ok := cache.get("key", "v1"); !ok {
+		t.Error("Expected cache hit for matching version")
+	}
+	if _, ok := cache.get("key", "v2"); ok {
+		t.Error("Expected cache miss after data version change")
+	}
+	if _, ok := cache.get("key", "v1"); ok {
+		t.Error("Stale entry should have been evicted")
+	}
+}
+
+func TestFeedCache_ExpiryAndInvalidate(t *testing.T) {
+	cache := newFeedCache(10 * time.Millisecond)
+	cache.put("key", "v1", "body")
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.get("key", "v1"); ok {
+		t.Error("Expected expired entry to be a miss")
+	}
+
+	cache = newFeedCache(time.Minute)
+	cache.put("key", "v1", "body")
+	cache.invalidate()
+	if _, ok := cache.get("key", "v1"); ok {
+		t.Error("Expected miss after invalidate")
+	}
+}
+
+func TestFeedQuery_CacheKey(t *testing.T) {
+	a := feedQuery{filter: ItemFilter{Limit: 30, MinPoints: 50}, include: []string{"GitHub", "Twitter"}}
+	b := feedQuery{filter: ItemFilter{Limit: 30, MinPoints: 50}, include: []string{"twitter", "github"}}
+	c := feedQuery{filter: ItemFilter{Limit: 30, MinPoints: 100}, include: []string{"GitHub", "Twitter"}}
+
+	if a.cacheKey() != b.cacheKey() {
+		t.Error("Equivalent queries should share a cache key")
+	}
+	if a.cacheKey() == c.cacheKey() {
+		t.Error("Different thresholds should not share a cache key")
+	}
+}
+
+func TestHandleFeed_InvalidatedOnDataChange(t *testing.T) {
+	server := setupTestServer(t)
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	server.routes().ServeHTTP(httptest.NewRecorder(), req)
+
+	_, err := server.db.Exec("UPDATE items SET title = 'Renamed', updated_at = ? WHERE item_hn_id = '1'", time.Now().UTC().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to update item: %v", err)
+	}
+	server.dataChanged()
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "Renamed") {
+		t.Error("Expected cache to be invalidated after data change")
+	}
+}
+
+func TestHandleFeed_ETag(t *testing.T) {
+	server := setupTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for matching ETag, got %d", rec.Code)
+	}
+
+	// The compressed response carries the same weak ETag, and validates against it
+	req = httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("ETag") != etag {
+		t.Errorf("Expected gzip with ETag %s, got %q with %s", etag, rec.Header().Get("Content-Encoding"), rec.Header().Get("ETag"))
+	}
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("Expected a weak ETag shared by all encodings, got %s", etag)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"3-abcdef"`
+	testCases := []struct {
+		header   string
+		expected bool
+	}{
+		{"", false},
+		{`W/"3-abcdef"`, true},
+		{`"3-abcdef"`, true},
+		{`"2-abcdef", W/"3-abcdef"`, true},
+		{"*", true},
+		{`W/"2-abcdef"`, false},
+	}
+
+	for _, tc := range testCases {
+		if result := etagMatches(tc.header, etag); result != tc.expected {
+			t.Errorf("etagMatches(%q) = %v, expected %v", tc.header, result, tc.expected)
+		}
+	}
+}
+
+func TestHandleFeed_CacheHitsSkipDatabase(t *testing.T) {
+	server := setupTestServer(t)
+	etag := func() string {
+		rec := httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		return rec.Header().Get("ETag")
+	}
+
+	// A data change gets a new ETag, so clients don't keep a stale feed
+	first := etag()
+	server.dataChanged()
+	second := etag()
+	if second == first {
+		t.Errorf("Expected a new ETag after a data change, got %s again", second)
+	}
+
+	// Cached feeds are validated against the in-process data version, not the database
+	_ = server.db.Close()
+	if cached := etag(); cached != second {
+		t.Errorf("Expected the cached feed with ETag %s, got %s", second, cached)
+	}
+}
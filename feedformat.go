@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"text/template"
+)
+
+// FeedFormat holds rendering toggles for readers where emoji and styled HTML render poorly,
+// such as terminal readers and email, and the entry title template of a profile
+type FeedFormat struct {
+	NoEmoji       bool   `json:"no_emoji,omitempty"`       // strip emoji from titles and content
+	NoEngagement  bool   `json:"no_engagement,omitempty"`  // drop the points, comments and engagement line
+	PlainLinks    bool   `json:"plain_links,omitempty"`    // plain links instead of styled buttons
+	TitleTemplate string `json:"title_template,omitempty"` // overrides the configured title_template
+}
+
+// String returns the enabled toggles as a comma-separated list, or "default"
+func (f FeedFormat) String() string {
+	var toggles []string
+	if f.NoEmoji {
+		toggles = append(toggles, "no-emoji")
+	}
+	if f.NoEngagement {
+		toggles = append(toggles, "no-engagement")
+	}
+	if f.PlainLinks {
+		toggles = append(toggles, "plain-links")
+	}
+	if f.TitleTemplate != "" {
+		toggles = append(toggles, fmt.Sprintf("title=%q", f.TitleTemplate))
+	}
+	if len(toggles) == 0 {
+		return "default"
+	}
+	return strings.Join(toggles, ",")
+}
+
+// titleTemplate returns the profile's title template, or fallback when it has none or it doesn't parse
+func (f FeedFormat) titleTemplate(fallback *template.Template) *template.Template {
+	if strings.TrimSpace(f.TitleTemplate) == "" {
+		return fallback
+	}
+	tmpl, err := parseTitleTemplate(f.TitleTemplate)
+	if err != nil {
+		slog.Warn("Invalid profile title template, using the default", "template", f.TitleTemplate, "error", err)
+		return fallback
+	}
+	return tmpl
+}
+
+// icon returns a decorative emoji followed by a space, hidden from screen readers, or nothing without emoji
+func (f FeedFormat) icon(emoji string) string {
+	if f.NoEmoji {
+		return ""
+	}
+	return `<span aria-hidden="true">` + emoji + `</span> `
+}
+
+// entryLinks renders the discussion and article links at the bottom of an entry
+func (f FeedFormat) entryLinks(commentsLink, discussionLabel, articleLink, articleLabel string) string {
+	if f.PlainLinks {
+		return fmt.Sprintf(`<p><a href="%s">%s</a> | <a href="%s">%s</a></p>`,
+			commentsLink, discussionLabel, articleLink, html.EscapeString(articleLabel))
+	}
+	return fmt.Sprintf(`<p style="margin: 16px 0 0 0; padding-top: 12px; border-top: 1px solid #e5e5e5;">
+			<a href="%s" style="display: inline-block; padding: 6px 12px; background-color: #ff6600; color: white; text-decoration: none; border-radius: 4px; margin-right: 8px;">%s%s</a>
+			<a href="%s" style="display: inline-block; padding: 6px 12px; background-color: #666; color: white; text-decoration: none; border-radius: 4px;">%s%s</a>
+		</p>`, commentsLink, f.icon("💬"), discussionLabel, articleLink, f.icon("📖"), html.EscapeString(articleLabel))
+}
+
+// isEmojiRune reports whether r is an emoji, pictograph or a modifier used to compose them
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, flags, skin tones
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // arrows and stars such as ⭐
+		return true
+	case r >= 0x231A && r <= 0x23FF: // watches, hourglasses and media controls
+		return true
+	case r == 0x200D, r == 0x20E3, r == 0xFE0E, r == 0xFE0F: // joiner, keycap and variation selectors
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // tag sequences of subdivision flags
+		return true
+	}
+	return false
+}
+
+// stripEmoji removes emoji along with the space that separated them from the following text
+func stripEmoji(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	skipSpace := false
+	for _, r := range s {
+		if isEmojiRune(r) {
+			skipSpace = true
+			continue
+		}
+		if skipSpace && r == ' ' {
+			skipSpace = false
+			continue
+		}
+		skipSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
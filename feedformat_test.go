@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStripEmoji(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"📖 Read Article", "Read Article"},
+		{"Launch 🚀 day", "Launch day"},
+		{"⚔️ Flamewar", "Flamewar"},
+		{"Flags 🇫🇮🇸🇪 and families 👨‍👩‍👧 too", "Flags and families too"},
+		{"Ünïcode – “quotes” ✓ stay", "Ünïcode – “quotes” stay"},
+		{"No emoji here", "No emoji here"},
+	}
+	for _, tc := range testCases {
+		if got := stripEmoji(tc.input); got != tc.expected {
+			t.Errorf("stripEmoji(%q) = %q, expected %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestFeedFormat_Toggles(t *testing.T) {
+	items := []HackerNewsItem{{
+		ItemID:       "1",
+		Title:        "Shipping it 🚀",
+		Link:         "https://example.com/a",
+		CommentsLink: "https://news.ycombinator.com/item?id=1",
+		Points:       100,
+		CommentCount: 90,
+		Author:       "someone",
+		CreatedAt:    time.Now().Add(-time.Hour),
+	}}
+
+	render := func(format FeedFormat) string {
+		info := defaultFeedInfo
+		info.Format = format
+		return generateFeed(nil, items, 50, nil, info)
+	}
+
+	plain := render(FeedFormat{})
+	for _, want := range []string{"🚀", "🔥", "High engagement", "100 points", "💬", "HN discussion (90 comments)", "Read article on example.com", "border-radius: 4px"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("Expected the default format to contain %q", want)
+		}
+	}
+
+	stripped := render(FeedFormat{NoEmoji: true})
+	for _, emoji := range []string{"🚀", "🔥", "💬", "📖"} {
+		if strings.Contains(stripped, emoji) {
+			t.Errorf("Expected %s to be stripped", emoji)
+		}
+	}
+	if !strings.Contains(stripped, "<title>Shipping it</title>") || !strings.Contains(stripped, "High engagement") {
+		t.Error("Expected the text around stripped emoji to be kept")
+	}
+
+	compact := render(FeedFormat{NoEngagement: true, PlainLinks: true})
+	if strings.Contains(compact, "100 points") || strings.Contains(compact, "High engagement") {
+		t.Error("Expected the engagement line to be dropped")
+	}
+	if strings.Contains(compact, "border-radius: 4px") || !strings.Contains(compact, "HN discussion (90 comments)&lt;/a&gt; | &lt;a href=") {
+		t.Error("Expected plain links instead of buttons")
+	}
+	if strings.Contains(compact, "💬") || strings.Contains(compact, "📖") {
+		t.Error("Plain links should not carry emoji")
+	}
+}
+
+func TestHandleProfileFeed_Format(t *testing.T) {
+	server := setupTestServer(t)
+
+	profile := &FeedProfile{Name: "terminal", Format: FeedFormat{NoEmoji: true, PlainLinks: true}}
+	if err := createFeedProfile(server.db, profile); err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+	stored, err := getFeedProfile(server.db, profile.Token)
+	if err != nil || stored == nil || stored.Format != profile.Format {
+		t.Fatalf("Expected the format to round-trip, got %+v (%v)", stored, err)
+	}
+
+	// Render the default feed first so a shared cache entry would be served
+	server.routes().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed/"+profile.Token+".xml", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || strings.Contains(body, "💬") || !strings.Contains(body, "comments)&lt;/a&gt; | ") {
+		t.Errorf("Expected an emoji-free feed with plain links, got %d", rec.Code)
+	}
+}
+
+func TestHandleProfileFeed_TitleTemplate(t *testing.T) {
+	server := setupTestServer(t)
+
+	profile := &FeedProfile{Name: "titles", Format: FeedFormat{TitleTemplate: "[{{.Points}}p] {{.Title}} ({{.Domain}})"}}
+	if err := createFeedProfile(server.db, profile); err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+	broken := &FeedProfile{Name: "broken", Format: FeedFormat{TitleTemplate: "{{.Karma}}"}}
+	if err := createFeedProfile(server.db, broken); err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.String()
+	}
+
+	if body := get("/feed.xml"); !strings.Contains(body, "<title>GitHub Project</title>") {
+		t.Error("Expected plain titles in the default feed")
+	}
+	if body := get("/feed/" + profile.Token + ".xml"); !strings.Contains(body, "<title>[250p] GitHub Project (github.com)</title>") {
+		t.Error("Expected templated titles in the profile feed")
+	}
+	if body := get("/feed/" + broken.Token + ".xml"); !strings.Contains(body, "<title>GitHub Project</title>") {
+		t.Error("Expected an invalid profile template to fall back to plain titles")
+	}
+}
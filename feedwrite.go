@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+)
+
+// gzipFeeds makes every written feed file get a gzip-compressed copy next to it, named like
+// hackernews.xml.gz, for static hosts that serve precompressed files
+var gzipFeeds bool
+
+// feedUpdatedPattern matches an Atom updated element or an OPML dateCreated element. The feed's own
+// updated comes before any entry's, so the first match is the timestamp that changes on every render.
+var feedUpdatedPattern = regexp.MustCompile(`<updated>[^<]*</updated>|<dateCreated>[^<]*</dateCreated>`)
+
+// withoutFeedUpdated returns an Atom or OPML document with its document-level timestamp removed
+func withoutFeedUpdated(document string) string {
+	loc := feedUpdatedPattern.FindStringIndex(document)
+	if loc == nil {
+		return document
+	}
+	return document[:loc[0]] + document[loc[1]:]
+}
+
+// writeFeedFile writes an Atom or OPML document to filename and reports whether it was written. When the
+// existing file holds the same feed apart from its updated timestamp the file is left alone, so it
+// keeps its previous updated value and modification time and readers and sync jobs see no change.
+// With gzipFeeds a compressed copy is written alongside, or added if an unchanged file lacks one.
+func writeFeedFile(filename, document string) (bool, error) {
+	if existing, err := os.ReadFile(filename); err == nil && withoutFeedUpdated(string(existing)) == withoutFeedUpdated(document) {
+		slog.Debug("Feed unchanged, skipping write", "filename", filename)
+		if _, err := os.Stat(filename + ".gz"); gzipFeeds && errors.Is(err, fs.ErrNotExist) {
+			return false, writeGzipFile(filename+".gz", existing)
+		}
+		return false, nil
+	}
+	if err := os.WriteFile(filename, []byte(document), 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	if gzipFeeds {
+		if err := writeGzipFile(filename+".gz", []byte(document)); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// writeGzipFile writes data gzip-compressed to filename. The gzip header carries no name or
+// timestamp, so the same data always compresses to the same bytes.
+func writeGzipFile(filename string, data []byte) error {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", filename, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", filename, err)
+	}
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithoutFeedUpdated(t *testing.T) {
+	document := "<feed><id>x</id><updated>2024-01-01T00:00:00Z</updated><entry><updated>2023-12-31T00:00:00Z</updated></entry></feed>"
+	got := withoutFeedUpdated(document)
+	want := "<feed><id>x</id><entry><updated>2023-12-31T00:00:00Z</updated></entry></feed>"
+	if got != want {
+		t.Errorf("withoutFeedUpdated = %q, want %q", got, want)
+	}
+}
+
+func TestWriteFeedFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "feed.xml")
+	first := "<feed><updated>2024-01-01T00:00:00Z</updated><entry><title>A</title></entry></feed>"
+
+	if written, err := writeFeedFile(filename, first); err != nil || !written {
+		t.Fatalf("Expected the first write to happen, got %v, %v", written, err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filename, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the feed-level timestamp differs, so the file keeps its previous contents
+	retimed := strings.Replace(first, "2024-01-01", "2024-01-02", 1)
+	if written, err := writeFeedFile(filename, retimed); err != nil || written {
+		t.Fatalf("Expected an unchanged feed to be skipped, got %v, %v", written, err)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != first {
+		t.Errorf("Expected the previous updated value to be kept, got %q", data)
+	}
+	if info, _ := os.Stat(filename); info.ModTime().After(old.Add(time.Second)) {
+		t.Errorf("Expected the modification time to be kept, got %v", info.ModTime())
+	}
+
+	changed := strings.Replace(retimed, "<title>A</title>", "<title>B</title>", 1)
+	if written, err := writeFeedFile(filename, changed); err != nil || !written {
+		t.Fatalf("Expected a changed feed to be written, got %v, %v", written, err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != changed {
+		t.Errorf("Expected the changed feed to be written, got %q", data)
+	}
+}
+
+// readGzipFile returns the decompressed contents of a gzip file
+func readGzipFile(t *testing.T, filename string) string {
+	t.Helper()
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", filename, err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Failed to read gzip header of %s: %v", filename, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress %s: %v", filename, err)
+	}
+	return string(data)
+}
+
+func TestWriteFeedFile_Gzip(t *testing.T) {
+	gzipFeeds = true
+	t.Cleanup(func() { gzipFeeds = false })
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "feed.xml")
+	feed := "<feed><updated>2024-01-01T00:00:00Z</updated></feed>"
+	if _, err := writeFeedFile(filename, feed); err != nil {
+		t.Fatalf("writeFeedFile failed: %v", err)
+	}
+	if got := readGzipFile(t, filename+".gz"); got != feed {
+		t.Errorf("Compressed copy = %q, want %q", got, feed)
+	}
+
+	// An unchanged feed written before -gzip was used still gets its compressed copy
+	if err := os.Remove(filename + ".gz"); err != nil {
+		t.Fatal(err)
+	}
+	retimed := strings.Replace(feed, "2024-01-01", "2024-01-02", 1)
+	if written, err := writeFeedFile(filename, retimed); err != nil || written {
+		t.Fatalf("Expected the unchanged feed to be skipped, got %v, %v", written, err)
+	}
+	if got := readGzipFile(t, filename+".gz"); got != feed {
+		t.Errorf("Compressed copy = %q, want the kept feed %q", got, feed)
+	}
+}
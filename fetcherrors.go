@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// fetchErrorClass is the kind of failure behind an OpenGraph or API fetch error
+type fetchErrorClass string
+
+const (
+	fetchErrorDNS        fetchErrorClass = "dns"        // the host name didn't resolve
+	fetchErrorTLS        fetchErrorClass = "tls"        // handshake or certificate failure
+	fetchErrorTimeout    fetchErrorClass = "timeout"    // the request or connection timed out
+	fetchErrorConnection fetchErrorClass = "connection" // refused, reset or otherwise broken connections
+	fetchErrorHTTP4xx    fetchErrorClass = "http_4xx"
+	fetchErrorHTTP5xx    fetchErrorClass = "http_5xx"
+	fetchErrorNotHTML    fetchErrorClass = "not_html"  // OpenGraph target isn't an HTML page
+	fetchErrorTooLarge   fetchErrorClass = "too_large" // declared body size is over the limit
+	fetchErrorOther      fetchErrorClass = "other"
+)
+
+// Sources of fetch errors, used as the source label of the metrics
+const (
+	fetchSourceOpenGraph = "opengraph"
+	fetchSourceAlgolia   = "algolia"
+	fetchSourceHNAPI     = "hn_api"
+)
+
+// fetchError is a fetch failure with its class and, for HTTP errors, the status code
+type fetchError struct {
+	Class      fetchErrorClass
+	StatusCode int
+	Err        error
+}
+
+func (e *fetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *fetchError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether fetching again later may succeed. Client errors other than
+// timeouts and rate limiting, certificate problems and unusable pages won't fix themselves.
+func (e *fetchError) retryable() bool {
+	switch e.Class {
+	case fetchErrorHTTP4xx:
+		return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+	case fetchErrorTLS, fetchErrorNotHTML, fetchErrorTooLarge:
+		return false
+	}
+	return true
+}
+
+// httpStatusError returns the typed error of an unexpected HTTP status
+func httpStatusError(statusCode int) error {
+	class := fetchErrorOther
+	switch {
+	case statusCode >= 400 && statusCode < 500:
+		class = fetchErrorHTTP4xx
+	case statusCode >= 500:
+		class = fetchErrorHTTP5xx
+	}
+	return &fetchError{Class: class, StatusCode: statusCode, Err: fmt.Errorf("HTTP error %d", statusCode)}
+}
+
+// classifyFetchError returns err as a typed fetch error, working out its class from the wrapped
+// network errors unless it already is one. It returns nil for a nil error.
+func classifyFetchError(err error) *fetchError {
+	if err == nil {
+		return nil
+	}
+	var typed *fetchError
+	if errors.As(err, &typed) {
+		return typed
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var opErr *net.OpError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+
+	class := fetchErrorOther
+	switch {
+	case errors.As(err, &dnsErr) && !dnsErr.IsTimeout:
+		class = fetchErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &invalidCert),
+		errors.As(err, &hostnameErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		class = fetchErrorTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		class = fetchErrorTimeout
+	case errors.As(err, &opErr):
+		class = fetchErrorConnection
+	}
+	return &fetchError{Class: class, Err: err}
+}
+
+// fetchErrorKey identifies a counter of fetch errors
+type fetchErrorKey struct {
+	Source string
+	Class  fetchErrorClass
+}
+
+// fetchErrorCounts counts fetch errors by source and class since the process started
+var fetchErrorCounts = struct {
+	sync.Mutex
+	counts map[fetchErrorKey]int
+}{counts: make(map[fetchErrorKey]int)}
+
+// recordFetchError classifies and counts a fetch error of a source, returning the typed error
+func recordFetchError(source string, err error) *fetchError {
+	typed := classifyFetchError(err)
+	if typed == nil {
+		return nil
+	}
+	fetchErrorCounts.Lock()
+	fetchErrorCounts.counts[fetchErrorKey{Source: source, Class: typed.Class}]++
+	fetchErrorCounts.Unlock()
+	return typed
+}
+
+// fetchErrorSnapshot returns a copy of the fetch error counters
+func fetchErrorSnapshot() map[fetchErrorKey]int {
+	fetchErrorCounts.Lock()
+	defer fetchErrorCounts.Unlock()
+	snapshot := make(map[fetchErrorKey]int, len(fetchErrorCounts.counts))
+	for key, count := range fetchErrorCounts.counts {
+		snapshot[key] = count
+	}
+	return snapshot
+}
+
+// fetchErrorsSince returns the fetch errors counted after the snapshot was taken, keyed by
+// "source/class", or nil if there were none
+func fetchErrorsSince(before map[fetchErrorKey]int) map[string]int {
+	var errs map[string]int
+	for key, count := range fetchErrorSnapshot() {
+		if diff := count - before[key]; diff > 0 {
+			if errs == nil {
+				errs = make(map[string]int)
+			}
+			errs[key.Source+"/"+string(key.Class)] = diff
+		}
+	}
+	return errs
+}
+
+// handleMetrics serves the fetch error counters in the Prometheus text format
+func (s *feedServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	snapshot := fetchErrorSnapshot()
+	keys := make([]fetchErrorKey, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Source != keys[j].Source {
+			return keys[i].Source < keys[j].Source
+		}
+		return keys[i].Class < keys[j].Class
+	})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = fmt.Fprintln(w, "# HELP hntop_fetch_errors_total Failed OpenGraph and API fetches by source and error class.")
+	_, _ = fmt.Fprintln(w, "# TYPE hntop_fetch_errors_total counter")
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "hntop_fetch_errors_total{source=%q,class=%q} %d\n", key.Source, key.Class, snapshot[key])
+	}
+}
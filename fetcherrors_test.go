@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClassifyFetchError(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		class     fetchErrorClass
+		retryable bool
+	}{
+		{"dns", fmt.Errorf("HTTP request failed: %w", &net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true}), fetchErrorDNS, true},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, fetchErrorTimeout, true},
+		{"deadline", fmt.Errorf("HTTP request failed: %w", context.DeadlineExceeded), fetchErrorTimeout, true},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, fetchErrorConnection, true},
+		{"certificate", fmt.Errorf("HTTP request failed: %w", x509.UnknownAuthorityError{}), fetchErrorTLS, false},
+		{"not found", httpStatusError(http.StatusNotFound), fetchErrorHTTP4xx, false},
+		{"rate limited", httpStatusError(http.StatusTooManyRequests), fetchErrorHTTP4xx, true},
+		{"server error", httpStatusError(http.StatusBadGateway), fetchErrorHTTP5xx, true},
+		{"not html", &fetchError{Class: fetchErrorNotHTML, Err: errors.New("not an HTML page")}, fetchErrorNotHTML, false},
+		{"unknown", errors.New("no OpenGraph data"), fetchErrorOther, true},
+	}
+	for _, tc := range testCases {
+		got := classifyFetchError(tc.err)
+		if got.Class != tc.class || got.retryable() != tc.retryable {
+			t.Errorf("%s: got class %s retryable %v, expected %s retryable %v", tc.name, got.Class, got.retryable(), tc.class, tc.retryable)
+		}
+		if !errors.Is(got, tc.err) && got.Err != tc.err {
+			t.Errorf("%s: expected the original error to stay wrapped", tc.name)
+		}
+	}
+	if classifyFetchError(nil) != nil {
+		t.Error("Expected nil for a nil error")
+	}
+}
+
+func TestFetchOpenGraph_ErrorClasses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/down":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case "/paper.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		case "/dump":
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Content-Length", fmt.Sprint(ogMaxPageSize+1))
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		path   string
+		class  fetchErrorClass
+		status int
+	}{
+		{"/missing", fetchErrorHTTP4xx, http.StatusNotFound},
+		{"/down", fetchErrorHTTP5xx, http.StatusServiceUnavailable},
+		{"/paper.pdf", fetchErrorNotHTML, 0},
+		{"/dump", fetchErrorTooLarge, 0},
+	}
+	for _, tc := range testCases {
+		fetcher := NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts())
+		_, err := fetcher.FetchOpenGraph(t.Context(), server.URL+tc.path)
+		var fetchErr *fetchError
+		if !errors.As(err, &fetchErr) {
+			t.Errorf("%s: expected a typed fetch error, got %v", tc.path, err)
+			continue
+		}
+		if fetchErr.Class != tc.class || fetchErr.StatusCode != tc.status {
+			t.Errorf("%s: got class %s status %d, expected %s status %d", tc.path, fetchErr.Class, fetchErr.StatusCode, tc.class, tc.status)
+		}
+	}
+}
+
+func TestOpenGraphQueue_PermanentErrorsGiveUpRightAway(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.Error(w, "gone", http.StatusGone)
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	items := []HackerNewsItem{
+		{ItemID: "1", Link: server.URL + "/gone", Points: 200},
+		{ItemID: "2", Link: server.URL + "/flaky", Points: 100},
+	}
+	if err := enqueueOpenGraph(db, items, now); err != nil {
+		t.Fatal(err)
+	}
+	before := fetchErrorSnapshot()
+	processOpenGraphQueue(db, NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts()), 1, now)
+
+	// The 410 is cached as a failure with its class after a single attempt
+	cached, err := getOpenGraphData(db, server.URL+"/gone")
+	if err != nil || cached == nil {
+		t.Fatalf("Expected a cached failure, got %+v (%v)", cached, err)
+	}
+	if cached.FetchSuccess || cached.ErrorClass != fetchErrorHTTP4xx {
+		t.Errorf("Expected a failed http_4xx entry, got %+v", cached)
+	}
+	if cached.ExpiresAt.Before(now.Add(6 * 24 * time.Hour)) {
+		t.Errorf("Expected permanent failures to be kept for a week, expires %v", cached.ExpiresAt)
+	}
+
+	// The 503 stays queued for a retry with its class recorded
+	due, err := getDueOpenGraphQueue(db, now.Add(24*time.Hour))
+	if err != nil || len(due) != 1 || due[0].URL != server.URL+"/flaky" || due[0].Attempts != 1 {
+		t.Fatalf("Expected only the flaky URL queued for a retry, got %+v (%v)", due, err)
+	}
+	var class sql.NullString
+	if err := db.QueryRow("SELECT last_error_class FROM opengraph_queue WHERE url = ?", server.URL+"/flaky").Scan(&class); err != nil {
+		t.Fatal(err)
+	}
+	if class.String != string(fetchErrorHTTP5xx) {
+		t.Errorf("Expected last_error_class http_5xx, got %q", class.String)
+	}
+
+	errs := fetchErrorsSince(before)
+	if errs["opengraph/http_4xx"] != 1 || errs["opengraph/http_5xx"] != 1 || len(errs) != 2 {
+		t.Errorf("Unexpected fetch error counts: %v", errs)
+	}
+}
+
+func TestRecordRun_FetchErrors(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	if err := recordRun(db, &RunRecord{Source: "cli", StartedAt: now, FinishedAt: now, FetchErrors: map[string]int{"opengraph/timeout": 3, "algolia/http_5xx": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordRun(db, &RunRecord{Source: "cli", StartedAt: now, FinishedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := listRuns(db, 10, 0)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d (%v)", len(runs), err)
+	}
+	if runs[0].FetchErrors != nil {
+		t.Errorf("Expected no fetch errors on a clean run, got %v", runs[0].FetchErrors)
+	}
+	if runs[1].FetchErrors["opengraph/timeout"] != 3 || runs[1].FetchErrors["algolia/http_5xx"] != 1 {
+		t.Errorf("Unexpected fetch errors: %v", runs[1].FetchErrors)
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	server := setupTestServer(t)
+	recordFetchError(fetchSourceHNAPI, httpStatusError(http.StatusInternalServerError))
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "# TYPE hntop_fetch_errors_total counter") ||
+		!strings.Contains(body, `hntop_fetch_errors_total{source="hn_api",class="http_5xx"} `) {
+		t.Errorf("Unexpected metrics:\n%s", body)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitPublishTimeout bounds committing and pushing the output directory
+const gitPublishTimeout = 2 * time.Minute
+
+// maxCommitStories is how many new stories a publish commit message lists
+const maxCommitStories = 10
+
+// runGit runs a git command in dir and returns its trimmed output. Prompts are disabled so a
+// push needing credentials fails instead of waiting for input.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// newFeedStories returns the items whose entries aren't in the previously committed feed
+func newFeedStories(previousFeed string, items []HackerNewsItem) []HackerNewsItem {
+	var stories []HackerNewsItem
+	for _, item := range items {
+		if !strings.Contains(previousFeed, "<id>"+item.CommentsLink+"</id>") {
+			stories = append(stories, item)
+		}
+	}
+	return stories
+}
+
+// gitCommitMessage summarizes a feed update with the story counts and lists the new stories
+func gitCommitMessage(items, newStories []HackerNewsItem) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update feed: %d stories, %d new", len(items), len(newStories))
+	if len(newStories) > 0 {
+		b.WriteString("\n\n")
+		for i, item := range newStories {
+			if i == maxCommitStories {
+				fmt.Fprintf(&b, "- and %d more\n", len(newStories)-maxCommitStories)
+				break
+			}
+			fmt.Fprintf(&b, "- %s (%d points)\n", item.Title, item.Points)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// gitPublish commits the changes in outDir, which must be inside a git working tree, and pushes
+// them to remote. Only outDir is committed, so it can be a subdirectory such as docs/ of a
+// larger repository. Nothing is committed when no file changed. A failed push leaves the commit
+// in place, and the next successful push sends it along.
+func gitPublish(outDir, remote string, items []HackerNewsItem) error {
+	ctx, cancel := context.WithTimeout(context.Background(), gitPublishTimeout)
+	defer cancel()
+
+	if _, err := runGit(ctx, outDir, "rev-parse", "--show-toplevel"); err != nil {
+		return fmt.Errorf("output directory is not in a git repository: %w", err)
+	}
+	// The upload manifest describes this machine's uploads, not the published site
+	if _, err := runGit(ctx, outDir, "add", "-A", "--", ".", ":(exclude)"+uploadManifestFile); err != nil {
+		return err
+	}
+	// diff --quiet exits with 1 when there are staged changes
+	_, err := runGit(ctx, outDir, "diff", "--cached", "--quiet", "--", ".")
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		slog.Debug("Output unchanged, nothing to commit", "dir", outDir)
+		return nil
+	case !errors.As(err, &exitErr) || exitErr.ExitCode() != 1:
+		return err
+	}
+
+	// A repository without any commits has no previous feed
+	previousFeed, _ := runGit(ctx, outDir, "show", "HEAD:./"+feedFileName)
+	newStories := newFeedStories(previousFeed, items)
+
+	args := []string{"commit", "-q", "-m", gitCommitMessage(items, newStories), "--", "."}
+	// Machines that only publish often have no identity configured
+	if name, _ := runGit(ctx, outDir, "config", "user.name"); name == "" {
+		args = append([]string{"-c", "user.name=hntop-rss", "-c", "user.email=hntop-rss@localhost"}, args...)
+	}
+	if _, err := runGit(ctx, outDir, args...); err != nil {
+		return err
+	}
+	slog.Info("Committed feed update", "dir", outDir, "stories", len(items), "new", len(newStories))
+
+	if remote == "" {
+		return nil
+	}
+	if _, err := runGit(ctx, outDir, "push", "-q", remote, "HEAD"); err != nil {
+		return err
+	}
+	slog.Info("Pushed feed update", "remote", remote)
+	return nil
+}
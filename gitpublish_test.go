@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupGitPublishRepo creates a bare remote and a clone of it with the output in docs/
+func setupGitPublishRepo(t *testing.T) (remote, outDir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	remote = filepath.Join(dir, "remote.git")
+	clone := filepath.Join(dir, "site")
+	for _, args := range [][]string{
+		{"init", "-q", "--bare", remote},
+		{"init", "-q", clone},
+		{"-C", clone, "remote", "add", "origin", remote},
+		{"-C", clone, "config", "user.name", "Test"},
+		{"-C", clone, "config", "user.email", "test@example.com"},
+	} {
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, output)
+		}
+	}
+	return remote, filepath.Join(clone, "docs")
+}
+
+func feedWithEntries(items []HackerNewsItem) string {
+	var b strings.Builder
+	b.WriteString("<feed>")
+	for _, item := range items {
+		fmt.Fprintf(&b, "<entry><id>%s</id></entry>", item.CommentsLink)
+	}
+	b.WriteString("</feed>")
+	return b.String()
+}
+
+func TestGitPublish(t *testing.T) {
+	remote, outDir := setupGitPublishRepo(t)
+	ctx := context.Background()
+
+	items := []HackerNewsItem{
+		{Title: "First", Points: 300, CommentsLink: "https://news.ycombinator.com/item?id=1"},
+		{Title: "Second", Points: 200, CommentsLink: "https://news.ycombinator.com/item?id=2"},
+	}
+	writeOutputFile(t, outDir, feedFileName, feedWithEntries(items))
+	writeOutputFile(t, outDir, uploadManifestFile, "{}")
+	writeOutputFile(t, filepath.Dir(outDir), "README.md", "not part of the output")
+	if err := gitPublish(outDir, "origin", items); err != nil {
+		t.Fatalf("gitPublish failed: %v", err)
+	}
+
+	message, err := runGit(ctx, remote, "log", "-1", "--format=%B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message != "Update feed: 2 stories, 2 new\n\n- First (300 points)\n- Second (200 points)" {
+		t.Errorf("Unexpected commit message %q", message)
+	}
+	files, _ := runGit(ctx, remote, "ls-tree", "-r", "--name-only", "HEAD")
+	if files != "docs/"+feedFileName {
+		t.Errorf("Expected only the feed to be committed, got %q", files)
+	}
+
+	// Unchanged output makes no commit
+	if err := gitPublish(outDir, "origin", items); err != nil {
+		t.Fatalf("gitPublish failed: %v", err)
+	}
+	if count, _ := runGit(ctx, remote, "rev-list", "--count", "HEAD"); count != "1" {
+		t.Errorf("Expected no commit for unchanged output, got %s commits", count)
+	}
+
+	// New stories are counted against the committed feed
+	items = append([]HackerNewsItem{{Title: "Third", Points: 150, CommentsLink: "https://news.ycombinator.com/item?id=3"}}, items[1:]...)
+	writeOutputFile(t, outDir, feedFileName, feedWithEntries(items))
+	if err := gitPublish(outDir, "origin", items); err != nil {
+		t.Fatalf("gitPublish failed: %v", err)
+	}
+	if message, _ := runGit(ctx, remote, "log", "-1", "--format=%B"); message != "Update feed: 2 stories, 1 new\n\n- Third (150 points)" {
+		t.Errorf("Unexpected commit message %q", message)
+	}
+}
+
+func TestGitPublish_CommitOnly(t *testing.T) {
+	remote, outDir := setupGitPublishRepo(t)
+	writeOutputFile(t, outDir, feedFileName, "<feed></feed>")
+	if err := gitPublish(outDir, "", nil); err != nil {
+		t.Fatalf("gitPublish failed: %v", err)
+	}
+	if count, _ := runGit(context.Background(), remote, "rev-list", "--all", "--count"); count != "0" {
+		t.Error("Expected nothing to be pushed without a remote")
+	}
+	if message, _ := runGit(context.Background(), outDir, "log", "-1", "--format=%s"); message != "Update feed: 0 stories, 0 new" {
+		t.Errorf("Unexpected commit message %q", message)
+	}
+}
+
+func TestGitPublish_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	if err := gitPublish(t.TempDir(), "origin", nil); err == nil || !strings.Contains(err.Error(), "not in a git repository") {
+		t.Errorf("Expected an error outside a repository, got %v", err)
+	}
+}
+
+func TestGitCommitMessage_ListsAtMostTenStories(t *testing.T) {
+	var items []HackerNewsItem
+	for i := range 12 {
+		items = append(items, HackerNewsItem{Title: fmt.Sprintf("Story %d", i), Points: 100})
+	}
+	message := gitCommitMessage(items, items)
+	if !strings.HasSuffix(message, "- Story 9 (100 points)\n- and 2 more") {
+		t.Errorf("Unexpected commit message %q", message)
+	}
+}
@@ -0,0 +1,947 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file implements a small, read-only subset of GraphQL over the stored data:
+// a single query operation with typed variable definitions, fields, aliases, literal
+// or variable arguments and nested selection sets. Fragments, directives and
+// mutations are not supported.
+
+// gqlField is a parsed field selection
+type gqlField struct {
+	alias      string
+	name       string
+	args       map[string]any
+	selections []gqlField
+}
+
+// responseKey returns the key the field's value is stored under in the response
+func (f gqlField) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// gqlToken is a lexical token; kind is one of: name, int, float, string, punct, eof
+type gqlToken struct {
+	kind  string
+	value string
+}
+
+// gqlType is a variable type such as Int, [String!] or ID!
+type gqlType struct {
+	name    string
+	elem    *gqlType
+	nonNull bool
+}
+
+// String formats the type the way it is written in a query
+func (t gqlType) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// gqlScalarTypes are the input types variables may be declared with
+var gqlScalarTypes = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+// gqlParser parses a GraphQL query document
+type gqlParser struct {
+	src string
+	pos int
+	tok gqlToken
+	// variables holds the coerced values of the declared variables
+	variables map[string]any
+	used      map[string]bool
+	// constant is set while parsing default values, which must not reference variables
+	constant bool
+}
+
+// parseGraphQL parses a query document into its top-level field selections
+func parseGraphQL(query string, variables map[string]any) ([]gqlField, error) {
+	p := &gqlParser{src: query, used: make(map[string]bool)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	// Optional "query [Name] [(variable definitions)]" prefix
+	if p.tok.kind == "name" && p.tok.value == "query" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == "name" {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if p.isPunct("(") {
+			values, err := p.parseVariableDefinitions(variables)
+			if err != nil {
+				return nil, err
+			}
+			p.variables = values
+		}
+	} else if p.tok.kind == "name" {
+		return nil, fmt.Errorf("unsupported operation %q, only queries are supported", p.tok.value)
+	}
+
+	fields, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q after query", p.tok.value)
+	}
+	for name := range p.variables {
+		if !p.used[name] {
+			return nil, fmt.Errorf("variable $%s is never used", name)
+		}
+	}
+	return fields, nil
+}
+
+// next advances to the next token, skipping whitespace, commas and comments
+func (p *gqlParser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+			continue
+		}
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+
+	if p.pos >= len(p.src) {
+		p.tok = gqlToken{kind: "eof"}
+		return nil
+	}
+
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.ContainsRune("{}()[]:!$=@", rune(c)):
+		p.pos++
+		p.tok = gqlToken{kind: "punct", value: string(c)}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = gqlToken{kind: "name", value: p.src[start:p.pos]}
+	case c == '-' || isDigit(c):
+		kind, err := p.readNumber()
+		if err != nil {
+			return err
+		}
+		p.tok = gqlToken{kind: kind, value: p.src[start:p.pos]}
+	case c == '"':
+		value, err := p.readString()
+		if err != nil {
+			return err
+		}
+		p.tok = gqlToken{kind: "string", value: value}
+	default:
+		return fmt.Errorf("unexpected character %q at position %d", c, p.pos)
+	}
+	return nil
+}
+
+// isNameChar reports whether c may appear in a GraphQL name after the first character
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c)
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// peek returns the byte at the current position, or 0 at the end of the input
+func (p *gqlParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// readDigits consumes a run of digits and reports whether there was at least one
+func (p *gqlParser) readDigits() bool {
+	start := p.pos
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// readNumber reads an IntValue or FloatValue: -?(0|[1-9][0-9]*), optionally followed
+// by a fraction (.digits) and/or an exponent ([eE][+-]?digits). A number must not be
+// directly followed by a digit, '.' or a name character, so "01", "1.2.3" and "1e"
+// are errors rather than being split into several tokens.
+func (p *gqlParser) readNumber() (string, error) {
+	start := p.pos
+	invalid := func() (string, error) {
+		end := p.pos
+		for end < len(p.src) && (isNameChar(p.src[end]) || strings.IndexByte(".+-", p.src[end]) >= 0) {
+			end++
+		}
+		return "", fmt.Errorf("invalid number %q at position %d", p.src[start:end], start)
+	}
+
+	if p.peek() == '-' {
+		p.pos++
+	}
+	if p.peek() == '0' {
+		p.pos++
+	} else if !p.readDigits() {
+		return invalid()
+	}
+
+	kind := "int"
+	if p.peek() == '.' {
+		p.pos++
+		kind = "float"
+		if !p.readDigits() {
+			return invalid()
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		kind = "float"
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		if !p.readDigits() {
+			return invalid()
+		}
+	}
+	if c := p.peek(); c == '.' || isNameChar(c) {
+		return invalid()
+	}
+	return kind, nil
+}
+
+// readString reads a double-quoted string literal starting at the current position
+func (p *gqlParser) readString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			value, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", fmt.Errorf("invalid string literal at position %d", start)
+			}
+			return value, nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string at position %d", start)
+		default:
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string at position %d", start)
+}
+
+// isPunct reports whether the current token is the given punctuator
+func (p *gqlParser) isPunct(value string) bool {
+	return p.tok.kind == "punct" && p.tok.value == value
+}
+
+// expectPunct consumes the given punctuator or fails
+func (p *gqlParser) expectPunct(value string) error {
+	if !p.isPunct(value) {
+		return fmt.Errorf("expected %q, got %q", value, p.tok.value)
+	}
+	return p.next()
+}
+
+// parseVariableDefinitions parses "($name: Type [= default] ...)" and returns the
+// provided variables coerced to their declared types, with defaults applied.
+// Provided variables that aren't declared are ignored, as the spec requires.
+func (p *gqlParser) parseVariableDefinitions(provided map[string]any) (map[string]any, error) {
+	values := make(map[string]any)
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for !p.isPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != "name" {
+			return nil, fmt.Errorf("expected variable name, got %q", p.tok.value)
+		}
+		name := p.tok.value
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("variable $%s is defined more than once", name)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		var def any
+		hasDefault := false
+		if p.isPunct("=") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			p.constant = true
+			def, err = p.parseValue()
+			p.constant = false
+			if err != nil {
+				return nil, err
+			}
+			if def, err = coerceGraphQLValue(def, typ); err != nil {
+				return nil, fmt.Errorf("default value of $%s: %w", name, err)
+			}
+			hasDefault = true
+		}
+
+		value, ok := provided[name]
+		switch {
+		case ok:
+			if value, err = coerceGraphQLValue(value, typ); err != nil {
+				return nil, fmt.Errorf("variable $%s: %w", name, err)
+			}
+		case hasDefault:
+			value = def
+		case typ.nonNull:
+			return nil, fmt.Errorf("variable $%s of type %s is required", name, typ)
+		}
+		values[name] = value
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("empty variable definitions")
+	}
+	return values, p.next()
+}
+
+// parseType parses a variable type: a scalar name or [Type], optionally followed by "!"
+func (p *gqlParser) parseType() (gqlType, error) {
+	var typ gqlType
+	switch {
+	case p.isPunct("["):
+		if err := p.next(); err != nil {
+			return typ, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return typ, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return typ, err
+		}
+		typ.elem = &elem
+	case p.tok.kind == "name":
+		if !gqlScalarTypes[p.tok.value] {
+			return typ, fmt.Errorf("unknown type %q", p.tok.value)
+		}
+		typ.name = p.tok.value
+		if err := p.next(); err != nil {
+			return typ, err
+		}
+	default:
+		return typ, fmt.Errorf("expected type, got %q", p.tok.value)
+	}
+	if p.isPunct("!") {
+		typ.nonNull = true
+		return typ, p.next()
+	}
+	return typ, nil
+}
+
+// coerceGraphQLValue checks a variable or default value against its declared type.
+// JSON numbers arrive as float64, so integral values are accepted for Int and ID;
+// a single value is accepted for a list type and wrapped in a list.
+func coerceGraphQLValue(value any, typ gqlType) (any, error) {
+	if value == nil {
+		if typ.nonNull {
+			return nil, fmt.Errorf("expected a non-null %s", typ)
+		}
+		return nil, nil
+	}
+
+	if typ.elem != nil {
+		elems, ok := value.([]any)
+		if !ok {
+			elems = []any{value}
+		}
+		list := make([]any, len(elems))
+		for i, elem := range elems {
+			coerced, err := coerceGraphQLValue(elem, *typ.elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	switch v := value.(type) {
+	case int:
+		switch typ.name {
+		case "Int":
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return v, nil
+			}
+		case "Float":
+			return float64(v), nil
+		case "ID":
+			return strconv.Itoa(v), nil
+		}
+	case float64:
+		integral := v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32
+		switch {
+		case typ.name == "Float":
+			return v, nil
+		case typ.name == "Int" && integral:
+			return int(v), nil
+		case typ.name == "ID" && integral:
+			return strconv.Itoa(int(v)), nil
+		}
+	case string:
+		if typ.name == "String" || typ.name == "ID" {
+			return v, nil
+		}
+	case bool:
+		if typ.name == "Boolean" {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value of type %s, got %v", typ, value)
+}
+
+// parseSelectionSet parses "{ field ... }"
+func (p *gqlParser) parseSelectionSet() ([]gqlField, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	var fields []gqlField
+	for !p.isPunct("}") {
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return fields, p.next()
+}
+
+// parseField parses "[alias:] name [(args)] [selection set]"
+func (p *gqlParser) parseField() (gqlField, error) {
+	var field gqlField
+	if p.tok.kind != "name" {
+		return field, fmt.Errorf("expected field name, got %q", p.tok.value)
+	}
+	field.name = p.tok.value
+	if err := p.next(); err != nil {
+		return field, err
+	}
+
+	if p.isPunct(":") {
+		if err := p.next(); err != nil {
+			return field, err
+		}
+		if p.tok.kind != "name" {
+			return field, fmt.Errorf("expected field name after alias %q", field.name)
+		}
+		field.alias, field.name = field.name, p.tok.value
+		if err := p.next(); err != nil {
+			return field, err
+		}
+	}
+
+	if p.isPunct("(") {
+		args, err := p.parseArguments()
+		if err != nil {
+			return field, err
+		}
+		field.args = args
+	}
+
+	if p.isPunct("{") {
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return field, err
+		}
+		field.selections = selections
+	}
+	return field, nil
+}
+
+// parseArguments parses "(name: value ...)"
+func (p *gqlParser) parseArguments() (map[string]any, error) {
+	args := make(map[string]any)
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for !p.isPunct(")") {
+		if p.tok.kind != "name" {
+			return nil, fmt.Errorf("expected argument name, got %q", p.tok.value)
+		}
+		name := p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, p.next()
+}
+
+// parseValue parses a literal, list or variable reference
+func (p *gqlParser) parseValue() (any, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == "int":
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", tok.value)
+		}
+		return n, p.next()
+	case tok.kind == "float":
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q", tok.value)
+		}
+		return f, p.next()
+	case tok.kind == "string":
+		return tok.value, p.next()
+	case tok.kind == "name":
+		var value any = tok.value
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		}
+		return value, p.next()
+	case p.isPunct("$"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != "name" {
+			return nil, fmt.Errorf("expected variable name")
+		}
+		if p.constant {
+			return nil, fmt.Errorf("variable $%s cannot be used in a default value", p.tok.value)
+		}
+		value, ok := p.variables[p.tok.value]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", p.tok.value)
+		}
+		p.used[p.tok.value] = true
+		return value, p.next()
+	case p.isPunct("["):
+		var list []any
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.isPunct("]") {
+			if p.tok.kind == "eof" {
+				return nil, fmt.Errorf("unterminated list")
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, p.next()
+	}
+	return nil, fmt.Errorf("unexpected %q in value", tok.value)
+}
+
+// argInt returns an integer argument or the default; JSON variables arrive as float64
+func argInt(args map[string]any, name string, def int) (int, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// argString returns a string argument or empty string
+func argString(args map[string]any, name string) (string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("argument %q must be a string", name)
+}
+
+// argStringList returns a string or list-of-strings argument as a slice
+func argStringList(args map[string]any, name string) ([]string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		var list []string
+		for _, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %q must be a list of strings", name)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("argument %q must be a list of strings", name)
+}
+
+// gqlResolver computes a field value only when the field is selected
+type gqlResolver func() (any, error)
+
+// gqlList is a list of objects whose fields are projected by the field's selection
+type gqlList struct {
+	typeName string
+	objects  []map[string]any
+}
+
+// gqlItemObject maps an item to its GraphQL fields. The stats fields query the history
+// only when selected, so listing items doesn't cost a query per item.
+func gqlItemObject(db *sql.DB, item apiItem) map[string]any {
+	history := func() ([]statsSnapshot, error) { return getStatsHistory(db, item.ID) }
+	delta := func() (*statsDelta, error) {
+		delta, ok, err := getStatsDelta(db, HackerNewsItem{ItemID: item.ID, Points: item.Points, CommentCount: item.CommentCount})
+		if err != nil || !ok {
+			return nil, err
+		}
+		return &delta, nil
+	}
+
+	return map[string]any{
+		"id":           item.ID,
+		"title":        item.Title,
+		"url":          item.URL,
+		"commentsUrl":  item.CommentsURL,
+		"points":       item.Points,
+		"commentCount": item.CommentCount,
+		"author":       item.Author,
+		"createdAt":    item.CreatedAt.Format(time.RFC3339),
+		"updatedAt":    item.UpdatedAt.Format(time.RFC3339),
+		"categories":   item.Categories,
+		"history": gqlResolver(func() (any, error) {
+			snapshots, err := history()
+			if err != nil {
+				return nil, err
+			}
+			objects := make([]map[string]any, len(snapshots))
+			for i, snapshot := range snapshots {
+				objects[i] = gqlSnapshotObject(snapshot)
+			}
+			return gqlList{typeName: "StatsSnapshot", objects: objects}, nil
+		}),
+		"velocity": gqlResolver(func() (any, error) {
+			snapshots, err := history()
+			if err != nil {
+				return nil, err
+			}
+			if velocity, ok := statsVelocity(snapshots); ok {
+				return velocity, nil
+			}
+			return nil, nil
+		}),
+		"pointsDelta": gqlResolver(func() (any, error) {
+			delta, err := delta()
+			if delta == nil {
+				return nil, err
+			}
+			return delta.Points, nil
+		}),
+		"commentsDelta": gqlResolver(func() (any, error) {
+			delta, err := delta()
+			if delta == nil {
+				return nil, err
+			}
+			return delta.Comments, nil
+		}),
+	}
+}
+
+// gqlSnapshotObject maps a stats snapshot to its GraphQL fields
+func gqlSnapshotObject(snapshot statsSnapshot) map[string]any {
+	return map[string]any{
+		"recordedAt":   snapshot.RecordedAt.Format(time.RFC3339),
+		"points":       snapshot.Points,
+		"commentCount": snapshot.Comments,
+	}
+}
+
+// gqlCategoryObject maps a category count to its GraphQL fields
+func gqlCategoryObject(category apiCategory) map[string]any {
+	return map[string]any{"name": category.Name, "count": category.Count}
+}
+
+// gqlRunObject maps a run record to its GraphQL fields
+func gqlRunObject(run RunRecord) map[string]any {
+	return map[string]any{
+		"id":          run.ID,
+		"source":      run.Source,
+		"startedAt":   run.StartedAt.Format(time.RFC3339),
+		"finishedAt":  run.FinishedAt.Format(time.RFC3339),
+		"fetched":     run.Fetched,
+		"updated":     run.Updated,
+		"feedItems":   run.FeedItems,
+		"error":       run.Error,
+		"fetchErrors": run.FetchErrors,
+	}
+}
+
+// projectObject keeps only the selected fields of an object
+func projectObject(typeName string, object map[string]any, selections []gqlField) (map[string]any, error) {
+	result := make(map[string]any, len(selections))
+	for _, field := range selections {
+		if field.name == "__typename" {
+			result[field.responseKey()] = typeName
+			continue
+		}
+		value, ok := object[field.name]
+		if !ok {
+			return nil, fmt.Errorf("cannot query field %q on type %q", field.name, typeName)
+		}
+		if resolve, ok := value.(gqlResolver); ok {
+			var err error
+			if value, err = resolve(); err != nil {
+				return nil, err
+			}
+		}
+		if list, ok := value.(gqlList); ok {
+			projected, err := projectList(list.typeName, list.objects, field)
+			if err != nil {
+				return nil, err
+			}
+			result[field.responseKey()] = projected
+			continue
+		}
+		if len(field.selections) > 0 {
+			return nil, fmt.Errorf("field %q on type %q must not have a selection", field.name, typeName)
+		}
+		result[field.responseKey()] = value
+	}
+	return result, nil
+}
+
+// projectList projects each object in a list
+func projectList(typeName string, objects []map[string]any, field gqlField) ([]map[string]any, error) {
+	if len(field.selections) == 0 {
+		return nil, fmt.Errorf("field %q of type [%s] must have a selection of subfields", field.name, typeName)
+	}
+	result := make([]map[string]any, 0, len(objects))
+	for _, object := range objects {
+		projected, err := projectObject(typeName, object, field.selections)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, projected)
+	}
+	return result, nil
+}
+
+// resolveGraphQLField resolves a top-level query field
+func (s *feedServer) resolveGraphQLField(field gqlField) (any, error) {
+	defaults, _ := s.settings()
+
+	switch field.name {
+	case "items":
+		query := feedQuery{filter: defaults}
+		var err error
+		if query.filter.MinPoints, err = argInt(field.args, "minPoints", defaults.MinPoints); err != nil {
+			return nil, err
+		}
+		limit, err := argInt(field.args, "limit", 30)
+		if err != nil {
+			return nil, err
+		}
+		offset, err := argInt(field.args, "offset", 0)
+		if err != nil {
+			return nil, err
+		}
+		if limit <= 0 || limit > 100 || offset < 0 {
+			return nil, fmt.Errorf("limit must be 1-100 and offset non-negative")
+		}
+		if query.include, err = argStringList(field.args, "category"); err != nil {
+			return nil, err
+		}
+		if query.exclude, err = argStringList(field.args, "exclude"); err != nil {
+			return nil, err
+		}
+		if query.keywords, err = argStringList(field.args, "q"); err != nil {
+			return nil, err
+		}
+
+		items, _, _, err := s.queryAPIItems(query, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		objects := make([]map[string]any, len(items))
+		for i, item := range items {
+			objects[i] = gqlItemObject(s.db, item)
+		}
+		return projectList("Item", objects, field)
+
+	case "item":
+		id, err := argString(field.args, "id")
+		if err != nil || id == "" {
+			return nil, fmt.Errorf("argument \"id\" of type String! is required")
+		}
+		item, err := getItemByID(s.db, id)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			return nil, nil
+		}
+		if len(field.selections) == 0 {
+			return nil, fmt.Errorf("field %q of type Item must have a selection of subfields", field.name)
+		}
+		_, categoryMapper := s.settings()
+		return projectObject("Item", gqlItemObject(s.db, toAPIItem(*item, defaults.MinPoints, categoryMapper)), field.selections)
+
+	case "categories":
+		filter := defaults
+		var err error
+		if filter.MinPoints, err = argInt(field.args, "minPoints", defaults.MinPoints); err != nil {
+			return nil, err
+		}
+		categories, err := s.categoryCounts(filter)
+		if err != nil {
+			return nil, err
+		}
+		objects := make([]map[string]any, len(categories))
+		for i, category := range categories {
+			objects[i] = gqlCategoryObject(category)
+		}
+		return projectList("Category", objects, field)
+
+	case "runs":
+		limit, err := argInt(field.args, "limit", 30)
+		if err != nil {
+			return nil, err
+		}
+		offset, err := argInt(field.args, "offset", 0)
+		if err != nil {
+			return nil, err
+		}
+		if limit <= 0 || limit > 100 || offset < 0 {
+			return nil, fmt.Errorf("limit must be 1-100 and offset non-negative")
+		}
+		runs, err := listRuns(s.db, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		objects := make([]map[string]any, len(runs))
+		for i, run := range runs {
+			objects[i] = gqlRunObject(run)
+		}
+		return projectList("Run", objects, field)
+
+	case "__typename":
+		return "Query", nil
+	}
+
+	return nil, fmt.Errorf("cannot query field %q on type \"Query\"", field.name)
+}
+
+// gqlError is a GraphQL error entry
+type gqlError struct {
+	Message string   `json:"message"`
+	Path    []string `json:"path,omitempty"`
+}
+
+// gqlResponse is a GraphQL response document
+type gqlResponse struct {
+	Data   map[string]any `json:"data,omitempty"`
+	Errors []gqlError     `json:"errors,omitempty"`
+}
+
+// handleGraphQL executes a query given as ?query= (GET) or a JSON {"query", "variables"} body (POST)
+func (s *feedServer) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64*1024)).Decode(&request); err != nil {
+			writeJSON(w, http.StatusBadRequest, gqlResponse{Errors: []gqlError{{Message: "invalid request body: " + err.Error()}}})
+			return
+		}
+	} else {
+		request.Query = r.URL.Query().Get("query")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &request.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, gqlResponse{Errors: []gqlError{{Message: "invalid variables: " + err.Error()}}})
+				return
+			}
+		}
+	}
+
+	fields, err := parseGraphQL(request.Query, request.Variables)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, gqlResponse{Errors: []gqlError{{Message: err.Error()}}})
+		return
+	}
+
+	response := gqlResponse{Data: make(map[string]any)}
+	for _, field := range fields {
+		value, err := s.resolveGraphQLField(field)
+		if err != nil {
+			response.Errors = append(response.Errors, gqlError{Message: err.Error(), Path: []string{field.responseKey()}})
+			value = nil
+		}
+		response.Data[field.responseKey()] = value
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
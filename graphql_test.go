@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	fields, err := parseGraphQL(`query Top($min: Int) {
+		# only what the dashboard needs
+		top: items(minPoints: $min, category: ["GitHub", "Twitter"], limit: 5) { title points }
+		item(id: "1") { id }
+	}`, map[string]any{"min": float64(100)})
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+	if len(fields) != 2 {
+		t.Fatalf("Expected 2 fields, got %d", len(fields))
+	}
+
+	top := fields[0]
+	if top.alias != "top" || top.name != "items" || top.responseKey() != "top" {
+		t.Errorf("Unexpected alias handling: %+v", top)
+	}
+	if top.args["minPoints"] != 100 || top.args["limit"] != 5 {
+		t.Errorf("Unexpected arguments: %+v", top.args)
+	}
+	if list, ok := top.args["category"].([]any); !ok || len(list) != 2 {
+		t.Errorf("Expected list argument, got %+v", top.args["category"])
+	}
+	if len(top.selections) != 2 || top.selections[1].name != "points" {
+		t.Errorf("Unexpected selections: %+v", top.selections)
+	}
+}
+
+func TestParseGraphQL_Errors(t *testing.T) {
+	testCases := []string{
+		"",
+		"{ items { title }",
+		"mutation { addItem }",
+		`{ item(id: "1) { id } }`,
+		"{ items(minPoints: $undefined) { id } }",
+		"{ }",
+		"{ items(minPoints: 1-2) { id } }",
+		"{ items(minPoints: 01) { id } }",
+		"{ items(minPoints: 1.) { id } }",
+		"{ items(minPoints: 1e) { id } }",
+		"{ items(minPoints: 1.2.3) { id } }",
+		"{ items(minPoints: 12abc) { id } }",
+		"{ items(minPoints: -) { id } }",
+	}
+
+	for _, query := range testCases {
+		if _, err := parseGraphQL(query, nil); err == nil {
+			t.Errorf("Expected parse error for %q", query)
+		}
+	}
+}
+
+func TestParseGraphQL_Numbers(t *testing.T) {
+	testCases := []struct {
+		literal  string
+		expected any
+	}{
+		{"0", 0},
+		{"-7", -7},
+		{"120", 120},
+		{"1.5", 1.5},
+		{"-0.25", -0.25},
+		{"1e3", 1000.0},
+		{"2.5E-1", 0.25},
+		{"6e+2", 600.0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.literal, func(t *testing.T) {
+			fields, err := parseGraphQL("{ items(minPoints: "+tc.literal+") { id } }", nil)
+			if err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+			if value := fields[0].args["minPoints"]; value != tc.expected {
+				t.Errorf("Expected %v (%T), got %v (%T)", tc.expected, tc.expected, value, value)
+			}
+		})
+	}
+}
+
+func TestParseGraphQL_Variables(t *testing.T) {
+	const query = `query Feed($min: Int = 50, $categories: [String!], $id: ID) {
+		items(minPoints: $min, category: $categories) { id }
+		item(id: $id) { id }
+	}`
+
+	fields, err := parseGraphQL(query, map[string]any{"categories": "GitHub", "id": float64(42), "extra": true})
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+	if fields[0].args["minPoints"] != 50 {
+		t.Errorf("Expected the default to apply, got %v", fields[0].args["minPoints"])
+	}
+	if list, ok := fields[0].args["category"].([]any); !ok || len(list) != 1 || list[0] != "GitHub" {
+		t.Errorf("Expected a single value to be wrapped in a list, got %+v", fields[0].args["category"])
+	}
+	if fields[1].args["id"] != "42" {
+		t.Errorf("Expected the ID to be coerced to a string, got %v", fields[1].args["id"])
+	}
+}
+
+func TestParseGraphQL_VariableErrors(t *testing.T) {
+	testCases := []struct {
+		name      string
+		query     string
+		variables map[string]any
+	}{
+		{"type mismatch", "query ($min: Int) { items(minPoints: $min) { id } }", map[string]any{"min": "lots"}},
+		{"fractional int", "query ($min: Int) { items(minPoints: $min) { id } }", map[string]any{"min": 1.5}},
+		{"null for non-null", "query ($id: ID!) { item(id: $id) { id } }", map[string]any{"id": nil}},
+		{"missing required", "query ($id: ID!) { item(id: $id) { id } }", nil},
+		{"unused", "query ($min: Int, $limit: Int) { items(minPoints: $min) { id } }", nil},
+		{"duplicate", "query ($min: Int, $min: Int) { items(minPoints: $min) { id } }", nil},
+		{"undeclared", "query ($min: Int) { items(minPoints: $min, limit: $limit) { id } }", map[string]any{"limit": float64(5)}},
+		{"unknown type", "query ($min: Long) { items(minPoints: $min) { id } }", nil},
+		{"bad list element", "query ($c: [String!]) { items(category: $c) { id } }", map[string]any{"c": []any{"GitHub", nil}}},
+		{"bad default", `query ($min: Int = "ten") { items(minPoints: $min) { id } }`, nil},
+		{"variable in default", "query ($a: Int, $min: Int = $a) { items(minPoints: $min, limit: $a) { id } }", nil},
+		{"missing type", "query ($min) { items(minPoints: $min) { id } }", nil},
+		{"empty definitions", "query () { items { id } }", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseGraphQL(tc.query, tc.variables); err == nil {
+				t.Errorf("Expected an error for %q", tc.query)
+			}
+		})
+	}
+}
+
+func postGraphQL(t *testing.T, server *feedServer, query string) (int, gqlResponse) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query})
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+
+	var response gqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return rec.Code, response
+}
+
+func TestHandleGraphQL(t *testing.T) {
+	server := setupTestServer(t)
+	server.graphQL = true
+
+	code, response := postGraphQL(t, server, `{ items(minPoints: 100) { title points } categories { name count } }`)
+	if code != http.StatusOK || len(response.Errors) > 0 {
+		t.Fatalf("Unexpected response %d: %+v", code, response.Errors)
+	}
+
+	items, ok := response.Data["items"].([]any)
+	if !ok || len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %+v", response.Data["items"])
+	}
+	first := items[0].(map[string]any)
+	if len(first) != 2 || first["title"] != "GitHub Project" {
+		t.Errorf("Expected only the selected fields, got %+v", first)
+	}
+
+	code, response = postGraphQL(t, server, `{ item(id: "2") { title categories } missing: item(id: "999") { title } }`)
+	if code != http.StatusOK || len(response.Errors) > 0 {
+		t.Fatalf("Unexpected response %d: %+v", code, response.Errors)
+	}
+	if response.Data["missing"] != nil {
+		t.Errorf("Expected null for unknown item, got %+v", response.Data["missing"])
+	}
+
+	_, response = postGraphQL(t, server, `{ items { secret } }`)
+	if len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, "secret") {
+		t.Errorf("Expected field error, got %+v", response.Errors)
+	}
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape("{ runs { id } }"), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected GET queries to be supported, got %d", rec.Code)
+	}
+}
+
+func TestHandleGraphQL_ItemStats(t *testing.T) {
+	server := setupTestServer(t)
+	server.graphQL = true
+
+	start := time.Now().Add(-2 * time.Hour)
+	for i, points := range []int{150, 200, 250} {
+		if _, err := server.db.Exec("INSERT INTO item_stats_history (item_hn_id, recorded_at, points, comment_count) VALUES ('1', ?, ?, ?)",
+			start.Add(time.Duration(i)*30*time.Minute), points, 10*i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, response := postGraphQL(t, server, `{ item(id: "1") { history { points commentCount } pointsDelta velocity } other: item(id: "2") { history { points } pointsDelta velocity } }`)
+	if code != http.StatusOK || len(response.Errors) > 0 {
+		t.Fatalf("Unexpected response %d: %+v", code, response.Errors)
+	}
+	item := response.Data["item"].(map[string]any)
+	history, ok := item["history"].([]any)
+	if !ok || len(history) != 3 || history[2].(map[string]any)["points"] != float64(250) {
+		t.Errorf("Expected three snapshots, got %+v", item["history"])
+	}
+	if item["pointsDelta"] != float64(50) || item["velocity"] != float64(100) {
+		t.Errorf("Expected +50 points at 100 per hour, got %+v", item)
+	}
+	other := response.Data["other"].(map[string]any)
+	if len(other["history"].([]any)) != 0 || other["pointsDelta"] != nil || other["velocity"] != nil {
+		t.Errorf("Expected no stats for an item without history, got %+v", other)
+	}
+
+	_, response = postGraphQL(t, server, `{ items { history } }`)
+	if len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, "selection") {
+		t.Errorf("Expected a selection error for history, got %+v", response.Errors)
+	}
+}
+
+func TestHandleGraphQL_RunsPagination(t *testing.T) {
+	server := setupTestServer(t)
+	server.graphQL = true
+
+	for _, query := range []string{`{ runs(limit: 0) { id } }`, `{ runs(limit: 101) { id } }`, `{ runs(offset: -1) { id } }`} {
+		if _, response := postGraphQL(t, server, query); len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, "limit must be") {
+			t.Errorf("Expected a pagination error for %s, got %+v", query, response.Errors)
+		}
+	}
+}
+
+func TestHandleGraphQL_Disabled(t *testing.T) {
+	server := setupTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql?query={runs{id}}", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 when GraphQL is disabled, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// graveyardFeedLimit bounds how many dead items the graveyard feed lists
+const graveyardFeedLimit = 100
+
+// graveyardFeedFile is the name of the graveyard feed in the output directory
+const graveyardFeedFile = "graveyard.xml"
+
+// graveyardFeedInfo describes the feed of stories that died or were flagged
+var graveyardFeedInfo = feedInfo{
+	Title:       "Hacker News Graveyard",
+	Description: "Stories that made the Hacker News front page but later died or were flagged, with their last-known stats",
+	ID:          "tag:news.ycombinator.com,2024:graveyard",
+}
+
+// writeGraveyardFeed writes the graveyard feed of the most recently died items to outDir
+func writeGraveyardFeed(db *sql.DB, outDir string, minPoints int, categoryMapper *CategoryMapper) error {
+	items, err := getDeadItems(db, graveyardFeedLimit)
+	if err != nil {
+		return err
+	}
+	feed := generateFeed(db, items, minPoints, categoryMapper, graveyardFeedInfo)
+	filename := filepath.Join(outDir, graveyardFeedFile)
+	written, err := writeFeedFile(filename, feed)
+	if err != nil {
+		return fmt.Errorf("failed to write graveyard feed: %w", err)
+	}
+	if written {
+		slog.Info("Graveyard feed saved", "count", len(items), "filename", filename)
+	}
+	return nil
+}
+
+// renderDeadNote returns a note on when a dead item died, or an empty string for a live item
+func renderDeadNote(item HackerNewsItem, format FeedFormat) string {
+	if item.DeadAt.IsZero() {
+		return ""
+	}
+	died := localTime(item.DeadAt)
+	return fmt.Sprintf(`<p style="margin: 0 0 8px 0; color: #828282;">%s<em>Dead or flagged since <time datetime="%s">%s</time>; stats as last seen.</em></p>`,
+		format.icon("🪦"), died.Format(time.RFC3339), died.Format("2006-01-02 15:04 MST"))
+}
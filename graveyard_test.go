@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkItemDead(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Alive", Link: "https://example.com/1", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CommentCount: 10, Author: "a", CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "Flagged", Link: "https://example.com/2", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 150, CommentCount: 80, Author: "b", CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+
+	if err := markItemDead(db, "2", now); err != nil {
+		t.Fatal(err)
+	}
+	// Marking again keeps the original time of death
+	if err := markItemDead(db, "2", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	live, err := getFilteredItems(db, ItemFilter{Limit: 10, MinPoints: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(live) != 1 || live[0].ItemID != "1" {
+		t.Errorf("Expected only the live item in the feed, got %+v", live)
+	}
+
+	dead, err := getDeadItems(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].ItemID != "2" || dead[0].Points != 150 || dead[0].CommentCount != 80 {
+		t.Fatalf("Expected the flagged item with its last-known stats, got %+v", dead)
+	}
+	if !dead[0].DeadAt.Equal(now) {
+		t.Errorf("Expected dead_at %v, got %v", now, dead[0].DeadAt)
+	}
+
+	// Showing up on the front page again brings the item back to life
+	updateStoredItems(db, items[1:])
+	if dead, _ := getDeadItems(db, 10); len(dead) != 0 {
+		t.Errorf("Expected the revived item to leave the graveyard, got %+v", dead)
+	}
+	if live, _ := getFilteredItems(db, ItemFilter{Limit: 10, MinPoints: 50}); len(live) != 2 {
+		t.Errorf("Expected both items in the feed after the revival, got %d", len(live))
+	}
+}
+
+func TestWriteGraveyardFeed(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+	useDisplayLocation(t, "UTC")
+
+	now := time.Now().UTC()
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "3", Title: "Gone too soon", Link: "https://example.com/3", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 300, CommentCount: 120, Author: "c", CreatedAt: now.Add(-5 * time.Hour), UpdatedAt: now},
+	})
+	if err := markItemDead(db, "3", time.Date(2026, 3, 4, 5, 6, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	if err := writeGraveyardFeed(db, outDir, 50, NewCategoryMapper(&DomainConfig{})); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, graveyardFeedFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := string(data)
+	for _, want := range []string{"Hacker News Graveyard", "Gone too soon", "300 points", "Dead or flagged since", "2026-03-04 05:06 UTC"} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected %q in the graveyard feed", want)
+		}
+	}
+}
+
+func TestItemCategoryList_Flagged(t *testing.T) {
+	item := HackerNewsItem{ItemID: "5", Title: "Flagged story", Link: "https://example.com/5", Points: 120}
+	if slices.Contains(itemCategoryList(item, 50, nil), "Flagged") {
+		t.Error("Expected no Flagged category for a live item")
+	}
+	item.DeadAt = time.Now()
+	if !slices.Contains(itemCategoryList(item, 50, nil), "Flagged") {
+		t.Error("Expected the Flagged category for a dead item")
+	}
+}
+
+func TestHandleGraveyardFeed(t *testing.T) {
+	server := setupTestServer(t)
+
+	get := func() string {
+		rec := httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graveyard.xml", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	if feed := get(); !strings.Contains(feed, "Hacker News Graveyard") || strings.Contains(feed, "<entry>") {
+		t.Errorf("Expected an empty graveyard feed, got:\n%s", feed)
+	}
+
+	// A refresh that marks a story dead bumps the data version, so the cached empty feed isn't served again
+	if err := markItemDead(server.db, "2", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	server.dataChanged()
+	feed := get()
+	if !strings.Contains(feed, "A Tweet") || !strings.Contains(feed, `term="Flagged"`) || strings.Contains(feed, "GitHub Project") {
+		t.Errorf("Expected only the dead story with the Flagged category, got:\n%s", feed)
+	}
+}
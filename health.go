@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds the database checks of a health request
+const healthCheckTimeout = 5 * time.Second
+
+// healthResponse is the body of /healthz
+type healthResponse struct {
+	Status      string  `json:"status"`   // ok, stale or unavailable
+	Database    string  `json:"database"` // ok, or why the database can't be reached
+	LastRun     *apiRun `json:"last_run,omitempty"`
+	LastSuccess *apiRun `json:"last_success,omitempty"`
+}
+
+// handleHealth reports database reachability and the last run. It responds 503 when the database is
+// unreachable, or when healthMaxAge is set and no update has succeeded within it.
+func (s *feedServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	health := s.checkHealth(ctx, time.Now())
+	status := http.StatusOK
+	if health.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+// checkHealth checks the database and looks up the last run and the last successful one
+func (s *feedServer) checkHealth(ctx context.Context, now time.Time) healthResponse {
+	unavailable := func(err error) healthResponse {
+		slog.Warn("Health check failed", "error", err)
+		return healthResponse{Status: "unavailable", Database: err.Error()}
+	}
+	if err := s.db.PingContext(ctx); err != nil {
+		return unavailable(err)
+	}
+	runs, err := listRuns(s.db, 1, 0)
+	if err != nil {
+		return unavailable(err)
+	}
+	lastSuccess, err := getLastSuccessfulRun(s.db)
+	if err != nil {
+		return unavailable(err)
+	}
+
+	health := healthResponse{Status: "ok", Database: "ok"}
+	if len(runs) > 0 {
+		run := apiRun(runs[0])
+		health.LastRun = &run
+	}
+	if lastSuccess != nil {
+		run := apiRun(*lastSuccess)
+		health.LastSuccess = &run
+	}
+	if s.healthMaxAge > 0 && (lastSuccess == nil || now.Sub(lastSuccess.FinishedAt) > s.healthMaxAge) {
+		health.Status = "stale"
+	}
+	return health
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// getHealth requests /healthz and decodes the response
+func getHealth(t *testing.T, server *feedServer) (int, healthResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var health healthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&health); err != nil {
+		t.Fatalf("Invalid health response: %v", err)
+	}
+	return rec.Code, health
+}
+
+func TestHandleHealth(t *testing.T) {
+	server := setupTestServer(t)
+
+	// A fresh install without runs is healthy as long as the database is reachable
+	code, health := getHealth(t, server)
+	if code != http.StatusOK || health.Status != "ok" || health.Database != "ok" || health.LastRun != nil {
+		t.Errorf("Unexpected health of a fresh install: %d %+v", code, health)
+	}
+
+	now := time.Now()
+	ok := RunRecord{Source: "serve", StartedAt: now.Add(-2 * time.Hour), FinishedAt: now.Add(-2 * time.Hour), FeedItems: 30}
+	failed := RunRecord{Source: "serve", StartedAt: now.Add(-time.Hour), FinishedAt: now.Add(-time.Hour), Error: "failed to fetch front page items"}
+	for _, run := range []*RunRecord{&ok, &failed} {
+		if err := recordRun(server.db, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	code, health = getHealth(t, server)
+	if code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("Expected a healthy status without a max age, got %d %+v", code, health)
+	}
+	if health.LastRun == nil || health.LastRun.ID != failed.ID || health.LastRun.Error == "" {
+		t.Errorf("Expected the failed run as the last run, got %+v", health.LastRun)
+	}
+	if health.LastSuccess == nil || health.LastSuccess.ID != ok.ID || health.LastSuccess.FeedItems != 30 {
+		t.Errorf("Expected the successful run as the last success, got %+v", health.LastSuccess)
+	}
+
+	server.healthMaxAge = 3 * time.Hour
+	if code, health = getHealth(t, server); code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("Expected a recent success to be healthy, got %d %+v", code, health)
+	}
+	server.healthMaxAge = time.Hour
+	if code, health = getHealth(t, server); code != http.StatusServiceUnavailable || health.Status != "stale" {
+		t.Errorf("Expected an old success to be stale, got %d %+v", code, health)
+	}
+}
+
+func TestHandleHealth_DatabaseUnavailable(t *testing.T) {
+	server := setupTestServer(t)
+	_ = server.db.Close()
+
+	code, health := getHealth(t, server)
+	if code != http.StatusServiceUnavailable || health.Status != "unavailable" || health.Database == "ok" {
+		t.Errorf("Expected an unreachable database to be unhealthy, got %d %+v", code, health)
+	}
+}
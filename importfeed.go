@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxImportFeedBytes limits how much of a feed is read
+const maxImportFeedBytes = 50 << 20
+
+var (
+	hnItemURLPattern      = regexp.MustCompile(`^https?://news\.ycombinator\.com/item\?id=(\d+)`)
+	importPointsPattern   = regexp.MustCompile(`(?i)(?:(\d+)\s+points?\b|points:\s*(\d+))`)
+	importCommentsPattern = regexp.MustCompile(`(?i)(?:(\d+)\s+comments?\b|comments:\s*(\d+))`)
+)
+
+// importFeedDocument holds the entries of an Atom feed or the items of an RSS 2.0 or RSS 1.0 feed
+type importFeedDocument struct {
+	XMLName     xml.Name
+	AtomEntries []importAtomEntry `xml:"entry"`
+	RSSItems    []importRSSItem   `xml:"channel>item"`
+	RDFItems    []importRSSItem   `xml:"item"`
+}
+
+type importAtomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Author  string `xml:"author>name"`
+	Content string `xml:"content"`
+	Summary string `xml:"summary"`
+}
+
+type importRSSItem struct {
+	Title       string   `xml:"title"`
+	Links       []string `xml:"link"`
+	Comments    string   `xml:"comments"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"date"` // dc:date
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"creator"` // dc:creator
+	Description string   `xml:"description"`
+	Encoded     string   `xml:"encoded"` // content:encoded
+}
+
+// importedEntry is a feed entry reduced to the fields items are built from
+type importedEntry struct {
+	title     string
+	links     []string // candidate links, most specific first
+	author    string
+	published string
+	updated   string
+	body      string // HTML content or description
+}
+
+// parseImportFeed parses an Atom or RSS feed into items. Entries that can't be tied to a
+// Hacker News item are skipped; the number skipped is returned alongside the items.
+func parseImportFeed(data []byte, now time.Time) ([]HackerNewsItem, int, error) {
+	var doc importFeedDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var entries []importedEntry
+	switch doc.XMLName.Local {
+	case "feed":
+		for _, e := range doc.AtomEntries {
+			entry := importedEntry{title: e.Title, author: e.Author, published: e.Published, updated: e.Updated, body: e.Content}
+			if entry.body == "" {
+				entry.body = e.Summary
+			}
+			for _, link := range e.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					entry.links = append(entry.links, link.Href)
+				}
+			}
+			entry.links = append(entry.links, e.ID)
+			entries = append(entries, entry)
+		}
+	case "rss", "RDF":
+		for _, i := range append(doc.RSSItems, doc.RDFItems...) {
+			entry := importedEntry{title: i.Title, author: i.Creator, published: i.PubDate, body: i.Encoded}
+			if entry.author == "" {
+				entry.author = i.Author
+			}
+			if entry.published == "" {
+				entry.published = i.Date
+			}
+			if entry.body == "" {
+				entry.body = i.Description
+			}
+			for _, link := range i.Links {
+				if link = strings.TrimSpace(link); link != "" {
+					entry.links = append(entry.links, link)
+				}
+			}
+			entry.links = append(entry.links, i.Comments, i.GUID)
+			entries = append(entries, entry)
+		}
+	default:
+		return nil, 0, fmt.Errorf("unsupported feed format: root element <%s>", doc.XMLName.Local)
+	}
+
+	var items []HackerNewsItem
+	skipped := 0
+	for _, entry := range entries {
+		item, ok := importedItem(entry, now)
+		if !ok {
+			slog.Debug("Skipping feed entry without a Hacker News item link", "title", entry.title)
+			skipped++
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, skipped, nil
+}
+
+// importedItem builds an item from a feed entry. The HN item is identified from the entry links or,
+// failing that, links in the content. The article URL is the first link that isn't an HN item,
+// so text posts (whose only links are to HN) get an empty link as when fetched from Algolia.
+func importedItem(entry importedEntry, now time.Time) (HackerNewsItem, bool) {
+	var contentLinks []string
+	var originalTitle, text string
+	if doc, err := html.Parse(strings.NewReader(entry.body)); err == nil {
+		contentLinks, originalTitle = scanImportContent(doc)
+		text = strings.Join(strings.Fields(nodeText(doc)), " ")
+	}
+
+	var itemID, article string
+	for _, link := range append(entry.links, contentLinks...) {
+		link = strings.TrimSpace(link)
+		if match := hnItemURLPattern.FindStringSubmatch(link); match != nil {
+			if itemID == "" {
+				itemID = match[1]
+			}
+			continue
+		}
+		if article == "" && (strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://")) {
+			article = link
+		}
+	}
+	if itemID == "" {
+		return HackerNewsItem{}, false
+	}
+
+	// Translated feeds keep the original title in the content
+	title := strings.TrimSpace(entry.title)
+	if originalTitle != "" {
+		title = originalTitle
+	}
+
+	createdAt := parseFeedTime(entry.published)
+	updatedAt := parseFeedTime(entry.updated)
+	if createdAt.IsZero() {
+		createdAt = updatedAt
+	}
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	if updatedAt.IsZero() || updatedAt.Before(createdAt) {
+		updatedAt = createdAt
+	}
+
+	return HackerNewsItem{
+		ItemID:       itemID,
+		Title:        title,
+		Link:         article,
+		CommentsLink: "https://news.ycombinator.com/item?id=" + itemID,
+		Points:       firstPatternNumber(importPointsPattern, text),
+		CommentCount: firstPatternNumber(importCommentsPattern, text),
+		Author:       strings.TrimSpace(entry.author),
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}, true
+}
+
+// scanImportContent returns the link targets in entry content and the original title
+// shown by translated feeds, if any
+func scanImportContent(doc *html.Node) ([]string, string) {
+	var links []string
+	var originalTitle string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "a":
+				for _, attr := range n.Attr {
+					if attr.Key == "href" {
+						links = append(links, attr.Val)
+					}
+				}
+			case "em":
+				// The original title follows an "Original title:" label in the same element
+				if strings.TrimSpace(nodeText(n)) == "Original title:" && n.Parent != nil && originalTitle == "" {
+					text := strings.Join(strings.Fields(nodeText(n.Parent)), " ")
+					originalTitle = strings.TrimSpace(strings.TrimPrefix(text, "Original title:"))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return links, originalTitle
+}
+
+// firstPatternNumber returns the number captured by the first match of a pattern, or 0
+func firstPatternNumber(pattern *regexp.Regexp, text string) int {
+	match := pattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	for _, group := range match[1:] {
+		if n, err := strconv.Atoi(group); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// parseFeedTime parses Atom (RFC 3339) and RSS (RFC 822 and variants) timestamps, returning zero if none match
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	layouts := []string{time.RFC3339, time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", time.RFC822Z, time.RFC822}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// readImportSource reads a feed from a local file or an http(s) URL
+func readImportSource(source string, timeout time.Duration) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/atom+xml, application/rss+xml, application/xml;q=0.9, */*;q=0.8")
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.Body, maxImportFeedBytes)); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// importItems inserts items that aren't in the database yet. Existing items are left alone
+// since their stored stats are at least as fresh as a feed snapshot.
+func importItems(db *sql.DB, items []HackerNewsItem) (int, error) {
+	inserted := 0
+	for _, item := range items {
+		added, err := insertItemIfMissing(db, item)
+		if err != nil {
+			return inserted, fmt.Errorf("failed to import item %s: %w", item.ItemID, err)
+		}
+		if added {
+			inserted++
+		}
+	}
+	return inserted, nil
+}
+
+// runImport handles the import subcommand
+func runImport(args []string) {
+	if len(args) < 1 || args[0] != "feed" {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss import feed [options] <file-or-url>")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("import feed", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "enable debug logging")
+	timeout := fs.Duration("timeout", defaultAlgoliaTimeout, "timeout for fetching a feed URL")
+	proxy := addProxyFlag(fs)
+	_ = fs.Parse(args[1:])
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss import feed [options] <file-or-url>")
+		os.Exit(2)
+	}
+	source := fs.Arg(0)
+
+	data, err := readImportSource(source, *timeout)
+	if err != nil {
+		slog.Error("Failed to read feed", "source", source, "error", err)
+		os.Exit(1)
+	}
+	items, skipped, err := parseImportFeed(data, time.Now())
+	if err != nil {
+		slog.Error("Failed to parse feed", "source", source, "error", err)
+		os.Exit(1)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	inserted, err := importItems(db, items)
+	if err != nil {
+		slog.Error("Failed to import items", "error", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d new items from %s (%d already stored, %d entries without a Hacker News link skipped)\n",
+		inserted, source, len(items)-inserted, skipped)
+}
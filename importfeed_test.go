@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseImportFeed_GeneratedFeed(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	original := []HackerNewsItem{
+		{ItemID: "101", Title: "Rust & Go <compared>", Link: "https://example.com/article", CommentsLink: "https://news.ycombinator.com/item?id=101", Points: 150, CommentCount: 42, Author: "alice", CreatedAt: now.Add(-2 * time.Hour)},
+		{ItemID: "102", Title: "Ask HN: Favorite editor?", CommentsLink: "https://news.ycombinator.com/item?id=102", Points: 80, CommentCount: 120, Author: "bob", CreatedAt: now.Add(-time.Hour)},
+	}
+	feed := generateRSSFeed(nil, original, 50, nil)
+
+	items, skipped, err := parseImportFeed([]byte(feed), now)
+	if err != nil {
+		t.Fatalf("parseImportFeed failed: %v", err)
+	}
+	if skipped != 0 || len(items) != 2 {
+		t.Fatalf("Expected 2 items and none skipped, got %d items and %d skipped", len(items), skipped)
+	}
+
+	for i, want := range original {
+		got := items[i]
+		if got.ItemID != want.ItemID || got.Title != want.Title || got.Link != want.Link || got.CommentsLink != want.CommentsLink {
+			t.Errorf("Item %d: got %+v, want %+v", i, got, want)
+		}
+		if got.Points != want.Points || got.CommentCount != want.CommentCount || got.Author != want.Author {
+			t.Errorf("Item %d: got stats %d/%d by %q, want %d/%d by %q", i, got.Points, got.CommentCount, got.Author, want.Points, want.CommentCount, want.Author)
+		}
+		if !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("Item %d: got created %v, want %v", i, got.CreatedAt, want.CreatedAt)
+		}
+	}
+}
+
+func TestParseImportFeed_RSS(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Hacker News: Front Page</title>
+    <item>
+      <title>Show HN: A thing</title>
+      <description><![CDATA[<p>Article URL: <a href="https://thing.example/">https://thing.example/</a></p><p>Points: 321</p><p># Comments: 54</p>]]></description>
+      <pubDate>Fri, 01 Mar 2024 10:00:00 +0000</pubDate>
+      <dc:creator>carol</dc:creator>
+      <link>https://thing.example/</link>
+      <comments>https://news.ycombinator.com/item?id=555</comments>
+      <guid isPermaLink="false">https://news.ycombinator.com/item?id=555</guid>
+    </item>
+    <item>
+      <title>Unrelated</title>
+      <link>https://elsewhere.example/</link>
+    </item>
+  </channel>
+</rss>`
+
+	items, skipped, err := parseImportFeed([]byte(feed), time.Now())
+	if err != nil {
+		t.Fatalf("parseImportFeed failed: %v", err)
+	}
+	if len(items) != 1 || skipped != 1 {
+		t.Fatalf("Expected 1 item and 1 skipped, got %d and %d", len(items), skipped)
+	}
+	item := items[0]
+	if item.ItemID != "555" || item.Link != "https://thing.example/" || item.Author != "carol" {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+	if item.Points != 321 || item.CommentCount != 54 {
+		t.Errorf("Expected 321 points and 54 comments, got %d and %d", item.Points, item.CommentCount)
+	}
+	if !item.CreatedAt.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected created time %v", item.CreatedAt)
+	}
+}
+
+func TestParseImportFeed_Unsupported(t *testing.T) {
+	if _, _, err := parseImportFeed([]byte(`<html><body>nope</body></html>`), time.Now()); err == nil {
+		t.Error("Expected an error for a non-feed document")
+	}
+	if _, _, err := parseImportFeed([]byte(`not xml`), time.Now()); err == nil {
+		t.Error("Expected an error for invalid XML")
+	}
+}
+
+func TestScanImportContent_OriginalTitle(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<div><div style="color: #828282;"><em>Original title:</em> Bonjour le monde</div><a href="https://example.com/">x</a></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, originalTitle := scanImportContent(doc)
+	if originalTitle != "Bonjour le monde" {
+		t.Errorf("Expected original title, got %q", originalTitle)
+	}
+	if len(links) != 1 || links[0] != "https://example.com/" {
+		t.Errorf("Unexpected links %v", links)
+	}
+}
+
+func TestParseFeedTime(t *testing.T) {
+	want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, value := range []string{"2024-03-01T10:00:00Z", "Fri, 01 Mar 2024 10:00:00 +0000", "Fri, 1 Mar 2024 10:00:00 +0000", " 2024-03-01T12:00:00+02:00 "} {
+		if got := parseFeedTime(value); !got.Equal(want) {
+			t.Errorf("parseFeedTime(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if !parseFeedTime("yesterday").IsZero() {
+		t.Error("Expected zero time for an unparseable value")
+	}
+}
+
+func TestImportItems_KeepsExisting(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	stored := HackerNewsItem{ItemID: "1", Title: "Stored", Link: "https://example.com/1", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 500, CreatedAt: now, UpdatedAt: now}
+	updateStoredItems(db, []HackerNewsItem{stored})
+
+	imported := []HackerNewsItem{
+		{ItemID: "1", Title: "Stale", Link: "https://example.com/1", CommentsLink: stored.CommentsLink, Points: 100, CreatedAt: now, UpdatedAt: now},
+		{ItemID: "2", Title: "New", Link: "https://example.com/2", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 90, CreatedAt: now, UpdatedAt: now},
+	}
+	inserted, err := importItems(db, imported)
+	if err != nil {
+		t.Fatalf("importItems failed: %v", err)
+	}
+	if inserted != 1 {
+		t.Errorf("Expected 1 inserted item, got %d", inserted)
+	}
+
+	item, err := getItemByID(db, "1")
+	if err != nil || item == nil {
+		t.Fatalf("Failed to load stored item: %v", err)
+	}
+	if item.Title != "Stored" || item.Points != 500 {
+		t.Errorf("Expected the stored item to be kept, got %q with %d points", item.Title, item.Points)
+	}
+	if item, _ := getItemByID(db, "2"); item == nil || item.Title != "New" {
+		t.Error("Expected the new item to be imported")
+	}
+}
+
+func TestReadImportSource_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hntop30.xml")
+	if err := os.WriteFile(path, []byte("<feed/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := readImportSource(path, time.Second)
+	if err != nil || string(data) != "<feed/>" {
+		t.Errorf("Expected file contents, got %q, %v", data, err)
+	}
+	if _, err := readImportSource(filepath.Join(t.TempDir(), "missing.xml"), time.Second); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
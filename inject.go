@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// algoliaSearchURL is the Algolia endpoint for searching stories by relevance
+var algoliaSearchURL = "https://hn.algolia.com/api/v1/search"
+
+var (
+	// errStoryNotFound is returned when no Hacker News story matches a reference
+	errStoryNotFound = errors.New("no matching Hacker News story found")
+	// errInvalidStoryRef is returned for references that are neither item IDs nor URLs
+	errInvalidStoryRef = errors.New("not an item ID or article URL")
+)
+
+// injectRequest is the body of POST /api/items; exactly one field is set
+type injectRequest struct {
+	ID  string `json:"id"`  // Hacker News item ID or item URL
+	URL string `json:"url"` // article URL submitted to Hacker News
+}
+
+// searchStories runs an Algolia search and returns the hits
+func searchStories(ctx context.Context, client *http.Client, params url.Values) ([]AlgoliaHit, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, algoliaSearchURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, recordFetchError(fetchSourceAlgolia, fmt.Errorf("HTTP request failed: %w", err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, recordFetchError(fetchSourceAlgolia, httpStatusError(resp.StatusCode))
+	}
+
+	var result AlgoliaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return result.Hits, nil
+}
+
+// findStory looks up a story by Hacker News item ID, item URL or article URL.
+// An article submitted several times resolves to its highest scoring submission.
+func findStory(ctx context.Context, client *http.Client, ref string) (AlgoliaHit, error) {
+	ref = strings.TrimSpace(ref)
+	itemID := ref
+	if match := hnItemURLPattern.FindStringSubmatch(ref); match != nil {
+		itemID = match[1]
+	}
+
+	if isNumericID(itemID) {
+		hits, err := searchStories(ctx, client, url.Values{"tags": {"story,story_" + itemID}})
+		if err != nil {
+			return AlgoliaHit{}, err
+		}
+		for _, hit := range hits {
+			if hit.ObjectID == itemID {
+				return hit, nil
+			}
+		}
+		return AlgoliaHit{}, errStoryNotFound
+	}
+
+	article, err := normalizeArticleURL(ref)
+	if err != nil {
+		return AlgoliaHit{}, fmt.Errorf("%w: %v", errInvalidStoryRef, err)
+	}
+	hits, err := searchStories(ctx, client, url.Values{
+		"query":                        {article},
+		"restrictSearchableAttributes": {"url"},
+		"tags":                         {"story"},
+		"hitsPerPage":                  {"50"},
+	})
+	if err != nil {
+		return AlgoliaHit{}, err
+	}
+
+	// The search is fuzzy, so only submissions of the same article count
+	var best *AlgoliaHit
+	for i, hit := range hits {
+		if normalizeSubmissionURL(hit.URL) != normalizeSubmissionURL(article) {
+			continue
+		}
+		if best == nil || hit.Points > best.Points {
+			best = &hits[i]
+		}
+	}
+	if best == nil {
+		return AlgoliaHit{}, errStoryNotFound
+	}
+	return *best, nil
+}
+
+// isNumericID reports whether s is a plausible Hacker News item ID
+func isNumericID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// injectStory fetches the current stats of a story, stores it and pins it into the feed
+func injectStory(db *sql.DB, ref string, timeout time.Duration, now time.Time) (HackerNewsItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	hit, err := findStory(ctx, &http.Client{}, ref)
+	if err != nil {
+		return HackerNewsItem{}, err
+	}
+
+	item, err := itemFromHit(hit, now)
+	if err != nil {
+		return HackerNewsItem{}, err
+	}
+	updateStoredItems(db, []HackerNewsItem{item})
+	if err := pinItem(db, item.ItemID, now); err != nil {
+		return HackerNewsItem{}, err
+	}
+	item.PinnedAt = now
+	slog.Info("Pinned item into the feed", "hn_id", item.ItemID, "title", item.Title, "points", item.Points)
+	return item, nil
+}
+
+// handleAPIInjectItem adds a story to the feed by item ID or URL. The story is stored and
+// pinned right away; summaries and translations are generated in the background.
+func (s *feedServer) handleAPIInjectItem(w http.ResponseWriter, r *http.Request) {
+	var req injectRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	ref := strings.TrimSpace(req.ID)
+	if ref == "" {
+		ref = strings.TrimSpace(req.URL)
+	}
+	if ref == "" || (req.ID != "" && req.URL != "") {
+		writeJSONError(w, http.StatusBadRequest, "either id or url is required")
+		return
+	}
+
+	defaults, categoryMapper := s.settings()
+	item, err := injectStory(s.db, ref, categoryMapper.Timeouts().Algolia, time.Now())
+	switch {
+	case errors.Is(err, errStoryNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, errInvalidStoryRef):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		slog.Warn("Failed to inject item", "ref", ref, "error", err)
+		writeJSONError(w, http.StatusBadGateway, "failed to fetch the story from Algolia")
+		return
+	}
+	s.dataChanged()
+
+	go safely("item enrichment", func() {
+		enrichItems(s.db, []HackerNewsItem{item}, categoryMapper)
+		s.dataChanged()
+	}, itemLogAttrs(item)...)
+
+	writeJSON(w, http.StatusCreated, toAPIItem(item, defaults.MinPoints, categoryMapper))
+}
+
+// runAdd handles the add subcommand, which pins stories into the feed from the command line
+func runAdd(args []string) {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "enable debug logging")
+	timeout := fs.Duration("timeout", defaultAlgoliaTimeout, "timeout for looking up each story on Algolia")
+	proxy := addProxyFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss add [options] <hn-id|item-url|article-url>...")
+		os.Exit(2)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	failed := false
+	for _, ref := range fs.Args() {
+		item, err := injectStory(db, ref, *timeout, time.Now())
+		if err != nil {
+			slog.Error("Failed to add story", "ref", ref, "error", err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Pinned %s: %s (%d points)\n", item.ItemID, item.Title, item.Points)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestAlgoliaSearch points the Algolia search endpoint at a test server answering with the given
+// stories. Like the real search, URL queries are fuzzy: every story is returned.
+func useTestAlgoliaSearch(t *testing.T, stories []AlgoliaHit) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hits []AlgoliaHit
+		tags := r.URL.Query().Get("tags")
+		for _, story := range stories {
+			if tags == "story" || tags == "story,story_"+story.ObjectID {
+				hits = append(hits, story)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(AlgoliaResponse{Hits: hits})
+	}))
+	original := algoliaSearchURL
+	algoliaSearchURL = server.URL
+	t.Cleanup(func() {
+		algoliaSearchURL = original
+		server.Close()
+	})
+}
+
+var testInjectStories = []AlgoliaHit{
+	{ObjectID: "100", Title: "Quiet Story", URL: "https://example.com/quiet", Author: "alice", Points: 3, NumComments: 1, CreatedAt: "2024-01-10T08:00:00Z"},
+	{ObjectID: "200", Title: "First Submission", URL: "https://www.example.org/post/", Author: "bob", Points: 5, CreatedAt: "2024-01-11T08:00:00Z"},
+	{ObjectID: "201", Title: "Second Submission", URL: "https://example.org/post", Author: "carol", Points: 40, CreatedAt: "2024-01-12T08:00:00Z"},
+	{ObjectID: "300", Title: "Similar URL", URL: "https://example.org/post/other", Author: "dave", Points: 500, CreatedAt: "2024-01-12T08:00:00Z"},
+}
+
+func TestFindStory(t *testing.T) {
+	useTestAlgoliaSearch(t, testInjectStories)
+
+	testCases := []struct {
+		ref      string
+		expected string
+	}{
+		{"100", "100"},
+		{" https://news.ycombinator.com/item?id=100 ", "100"},
+		{"https://example.org/post", "201"},
+		{"https://EXAMPLE.org/post/#comments", "201"},
+	}
+	for _, tc := range testCases {
+		hit, err := findStory(t.Context(), http.DefaultClient, tc.ref)
+		if err != nil {
+			t.Errorf("findStory(%q) failed: %v", tc.ref, err)
+			continue
+		}
+		if hit.ObjectID != tc.expected {
+			t.Errorf("findStory(%q) = %s, expected %s", tc.ref, hit.ObjectID, tc.expected)
+		}
+	}
+
+	if _, err := findStory(t.Context(), http.DefaultClient, "999"); err != errStoryNotFound {
+		t.Errorf("Expected errStoryNotFound for an unknown ID, got %v", err)
+	}
+	if _, err := findStory(t.Context(), http.DefaultClient, "https://unknown.example.net/"); err != errStoryNotFound {
+		t.Errorf("Expected errStoryNotFound for an unknown URL, got %v", err)
+	}
+	if _, err := findStory(t.Context(), http.DefaultClient, "ftp://example.com"); !errors.Is(err, errInvalidStoryRef) {
+		t.Errorf("Expected errInvalidStoryRef, got %v", err)
+	}
+}
+
+func TestInjectStory_PinsBelowThreshold(t *testing.T) {
+	useTestAlgoliaSearch(t, testInjectStories)
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	item, err := injectStory(db, "100", time.Second, now)
+	if err != nil {
+		t.Fatalf("injectStory failed: %v", err)
+	}
+	if item.Title != "Quiet Story" || item.CommentsLink != "https://news.ycombinator.com/item?id=100" || item.PinnedAt.IsZero() {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	// The story is weeks old and far below the threshold but still makes the feed
+	items, err := getFilteredItems(db, ItemFilter{Limit: 30, MinPoints: 100, MinAge: time.Hour, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ItemID != "100" || items[0].PinnedAt.IsZero() {
+		t.Fatalf("Expected the pinned story in the feed, got %+v", items)
+	}
+
+	// Stories submitted before the pin don't push it out of a full feed
+	var newer []HackerNewsItem
+	for _, id := range []string{"1", "2", "3"} {
+		newer = append(newer, HackerNewsItem{ItemID: id, Title: "Newer " + id, Points: 500, CreatedAt: now.Add(-time.Minute), UpdatedAt: now})
+	}
+	updateStoredItems(db, newer)
+	items, err = getFilteredItems(db, ItemFilter{Limit: 2, MinPoints: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ItemID != "100" {
+		t.Errorf("Expected the pinned story first in a full feed, got %+v", items)
+	}
+}
+
+func TestHandleAPIInjectItem(t *testing.T) {
+	useTestAlgoliaSearch(t, testInjectStories)
+	server := setupTestServer(t)
+	server.auth["api"] = authRule{scheme: "bearer", token: "secret"}
+	if err := cacheOpenGraphData(server.db, &OpenGraphData{URL: "https://example.org/post"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"id": "100"}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", rec.Code)
+	}
+
+	rec := post(`{"url": "https://example.org/post"}`, "secret")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created apiItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "201" || created.PinnedAt == nil {
+		t.Errorf("Unexpected response: %+v", created)
+	}
+
+	feed := httptest.NewRecorder()
+	server.routes().ServeHTTP(feed, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	if !strings.Contains(feed.Body.String(), "Second Submission") {
+		t.Error("Expected the injected story in the feed")
+	}
+
+	errorCases := []struct {
+		body     string
+		expected int
+	}{
+		{`{"id": "999"}`, http.StatusNotFound},
+		{`{"url": "mailto:someone@example.com"}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+		{`{"id": "100", "url": "https://example.com/quiet"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tc := range errorCases {
+		if rec := post(tc.body, "secret"); rec.Code != tc.expected {
+			t.Errorf("POST %s: expected %d, got %d", tc.body, tc.expected, rec.Code)
+		}
+	}
+}
+
+func TestHandleAPIInjectItem_DisabledWithoutAuth(t *testing.T) {
+	server := setupTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader(`{"id": "1"}`)))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 without an api auth rule, got %d", rec.Code)
+	}
+}
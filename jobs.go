@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// jobsFeedLimit bounds how many job posts the jobs feed lists
+const jobsFeedLimit = 50
+
+// jobsFeedFile is the name of the jobs feed in the output directory
+const jobsFeedFile = "hn-jobs.xml"
+
+// jobsFeedInfo describes the feed of YC job posts
+var jobsFeedInfo = feedInfo{
+	Title:       "Hacker News Jobs",
+	Description: "Job posts from YC companies on Hacker News, which can't be voted on and never reach the points threshold",
+	ID:          "tag:news.ycombinator.com,2024:jobs",
+}
+
+// jobTitleRegex matches the company and YC batch in job titles, e.g. "Acme (YC W21) Is Hiring"
+var jobTitleRegex = regexp.MustCompile(`^(.+?)\s+\(YC ([A-Z]\d{2})\)`)
+
+// jobCompanyCategories returns the company of a job post and its YC batch, e.g.
+// "Company: Acme" and "YC W21", or nothing for titles without a company
+func jobCompanyCategories(title string) []string {
+	matches := jobTitleRegex.FindStringSubmatch(title)
+	if matches == nil {
+		return nil
+	}
+	return []string{"Company: " + matches[1], "YC " + matches[2]}
+}
+
+// getJobItems returns the live job posts, newest first
+func getJobItems(db *sql.DB, limit int) ([]HackerNewsItem, error) {
+	rows, err := db.Query("SELECT "+itemColumns+" WHERE items.dead_at IS NULL AND items.story_type = ? ORDER BY items.created_at DESC LIMIT ?", string(StoryTypeJob), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []HackerNewsItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// writeJobsFeed fetches the newest job posts from Algolia, stores them and writes the jobs feed
+// to outDir. Job posts have no points, so the feed lists them regardless of the threshold.
+func writeJobsFeed(db *sql.DB, outDir string, categoryMapper *CategoryMapper, timeout time.Duration) error {
+	// A failed fetch still writes the feed from the job posts stored by earlier runs
+	if jobs := fetchHackerNewsItems(FetchConfig{Tags: string(StoryTypeJob), Endpoint: defaultStorySetEndpoint}, timeout); jobs != nil {
+		updateStoredItems(db, jobs)
+	}
+
+	items, err := getJobItems(db, jobsFeedLimit)
+	if err != nil {
+		return err
+	}
+	feed := generateFeed(db, items, 0, categoryMapper, jobsFeedInfo)
+	filename := filepath.Join(outDir, jobsFeedFile)
+	written, err := writeFeedFile(filename, feed)
+	if err != nil {
+		return fmt.Errorf("failed to write jobs feed: %w", err)
+	}
+	if written {
+		slog.Info("Jobs feed saved", "count", len(items), "filename", filename)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobCompanyCategories(t *testing.T) {
+	testCases := []struct {
+		title string
+		want  []string
+	}{
+		{"Acme (YC W21) Is Hiring Backend Engineers", []string{"Company: Acme", "YC W21"}},
+		{"Rocket Labs Inc. (YC S19) is hiring a founding designer", []string{"Company: Rocket Labs Inc.", "YC S19"}},
+		{"We are hiring", nil},
+	}
+
+	for _, tc := range testCases {
+		if got := jobCompanyCategories(tc.title); !slices.Equal(got, tc.want) {
+			t.Errorf("jobCompanyCategories(%q) = %v, want %v", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestItemCategoryList_Job(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{CategoryDomains: map[string][]string{"Startups": {"acme.com"}}})
+	item := HackerNewsItem{ItemID: "7", Title: "Acme (YC W21) Is Hiring", Link: "https://acme.com/careers", Type: StoryTypeJob}
+
+	categories := itemCategoryList(item, 50, mapper)
+	for _, want := range []string{"acme.com", "Startups", "Company: Acme", "YC W21", "Job"} {
+		if !slices.Contains(categories, want) {
+			t.Errorf("Expected %q in %v", want, categories)
+		}
+	}
+	if slices.Contains(categories, "Rising") {
+		t.Errorf("Expected no points category for a job post, got %v", categories)
+	}
+}
+
+func TestWriteJobsFeed(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+	useDisplayLocation(t, "UTC")
+
+	now := time.Now().UTC()
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		_ = json.NewEncoder(w).Encode(AlgoliaResponse{Hits: []AlgoliaHit{
+			{ObjectID: "900", Title: "Acme (YC W21) Is Hiring", URL: "https://acme.com/careers", Author: "acme", CreatedAt: now.Add(-time.Hour).Format(time.RFC3339), Tags: []string{"job"}},
+		}})
+	}))
+	defer server.Close()
+	original := algoliaAPIURL
+	algoliaAPIURL = server.URL
+	defer func() { algoliaAPIURL = original }()
+
+	// A job post stored by an earlier run stays in the feed, while stories stay out of it
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "800", Title: "Widgets (YC S19) is hiring", Link: "https://widgets.io/jobs", CommentsLink: "https://news.ycombinator.com/item?id=800", Author: "w", CreatedAt: now.Add(-48 * time.Hour), UpdatedAt: now, Type: StoryTypeJob},
+		{ItemID: "1", Title: "A popular story", Link: "https://example.com/1", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 300, Author: "a", CreatedAt: now, UpdatedAt: now, Type: StoryTypeStory},
+	})
+
+	outDir := t.TempDir()
+	if err := writeJobsFeed(db, outDir, NewCategoryMapper(&DomainConfig{}), 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if requested != "/search_by_date?hitsPerPage=100&tags=job" {
+		t.Errorf("Unexpected request %q", requested)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, jobsFeedFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := string(data)
+	for _, want := range []string{"Hacker News Jobs", "Acme (YC W21) Is Hiring", "Widgets (YC S19) is hiring", "Company: Acme", "YC S19"} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("Expected %q in the jobs feed", want)
+		}
+	}
+	if strings.Contains(feed, "A popular story") {
+		t.Error("Expected stories to stay out of the jobs feed")
+	}
+	if strings.Index(feed, "Acme") > strings.Index(feed, "Widgets") {
+		t.Error("Expected the newest job post first")
+	}
+
+	// Job posts have no points, so they never reach the regular feeds
+	stored, err := getFilteredItems(db, ItemFilter{Limit: 10, MinPoints: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range stored {
+		if item.Type == StoryTypeJob {
+			t.Errorf("Expected no job posts in the regular feed, got %+v", item)
+		}
+	}
+}
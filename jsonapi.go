@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// apiScanLimit bounds how many stored items are considered for a single API query
+const apiScanLimit = 1000
+
+// apiItem is the JSON representation of a stored item
+type apiItem struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	CommentsURL  string     `json:"comments_url"`
+	Points       int        `json:"points"`
+	CommentCount int        `json:"comment_count"`
+	Author       string     `json:"author"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Categories   []string   `json:"categories"`
+	Type         StoryType  `json:"type"`
+	PinnedAt     *time.Time `json:"pinned_at,omitempty"`
+	DeadAt       *time.Time `json:"dead_at,omitempty"`
+}
+
+// apiPage wraps a paginated list response
+type apiPage struct {
+	Total       int  `json:"total"`
+	TotalCapped bool `json:"total_capped,omitempty"` // total only covers the first apiScanLimit stored items
+	Limit       int  `json:"limit"`
+	Offset      int  `json:"offset"`
+	Data        any  `json:"data"`
+}
+
+// apiCategory is a category with the number of items it applies to
+type apiCategory struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// apiRun is the JSON representation of a run record
+type apiRun struct {
+	ID         int64     `json:"id"`
+	Source     string    `json:"source"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Fetched    int       `json:"fetched"`
+	Updated    int       `json:"updated"`
+	FeedItems  int       `json:"feed_items"`
+	Error      string    `json:"error,omitempty"`
+	// FetchErrors counts failed fetches during the run by "source/class"
+	FetchErrors map[string]int `json:"fetch_errors,omitempty"`
+}
+
+// registerAPIRoutes adds the JSON API routes to the mux
+func (s *feedServer) registerAPIRoutes(mux *http.ServeMux) {
+	mux.Handle("GET /api/items", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleAPIItems))))
+	mux.Handle("GET /api/items/{id}", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleAPIItem))))
+	mux.Handle("GET /api/categories", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleAPICategories))))
+	mux.Handle("GET /api/runs", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleAPIRuns))))
+
+	// Adding items changes the feed, so it is never registered without an api auth rule
+	if _, ok := s.auth["api"]; ok {
+		mux.Handle("POST /api/items", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleAPIInjectItem))))
+	}
+}
+
+// writeJSON encodes a value as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// writeJSONError writes an error message as a JSON response
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// parsePagination parses limit (1-100, default 30) and offset query parameters
+func parsePagination(values url.Values) (limit, offset int, err error) {
+	limit, offset = 30, 0
+	if v := values.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > 100 {
+			return 0, 0, fmt.Errorf("invalid limit: %q", v)
+		}
+	}
+	if v := values.Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %q", v)
+		}
+	}
+	return limit, offset, nil
+}
+
+// toAPIItem converts an item to its JSON representation
+func toAPIItem(item HackerNewsItem, minPoints int, categoryMapper *CategoryMapper) apiItem {
+	result := apiItem{
+		ID:           item.ItemID,
+		Title:        item.Title,
+		URL:          item.Link,
+		CommentsURL:  item.CommentsLink,
+		Points:       item.Points,
+		CommentCount: item.CommentCount,
+		Author:       item.Author,
+		CreatedAt:    item.CreatedAt,
+		UpdatedAt:    item.UpdatedAt,
+		Categories:   itemCategoryList(item, minPoints, categoryMapper),
+		Type:         itemStoryType(item),
+	}
+	if !item.PinnedAt.IsZero() {
+		result.PinnedAt = &item.PinnedAt
+	}
+	if !item.DeadAt.IsZero() {
+		result.DeadAt = &item.DeadAt
+	}
+	return result
+}
+
+// handleAPIItems lists stored items with min_points, category, exclude, type, q and dead filters and pagination
+func (s *feedServer) handleAPIItems(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query, err := s.parseFeedQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, offset, err := parsePagination(values)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query.keywords = splitQueryList(values["q"])
+	if v := values.Get("dead"); v != "" {
+		if query.dead, err = strconv.ParseBool(v); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid dead: %q", v))
+			return
+		}
+	}
+	data, total, capped, err := s.queryAPIItems(query, limit, offset)
+	if err != nil {
+		slog.Error("Failed to query items", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, apiPage{Total: total, TotalCapped: capped, Limit: limit, Offset: offset, Data: data})
+}
+
+// queryAPIItems returns one page of items matching the query along with the total match count.
+// Without category, type or keyword filters the total is counted in the database. Those filters
+// are applied in Go to the newest apiScanLimit items, so with them the total only covers that
+// scan, and capped reports whether the scan stopped short of the stored items.
+func (s *feedServer) queryAPIItems(query feedQuery, limit, offset int) (data []apiItem, total int, capped bool, err error) {
+	_, categoryMapper := s.settings()
+	scan := query.filter
+	scan.Limit = offset + limit
+	if query.hasItemFilters() {
+		scan.Limit = apiScanLimit
+	}
+
+	var items []HackerNewsItem
+	if query.dead {
+		// Dead items are kept for auditing and listed regardless of points
+		items, err = getDeadItems(s.db, scan.Limit)
+	} else {
+		items, err = getFilteredItems(s.db, scan)
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if query.hasItemFilters() {
+		capped = len(items) == apiScanLimit
+		items = filterItemsByCategory(items, query.include, query.exclude, scan.MinPoints, categoryMapper)
+		items = filterItemsByType(items, query.types)
+		items = filterItemsByKeywords(items, query.keywords)
+		total = len(items)
+	} else if query.dead {
+		total, err = countDeadItems(s.db)
+	} else {
+		total, err = countFilteredItems(s.db, scan)
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	data = []apiItem{}
+	for i := offset; i < len(items) && i < offset+limit; i++ {
+		data = append(data, toAPIItem(items[i], scan.MinPoints, categoryMapper))
+	}
+	return data, total, capped, nil
+}
+
+// categoryCounts returns the categories of items matching the filter, most common first
+func (s *feedServer) categoryCounts(filter ItemFilter) ([]apiCategory, error) {
+	_, categoryMapper := s.settings()
+	filter.Limit = apiScanLimit
+	items, err := getFilteredItems(s.db, filter)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, item := range items {
+		for _, category := range itemCategoryList(item, filter.MinPoints, categoryMapper) {
+			counts[category]++
+		}
+	}
+
+	categories := make([]apiCategory, 0, len(counts))
+	for name, count := range counts {
+		categories = append(categories, apiCategory{Name: name, Count: count})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Count != categories[j].Count {
+			return categories[i].Count > categories[j].Count
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	return categories, nil
+}
+
+// handleAPIItem returns a single item by Hacker News ID
+func (s *feedServer) handleAPIItem(w http.ResponseWriter, r *http.Request) {
+	item, err := getItemByID(s.db, r.PathValue("id"))
+	if err != nil {
+		slog.Error("Failed to load item", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if item == nil {
+		writeJSONError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	defaults, categoryMapper := s.settings()
+	writeJSON(w, http.StatusOK, toAPIItem(*item, defaults.MinPoints, categoryMapper))
+}
+
+// handleAPICategories returns all categories of stored items above the threshold with their counts
+func (s *feedServer) handleAPICategories(w http.ResponseWriter, r *http.Request) {
+	query, err := s.parseFeedQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	categories, err := s.categoryCounts(query.filter)
+	if err != nil {
+		slog.Error("Failed to count categories", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, categories)
+}
+
+// handleAPIRuns lists recorded fetch/update runs, most recent first
+func (s *feedServer) handleAPIRuns(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	runs, err := listRuns(s.db, limit, offset)
+	if err != nil {
+		slog.Error("Failed to list runs", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	total, err := countRuns(s.db)
+	if err != nil {
+		slog.Warn("Failed to count runs", "error", err)
+	}
+
+	data := make([]apiRun, 0, len(runs))
+	for _, run := range runs {
+		data = append(data, apiRun(run))
+	}
+	writeJSON(w, http.StatusOK, apiPage{Total: total, Limit: limit, Offset: offset, Data: data})
+}
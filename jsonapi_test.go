@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func getJSON(t *testing.T, server *feedServer, target string, dest any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if dest != nil && rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), dest); err != nil {
+			t.Fatalf("Failed to decode JSON from %s: %v", target, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestAPIItems_Dead(t *testing.T) {
+	server := setupTestServer(t)
+	diedAt := time.Now().UTC().Truncate(time.Second)
+	if err := markItemDead(server.db, "2", diedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	var page struct {
+		Total int       `json:"total"`
+		Data  []apiItem `json:"data"`
+	}
+	getJSON(t, server, "/api/items", &page)
+	if page.Total != 2 {
+		t.Errorf("Expected the dead item to be left out by default, got %+v", page.Data)
+	}
+
+	getJSON(t, server, "/api/items?dead=true", &page)
+	if page.Total != 1 || page.Data[0].ID != "2" || page.Data[0].DeadAt == nil || !page.Data[0].DeadAt.Equal(diedAt) {
+		t.Errorf("Expected only the dead item with its time of death, got %+v", page.Data)
+	}
+
+	// Dead items can still be looked up for auditing
+	var item apiItem
+	if code := getJSON(t, server, "/api/items/2", &item); code != http.StatusOK || item.DeadAt == nil {
+		t.Errorf("Expected the dead item by ID, got %d %+v", code, item)
+	}
+
+	if code := getJSON(t, server, "/api/items?dead=maybe", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid dead filter, got %d", code)
+	}
+}
+
+func TestAPIItems_FilterAndPaginate(t *testing.T) {
+	server := setupTestServer(t)
+
+	var page struct {
+		Total  int       `json:"total"`
+		Limit  int       `json:"limit"`
+		Offset int       `json:"offset"`
+		Data   []apiItem `json:"data"`
+	}
+
+	if code := getJSON(t, server, "/api/items", &page); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if page.Total != 3 || len(page.Data) != 3 {
+		t.Fatalf("Expected 3 items, got total=%d len=%d", page.Total, len(page.Data))
+	}
+	if page.Data[0].ID != "1" || len(page.Data[0].Categories) == 0 {
+		t.Errorf("Expected newest item first with categories, got %+v", page.Data[0])
+	}
+
+	getJSON(t, server, "/api/items?limit=1&offset=1", &page)
+	if page.Total != 3 || len(page.Data) != 1 || page.Data[0].ID != "2" {
+		t.Errorf("Unexpected page: total=%d data=%+v", page.Total, page.Data)
+	}
+
+	getJSON(t, server, "/api/items?exclude=Twitter&min_points=100", &page)
+	if page.Total != 1 || page.Data[0].ID != "1" {
+		t.Errorf("Expected only item 1, got %+v", page.Data)
+	}
+
+	getJSON(t, server, "/api/items?q=blog", &page)
+	if page.Total != 1 || page.Data[0].ID != "3" {
+		t.Errorf("Expected only item 3 for keyword search, got %+v", page.Data)
+	}
+
+	if code := getJSON(t, server, "/api/items?offset=-1", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for negative offset, got %d", code)
+	}
+}
+
+func TestAPIItems_TotalBeyondScan(t *testing.T) {
+	server := setupTestServer(t)
+	start := time.Now().Add(-48 * time.Hour)
+	items := make([]HackerNewsItem, apiScanLimit)
+	for i := range items {
+		id := strconv.Itoa(1000 + i)
+		items[i] = HackerNewsItem{ItemID: id, Title: "Story " + id, Link: "https://example.com/" + id, CommentsLink: "https://news.ycombinator.com/item?id=" + id, Points: 60, CreatedAt: start.Add(-time.Duration(i) * time.Minute)}
+	}
+	updateStoredItems(server.db, items)
+
+	var page struct {
+		Total       int       `json:"total"`
+		TotalCapped bool      `json:"total_capped"`
+		Data        []apiItem `json:"data"`
+	}
+
+	// Without Go-side filters the total is counted in the database and every page is reachable
+	getJSON(t, server, "/api/items?limit=5&offset=1000", &page)
+	if page.Total != apiScanLimit+3 || page.TotalCapped || len(page.Data) != 3 {
+		t.Errorf("Expected an exact total and the last page, got total=%d capped=%v len=%d", page.Total, page.TotalCapped, len(page.Data))
+	}
+
+	getJSON(t, server, "/api/items?q=story&limit=5", &page)
+	if page.Total != apiScanLimit-3 || !page.TotalCapped {
+		t.Errorf("Expected a capped total for a keyword search, got total=%d capped=%v", page.Total, page.TotalCapped)
+	}
+
+	getJSON(t, server, "/api/items?q=github", &page)
+	if page.Total != 1 || !page.TotalCapped {
+		t.Errorf("Expected the scan to be reported as capped, got total=%d capped=%v", page.Total, page.TotalCapped)
+	}
+}
+
+func TestAPIItem(t *testing.T) {
+	server := setupTestServer(t)
+
+	var item apiItem
+	if code := getJSON(t, server, "/api/items/2", &item); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if item.Title != "A Tweet" || item.Points != 120 {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+
+	if code := getJSON(t, server, "/api/items/999", nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown item, got %d", code)
+	}
+}
+
+func TestAPICategories(t *testing.T) {
+	server := setupTestServer(t)
+
+	var categories []apiCategory
+	if code := getJSON(t, server, "/api/categories", &categories); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+
+	counts := make(map[string]int)
+	for _, c := range categories {
+		counts[c.Name] = c.Count
+	}
+	if counts["GitHub"] != 1 || counts["Twitter"] != 1 {
+		t.Errorf("Expected GitHub and Twitter categories, got %+v", categories)
+	}
+	if counts["High Score 100+"] != 1 || counts["Hot 200+"] != 1 {
+		t.Errorf("Expected point categories, got %+v", categories)
+	}
+}
+
+func TestAPIRuns(t *testing.T) {
+	server := setupTestServer(t)
+
+	for i := range 3 {
+		run := RunRecord{Source: "cli", StartedAt: time.Now(), FinishedAt: time.Now(), Fetched: 30 + i}
+		if err := recordRun(server.db, &run); err != nil {
+			t.Fatalf("Failed to record run: %v", err)
+		}
+	}
+
+	var page struct {
+		Total int      `json:"total"`
+		Data  []apiRun `json:"data"`
+	}
+	if code := getJSON(t, server, "/api/runs?limit=2", &page); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if page.Total != 3 || len(page.Data) != 2 {
+		t.Fatalf("Expected 2 of 3 runs, got total=%d len=%d", page.Total, len(page.Data))
+	}
+	if page.Data[0].Fetched != 32 {
+		t.Errorf("Expected most recent run first, got %+v", page.Data[0])
+	}
+}
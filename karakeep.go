@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// karakeepTimeout bounds saving one story, including its preview image
+const karakeepTimeout = 30 * time.Second
+
+// karakeepMaxImageSize is the largest preview image uploaded as a bookmark banner
+const karakeepMaxImageSize = 5 << 20
+
+// KarakeepConfig enables saving feed stories as bookmarks in a Karakeep (formerly Hoarder) instance
+type KarakeepConfig struct {
+	Instance  string `json:"instance"`    // Karakeep URL, e.g. https://karakeep.example.com
+	APIKey    string `json:"api_key"`     // API key from User Settings → API Keys
+	MinPoints int    `json:"min_points"`  // only save stories with at least this many points
+	MaxPerRun int    `json:"max_per_run"` // bookmarks saved per run (default 5)
+}
+
+// karakeepClient calls the Karakeep REST API
+type karakeepClient struct {
+	client *http.Client
+	config KarakeepConfig
+}
+
+// call sends an API request and decodes the JSON response into out, if given
+func (k *karakeepClient) call(ctx context.Context, method, endpoint, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(k.config.Instance, "/")+"/api/v1"+endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+k.config.APIKey)
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: unexpected status code %d: %s", method, endpoint, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// callJSON sends an API request with a JSON body
+func (k *karakeepClient) callJSON(ctx context.Context, method, endpoint string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", endpoint, err)
+	}
+	return k.call(ctx, method, endpoint, "application/json", bytes.NewReader(body), out)
+}
+
+// createBookmark saves a link and returns its bookmark ID. Karakeep returns the existing bookmark
+// for a URL that is already saved, reported by created being false.
+func (k *karakeepClient) createBookmark(ctx context.Context, item HackerNewsItem) (id string, created bool, err error) {
+	request := map[string]string{
+		"type":  "link",
+		"url":   storyForItem(item).URL,
+		"title": item.Title,
+		"note":  fmt.Sprintf("%d points, %d comments on Hacker News: %s", item.Points, item.CommentCount, item.CommentsLink),
+	}
+	var bookmark struct {
+		ID            string `json:"id"`
+		AlreadyExists bool   `json:"alreadyExists"`
+	}
+	if err := k.callJSON(ctx, http.MethodPost, "/bookmarks", request, &bookmark); err != nil {
+		return "", false, err
+	}
+	return bookmark.ID, !bookmark.AlreadyExists, nil
+}
+
+// attachBanner uploads an image and attaches it to a bookmark as its banner, so the preview shows
+// before Karakeep has crawled the page itself
+func (k *karakeepClient) attachBanner(ctx context.Context, bookmarkID, imageURL string) error {
+	image, contentType, err := fetchPreviewImage(ctx, k.client, imageURL, karakeepMaxImageSize)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	filename := "preview"
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		filename += exts[0]
+	}
+	part, err := form.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename="%s"`, filename)},
+		"Content-Type":        {contentType},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+	if _, err := part.Write(image); err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	var asset struct {
+		AssetID string `json:"assetId"`
+	}
+	if err := k.call(ctx, http.MethodPost, "/assets", form.FormDataContentType(), &body, &asset); err != nil {
+		return err
+	}
+	attach := map[string]string{"id": asset.AssetID, "assetType": "bannerImage"}
+	return k.callJSON(ctx, http.MethodPost, "/bookmarks/"+bookmarkID+"/assets", attach, nil)
+}
+
+// saveToKarakeep bookmarks new stories with enough points when a Karakeep instance is configured,
+// with the cached OpenGraph image as the banner of new bookmarks
+func saveToKarakeep(db *sql.DB, items []HackerNewsItem, config KarakeepConfig, now time.Time) {
+	if config.Instance == "" || config.APIKey == "" {
+		return
+	}
+	var qualifying []HackerNewsItem
+	for _, item := range items {
+		if item.Points >= config.MinPoints {
+			qualifying = append(qualifying, item)
+		}
+	}
+
+	k := &karakeepClient{client: &http.Client{Timeout: karakeepTimeout}, config: config}
+	postNewStories(db, qualifying, "Karakeep", "karakeep_saved_at", config.MaxPerRun, now, func(item HackerNewsItem) error {
+		ctx, cancel := context.WithTimeout(context.Background(), karakeepTimeout)
+		defer cancel()
+		id, created, err := k.createBookmark(ctx, item)
+		if err != nil || !created || isTextPost(item) {
+			return err
+		}
+
+		// The bookmark is saved either way; a missing banner is filled in by Karakeep's own crawl
+		if ogData := cachedOpenGraph(db, item.Link); ogData != nil && ogData.Image != "" {
+			if err := k.attachBanner(ctx, id, ogData.Image); err != nil {
+				slog.Warn("Failed to attach preview image in Karakeep", "hn_id", item.ItemID, "image", ogData.Image, "error", err)
+			}
+		}
+		return nil
+	})
+}
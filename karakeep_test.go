@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveToKarakeep(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var requests []string
+	var created []map[string]string
+	var attached map[string]string
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/og.png" {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png"))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected auth %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/api/v1/bookmarks":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			created = append(created, body)
+			if body["url"] == "https://example.com/saved" {
+				_, _ = w.Write([]byte(`{"id": "existing", "alreadyExists": true}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": "bm1"}`))
+		case "/api/v1/assets":
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("Expected a file upload: %v", err)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			uploaded = header.Header.Get("Content-Type") + ": " + string(data)
+			_, _ = w.Write([]byte(`{"assetId": "asset1", "contentType": "image/png"}`))
+		case "/api/v1/bookmarks/bm1/assets":
+			_ = json.NewDecoder(r.Body).Decode(&attached)
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "New article", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 400, CommentCount: 50, CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "Saved before", Link: "https://example.com/saved", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 300, CreatedAt: now, UpdatedAt: now},
+		{ItemID: "3", Title: "Quiet", Link: "https://example.com/q", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 90, CreatedAt: now, UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+	for _, link := range []string{"https://example.com/a", "https://example.com/saved"} {
+		if err := cacheOpenGraphData(db, &OpenGraphData{URL: link, Title: "T", Image: server.URL + "/og.png"}, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := KarakeepConfig{Instance: server.URL, APIKey: "secret", MinPoints: 200}
+	saveToKarakeep(db, storiesToPublish(items), config, now)
+	saveToKarakeep(db, storiesToPublish(items), config, now)
+
+	if len(created) != 2 || created[0]["type"] != "link" || created[0]["title"] != "New article" ||
+		created[0]["note"] != "400 points, 50 comments on Hacker News: https://news.ycombinator.com/item?id=1" {
+		t.Fatalf("Expected the qualifying stories to be saved once, got %v", created)
+	}
+	if uploaded != "image/png: png" || attached["id"] != "asset1" || attached["assetType"] != "bannerImage" {
+		t.Errorf("Expected the OpenGraph image as the banner, got upload %q and attachment %v", uploaded, attached)
+	}
+	// An existing bookmark is left alone
+	if strings.Count(strings.Join(requests, ","), "/og.png") != 1 {
+		t.Errorf("Expected only the new bookmark to get an image, got requests %v", requests)
+	}
+	if saved, _ := storyPosted(db, "karakeep_saved_at", "2"); !saved {
+		t.Error("Expected an already saved story to be recorded")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// TitleBlocked reports whether the title contains one of the blocked keywords (case-insensitive)
+func (cm *CategoryMapper) TitleBlocked(title string) bool {
+	if cm == nil {
+		return false
+	}
+	title = strings.ToLower(title)
+	for _, keyword := range cm.config.BlockedKeywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && strings.Contains(title, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterBlockedKeywords drops items whose title contains a blocked keyword
+func filterBlockedKeywords(items []HackerNewsItem, categoryMapper *CategoryMapper) []HackerNewsItem {
+	var filtered []HackerNewsItem
+	for _, item := range items {
+		// Pinned items were added on purpose and always stay in the feed
+		if item.PinnedAt.IsZero() && categoryMapper.TitleBlocked(item.Title) {
+			slog.Debug("Excluding item with blocked keyword", "hn_id", item.ItemID)
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTitleBlocked(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{BlockedKeywords: []string{"Crypto", " ", "web3"}})
+
+	testCases := []struct {
+		title    string
+		expected bool
+	}{
+		{"The crypto winter is here", true},
+		{"Why WEB3 failed", true},
+		{"Show HN: A tiny database", false},
+	}
+	for _, tc := range testCases {
+		if got := mapper.TitleBlocked(tc.title); got != tc.expected {
+			t.Errorf("TitleBlocked(%q) = %v, expected %v", tc.title, got, tc.expected)
+		}
+	}
+
+	var none *CategoryMapper
+	if none.TitleBlocked("crypto") {
+		t.Error("Expected nothing blocked without a mapper")
+	}
+}
+
+func TestPrepareFeedItems_BlockedKeywords(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{BlockedKeywords: []string{"crypto"}})
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Crypto exchange collapses", Link: "https://example.com/a", Points: 300},
+		{ItemID: "2", Title: "A new compiler", Link: "https://example.com/b", Points: 200},
+		{ItemID: "3", Title: "Pinned crypto story", Link: "https://example.com/c", Points: 10, PinnedAt: time.Now()},
+	}
+
+	prepared := prepareFeedItems(nil, items, mapper)
+	if len(prepared) != 2 || prepared[0].ItemID != "2" || prepared[1].ItemID != "3" {
+		t.Errorf("Expected the unblocked and pinned items, got %+v", prepared)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// refreshLease is the lease held by the instance that fetches and publishes
+	refreshLease = "refresh"
+	// defaultLeaseSeconds is how long a leader keeps the lease without renewing it
+	defaultLeaseSeconds = 900
+)
+
+// CoordinationConfig enables leader election between instances sharing one database. The lease
+// is a row in the database, so instances on different hosts coordinate through a shared
+// PostgreSQL database, and instances on one host through the SQLite file as well.
+type CoordinationConfig struct {
+	Enabled      bool   `json:"enabled"`
+	InstanceID   string `json:"instance_id"`   // stable name of this instance, distinct from every other instance (required)
+	LeaseSeconds int    `json:"lease_seconds"` // lease duration; longer than the run interval (default 900)
+}
+
+// validateCoordination refuses coordination without an instance ID. There is no safe default:
+// instances on one host share a hostname and would all count as the leader, and a per-process
+// ID would stop a cron job from renewing its own lease.
+func validateCoordination(config CoordinationConfig) error {
+	if config.Enabled && config.InstanceID == "" {
+		return fmt.Errorf("instance_id is required when coordination is enabled")
+	}
+	return nil
+}
+
+// leaseDuration returns the configured lease duration with the default applied
+func (c CoordinationConfig) leaseDuration() time.Duration {
+	if c.LeaseSeconds > 0 {
+		return time.Duration(c.LeaseSeconds) * time.Second
+	}
+	return defaultLeaseSeconds * time.Second
+}
+
+// acquireLease takes or renews the named lease for holder until now+ttl. It succeeds when the
+// lease is free, expired or already held by holder; the conditional upsert makes the check and
+// the takeover a single atomic statement.
+func acquireLease(db *sql.DB, name, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	result, err := execWithRetry(db, `
+		INSERT INTO leader_lease (name, holder, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			holder = excluded.holder,
+			expires_at = excluded.expires_at
+		WHERE leader_lease.holder = excluded.holder OR leader_lease.expires_at <= ?`,
+		name, holder, now.Add(ttl).Unix(), now.Unix())
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// getLeaseHolder returns the current holder of the named lease and when it expires, or "" if nobody holds it
+func getLeaseHolder(db *sql.DB, name string) (string, time.Time, error) {
+	var holder string
+	var expiresAt int64
+	err := db.QueryRow("SELECT holder, expires_at FROM leader_lease WHERE name = ?", name).Scan(&holder, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return holder, time.Unix(expiresAt, 0), nil
+}
+
+// isRefreshLeader reports whether this instance should fetch and publish in this cycle.
+// Without coordination every instance is the leader. The leader keeps the lease by renewing
+// it every run; if it stops running, another instance takes over once the lease expires.
+func isRefreshLeader(db *sql.DB, config CoordinationConfig, now time.Time) bool {
+	if !config.Enabled {
+		return true
+	}
+
+	id := config.InstanceID
+	acquired, err := acquireLease(db, refreshLease, id, config.leaseDuration(), now)
+	if err != nil {
+		// Running twice is better than not running at all
+		slog.Warn("Failed to acquire leader lease, running anyway", "instance", id, "error", err)
+		return true
+	}
+	if !acquired {
+		holder, expiresAt, _ := getLeaseHolder(db, refreshLease)
+		slog.Info("Another instance is the leader, skipping this cycle", "instance", id, "leader", holder, "lease_expires", expiresAt)
+		return false
+	}
+	slog.Debug("Holding leader lease", "instance", id, "lease", config.leaseDuration())
+	return true
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquireLease(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Unix(1_700_000_000, 0)
+	ttl := 10 * time.Minute
+
+	acquired, err := acquireLease(db, refreshLease, "a", ttl, now)
+	if err != nil || !acquired {
+		t.Fatalf("Expected instance a to acquire a free lease, got %v, %v", acquired, err)
+	}
+	if acquired, _ := acquireLease(db, refreshLease, "b", ttl, now.Add(time.Minute)); acquired {
+		t.Error("Expected instance b not to acquire a lease held by a")
+	}
+
+	// The leader renews its lease, pushing back the expiry
+	if acquired, _ := acquireLease(db, refreshLease, "a", ttl, now.Add(5*time.Minute)); !acquired {
+		t.Error("Expected instance a to renew its lease")
+	}
+	if acquired, _ := acquireLease(db, refreshLease, "b", ttl, now.Add(12*time.Minute)); acquired {
+		t.Error("Expected the renewed lease to still be held")
+	}
+
+	// Once the leader stops renewing, another instance takes over
+	if acquired, _ := acquireLease(db, refreshLease, "b", ttl, now.Add(16*time.Minute)); !acquired {
+		t.Error("Expected instance b to take over the expired lease")
+	}
+	holder, expiresAt, err := getLeaseHolder(db, refreshLease)
+	if err != nil || holder != "b" || !expiresAt.Equal(now.Add(26*time.Minute)) {
+		t.Errorf("Expected b to hold the lease until %v, got %q until %v (%v)", now.Add(26*time.Minute), holder, expiresAt, err)
+	}
+
+	// Leases with other names are independent
+	if acquired, _ := acquireLease(db, "other", "a", ttl, now); !acquired {
+		t.Error("Expected an independent lease to be free")
+	}
+}
+
+func TestGetLeaseHolder_Free(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	holder, _, err := getLeaseHolder(db, refreshLease)
+	if err != nil || holder != "" {
+		t.Errorf("Expected no holder, got %q, %v", holder, err)
+	}
+}
+
+func TestIsRefreshLeader(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+	now := time.Now()
+
+	if !isRefreshLeader(nil, CoordinationConfig{}, now) {
+		t.Error("Expected every instance to lead without coordination")
+	}
+
+	first := CoordinationConfig{Enabled: true, InstanceID: "first", LeaseSeconds: 60}
+	second := CoordinationConfig{Enabled: true, InstanceID: "second", LeaseSeconds: 60}
+	if !isRefreshLeader(db, first, now) {
+		t.Error("Expected the first instance to become leader")
+	}
+	if isRefreshLeader(db, second, now.Add(30*time.Second)) {
+		t.Error("Expected the second instance to skip while the first leads")
+	}
+	if !isRefreshLeader(db, second, now.Add(2*time.Minute)) {
+		t.Error("Expected the second instance to take over after the lease expired")
+	}
+}
+
+func TestCoordinationConfigDefaults(t *testing.T) {
+	config := CoordinationConfig{}
+	if config.leaseDuration() != defaultLeaseSeconds*time.Second {
+		t.Errorf("Expected default lease duration, got %v", config.leaseDuration())
+	}
+}
+
+func TestValidateCoordination(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  CoordinationConfig
+		wantErr bool
+	}{
+		{"disabled", CoordinationConfig{}, false},
+		{"enabled with instance ID", CoordinationConfig{Enabled: true, InstanceID: "pi-1"}, false},
+		{"enabled without instance ID", CoordinationConfig{Enabled: true}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCoordination(tc.config)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
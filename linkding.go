@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// linkdingTimeout bounds a single Linkding API request
+const linkdingTimeout = 15 * time.Second
+
+// LinkdingConfig enables saving feed stories as bookmarks in a Linkding instance
+type LinkdingConfig struct {
+	Instance  string   `json:"instance"`    // Linkding URL, e.g. https://links.example.com
+	Token     string   `json:"token"`       // REST API token from Settings → Integrations
+	Tags      []string `json:"tags"`        // tags added to every bookmark (default ["hackernews"])
+	MinPoints int      `json:"min_points"`  // only bookmark stories with at least this many points
+	Unread    bool     `json:"unread"`      // mark bookmarks as unread, for the reading queue
+	MaxPerRun int      `json:"max_per_run"` // bookmarks saved per run (default 5)
+}
+
+// linkdingBookmark is the request body of the Linkding bookmark API
+type linkdingBookmark struct {
+	URL      string   `json:"url"`
+	Title    string   `json:"title"`
+	Notes    string   `json:"notes"`
+	TagNames []string `json:"tag_names"`
+	Unread   bool     `json:"unread"`
+}
+
+// linkdingTag turns a category into a Linkding tag, which can't contain spaces
+func linkdingTag(category string) string {
+	return strings.ToLower(strings.Join(strings.Fields(category), "-"))
+}
+
+// linkdingTags returns the configured tags plus the item's topic categories. Categories that
+// change with the score, such as points tiers, are left out since bookmarks aren't updated.
+func linkdingTags(item HackerNewsItem, config LinkdingConfig, categoryMapper *CategoryMapper) []string {
+	tags := config.Tags
+	if tags == nil {
+		tags = []string{"hackernews"}
+	}
+	categories := categorizeContent(item.Title, extractDomain(item.Link), item.Link, itemStoryType(item), categoryMapper)
+	var names []string
+	for _, category := range append(slices.Clone(tags), categoryMapper.AliasCategories(categories)...) {
+		if tag := linkdingTag(category); tag != "" && !slices.Contains(names, tag) {
+			names = append(names, tag)
+		}
+	}
+	return names
+}
+
+// newLinkdingBookmark bookmarks the article, or the discussion of text posts, with the HN
+// stats and discussion link as notes
+func newLinkdingBookmark(item HackerNewsItem, config LinkdingConfig, categoryMapper *CategoryMapper) linkdingBookmark {
+	return linkdingBookmark{
+		URL:      storyForItem(item).URL,
+		Title:    item.Title,
+		Notes:    fmt.Sprintf("[%d points, %d comments on Hacker News](%s)", item.Points, item.CommentCount, item.CommentsLink),
+		TagNames: linkdingTags(item, config, categoryMapper),
+		Unread:   config.Unread,
+	}
+}
+
+// saveLinkdingBookmark creates a bookmark. Linkding updates the existing bookmark when the URL
+// is already saved, so retries don't create duplicates.
+func saveLinkdingBookmark(ctx context.Context, client *http.Client, config LinkdingConfig, bookmark linkdingBookmark) error {
+	body, err := json.Marshal(bookmark)
+	if err != nil {
+		return fmt.Errorf("failed to encode bookmark: %w", err)
+	}
+	endpoint := strings.TrimSuffix(config.Instance, "/") + "/api/bookmarks/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+config.Token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to save bookmark: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// saveToLinkding bookmarks new stories with enough points when a Linkding instance is configured
+func saveToLinkding(db *sql.DB, items []HackerNewsItem, config LinkdingConfig, categoryMapper *CategoryMapper, now time.Time) {
+	if config.Instance == "" || config.Token == "" {
+		return
+	}
+	var qualifying []HackerNewsItem
+	for _, item := range items {
+		if item.Points >= config.MinPoints {
+			qualifying = append(qualifying, item)
+		}
+	}
+
+	client := &http.Client{Timeout: linkdingTimeout}
+	postNewStories(db, qualifying, "Linkding", "linkding_saved_at", config.MaxPerRun, now, func(item HackerNewsItem) error {
+		ctx, cancel := context.WithTimeout(context.Background(), linkdingTimeout)
+		defer cancel()
+		return saveLinkdingBookmark(ctx, client, config, newLinkdingBookmark(item, config, categoryMapper))
+	})
+}
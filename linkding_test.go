@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLinkdingTags(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{CategoryDomains: map[string][]string{"Open Source": {"github.com"}}})
+
+	item := HackerNewsItem{Title: "Show HN: My project", Link: "https://github.com/me/project", Points: 500}
+	tags := linkdingTags(item, LinkdingConfig{}, mapper)
+	for _, expected := range []string{"hackernews", "open-source", "show-hn"} {
+		if !slices.Contains(tags, expected) {
+			t.Errorf("Expected tag %q in %v", expected, tags)
+		}
+	}
+	for _, tag := range tags {
+		if tag == "" || slices.Contains([]rune(tag), ' ') {
+			t.Errorf("Invalid tag %q", tag)
+		}
+	}
+
+	tags = linkdingTags(item, LinkdingConfig{Tags: []string{"HN", "hn"}}, mapper)
+	if tags[0] != "hn" || slices.Contains(tags[1:], "hn") || slices.Contains(tags, "hackernews") {
+		t.Errorf("Expected the configured tags to replace the default once each, got %v", tags)
+	}
+}
+
+func TestSaveToLinkding(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var bookmarks []linkdingBookmark
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/bookmarks/" || r.Header.Get("Authorization") != "Token secret" {
+			t.Errorf("Unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var bookmark linkdingBookmark
+		_ = json.NewDecoder(r.Body).Decode(&bookmark)
+		bookmarks = append(bookmarks, bookmark)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Popular article", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 400, CommentCount: 120, CreatedAt: now, UpdatedAt: now},
+		{ItemID: "2", Title: "Ask HN: Popular question", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 300, CreatedAt: now, UpdatedAt: now},
+		{ItemID: "3", Title: "Quiet article", Link: "https://example.com/b", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 80, CreatedAt: now, UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+	mapper := NewCategoryMapper(&DomainConfig{})
+	config := LinkdingConfig{Instance: server.URL + "/", Token: "secret", MinPoints: 200, Unread: true}
+
+	saveToLinkding(db, items, config, mapper, now)
+	saveToLinkding(db, items, config, mapper, now)
+	if len(bookmarks) != 2 {
+		t.Fatalf("Expected the 2 qualifying stories to be bookmarked once, got %+v", bookmarks)
+	}
+	article := bookmarks[0]
+	if article.URL != "https://example.com/a" || article.Title != "Popular article" || !article.Unread ||
+		article.Notes != "[400 points, 120 comments on Hacker News](https://news.ycombinator.com/item?id=1)" {
+		t.Errorf("Unexpected bookmark %+v", article)
+	}
+	if bookmarks[1].URL != "https://news.ycombinator.com/item?id=2" {
+		t.Errorf("Expected text posts to bookmark the discussion, got %q", bookmarks[1].URL)
+	}
+	if saved, _ := storyPosted(db, "linkding_saved_at", "3"); saved {
+		t.Error("Expected the low-scoring story not to be bookmarked")
+	}
+}
@@ -1,81 +1,417 @@
 package main
 
 import (
+	"database/sql"
 	"flag"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/gorilla/feeds"
 )
 
 var Version string
 
+// feedFileName is the name of the main feed written to the output directory
+const feedFileName = "hackernews.xml"
+
+// feedOutputOptions controls how the main feed is split across documents
+type feedOutputOptions struct {
+	PageSize  int    // items per RFC 5005 page, 0 for a single document
+	Archives  bool   // also write monthly RFC 5005 archive documents
+	Graveyard bool   // also write a feed of items that died or were flagged
+	Jobs      bool   // also fetch job posts and write them to a feed of their own
+	BaseURL   string // public URL of the output directory; enables the feeds.opml list of generated feeds
+	GitCommit bool   // commit the output directory to the git repository it is in
+	GitRemote string // remote the commit is pushed to, empty to only commit
+}
+
+// refreshItems fetches the current story set, front page by default, updates stored items and their stats,
+// sends watchlist and threshold alerts for newly matching items and queues the run's preview refreshes
+func refreshItems(db *sql.DB, filter ItemFilter, categoryMapper *CategoryMapper, source string) RunRecord {
+	run := RunRecord{Source: source, StartedAt: time.Now()}
+
+	// Fetch current items of the story set
+	timeouts := categoryMapper.Timeouts()
+	fetch := categoryMapper.Fetch()
+	newItems := fetchHackerNewsItems(fetch, timeouts.Algolia)
+	run.Fetched = len(newItems)
+	if newItems == nil {
+		run.Error = "failed to fetch " + fetch.Tags + " items"
+	}
+
+	// Note stories that came back to the front page after dropping off
+	if fetch.isFrontPage() {
+		recordFrontPageSightings(db, newItems, run.StartedAt)
+	}
+
+	// Update database with new items and get list of updated item IDs
+	recentlyUpdated := updateStoredItems(db, newItems)
+	run.Updated = len(recentlyUpdated)
+
+	// Alert on watchlist matches right away instead of waiting for feed generation
+	alertWatchlistMatches(db, newItems, filter.MinPoints, categoryMapper)
+
+	// Get all items from database
+	allItems, err := getFilteredItems(db, filter)
+	if err != nil {
+		slog.Error("Failed to load stored items", "error", err)
+		if run.Error == "" {
+			run.Error = err.Error()
+		}
+	}
+
+	// Update item stats with current data from Algolia, skipping recently updated items
+	updateItemStats(db, allItems, recentlyUpdated, timeouts.Algolia)
+
+	// Snapshot the refreshed stats so entries can show how much they changed since the last run
+	refreshed := append(newItems, allItems...)
+	recordStatsSnapshot(db, refreshed, run.StartedAt)
+
+	// Alert on stories whose refreshed points first crossed a configured threshold
+	alertThresholdCrossings(db, refreshed, filter.MinPoints, categoryMapper)
+
+	// Queue this run's preview refreshes; the enrichment step after the run fetches them
+	queueOpenGraphRefreshes(db, filterBlockedKeywords(filterBlockedDomains(allItems, categoryMapper), categoryMapper), categoryMapper, run.StartedAt)
+
+	// Append to the long-term archive, which outlives anything pruned from the database
+	if err := archiveItems(db, categoryMapper.Config().Archive, newItems, run.StartedAt); err != nil {
+		slog.Warn("Failed to update archive", "error", err)
+	}
+
+	run.FinishedAt = time.Now()
+	return run
+}
+
+// enrichItems fetches OpenGraph previews and runs the optional enrichers that cache extra per-item content for the feed
+func enrichItems(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	fetchOpenGraphPreviews(db, items, categoryMapper)
+	refreshPolls(db, items, categoryMapper.Timeouts().Algolia)
+	refreshAuthors(db, items, categoryMapper)
+	refreshPreviousDiscussions(db, items, categoryMapper)
+	refreshTopComments(db, items, categoryMapper)
+	summarizeItems(db, items, categoryMapper)
+	summarizeDiscussions(db, items, categoryMapper)
+	translateTitles(db, items, categoryMapper)
+}
+
+// prepareFeedItems applies feed-level filtering and merging to items selected from the database,
+// and attaches the other stored submissions of each article
+func prepareFeedItems(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) []HackerNewsItem {
+	// Drop blocked domains and keywords first, so no OpenGraph or summary requests are made for them
+	items = filterBlockedDomains(items, categoryMapper)
+	items = filterBlockedKeywords(items, categoryMapper)
+
+	// Drop flamewar items if configured to do so
+	if categoryMapper.ExcludeFlamewars() {
+		items = filterFlamewars(items, categoryMapper.FlamewarRatio())
+	}
+
+	// Merge multiple submissions of the same article into one entry, and mention the stored ones
+	// that didn't make it into the feed
+	return attachStoredDuplicates(db, collapseDuplicateSubmissions(items), time.Now().Add(-duplicateWindow))
+}
+
 // updateAndSaveFeed orchestrates the entire process of fetching, updating, and generating the RSS feed
-func updateAndSaveFeed(outDir string, minPoints int, limit int, categoryMapper *CategoryMapper) {
+func updateAndSaveFeed(outDir string, filter ItemFilter, categoryMapper *CategoryMapper, output feedOutputOptions) {
+	// Abort runs that hang on a flaky connection instead of piling up behind cron
+	stopDeadline := startRunDeadline(categoryMapper.Timeouts().RunDeadline)
+
 	db := initDB()
 	defer func() { _ = db.Close() }()
 
+	// With several instances sharing the database only the leader fetches and publishes
+	if !isRefreshLeader(db, categoryMapper.Config().Coordination, time.Now()) {
+		stopDeadline()
+		return
+	}
+
 	// Clean up expired OpenGraph cache entries
 	if err := cleanupExpiredOpenGraphCache(db); err != nil {
 		slog.Warn("Failed to cleanup expired OpenGraph cache", "error", err)
 	}
 
-	// Fetch current front page items
-	newItems := fetchHackerNewsItems()
+	fetchErrorsBefore := fetchErrorSnapshot()
+	run := refreshItems(db, filter, categoryMapper, "cli")
 
-	// Update database with new items and get list of updated item IDs
-	recentlyUpdated := updateStoredItems(db, newItems)
+	// Re-fetch items to get updated stats for RSS generation
+	allItems, err := getFilteredItems(db, filter)
+	if err != nil {
+		slog.Error("Failed to load items for the feed", "error", err)
+		os.Exit(1)
+	}
+	allItems = prepareFeedItems(db, allItems, categoryMapper)
 
-	// Get all items from database
-	allItems := getAllItems(db, limit, minPoints)
+	// Fetch previews and add optional LLM and discussion enrichments
+	enrichItems(db, allItems, categoryMapper)
 
-	// Update item stats with current data from Algolia, skipping recently updated items
-	updateItemStats(db, allItems, recentlyUpdated)
+	// The deadline stays active until the files are written and published
+	// Completed months go to archive documents, and the feed links to the newest one
+	info := defaultFeedInfo
+	var archives []feedArchive
+	if output.Archives {
+		var err error
+		if archives, err = listFeedArchives(db, feedFileName, filter.MinPoints, time.Now()); err != nil {
+			slog.Warn("Failed to list feed archives", "error", err)
+		}
+		if len(archives) > 0 {
+			info.Links = []feeds.AtomLink{prevArchiveLink(archives[len(archives)-1])}
+		}
+		if _, err := writeFeedArchives(db, outDir, feedFileName, archives, filter.MinPoints, categoryMapper); err != nil {
+			slog.Error("Error writing feed archives", "error", err)
+		}
+	}
 
-	// Re-fetch items to get updated stats for RSS generation
-	allItems = getAllItems(db, limit, minPoints)
+	// WebSub subscribers find the hubs and the topic URL in the feed
+	hubs := categoryMapper.Config().WebSub.Hubs
+	var topic string
+	if len(hubs) > 0 {
+		if output.BaseURL == "" {
+			slog.Warn("WebSub hubs are configured but -base-url is not set, skipping WebSub")
+		} else {
+			topic = publicFeedURL(output.BaseURL, feedFileName)
+			info.Links = append(info.Links, websubLinks(hubs, topic)...)
+		}
+	}
+
+	feedPages := generatePagedFeed(db, feedFileName, allItems, filter.MinPoints, categoryMapper, info, output.PageSize)
+	var watchlistItems []HackerNewsItem
+	var watchlistFeed string
+	if len(categoryMapper.Config().Watchlist) > 0 {
+		if watchlistItems, err = getWatchlistItems(db, filter, categoryMapper); err != nil {
+			slog.Error("Failed to load watchlist items", "error", err)
+		} else {
+			watchlistFeed = generateFeed(db, watchlistItems, filter.MinPoints, categoryMapper, watchlistFeedInfo)
+		}
+	}
+	stopDeadline()
 
 	// Ensure output directory exists
-	err := os.MkdirAll(outDir, 0755)
-	if err != nil {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
 		slog.Error("Error creating output directory", "error", err)
 		os.Exit(1)
 	}
 
-	// Generate and save the feed
-	filename := filepath.Join(outDir, "hackernews.xml")
-	rss := generateRSSFeed(db, allItems, minPoints, categoryMapper)
-	err = os.WriteFile(filename, []byte(rss), 0644)
+	// Browsers follow the feeds' xml-stylesheet instruction to the stylesheet next to them
+	if feedStylesheet != "" {
+		if err := writeFeedStylesheet(outDir); err != nil {
+			slog.Error("Error writing feed stylesheet", "error", err)
+		}
+	}
+
+	// Save the feed
+	filename := filepath.Join(outDir, feedFileName)
+	changed, err := writeFeedPages(outDir, feedFileName, feedPages)
 	if err != nil {
 		slog.Error("Error writing RSS feed to file", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("RSS feed saved", "count", len(allItems), "filename", filename)
+	slog.Info("RSS feed saved", "count", len(allItems), "filename", filename, "pages", len(feedPages))
+	generated := []generatedFeed{{Title: defaultFeedInfo.Title, File: feedFileName}}
+
+	// Watchlist matches get their own feed so they are never lost below the threshold
+	if watchlistFeed != "" {
+		watchlistFile := filepath.Join(outDir, "watchlist.xml")
+		if written, err := writeFeedFile(watchlistFile, watchlistFeed); err != nil {
+			slog.Error("Error writing watchlist feed to file", "error", err)
+		} else if written {
+			slog.Info("Watchlist feed saved", "count", len(watchlistItems), "filename", watchlistFile)
+		}
+		generated = append(generated, generatedFeed{Title: watchlistFeedInfo.Title, File: "watchlist.xml"})
+	}
+
+	// Configured domain groups get feeds of their own
+	domainGroups, err := writeDomainFeeds(db, outDir, filter, categoryMapper)
+	if err != nil {
+		slog.Error("Error writing domain feeds", "error", err)
+	}
+
+	// Front-page stories that later died keep their last-known stats in the graveyard
+	if output.Graveyard {
+		if err := writeGraveyardFeed(db, outDir, filter.MinPoints, categoryMapper); err != nil {
+			slog.Error("Error writing graveyard feed", "error", err)
+		} else {
+			generated = append(generated, generatedFeed{Title: graveyardFeedInfo.Title, File: graveyardFeedFile})
+		}
+	}
+
+	// Job posts never gather points, so they are fetched separately into a feed of their own
+	if output.Jobs {
+		if err := writeJobsFeed(db, outDir, categoryMapper, categoryMapper.Timeouts().Algolia); err != nil {
+			slog.Error("Error writing jobs feed", "error", err)
+		} else {
+			generated = append(generated, generatedFeed{Title: jobsFeedInfo.Title, File: jobsFeedFile})
+		}
+	}
+
+	// Post the feed's new stories to the configured social accounts
+	publishStories(db, allItems, categoryMapper, time.Now())
+
+	// With several feeds, a subscription list lets readers import the whole set at once
+	if output.BaseURL != "" {
+		if err := writeGeneratedFeedsOPML(outDir, output.BaseURL, generated, domainGroups, time.Now()); err != nil {
+			slog.Error("Error writing feed list", "error", err)
+		}
+	}
+
+	// Publish the output to static hosting, so the generator doesn't have to run on the web server
+	if err := uploadOutput(outDir, categoryMapper.Config().Upload); err != nil {
+		slog.Error("Output upload failed", "error", err)
+	}
+	if output.GitCommit {
+		if err := gitPublish(outDir, output.GitRemote, allItems); err != nil {
+			slog.Error("Git publishing failed", "error", err)
+		}
+	}
+
+	// Hubs fetch the feed and push it to subscribers, so they are only pinged when it changed,
+	// and after the upload so they fetch the new version
+	if changed && topic != "" {
+		pingWebSubHubs(hubs, topic)
+	}
+
+	run.FeedItems = len(allItems)
+	run.FetchErrors = fetchErrorsSince(fetchErrorsBefore)
+	run.FinishedAt = time.Now()
+	if err := recordRun(db, &run); err != nil {
+		slog.Warn("Failed to record run", "error", err)
+	}
+
+	// Ship a snapshot off the device so the accumulated history survives a failed disk
+	if err := replicateDatabase(db, categoryMapper.Config().Replication, run.FinishedAt); err != nil {
+		slog.Error("Database replication failed", "error", err)
+	}
+}
+
+// setupLogging configures the default logger based on the debug flag
+func setupLogging(debug bool) {
+	logLevel := slog.LevelWarn
+	if debug {
+		logLevel = slog.LevelDebug
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: logLevel,
+	})))
 }
 
 // main is the application entry point that parses flags and starts the RSS generation
 func main() {
+	// Dispatch subcommands before parsing the default flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "profile":
+			runProfile(os.Args[2:])
+			return
+		case "podcast":
+			runPodcast(os.Args[2:])
+			return
+		case "newsletter":
+			runNewsletter(os.Args[2:])
+			return
+		case "best-of":
+			runBestOf(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "report":
+			runReport(os.Args[2:])
+			return
+		case "add":
+			runAdd(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "prune":
+			runPrune(os.Args[2:])
+			return
+		case "backfill":
+			runBackfill(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "digest":
+			runDigest(os.Args[2:])
+			return
+		}
+	}
+
 	// Define and parse command line flags
 	outDir := flag.String("outdir", ".", "directory where the RSS feed file will be saved")
 	debug := flag.Bool("debug", false, "enable debug logging")
 	minPoints := flag.Int("min-points", 50, "minimum points threshold for items to include in RSS feed")
 	limit := flag.Int("limit", 30, "maximum number of items to include in RSS feed")
+	flag.IntVar(limit, "max-items", 30, "alias for -limit")
+	minAge := flag.Duration("min-age", 0, "minimum item age before inclusion in RSS feed (e.g. 2h)")
+	maxAgeCutoff := flag.Duration("max-age-cutoff", 0, "drop items older than this from the RSS feed (e.g. 72h)")
+	flag.DurationVar(maxAgeCutoff, "max-age", 0, "alias for -max-age-cutoff")
 	configPath := flag.String("config", "", "path to local configuration file (optional)")
 	configURL := flag.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(flag.CommandLine)
+	timezone := addTimezoneFlag(flag.CommandLine)
+	pageSize := flag.Int("page-size", 0, "split the RSS feed into linked pages of this many items (0 = a single document)")
+	archives := flag.Bool("archives", false, "also write monthly archive documents of past stories linked from the RSS feed")
+	graveyard := flag.Bool("graveyard", false, "also write graveyard.xml with front-page stories that later died or were flagged")
+	jobs := flag.Bool("jobs", false, "also fetch YC job posts and write them to hn-jobs.xml")
+	xslt := flag.Bool("xslt", false, "link the feeds to a generated feed.xsl stylesheet so browsers show a readable story list")
+	gzipOutput := flag.Bool("gzip", false, "also write a gzip-compressed .xml.gz copy of every feed file for precompressed serving")
+	gitPublish := flag.Bool("git-publish", false, "commit the output directory to the git repository it is in and push it")
+	gitRemote := flag.String("git-remote", "origin", "remote -git-publish pushes to (empty to only commit)")
+	baseURL := flag.String("base-url", "", "public URL the output directory is served from; with several feeds also writes feeds.opml listing them")
+	selfTest := flag.Bool("self-test", false, "generate a feed from fixture data, validate it and exit non-zero on problems")
 	flag.Parse()
 
 	// Configure log level based on debug flag
-	logLevel := slog.LevelWarn
-	if *debug {
-		logLevel = slog.LevelDebug
-	}
+	setupLogging(*debug)
+	setupTimezone(*timezone)
 
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: logLevel,
-	})))
+	// The self-test runs offline, validating the feed output against the local config if one is given
+	if *selfTest {
+		var selfTestMapper *CategoryMapper
+		if *configPath != "" {
+			config, err := loadConfigFromFile(*configPath)
+			if err != nil {
+				slog.Error("Failed to load config for self-test", "path", *configPath, "error", err)
+				os.Exit(1)
+			}
+			selfTestMapper = NewCategoryMapper(config)
+		}
+		os.Exit(runSelfTest(os.Stdout, selfTestMapper))
+	}
+	setupProxy(*proxy)
+	gzipFeeds = *gzipOutput
+	if *xslt {
+		feedStylesheet = feedStylesheetFile
+	}
 
 	// Load configuration
 	categoryMapper := LoadConfig(*configPath, *configURL)
 
-	slog.Debug("Starting application", "outDir", *outDir, "debugMode", *debug, "minPoints", *minPoints, "limit", *limit)
-	updateAndSaveFeed(*outDir, *minPoints, *limit, categoryMapper)
+	filter := categoryMapper.withAuthorLists(ItemFilter{
+		Limit:     *limit,
+		MinPoints: *minPoints,
+		MinAge:    *minAge,
+		MaxAge:    *maxAgeCutoff,
+	})
+
+	slog.Debug("Starting application", "outDir", *outDir, "debugMode", *debug, "minPoints", *minPoints, "limit", *limit, "minAge", *minAge, "maxAgeCutoff", *maxAgeCutoff)
+	output := feedOutputOptions{PageSize: *pageSize, Archives: *archives, Graveyard: *graveyard, Jobs: *jobs, GitCommit: *gitPublish, GitRemote: *gitRemote}
+	if *baseURL != "" {
+		var err error
+		if output.BaseURL, err = parseBaseURL(*baseURL); err != nil {
+			slog.Error("Invalid -base-url", "error", err)
+			os.Exit(2)
+		}
+	}
+	updateAndSaveFeed(*outDir, filter, categoryMapper, output)
 }
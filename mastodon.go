@@ -0,0 +1,86 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// mastodonTimeout bounds a single Mastodon API request
+const mastodonTimeout = 15 * time.Second
+
+// defaultMastodonTemplate is the status text used when no template is configured
+const defaultMastodonTemplate = `{{.Title}}{{if .Domain}} ({{.Domain}}){{end}}
+{{if .URL}}
+{{.URL}}
+{{end}}💬 {{.CommentsURL}}`
+
+// MastodonConfig enables posting feed stories to a Mastodon account
+type MastodonConfig struct {
+	Instance   string `json:"instance"`    // server URL, e.g. https://mastodon.social
+	Token      string `json:"token"`       // access token with the write:statuses scope
+	Template   string `json:"template"`    // Go template for the status text, with the storyPost fields
+	Visibility string `json:"visibility"`  // public, unlisted (default), private or direct
+	MaxPerRun  int    `json:"max_per_run"` // stories posted per run (default 5)
+}
+
+// postMastodonStatus publishes a status. The item ID is sent as the idempotency key, so a retried
+// request after a lost response doesn't post the story twice.
+func postMastodonStatus(ctx context.Context, client *http.Client, config MastodonConfig, status, itemID string) error {
+	form := url.Values{"status": {status}, "visibility": {cmp.Or(config.Visibility, "unlisted")}}
+	endpoint := strings.TrimSuffix(config.Instance, "/") + "/api/v1/statuses"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+config.Token)
+	req.Header.Set("Idempotency-Key", "hntop-rss-"+itemID)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post status: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("mastodon rejected the status (%d): %s", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postToMastodon posts new stories as statuses when a Mastodon account is configured
+func postToMastodon(db *sql.DB, items []HackerNewsItem, config MastodonConfig, now time.Time) {
+	if config.Instance == "" || config.Token == "" {
+		return
+	}
+	tmpl, err := parseStatusTemplate(config.Template, defaultMastodonTemplate)
+	if err != nil {
+		slog.Warn("Invalid Mastodon template, skipping Mastodon", "error", err)
+		return
+	}
+
+	client := &http.Client{Timeout: mastodonTimeout}
+	postNewStories(db, items, "Mastodon", "mastodon_posted_at", config.MaxPerRun, now, func(item HackerNewsItem) error {
+		status, err := renderStatus(tmpl, item)
+		if err != nil {
+			return fmt.Errorf("failed to render status: %w", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), mastodonTimeout)
+		defer cancel()
+		return postMastodonStatus(ctx, client, config, status, item.ItemID)
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderStatus_DefaultTemplate(t *testing.T) {
+	tmpl, err := parseStatusTemplate("", defaultMastodonTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	article := HackerNewsItem{Title: "A new database", Link: "https://www.example.com/db", CommentsLink: "https://news.ycombinator.com/item?id=1"}
+	got, err := renderStatus(tmpl, article)
+	want := "A new database (example.com)\n\nhttps://www.example.com/db\n💬 https://news.ycombinator.com/item?id=1"
+	if err != nil || got != want {
+		t.Errorf("renderStatus = %q, %v; want %q", got, err, want)
+	}
+
+	textPost := HackerNewsItem{Title: "Ask HN: Why?", CommentsLink: "https://news.ycombinator.com/item?id=2"}
+	got, _ = renderStatus(tmpl, textPost)
+	if want := "Ask HN: Why?\n💬 https://news.ycombinator.com/item?id=2"; got != want {
+		t.Errorf("renderStatus = %q, want %q", got, want)
+	}
+
+	if _, err := parseStatusTemplate("{{.Nope}}", defaultMastodonTemplate); err == nil {
+		t.Error("Expected unknown template fields to be rejected")
+	}
+}
+
+func TestPostToMastodon(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var statuses, keys []string
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/statuses" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if fail {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error": "Too many requests"}`))
+			return
+		}
+		if r.FormValue("visibility") != "public" {
+			t.Errorf("Expected the configured visibility, got %q", r.FormValue("visibility"))
+		}
+		statuses = append(statuses, r.FormValue("status"))
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+	}))
+	defer server.Close()
+
+	now := time.Now().UTC()
+	var items []HackerNewsItem
+	for i, id := range []string{"1", "2", "3"} {
+		items = append(items, HackerNewsItem{ItemID: id, Title: "Story " + id, Link: "https://example.com/" + id,
+			CommentsLink: "https://news.ycombinator.com/item?id=" + id, Points: 100, CreatedAt: now.Add(-time.Duration(i) * time.Hour), UpdatedAt: now})
+	}
+	updateStoredItems(db, items)
+	config := MastodonConfig{Instance: server.URL + "/", Token: "secret", Template: "{{.Title}}", Visibility: "public", MaxPerRun: 2}
+
+	// The feed lists stories newest first; they are posted oldest first
+	postToMastodon(db, storiesToPublish(items), config, now)
+	if strings.Join(statuses, ",") != "Story 3,Story 2" || keys[0] != "hntop-rss-3" {
+		t.Fatalf("Expected the two oldest stories to be posted, got %v with keys %v", statuses, keys)
+	}
+
+	postToMastodon(db, storiesToPublish(items), config, now)
+	postToMastodon(db, storiesToPublish(items), config, now)
+	if strings.Join(statuses, ",") != "Story 3,Story 2,Story 1" {
+		t.Fatalf("Expected every story to be posted exactly once, got %v", statuses)
+	}
+
+	// A failed post isn't recorded, so the story is tried again on the next run
+	items = append(items, HackerNewsItem{ItemID: "4", Title: "Story 4", CommentsLink: "https://news.ycombinator.com/item?id=4", Points: 100, CreatedAt: now, UpdatedAt: now})
+	updateStoredItems(db, items[3:])
+	fail = true
+	postToMastodon(db, storiesToPublish(items), config, now)
+	if posted, _ := storyPosted(db, "mastodon_posted_at", "4"); posted {
+		t.Error("Expected a rejected status not to be recorded as posted")
+	}
+	fail = false
+	postToMastodon(db, storiesToPublish(items), config, now)
+	if posted, _ := storyPosted(db, "mastodon_posted_at", "4"); !posted || statuses[len(statuses)-1] != "Story 4" {
+		t.Errorf("Expected the story to be posted on the next run, got %v", statuses)
+	}
+}
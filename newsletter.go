@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// newsletterOtherSection collects stories that don't belong to a named section
+const newsletterOtherSection = "Other"
+
+// newsletterTemplate renders an email-safe newsletter: table layout, inline styles, no scripts or external CSS
+var newsletterTemplate = template.Must(template.New("newsletter").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f6f6ef;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color: #f6f6ef;">
+<tr><td align="center" style="padding: 24px 12px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" border="0" style="width: 600px; max-width: 100%; background-color: #ffffff; font-family: Arial, Helvetica, sans-serif; color: #333333;">
+<tr><td style="background-color: #ff6600; padding: 16px 24px;">
+<h1 style="margin: 0; font-size: 22px; color: #ffffff;">{{.Title}}</h1>
+<p style="margin: 4px 0 0 0; font-size: 13px; color: #ffffff;">{{.Start.Format "January 2"}} – {{.End.Format "January 2, 2006"}} · {{.Count}} stories</p>
+</td></tr>
+{{range .Sections}}
+<tr><td style="padding: 20px 24px 4px 24px;">
+<h2 style="margin: 0; font-size: 16px; color: #ff6600; border-bottom: 1px solid #e5e5e5; padding-bottom: 6px;">{{.Name}}</h2>
+</td></tr>
+{{range .Stories}}
+<tr><td style="padding: 10px 24px;">
+<p style="margin: 0 0 4px 0; font-size: 15px; font-weight: bold;"><a href="{{.URL}}" style="color: #000000; text-decoration: none;">{{.Title}}</a>{{if .Domain}} <span style="font-weight: normal; font-size: 12px; color: #828282;">({{.Domain}})</span>{{end}}</p>
+{{if .Summary}}<p style="margin: 0 0 4px 0; font-size: 13px; line-height: 1.4; color: #555555;">{{.Summary}}</p>{{end}}
+<p style="margin: 0; font-size: 12px; color: #828282;">{{.Points}} points · <a href="{{.CommentsURL}}" style="color: #828282;">{{.Comments}} comments</a> · by {{.Author}}</p>
+</td></tr>
+{{end}}
+{{end}}
+<tr><td style="padding: 20px 24px; font-size: 11px; color: #828282; border-top: 1px solid #e5e5e5;">
+Generated by hntop-rss from the Hacker News front page.
+</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// newsletterStory is a single story in the newsletter
+type newsletterStory struct {
+	Title       string
+	URL         string
+	CommentsURL string
+	Domain      string
+	Points      int
+	Comments    int
+	Author      string
+	Summary     string
+}
+
+// storyForItem converts an item for display, linking to the discussion when there is no article
+func storyForItem(item HackerNewsItem) newsletterStory {
+	story := newsletterStory{
+		Title:       item.Title,
+		URL:         item.Link,
+		CommentsURL: item.CommentsLink,
+		Domain:      extractDomain(item.Link),
+		Points:      item.Points,
+		Comments:    item.CommentCount,
+		Author:      item.Author,
+	}
+	if story.URL == "" {
+		story.URL = item.CommentsLink
+	}
+	return story
+}
+
+// newsletterSection is a group of stories under one category
+type newsletterSection struct {
+	Name    string
+	Stories []newsletterStory
+}
+
+// newsletterPage is the data passed to newsletterTemplate
+type newsletterPage struct {
+	Title    string
+	Start    time.Time
+	End      time.Time
+	Count    int
+	Sections []newsletterSection
+}
+
+// newsletterSectionName picks the section an item is listed under: its configured
+// domain category, then Show HN / Ask HN, then the catch-all section. Category aliases apply.
+func newsletterSectionName(item HackerNewsItem, categoryMapper *CategoryMapper) string {
+	if domain := extractDomain(item.Link); domain != "" && categoryMapper != nil {
+		if category := categoryMapper.GetCategoryForDomain(domain); category != "" {
+			return categoryMapper.CategoryAlias(category)
+		}
+	}
+
+	switch storyType := itemStoryType(item); storyType {
+	case StoryTypeShowHN, StoryTypeAskHN:
+		return categoryMapper.CategoryAlias(storyTypeCategory(storyType))
+	}
+	return newsletterOtherSection
+}
+
+// newsletterItems returns the top stories created within the period, highest score first
+func newsletterItems(db *sql.DB, filter ItemFilter, period time.Duration, categoryMapper *CategoryMapper) ([]HackerNewsItem, error) {
+	items, err := getFilteredItems(db, ItemFilter{Limit: apiScanLimit, MinPoints: filter.MinPoints, MaxAge: period, BlockedAuthors: filter.BlockedAuthors, AllowedAuthors: filter.AllowedAuthors})
+	if err != nil {
+		return nil, err
+	}
+	items = prepareFeedItems(db, items, categoryMapper)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Points > items[j].Points })
+	if len(items) > filter.Limit {
+		items = items[:filter.Limit]
+	}
+	return items, nil
+}
+
+// renderNewsletter renders items as a self-contained HTML newsletter grouped by category.
+// Sections are ordered by their best story, with the catch-all section last.
+func renderNewsletter(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper, title string, start, end time.Time) (string, error) {
+	sections := make(map[string]*newsletterSection)
+	var order []string
+	for _, item := range items {
+		name := newsletterSectionName(item, categoryMapper)
+		section, ok := sections[name]
+		if !ok {
+			section = &newsletterSection{Name: name}
+			sections[name] = section
+			order = append(order, name)
+		}
+
+		story := storyForItem(item)
+		if db != nil && !isTextPost(item) {
+			if summary, err := getSummary(db, item.Link); err == nil {
+				story.Summary = summary
+			}
+		}
+		section.Stories = append(section.Stories, story)
+	}
+
+	// Items arrive sorted by points, so first appearance order is best-story order
+	page := newsletterPage{Title: title, Start: localTime(start), End: localTime(end), Count: len(items)}
+	for _, name := range order {
+		if name != newsletterOtherSection {
+			page.Sections = append(page.Sections, *sections[name])
+		}
+	}
+	if other, ok := sections[newsletterOtherSection]; ok {
+		page.Sections = append(page.Sections, *other)
+	}
+
+	var buf bytes.Buffer
+	if err := newsletterTemplate.Execute(&buf, page); err != nil {
+		return "", fmt.Errorf("failed to render newsletter: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// runNewsletter parses newsletter subcommand flags and writes the newsletter HTML file
+func runNewsletter(args []string) {
+	fs := flag.NewFlagSet("newsletter", flag.ExitOnError)
+	outDir := fs.String("outdir", ".", "directory where the newsletter file will be saved")
+	output := fs.String("output", "", "output file (defaults to newsletter-<year>-W<week>.html in -outdir)")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	minPoints := fs.Int("min-points", 100, "minimum points threshold for stories")
+	limit := fs.Int("limit", 25, "maximum number of stories")
+	days := fs.Int("days", 7, "number of days the newsletter covers")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	timezone := addTimezoneFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+	setupTimezone(*timezone)
+	categoryMapper := LoadConfig(*configPath, *configURL)
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	end := time.Now()
+	period := time.Duration(*days) * 24 * time.Hour
+	items, err := newsletterItems(db, categoryMapper.withAuthorLists(ItemFilter{Limit: *limit, MinPoints: *minPoints}), period, categoryMapper)
+	if err != nil {
+		slog.Error("Failed to load newsletter stories", "error", err)
+		os.Exit(1)
+	}
+
+	year, week := localTime(end).ISOWeek()
+	title := fmt.Sprintf("Hacker News Weekly – Week %d, %d", week, year)
+	if *days != 7 {
+		title = fmt.Sprintf("Hacker News – the last %d days", *days)
+	}
+	newsletter, err := renderNewsletter(db, items, categoryMapper, title, end.Add(-period), end)
+	if err != nil {
+		slog.Error("Failed to render newsletter", "error", err)
+		os.Exit(1)
+	}
+
+	filename := *output
+	if filename == "" {
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			slog.Error("Error creating output directory", "error", err)
+			os.Exit(1)
+		}
+		filename = filepath.Join(*outDir, fmt.Sprintf("newsletter-%d-W%02d.html", year, week))
+	}
+	if err := os.WriteFile(filename, []byte(newsletter), 0644); err != nil {
+		slog.Error("Error writing newsletter", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Newsletter saved", "stories", len(items), "filename", filename)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewsletterSectionName(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{CategoryDomains: map[string][]string{"GitHub": {"github.com"}}})
+
+	testCases := []struct {
+		name     string
+		item     HackerNewsItem
+		expected string
+	}{
+		{"domain category", HackerNewsItem{Title: "Show HN: repo", Link: "https://github.com/a/b"}, "GitHub"},
+		{"show hn", HackerNewsItem{Title: "Show HN: My app", Link: "https://example.com"}, "Show HN"},
+		{"ask hn", HackerNewsItem{Title: "Ask HN: Why?", Link: ""}, "Ask HN"},
+		{"other", HackerNewsItem{Title: "A blog post", Link: "https://example.com"}, newsletterOtherSection},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := newsletterSectionName(tc.item, mapper); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderNewsletter(t *testing.T) {
+	server := setupTestServer(t)
+	_, mapper := server.settings()
+	if err := cacheSummary(server.db, "https://github.com/user/repo", "Repo <summary>.", "m"); err != nil {
+		t.Fatalf("Failed to cache summary: %v", err)
+	}
+
+	items, err := newsletterItems(server.db, ItemFilter{Limit: 10, MinPoints: 50}, 7*24*time.Hour, mapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 stories, got %d", len(items))
+	}
+
+	end := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	newsletter, err := renderNewsletter(server.db, items, mapper, "Weekly", end.Add(-7*24*time.Hour), end)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"October 8 – October 15, 2026 · 3 stories",
+		"Repo &lt;summary&gt;.",
+		`href="https://news.ycombinator.com/item?id=2"`,
+		"250 points",
+	} {
+		if !strings.Contains(newsletter, want) {
+			t.Errorf("Expected newsletter to contain %q", want)
+		}
+	}
+
+	// Sections follow their best story, with the catch-all last
+	github := strings.Index(newsletter, ">GitHub</h2>")
+	twitter := strings.Index(newsletter, ">Twitter</h2>")
+	other := strings.Index(newsletter, ">Other</h2>")
+	if github < 0 || twitter < 0 || other < 0 || github > twitter || twitter > other {
+		t.Errorf("Unexpected section order: GitHub=%d Twitter=%d Other=%d", github, twitter, other)
+	}
+
+	// Email-safe: no scripts or external stylesheets
+	if strings.Contains(newsletter, "<script") || strings.Contains(newsletter, "<link") || strings.Contains(newsletter, "<style") {
+		t.Error("Expected newsletter to be self-contained with inline styles only")
+	}
+}
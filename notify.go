@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// notifyTimeout bounds how long a single notifier may take to deliver a notification
+const notifyTimeout = 10 * time.Second
+
+// NotifierConfig describes a notification target
+type NotifierConfig struct {
+	Type    string            `json:"type"` // "webhook" or "telegram"
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // extra request headers, e.g. Authorization
+	// Payload is a Go template for the JSON body, e.g. {"text": {{json .Item.Title}}}; the whole notification by default
+	Payload string `json:"payload,omitempty"`
+
+	BotToken       string `json:"bot_token,omitempty"`       // Telegram bot token from @BotFather
+	ChatID         string `json:"chat_id,omitempty"`         // Telegram chat, group or @channel to post to
+	DisablePreview bool   `json:"disable_preview,omitempty"` // send Telegram messages without a link preview
+}
+
+// notification is an event about a single item sent to notifiers
+type notification struct {
+	Event     string   `json:"event"`               // what triggered the notification: "watchlist" or "threshold"
+	Matches   []string `json:"matches,omitempty"`   // the terms that matched, if any
+	Threshold int      `json:"threshold,omitempty"` // the points threshold the item crossed, if any
+	Item      apiItem  `json:"item"`
+}
+
+// notificationReason describes why a notification was sent, e.g. "Crossed 500 points"
+func notificationReason(n notification) string {
+	switch n.Event {
+	case "threshold":
+		return fmt.Sprintf("Crossed %d points", n.Threshold)
+	case "watchlist":
+		return "Watchlist match: " + strings.Join(n.Matches, ", ")
+	default:
+		return n.Event
+	}
+}
+
+// payloadFuncs are the functions available to webhook payload templates
+var payloadFuncs = template.FuncMap{
+	// json encodes a value as JSON, so strings are quoted and escaped
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// parsePayloadTemplate compiles a webhook payload template, checking it renders valid JSON
+func parsePayloadTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("payload").Funcs(payloadFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Render sample data once so mistakes fail at load time instead of on every notification
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, notification{Event: "watchlist"}); err != nil {
+		return nil, err
+	}
+	if !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("payload template does not render valid JSON")
+	}
+	return tmpl, nil
+}
+
+// notifier delivers notifications to an external service
+type notifier interface {
+	Notify(ctx context.Context, n notification) error
+}
+
+// newNotifier creates a notifier from its configuration
+func newNotifier(config NotifierConfig) (notifier, error) {
+	switch config.Type {
+	case "webhook":
+		if config.URL == "" {
+			return nil, fmt.Errorf("webhook notifier requires a url")
+		}
+		target := &webhookNotifier{url: config.URL, headers: config.Headers, client: &http.Client{Timeout: notifyTimeout}}
+		if config.Payload != "" {
+			payload, err := parsePayloadTemplate(config.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("invalid webhook payload: %w", err)
+			}
+			target.payload = payload
+		}
+		return target, nil
+	case "telegram":
+		if config.BotToken == "" || config.ChatID == "" {
+			return nil, fmt.Errorf("telegram notifier requires a bot_token and chat_id")
+		}
+		return &telegramNotifier{token: config.BotToken, chatID: config.ChatID, disablePreview: config.DisablePreview, client: &http.Client{Timeout: notifyTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type: %q", config.Type)
+	}
+}
+
+// sendNotification delivers a notification to every configured notifier, logging failures
+func sendNotification(configs []NotifierConfig, n notification) {
+	for _, config := range configs {
+		target, err := newNotifier(config)
+		if err != nil {
+			slog.Warn("Invalid notifier configuration, skipping", "error", err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		err = target.Notify(ctx, n)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to send notification", "type", config.Type, "event", n.Event, "hn_id", n.Item.ID, "error", err)
+			continue
+		}
+		slog.Info("Sent notification", "type", config.Type, "event", n.Event, "hn_id", n.Item.ID)
+	}
+}
+
+// webhookNotifier POSTs notifications as JSON to a URL
+type webhookNotifier struct {
+	url     string
+	headers map[string]string
+	payload *template.Template // nil sends the notification as is
+	client  *http.Client
+}
+
+// Notify implements notifier
+func (w *webhookNotifier) Notify(ctx context.Context, n notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	if w.payload != nil {
+		var b bytes.Buffer
+		if err := w.payload.Execute(&b, n); err != nil {
+			return fmt.Errorf("failed to render payload: %w", err)
+		}
+		body = b.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
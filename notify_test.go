@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewNotifier(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    NotifierConfig
+		shouldErr bool
+	}{
+		{"webhook", NotifierConfig{Type: "webhook", URL: "https://example.com/hook"}, false},
+		{"webhook without url", NotifierConfig{Type: "webhook"}, true},
+		{"telegram", NotifierConfig{Type: "telegram", BotToken: "123:abc", ChatID: "@hn"}, false},
+		{"telegram without chat", NotifierConfig{Type: "telegram", BotToken: "123:abc"}, true},
+		{"unknown type", NotifierConfig{Type: "carrier-pigeon"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newNotifier(tc.config)
+			if tc.shouldErr != (err != nil) {
+				t.Errorf("Expected error=%v, got %v", tc.shouldErr, err)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var gotAuth, gotType string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("Content-Type")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	target, err := newNotifier(NotifierConfig{Type: "webhook", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer abc"}})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	n := notification{Event: "watchlist", Item: apiItem{ID: "1", Title: "Test"}}
+	if err := target.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer abc" || gotType != "application/json" {
+		t.Errorf("Unexpected headers: auth=%q content-type=%q", gotAuth, gotType)
+	}
+
+	status = http.StatusInternalServerError
+	if err := target.Notify(context.Background(), n); err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
+
+func TestWebhookNotifier_Payload(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	target, err := newNotifier(NotifierConfig{Type: "webhook", URL: server.URL, Payload: `{"text": {{json .Item.Title}}, "points": {{.Item.Points}}}`})
+	if err != nil {
+		t.Fatalf("Failed to create notifier: %v", err)
+	}
+	n := notification{Event: "threshold", Threshold: 500, Item: apiItem{ID: "1", Title: `A "quoted" title`, Points: 512}}
+	if err := target.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if body["text"] != `A "quoted" title` || body["points"] != float64(512) {
+		t.Errorf("Unexpected payload: %v", body)
+	}
+
+	for _, payload := range []string{`{"text": {{.Item.Missing}}}`, `{"text": {{.Item.Title}}}`, `{{`} {
+		if _, err := newNotifier(NotifierConfig{Type: "webhook", URL: server.URL, Payload: payload}); err == nil {
+			t.Errorf("Expected payload %q to be rejected", payload)
+		}
+	}
+}
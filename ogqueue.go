@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	ogQueueWorkers     = 5                // concurrent OpenGraph fetches
+	ogQueueMaxAttempts = 4                // failed fetches before a URL is cached as failed and dropped
+	ogQueueBaseBackoff = 15 * time.Minute // delay after the first failure, doubling with each further one
+	ogQueueMaxBackoff  = 6 * time.Hour
+
+	defaultOGRefreshAfter  = 6 * time.Hour // item age at which its preview is refetched once
+	defaultOGRefreshBudget = 10            // preview refreshes queued per run
+)
+
+// ogQueueEntry is a URL waiting for its OpenGraph data to be fetched
+type ogQueueEntry struct {
+	URL      string
+	Priority int // points of the best item linking to the URL
+	Attempts int // failed fetches so far
+}
+
+// ogRetryBackoff returns how long to wait before fetching a URL again after the given number of failures
+func ogRetryBackoff(attempts int) time.Duration {
+	backoff := ogQueueBaseBackoff
+	for i := 1; i < attempts && backoff < ogQueueMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, ogQueueMaxBackoff)
+}
+
+// enqueueOpenGraph queues the article URLs of items that have no cached OpenGraph result, prioritized by points.
+// URLs already in the queue keep their retry schedule but move up if a better scoring item links to them.
+func enqueueOpenGraph(db *sql.DB, items []HackerNewsItem, now time.Time) error {
+	for _, item := range items {
+		if isTextPost(item) {
+			continue
+		}
+		// Invalid URLs are never fetched, and not cached as failures either
+		if _, err := normalizeArticleURL(item.Link); err != nil {
+			slog.Warn("Skipping OpenGraph fetch for invalid URL", "url", item.Link, "error", err)
+			continue
+		}
+		cached, err := getOpenGraphData(db, item.Link)
+		if err != nil {
+			slog.Warn("Error getting cached OpenGraph data", "error", err, "url", item.Link)
+		}
+		if cached != nil {
+			continue
+		}
+		if err := queueOpenGraphURL(db, item.Link, item.Points, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueOpenGraphRefreshes queues one refetch of the cached previews of items that have been up for at least
+// refreshAfter, since titles and images are often corrected after submission. A preview fetched after that
+// point is current, so each item is refreshed once. At most budget URLs are queued, highest points first.
+func enqueueOpenGraphRefreshes(db *sql.DB, items []HackerNewsItem, refreshAfter time.Duration, budget int, now time.Time) error {
+	if refreshAfter <= 0 || budget <= 0 {
+		return nil
+	}
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b HackerNewsItem) int { return b.Points - a.Points })
+
+	queued := make(map[string]bool)
+	for _, item := range sorted {
+		if len(queued) >= budget {
+			break
+		}
+		if isTextPost(item) || queued[item.Link] || now.Sub(item.CreatedAt) < refreshAfter {
+			continue
+		}
+		cached, err := getOpenGraphData(db, item.Link)
+		if err != nil {
+			slog.Warn("Error getting cached OpenGraph data", "error", err, "url", item.Link)
+			continue
+		}
+		if cached == nil || !cached.FetchSuccess || !cached.FetchedAt.Before(item.CreatedAt.Add(refreshAfter)) {
+			continue
+		}
+		slog.Debug("Refreshing OpenGraph data of a long-running item", "url", item.Link, "hn_id", item.ItemID, "fetched_at", cached.FetchedAt)
+		if err := queueOpenGraphURL(db, item.Link, item.Points, now); err != nil {
+			return err
+		}
+		queued[item.Link] = true
+	}
+	return nil
+}
+
+// processOpenGraphQueue fetches every due URL in the queue, highest priority first. Successful fetches
+// are cached and leave the queue; transient failures are retried with backoff on later runs until they
+// give up, while failures that retrying won't fix, such as 404s or non-HTML pages, give up right away.
+func processOpenGraphQueue(db *sql.DB, fetcher *OpenGraphFetcher, workers int, now time.Time) {
+	entries, err := getDueOpenGraphQueue(db, now)
+	if err != nil {
+		slog.Warn("Failed to load OpenGraph queue", "error", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	slog.Debug("Processing OpenGraph queue", "due", len(entries))
+
+	// The channel hands out entries in priority order, and each URL to exactly one worker
+	jobs := make(chan ogQueueEntry, len(entries))
+	for _, entry := range entries {
+		jobs <- entry
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for range min(workers, len(entries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				fetchQueuedOpenGraph(db, fetcher, entry, now)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchQueuedOpenGraph fetches and caches one queued URL, rescheduling or giving up on failure
+func fetchQueuedOpenGraph(db *sql.DB, fetcher *OpenGraphFetcher, entry ogQueueEntry, now time.Time) {
+	// A panic must not kill the worker, or the remaining entries would never be fetched
+	err := errPanicked
+	var ogData *OpenGraphData
+	safely("opengraph fetch", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetcher.fetchTimeout)
+		defer cancel()
+		ogData, err = fetcher.FetchOpenGraph(ctx, entry.URL)
+	}, "url", entry.URL)
+
+	if err == nil && ogData != nil {
+		cleanOpenGraphData(ogData)
+		slog.Debug("Successfully fetched OpenGraph data", "url", entry.URL, "title", ogData.Title)
+		if err := cacheOpenGraphData(db, ogData, true); err != nil {
+			slog.Warn("Failed to cache OpenGraph data", "error", err, "url", entry.URL)
+			return
+		}
+		if err := storeCanonicalURL(db, entry.URL, ogData.CanonicalURL); err != nil {
+			slog.Warn("Failed to store canonical URL", "error", err, "url", entry.URL)
+		}
+		if err := removeOpenGraphQueueEntry(db, entry.URL); err != nil {
+			slog.Warn("Failed to remove URL from OpenGraph queue", "error", err, "url", entry.URL)
+		}
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("no OpenGraph data")
+	}
+	fetchErr := recordFetchError(fetchSourceOpenGraph, err)
+
+	attempts := entry.Attempts + 1
+	if attempts < ogQueueMaxAttempts && fetchErr.retryable() {
+		retryAt := now.Add(ogRetryBackoff(attempts))
+		slog.Debug("Failed to fetch OpenGraph data, retrying later", "error", err, "class", fetchErr.Class, "url", entry.URL, "attempts", attempts, "retry_at", retryAt)
+		if err := rescheduleOpenGraphQueueEntry(db, entry.URL, attempts, retryAt, fetchErr); err != nil {
+			slog.Warn("Failed to reschedule OpenGraph fetch", "error", err, "url", entry.URL)
+		}
+		return
+	}
+
+	// Out of retries, or retrying won't help: cache the failure so the URL isn't queued again until it expires.
+	// A failed refresh keeps the preview fetched earlier and only marks it as refreshed.
+	slog.Debug("Giving up on OpenGraph data", "error", err, "class", fetchErr.Class, "url", entry.URL, "attempts", attempts)
+	if cached, _ := getOpenGraphData(db, entry.URL); cached != nil && cached.FetchSuccess {
+		if err := touchOpenGraphCache(db, entry.URL, now); err != nil {
+			slog.Warn("Failed to mark OpenGraph data as refreshed", "error", err, "url", entry.URL)
+		}
+	} else if err := cacheOpenGraphFailure(db, entry.URL, fetchErr); err != nil {
+		slog.Warn("Failed to cache OpenGraph data", "error", err, "url", entry.URL)
+	}
+	if err := removeOpenGraphQueueEntry(db, entry.URL); err != nil {
+		slog.Warn("Failed to remove URL from OpenGraph queue", "error", err, "url", entry.URL)
+	}
+}
+
+// cachedOpenGraph returns the cached OpenGraph data of a URL, or nil if there is no successful fetch
+func cachedOpenGraph(db *sql.DB, url string) *OpenGraphData {
+	cached, err := getOpenGraphData(db, url)
+	if err != nil {
+		slog.Warn("Error getting cached OpenGraph data", "error", err, "url", url)
+	}
+	if cached == nil || !cached.FetchSuccess {
+		return nil
+	}
+	ogData := &OpenGraphData{
+		URL:          cached.URL,
+		FinalURL:     cached.FinalURL,
+		CanonicalURL: cached.CanonicalURL,
+		Title:        cached.Title,
+		Description:  cached.Description,
+		Image:        cached.Image,
+		SiteName:     cached.SiteName,
+	}
+	// Later submissions of the same link pick up the canonical URL from the cache
+	if err := storeCanonicalURL(db, url, ogData.CanonicalURL); err != nil {
+		slog.Warn("Failed to store canonical URL", "error", err, "url", url)
+	}
+	return ogData
+}
+
+// queueOpenGraphRefreshes queues the preview refreshes of one refresh run. It is called once per
+// run from refreshItems, so the refresh budget is spent once per run rather than once per feed or
+// enrichment pass.
+func queueOpenGraphRefreshes(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper, now time.Time) {
+	fetcher := NewOpenGraphFetcher(categoryMapper.Config().OpenGraph, categoryMapper.Timeouts())
+	if err := enqueueOpenGraphRefreshes(db, items, fetcher.refreshAfter, fetcher.refreshLimit, now); err != nil {
+		slog.Warn("Failed to queue OpenGraph refreshes", "error", err)
+	}
+}
+
+// fetchOpenGraphPreviews queues the article URLs of items that have no cached preview, then works
+// through the due part of the queue, including refreshes queued by the run. It runs as part of a
+// refresh run, never while rendering a feed.
+func fetchOpenGraphPreviews(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	fetcher := NewOpenGraphFetcher(categoryMapper.Config().OpenGraph, categoryMapper.Timeouts())
+	now := time.Now()
+	if err := enqueueOpenGraph(db, items, now); err != nil {
+		slog.Warn("Failed to queue OpenGraph fetches", "error", err)
+	}
+	processOpenGraphQueue(db, fetcher, ogQueueWorkers, now)
+}
+
+// cachedOpenGraphPreviews returns the cached OpenGraph data of each article URL of items that has
+// some. Feeds are rendered from the cache only, so a request never waits on a fetch.
+func cachedOpenGraphPreviews(db *sql.DB, items []HackerNewsItem) map[string]*OpenGraphData {
+	ogDataMap := make(map[string]*OpenGraphData)
+	// Skip OpenGraph lookups if database is nil (for testing)
+	if db == nil {
+		return ogDataMap
+	}
+
+	for _, item := range items {
+		if isTextPost(item) {
+			continue
+		}
+		if ogData := cachedOpenGraph(db, item.Link); ogData != nil {
+			ogDataMap[item.Link] = ogData
+		}
+	}
+	return ogDataMap
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOGRetryBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 15 * time.Minute},
+		{2, 30 * time.Minute},
+		{3, time.Hour},
+		{10, ogQueueMaxBackoff},
+	}
+	for _, tc := range testCases {
+		if got := ogRetryBackoff(tc.attempts); got != tc.expected {
+			t.Errorf("ogRetryBackoff(%d) = %v, expected %v", tc.attempts, got, tc.expected)
+		}
+	}
+}
+
+func TestOpenGraphQueue_PriorityOrder(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><meta property="og:title" content="Page ` + r.URL.Path + `"></head></html>`))
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	items := []HackerNewsItem{
+		{ItemID: "1", Link: server.URL + "/low", Points: 60},
+		{ItemID: "2", Link: server.URL + "/high", Points: 900},
+		{ItemID: "3", Link: server.URL + "/mid", Points: 300},
+		{ItemID: "4", Title: "Ask HN: Text", Points: 1000},
+	}
+	if err := enqueueOpenGraph(db, items, now); err != nil {
+		t.Fatal(err)
+	}
+	// A better scoring submission of a queued URL raises its priority
+	if err := enqueueOpenGraph(db, []HackerNewsItem{{ItemID: "5", Link: server.URL + "/low", Points: 500}}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	processOpenGraphQueue(db, NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts()), 1, now)
+	if expected := []string{"/high", "/low", "/mid"}; !slices.Equal(fetched, expected) {
+		t.Errorf("Expected fetches by priority %v, got %v", expected, fetched)
+	}
+	if due, _ := getDueOpenGraphQueue(db, now.Add(24*time.Hour)); len(due) != 0 {
+		t.Errorf("Expected fetched URLs to leave the queue, got %+v", due)
+	}
+
+	// Cached URLs are not queued again
+	if err := enqueueOpenGraph(db, items, now); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ := getDueOpenGraphQueue(db, now); len(due) != 0 {
+		t.Errorf("Expected cached URLs to stay out of the queue, got %+v", due)
+	}
+}
+
+func TestOpenGraphQueue_RetriesThenGivesUp(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	url := server.URL + "/flaky"
+	now := time.Now()
+	if err := queueOpenGraphURL(db, url, 100, now); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= ogQueueMaxAttempts; attempt++ {
+		due, err := getDueOpenGraphQueue(db, now)
+		if err != nil || len(due) != 1 {
+			t.Fatalf("Attempt %d: expected the URL to be due, got %+v (err %v)", attempt, due, err)
+		}
+		// A fresh fetcher each time skips the per-domain rate limit
+		processOpenGraphQueue(db, NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts()), 1, now)
+		// Not retried before its backoff has passed
+		processOpenGraphQueue(db, NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts()), 1, now)
+		if requests != attempt {
+			t.Fatalf("Attempt %d: expected %d requests, got %d", attempt, attempt, requests)
+		}
+		now = now.Add(ogQueueMaxBackoff + time.Minute)
+	}
+
+	if due, _ := getDueOpenGraphQueue(db, now); len(due) != 0 {
+		t.Errorf("Expected the URL to leave the queue after %d attempts, got %+v", ogQueueMaxAttempts, due)
+	}
+	cached, err := getOpenGraphData(db, url)
+	if err != nil || cached == nil || cached.FetchSuccess {
+		t.Errorf("Expected the failure to be cached, got %+v (err %v)", cached, err)
+	}
+}
+
+func TestOpenGraphRefresh_LongRunningItems(t *testing.T) {
+	var mu sync.Mutex
+	fetched := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><meta property="og:title" content="Corrected ` + r.URL.Path + `"></head></html>`))
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	items := []HackerNewsItem{
+		{ItemID: "1", Link: server.URL + "/old", Points: 500, CreatedAt: now.Add(-8 * time.Hour)},
+		{ItemID: "2", Link: server.URL + "/gone", Points: 300, CreatedAt: now.Add(-8 * time.Hour)},
+		{ItemID: "3", Link: server.URL + "/lower", Points: 100, CreatedAt: now.Add(-8 * time.Hour)},
+		{ItemID: "4", Link: server.URL + "/young", Points: 900, CreatedAt: now.Add(-time.Hour)},
+	}
+	// Every preview was fetched shortly after submission
+	for _, item := range items {
+		if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link, Title: "Original"}, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := touchOpenGraphCache(db, item.Link, item.CreatedAt.Add(10*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The budget keeps the lowest scoring long-running item for a later run, and young items aren't refreshed
+	if err := enqueueOpenGraphRefreshes(db, items, defaultOGRefreshAfter, 2, now); err != nil {
+		t.Fatal(err)
+	}
+	processOpenGraphQueue(db, NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts()), 1, now)
+	if fetched["/old"] != 1 || fetched["/gone"] != 1 || fetched["/lower"] != 0 || fetched["/young"] != 0 {
+		t.Fatalf("Unexpected refreshes: %v", fetched)
+	}
+	if og := cachedOpenGraph(db, server.URL+"/old"); og == nil || og.Title != "Corrected /old" {
+		t.Errorf("Expected the refreshed title, got %+v", og)
+	}
+	// A failed refresh keeps the earlier preview
+	if og := cachedOpenGraph(db, server.URL+"/gone"); og == nil || og.Title != "Original" {
+		t.Errorf("Expected the original preview to survive a failed refresh, got %+v", og)
+	}
+
+	// Each item is refreshed only once
+	if err := enqueueOpenGraphRefreshes(db, items, defaultOGRefreshAfter, 2, now); err != nil {
+		t.Fatal(err)
+	}
+	processOpenGraphQueue(db, NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts()), 1, now)
+	if fetched["/old"] != 1 || fetched["/gone"] != 1 || fetched["/lower"] != 1 {
+		t.Errorf("Expected only the remaining item to be refreshed, got %v", fetched)
+	}
+}
+
+func TestNewOpenGraphFetcher_RefreshSettings(t *testing.T) {
+	fetcher := NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts())
+	if fetcher.refreshAfter != defaultOGRefreshAfter || fetcher.refreshLimit != defaultOGRefreshBudget {
+		t.Errorf("Unexpected defaults: %v, %d", fetcher.refreshAfter, fetcher.refreshLimit)
+	}
+	fetcher = NewOpenGraphFetcher(OpenGraphConfig{RefreshAfterHours: -1, RefreshBudget: 3}, defaultNetworkTimeouts())
+	if fetcher.refreshAfter != 0 || fetcher.refreshLimit != 3 {
+		t.Errorf("Expected refreshes disabled, got %v, %d", fetcher.refreshAfter, fetcher.refreshLimit)
+	}
+}
+
+func TestGenerateFeed_ReadsOpenGraphCacheOnly(t *testing.T) {
+	var fetched atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Add(1)
+		_, _ = w.Write([]byte(`<html><head><meta property="og:title" content="Fetched"></head></html>`))
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	items := []HackerNewsItem{{ItemID: "1", Title: "Story", Link: server.URL + "/post", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CreatedAt: now, UpdatedAt: now}}
+	updateStoredItems(db, items)
+
+	_ = generateFeed(db, items, 0, nil, defaultFeedInfo)
+	if n := fetched.Load(); n != 0 {
+		t.Fatalf("Expected rendering not to fetch previews, got %d requests", n)
+	}
+	if queued, err := getDueOpenGraphQueue(db, now.Add(time.Hour)); err != nil || len(queued) != 0 {
+		t.Errorf("Expected rendering not to queue previews, got %+v (err %v)", queued, err)
+	}
+
+	// The refresh run fetches the preview, and the next render uses it
+	fetchOpenGraphPreviews(db, items, nil)
+	if n := fetched.Load(); n != 1 {
+		t.Fatalf("Expected the refresh run to fetch the preview, got %d requests", n)
+	}
+	if og := cachedOpenGraphPreviews(db, items)[items[0].Link]; og == nil || og.Title != "Fetched" {
+		t.Errorf("Expected the cached preview, got %+v", og)
+	}
+}
+
+func TestFetchOpenGraphPreviews_LeavesRefreshesToTheRun(t *testing.T) {
+	var fetched atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Add(1)
+		_, _ = w.Write([]byte(`<html><head><meta property="og:title" content="Corrected"></head></html>`))
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	var items []HackerNewsItem
+	for i, path := range []string{"/a", "/b", "/c"} {
+		item := HackerNewsItem{ItemID: fmt.Sprint(i), Link: server.URL + path, Points: 300 - i, CreatedAt: now.Add(-8 * time.Hour)}
+		items = append(items, item)
+		if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link, Title: "Original"}, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := touchOpenGraphCache(db, item.Link, item.CreatedAt.Add(10*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Enrichment passes, such as adding a story or rendering several feeds, don't queue refreshes
+	fetchOpenGraphPreviews(db, items, nil)
+	fetchOpenGraphPreviews(db, items, nil)
+	if n := fetched.Load(); n != 0 {
+		t.Fatalf("Expected no refreshes outside a run, got %d fetches", n)
+	}
+
+	// A run spends its budget once
+	mapper := NewCategoryMapper(&DomainConfig{OpenGraph: OpenGraphConfig{RefreshBudget: 2}})
+	queueOpenGraphRefreshes(db, items, mapper, now)
+	fetchOpenGraphPreviews(db, items, mapper)
+	fetchOpenGraphPreviews(db, items, mapper)
+	if n := fetched.Load(); n != 2 {
+		t.Errorf("Expected the run's budget of 2 refreshes, got %d fetches", n)
+	}
+}
@@ -12,53 +12,109 @@ import (
 	"time"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
 )
 
+// defaultMaxRedirects is the number of redirect hops followed when none is configured
+const defaultMaxRedirects = 10
+
+// ogMaxPageSize is the largest declared page size fetched for a preview; only the first 1MB is parsed
+const ogMaxPageSize = 10 << 20
+
+// defaultBlockedRedirectors are ad and tracking redirectors that never lead to a useful preview
+var defaultBlockedRedirectors = []string{
+	"doubleclick.net",
+	"googleadservices.com",
+	"redirectingat.com",
+	"linksynergy.com",
+	"consent.google.com",
+	"consent.yahoo.com",
+}
+
+// OpenGraphConfig configures how the OpenGraph fetcher follows redirects
+type OpenGraphConfig struct {
+	MaxRedirects        int      `json:"max_redirects"`         // redirect hops followed (0 = 10, negative disables redirects)
+	SameDomainRedirects bool     `json:"same_domain_redirects"` // only follow redirects within the article's registrable domain
+	BlockedRedirectors  []string `json:"blocked_redirectors"`   // hosts (and their subdomains) never redirected to, in addition to the built-in list
+	RefreshAfterHours   int      `json:"refresh_after_hours"`   // refetch the preview once when an item is this old (0 = 6, negative disables)
+	RefreshBudget       int      `json:"refresh_budget"`        // preview refreshes queued per refresh run (0 = 10)
+}
+
 // OpenGraph fetcher with rate limiting and domain-based delays
 type OpenGraphFetcher struct {
-	client      *http.Client
-	domainMutex sync.Mutex
-	lastFetch   map[string]time.Time
-	semaphore   chan struct{}
-	urlMutexes  sync.Map // URL -> *sync.Mutex for preventing concurrent fetches of same URL
+	client       *http.Client
+	fetchTimeout time.Duration // overall limit per URL, including rate-limit waits
+	refreshAfter time.Duration // item age at which its preview is refetched once, 0 if disabled
+	refreshLimit int           // preview refreshes queued per refresh run
+	domainMutex  sync.Mutex
+	lastFetch    map[string]time.Time
 }
 
-// NewOpenGraphFetcher creates a new OpenGraph fetcher with rate limiting
-func NewOpenGraphFetcher() *OpenGraphFetcher {
+// NewOpenGraphFetcher creates a new OpenGraph fetcher with rate limiting, the configured redirect policy and timeouts
+func NewOpenGraphFetcher(config OpenGraphConfig, timeouts networkTimeouts) *OpenGraphFetcher {
+	refreshAfter := defaultOGRefreshAfter
+	if config.RefreshAfterHours != 0 {
+		refreshAfter = max(time.Duration(config.RefreshAfterHours)*time.Hour, 0)
+	}
+	refreshLimit := config.RefreshBudget
+	if refreshLimit == 0 {
+		refreshLimit = defaultOGRefreshBudget
+	}
 	return &OpenGraphFetcher{
 		client: &http.Client{
-			Timeout: 10 * time.Second,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				// Limit redirects to 10
-				if len(via) >= 10 {
-					return fmt.Errorf("too many redirects")
-				}
-				return nil
-			},
+			Timeout:       timeouts.OpenGraphRequest,
+			CheckRedirect: redirectPolicy(config),
 		},
-		lastFetch: make(map[string]time.Time),
-		semaphore: make(chan struct{}, 5), // Max 5 concurrent fetches
+		fetchTimeout: timeouts.OpenGraph,
+		refreshAfter: refreshAfter,
+		refreshLimit: refreshLimit,
+		lastFetch:    make(map[string]time.Time),
 	}
 }
 
-// FetchOpenGraph fetches OpenGraph data from a URL with rate limiting
-func (f *OpenGraphFetcher) FetchOpenGraph(ctx context.Context, targetURL string) (*OpenGraphData, error) {
-	// Get or create a mutex for this URL to prevent concurrent fetches
-	urlMutexInterface, _ := f.urlMutexes.LoadOrStore(targetURL, &sync.Mutex{})
-	urlMutex := urlMutexInterface.(*sync.Mutex)
+// redirectPolicy returns an http.Client CheckRedirect function enforcing the hop limit,
+// the same-domain restriction and the redirector blocklist
+func redirectPolicy(config OpenGraphConfig) func(req *http.Request, via []*http.Request) error {
+	maxRedirects := config.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	blocked := append(append([]string{}, defaultBlockedRedirectors...), config.BlockedRedirectors...)
 
-	// Lock for this specific URL
-	urlMutex.Lock()
-	defer urlMutex.Unlock()
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects || maxRedirects < 0 {
+			return fmt.Errorf("too many redirects")
+		}
 
-	// Acquire semaphore slot
-	select {
-	case f.semaphore <- struct{}{}:
-		defer func() { <-f.semaphore }()
-	case <-ctx.Done():
-		return nil, ctx.Err()
+		host := strings.ToLower(req.URL.Hostname())
+		for _, b := range blocked {
+			b = strings.ToLower(strings.TrimSpace(b))
+			if b != "" && (host == b || strings.HasSuffix(host, "."+b)) {
+				return fmt.Errorf("redirect to blocked host %s", host)
+			}
+		}
+
+		if config.SameDomainRedirects && registrableDomain(host) != registrableDomain(via[0].URL.Hostname()) {
+			return fmt.Errorf("redirect to another domain: %s", host)
+		}
+		return nil
+	}
+}
+
+// registrableDomain returns the public suffix plus one label of a host (e.g. "bbc.co.uk" for
+// "www.bbc.co.uk"), falling back to the host itself for IP addresses and bare suffixes
+func registrableDomain(host string) string {
+	host = strings.ToLower(host)
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
 	}
+	return domain
+}
 
+// FetchOpenGraph fetches OpenGraph data from a URL with per-domain rate limiting. Concurrency is
+// bounded by the OpenGraph queue, which hands each URL to a single worker.
+func (f *OpenGraphFetcher) FetchOpenGraph(ctx context.Context, targetURL string) (*OpenGraphData, error) {
 	// Parse URL to get domain
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
@@ -106,13 +162,18 @@ func (f *OpenGraphFetcher) FetchOpenGraph(ctx context.Context, targetURL string)
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+		return nil, httpStatusError(resp.StatusCode)
 	}
 
 	// Check content type
 	contentType := resp.Header.Get("Content-Type")
 	if !strings.Contains(strings.ToLower(contentType), "text/html") {
-		return nil, fmt.Errorf("not an HTML page: %s", contentType)
+		return nil, &fetchError{Class: fetchErrorNotHTML, Err: fmt.Errorf("not an HTML page: %s", contentType)}
+	}
+
+	// Pages this large are downloads or dumps rather than articles
+	if resp.ContentLength > ogMaxPageSize {
+		return nil, &fetchError{Class: fetchErrorTooLarge, Err: fmt.Errorf("page too large: %d bytes", resp.ContentLength)}
 	}
 
 	// Limit response body size to 1MB
@@ -124,12 +185,14 @@ func (f *OpenGraphFetcher) FetchOpenGraph(ctx context.Context, targetURL string)
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	// Extract OpenGraph data
+	// Extract OpenGraph data, remembering where redirects ended up
 	ogData := &OpenGraphData{
-		URL: targetURL,
+		URL:      targetURL,
+		FinalURL: resp.Request.URL.String(),
 	}
 
 	extractOpenGraphTags(doc, ogData)
+	ogData.CanonicalURL = resolveCanonicalURL(resp.Request.URL, ogData.CanonicalURL)
 
 	slog.Debug("Extracted OpenGraph data", "url", targetURL, "title", ogData.Title, "hasDescription", ogData.Description != "")
 
@@ -170,6 +233,24 @@ func extractOpenGraphTags(n *html.Node, ogData *OpenGraphData) {
 		}
 	}
 
+	// Remember the raw rel=canonical link, resolved once the whole page has been read
+	if n.Type == html.ElementNode && n.Data == "link" && ogData.CanonicalURL == "" {
+		var rel, href string
+		for _, attr := range n.Attr {
+			switch attr.Key {
+			case "rel":
+				rel = attr.Val
+			case "href":
+				href = attr.Val
+			}
+		}
+		for _, value := range strings.Fields(rel) {
+			if strings.EqualFold(value, "canonical") {
+				ogData.CanonicalURL = strings.TrimSpace(href)
+			}
+		}
+	}
+
 	// Also check for fallback title in <title> tag
 	if n.Type == html.ElementNode && n.Data == "title" && ogData.Title == "" {
 		if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
@@ -199,6 +280,29 @@ func extractOpenGraphTags(n *html.Node, ogData *OpenGraphData) {
 	}
 }
 
+// resolveCanonicalURL resolves a page's rel=canonical link against the URL the page was served from.
+// Missing, non-HTTP and front-page canonicals on article pages, a common CMS misconfiguration,
+// fall back to the served URL.
+func resolveCanonicalURL(pageURL *url.URL, href string) string {
+	fallback := pageURL.String()
+	if href == "" {
+		return fallback
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return fallback
+	}
+	canonical := pageURL.ResolveReference(ref)
+	if canonical.Scheme != "http" && canonical.Scheme != "https" || canonical.Host == "" {
+		return fallback
+	}
+	if strings.Trim(canonical.Path, "/") == "" && strings.Trim(pageURL.Path, "/") != "" {
+		return fallback
+	}
+	canonical.Fragment = ""
+	return canonical.String()
+}
+
 // truncateString truncates a string to a maximum length
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
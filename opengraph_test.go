@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -351,3 +356,155 @@ func TestCleanOpenGraphData_EmptyImageURL(t *testing.T) {
 		t.Errorf("Expected empty image URL to remain empty, got '%s'", ogData.Image)
 	}
 }
+
+func TestFetchOpenGraph_RecordsFinalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/new":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><meta property="og:title" content="Moved"></head></html>`))
+		default:
+			http.Redirect(w, r, "/loop"+r.URL.Path, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewOpenGraphFetcher(OpenGraphConfig{}, defaultNetworkTimeouts())
+	ogData, err := fetcher.FetchOpenGraph(context.Background(), server.URL+"/old")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ogData.FinalURL != server.URL+"/new" || ogData.Title != "Moved" {
+		t.Errorf("Expected final URL %s/new with title, got %+v", server.URL, ogData)
+	}
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+	if err := cacheOpenGraphData(db, ogData, true); err != nil {
+		t.Fatalf("Failed to cache data: %v", err)
+	}
+	cached, err := getOpenGraphData(db, server.URL+"/old")
+	if err != nil || cached == nil || cached.FinalURL != server.URL+"/new" {
+		t.Errorf("Expected final URL in cache, got %+v (err %v)", cached, err)
+	}
+
+	// Endless redirects stop at the configured hop limit
+	limited := NewOpenGraphFetcher(OpenGraphConfig{MaxRedirects: 2}, defaultNetworkTimeouts())
+	if _, err := limited.FetchOpenGraph(context.Background(), server.URL+"/x"); err == nil || !strings.Contains(err.Error(), "too many redirects") {
+		t.Errorf("Expected too many redirects error, got %v", err)
+	}
+}
+
+func TestResolveCanonicalURL(t *testing.T) {
+	page, _ := url.Parse("https://www.example.com/2024/01/post?utm_source=hn")
+
+	testCases := []struct {
+		href     string
+		expected string
+	}{
+		{"", "https://www.example.com/2024/01/post?utm_source=hn"},
+		{"https://example.com/post", "https://example.com/post"},
+		{"/2024/01/post", "https://www.example.com/2024/01/post"},
+		{"https://example.com/post#section", "https://example.com/post"},
+		{"https://example.com/", "https://www.example.com/2024/01/post?utm_source=hn"},
+		{"javascript:void(0)", "https://www.example.com/2024/01/post?utm_source=hn"},
+	}
+	for _, tc := range testCases {
+		if got := resolveCanonicalURL(page, tc.href); got != tc.expected {
+			t.Errorf("resolveCanonicalURL(%q) = %q, expected %q", tc.href, got, tc.expected)
+		}
+	}
+}
+
+func TestLoadOpenGraphPreviews_StoresCanonicalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/short":
+			http.Redirect(w, r, "/amp/story", http.StatusFound)
+		case "/amp/story":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="/story"><meta property="og:title" content="Story"></head></html>`))
+		}
+	}))
+	defer server.Close()
+
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	item := HackerNewsItem{ItemID: "1", Title: "Story", Link: server.URL + "/short", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CreatedAt: now, UpdatedAt: now}
+	updateStoredItems(db, []HackerNewsItem{item})
+
+	fetchOpenGraphPreviews(db, []HackerNewsItem{item}, nil)
+	ogData := cachedOpenGraphPreviews(db, []HackerNewsItem{item})[item.Link]
+	if ogData == nil || ogData.CanonicalURL != server.URL+"/story" || ogData.FinalURL != server.URL+"/amp/story" {
+		t.Fatalf("Expected the canonical URL from the page, got %+v", ogData)
+	}
+
+	stored, err := getItemByID(db, "1")
+	if err != nil || stored == nil || stored.CanonicalURL != server.URL+"/story" || stored.Link != item.Link {
+		t.Fatalf("Expected the canonical URL stored next to the submitted link, got %+v (err %v)", stored, err)
+	}
+
+	// A later submission of the same link picks up the canonical URL from the cache
+	second := item
+	second.ItemID = "2"
+	updateStoredItems(db, []HackerNewsItem{second})
+	if cached := cachedOpenGraphPreviews(db, []HackerNewsItem{second})[item.Link]; cached == nil || cached.CanonicalURL != server.URL+"/story" {
+		t.Fatalf("Expected the canonical URL from the cache, got %+v", cached)
+	}
+	if stored, _ := getItemByID(db, "2"); stored == nil || stored.CanonicalURL != server.URL+"/story" {
+		t.Errorf("Expected the second submission to get the canonical URL, got %+v", stored)
+	}
+
+	// Changing the submitted link forgets the canonical URL resolved for the old one
+	item.Link = server.URL + "/other"
+	updateStoredItems(db, []HackerNewsItem{item})
+	if stored, _ := getItemByID(db, "1"); stored == nil || stored.CanonicalURL != "" {
+		t.Errorf("Expected the canonical URL to be cleared, got %+v", stored)
+	}
+}
+
+func TestRedirectPolicy(t *testing.T) {
+	redirect := func(from, to string, hops int) (*http.Request, []*http.Request) {
+		first := httptest.NewRequest(http.MethodGet, from, nil)
+		via := []*http.Request{first}
+		for len(via) < hops {
+			via = append(via, first)
+		}
+		return httptest.NewRequest(http.MethodGet, to, nil), via
+	}
+
+	testCases := []struct {
+		name    string
+		config  OpenGraphConfig
+		from    string
+		to      string
+		hops    int
+		allowed bool
+	}{
+		{"default follows anywhere", OpenGraphConfig{}, "https://example.com/a", "https://other.org/b", 1, true},
+		{"default hop limit", OpenGraphConfig{}, "https://example.com/a", "https://example.com/b", 11, false},
+		{"configured hop limit", OpenGraphConfig{MaxRedirects: 1}, "https://example.com/a", "https://example.com/b", 2, false},
+		{"redirects disabled", OpenGraphConfig{MaxRedirects: -1}, "https://example.com/a", "https://example.com/b", 1, false},
+		{"built-in blocklist", OpenGraphConfig{}, "https://example.com/a", "https://ad.doubleclick.net/click", 1, false},
+		{"configured blocklist", OpenGraphConfig{BlockedRedirectors: []string{"Tracker.example"}}, "https://example.com/a", "https://go.tracker.example/x", 1, false},
+		{"same domain subdomain", OpenGraphConfig{SameDomainRedirects: true}, "https://bbc.co.uk/a", "https://www.bbc.co.uk/b", 1, true},
+		{"same domain elsewhere", OpenGraphConfig{SameDomainRedirects: true}, "https://bbc.co.uk/a", "https://cnn.co.uk/b", 1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, via := redirect(tc.from, tc.to, tc.hops)
+			err := redirectPolicy(tc.config)(req, via)
+			if tc.allowed && err != nil {
+				t.Errorf("Expected redirect to be allowed, got %v", err)
+			}
+			if !tc.allowed && err == nil {
+				t.Error("Expected redirect to be refused")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/feeds"
+)
+
+// feedPageFile returns the file name of a page of a paged feed. The first page keeps the feed's own name,
+// so subscriptions keep working, and later pages are named like hackernews-page2.xml.
+func feedPageFile(name string, page int) string {
+	if page <= 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-page%d%s", strings.TrimSuffix(name, ext), page, ext)
+}
+
+// paginateItems splits items into pages of pageSize, keeping them on one page if pageSize isn't positive
+func paginateItems(items []HackerNewsItem, pageSize int) [][]HackerNewsItem {
+	if pageSize <= 0 || len(items) <= pageSize {
+		return [][]HackerNewsItem{items}
+	}
+	var pages [][]HackerNewsItem
+	for start := 0; start < len(items); start += pageSize {
+		pages = append(pages, items[start:min(start+pageSize, len(items))])
+	}
+	return pages
+}
+
+// pagingLinks returns the RFC 5005 first, previous, next and last links of a page. The links are relative
+// to the page itself, since all pages are written to the same directory.
+func pagingLinks(name string, page, pages int) []feeds.AtomLink {
+	const atomType = "application/atom+xml"
+	links := []feeds.AtomLink{{Href: feedPageFile(name, 1), Rel: "first", Type: atomType}}
+	if page > 1 {
+		links = append(links, feeds.AtomLink{Href: feedPageFile(name, page-1), Rel: "previous", Type: atomType})
+	}
+	if page < pages {
+		links = append(links, feeds.AtomLink{Href: feedPageFile(name, page+1), Rel: "next", Type: atomType})
+	}
+	return append(links, feeds.AtomLink{Href: feedPageFile(name, pages), Rel: "last", Type: atomType})
+}
+
+// generatePagedFeed renders items as a feed split into pages of pageSize items linked to each other,
+// newest first. A feed that fits on one page is rendered as a single document without paging links.
+func generatePagedFeed(db *sql.DB, name string, items []HackerNewsItem, minPoints int, categoryMapper *CategoryMapper, info feedInfo, pageSize int) []string {
+	pages := paginateItems(items, pageSize)
+	if len(pages) == 1 {
+		return []string{generateFeed(db, items, minPoints, categoryMapper, info)}
+	}
+
+	documents := make([]string, len(pages))
+	for i, pageItems := range pages {
+		pageInfo := info
+		pageInfo.Links = slices.Concat(info.Links, pagingLinks(name, i+1, len(pages)))
+		if i > 0 {
+			// Only the first page is the WebSub topic
+			pageInfo.Links = slices.DeleteFunc(pageInfo.Links, isWebSubLink)
+			pageInfo.Title = fmt.Sprintf("%s (page %d)", info.Title, i+1)
+		}
+		documents[i] = generateFeed(db, pageItems, minPoints, categoryMapper, pageInfo)
+	}
+	return documents
+}
+
+// writeFeedPages writes the pages of a feed to outDir and removes pages left over from earlier runs
+// that had more of them. It reports whether the first page changed.
+func writeFeedPages(outDir, name string, pages []string) (bool, error) {
+	changed := false
+	for i, page := range pages {
+		filename := filepath.Join(outDir, feedPageFile(name, i+1))
+		written, err := writeFeedFile(filename, page)
+		if err != nil {
+			return changed, fmt.Errorf("failed to write feed page %d: %w", i+1, err)
+		}
+		if i == 0 {
+			changed = written
+		}
+	}
+	for page := len(pages) + 1; ; page++ {
+		filename := filepath.Join(outDir, feedPageFile(name, page))
+		err := os.Remove(filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			return changed, nil
+		}
+		if err != nil {
+			return changed, fmt.Errorf("failed to remove stale feed page %d: %w", page, err)
+		}
+		// A compressed copy exists if an earlier run used -gzip
+		if err := os.Remove(filename + ".gz"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return changed, fmt.Errorf("failed to remove stale feed page %d: %w", page, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFeedPageFile(t *testing.T) {
+	testCases := []struct {
+		page     int
+		expected string
+	}{
+		{1, "hackernews.xml"},
+		{2, "hackernews-page2.xml"},
+		{12, "hackernews-page12.xml"},
+	}
+	for _, tc := range testCases {
+		if got := feedPageFile("hackernews.xml", tc.page); got != tc.expected {
+			t.Errorf("feedPageFile(%d) = %s, expected %s", tc.page, got, tc.expected)
+		}
+	}
+}
+
+func TestPaginateItems(t *testing.T) {
+	items := make([]HackerNewsItem, 7)
+	var sizes []int
+	for _, page := range paginateItems(items, 3) {
+		sizes = append(sizes, len(page))
+	}
+	if !slices.Equal(sizes, []int{3, 3, 1}) {
+		t.Errorf("Expected pages of 3, 3 and 1 items, got %v", sizes)
+	}
+	if pages := paginateItems(items, 0); len(pages) != 1 || len(pages[0]) != 7 {
+		t.Errorf("Expected a single page without a page size, got %d", len(pages))
+	}
+	if pages := paginateItems(items, 10); len(pages) != 1 {
+		t.Errorf("Expected a single page when everything fits, got %d", len(pages))
+	}
+}
+
+// pagedFeedLinks parses the feed-level links of a generated feed by relation
+func pagedFeedLinks(t *testing.T, document string) map[string]string {
+	t.Helper()
+	var feed struct {
+		Links []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct{} `xml:"entry"`
+	}
+	if err := xml.Unmarshal([]byte(document), &feed); err != nil {
+		t.Fatalf("Invalid feed XML: %v", err)
+	}
+	links := map[string]string{"entries": fmt.Sprint(len(feed.Entries))}
+	for _, link := range feed.Links {
+		links[link.Rel] = link.Href
+	}
+	return links
+}
+
+func TestGeneratePagedFeed(t *testing.T) {
+	var items []HackerNewsItem
+	for i := range 5 {
+		id := fmt.Sprint(100 + i)
+		items = append(items, HackerNewsItem{ItemID: id, Title: "Story " + id, CommentsLink: "https://news.ycombinator.com/item?id=" + id, Points: 100, CreatedAt: time.Now()})
+	}
+
+	pages := generatePagedFeed(nil, "hackernews.xml", items, 50, nil, defaultFeedInfo, 2)
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(pages))
+	}
+
+	first := pagedFeedLinks(t, pages[0])
+	if first["entries"] != "2" || first["next"] != "hackernews-page2.xml" || first["previous"] != "" ||
+		first["first"] != "hackernews.xml" || first["last"] != "hackernews-page3.xml" || first["self"] == "" {
+		t.Errorf("Unexpected first page links: %v", first)
+	}
+	middle := pagedFeedLinks(t, pages[1])
+	if middle["previous"] != "hackernews.xml" || middle["next"] != "hackernews-page3.xml" {
+		t.Errorf("Unexpected middle page links: %v", middle)
+	}
+	last := pagedFeedLinks(t, pages[2])
+	if last["entries"] != "1" || last["previous"] != "hackernews-page2.xml" || last["next"] != "" {
+		t.Errorf("Unexpected last page links: %v", last)
+	}
+
+	single := generatePagedFeed(nil, "hackernews.xml", items, 50, nil, defaultFeedInfo, 0)
+	if links := pagedFeedLinks(t, single[0]); len(single) != 1 || links["entries"] != "5" || links["next"] != "" || links["first"] != "" {
+		t.Errorf("Expected an unpaged feed without paging links, got %d pages %v", len(single), links)
+	}
+}
+
+func TestWriteFeedPages_RemovesStalePages(t *testing.T) {
+	outDir := t.TempDir()
+	if _, err := writeFeedPages(outDir, "hackernews.xml", []string{"1", "2", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeFeedPages(outDir, "hackernews.xml", []string{"one"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "hackernews.xml" {
+		t.Errorf("Expected only the first page to remain, got %v", entries)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outDir, "hackernews.xml")); string(data) != "one" {
+		t.Errorf("Expected the first page to be rewritten, got %q", data)
+	}
+}
+
+func TestWriteFeedPages_RemovesStaleCompressedPages(t *testing.T) {
+	gzipFeeds = true
+	t.Cleanup(func() { gzipFeeds = false })
+
+	outDir := t.TempDir()
+	if _, err := writeFeedPages(outDir, "hackernews.xml", []string{"1", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeFeedPages(outDir, "hackernews.xml", []string{"one"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	entries, _ := os.ReadDir(outDir)
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 2 || names[0] != "hackernews.xml" || names[1] != "hackernews.xml.gz" {
+		t.Errorf("Expected only the first page and its compressed copy to remain, got %v", names)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Tables are exported as flat schemas of required or optional columns, gzip-compressed
+// and encoded by parquet-go, so DuckDB, pandas and Spark read them without surprises.
+
+// parquetExportFile is a table written by export -format parquet
+type parquetExportFile struct {
+	Name    string
+	Columns func(db *sql.DB) ([]parquetColumn, error)
+}
+
+// parquetExportFiles are the tables exported, written as <name>.parquet
+var parquetExportFiles = []parquetExportFile{
+	{Name: "items", Columns: itemsParquetColumns},
+	{Name: "runs", Columns: runsParquetColumns},
+	{Name: "item_stats_history", Columns: statsHistoryParquetColumns},
+}
+
+// itemsParquetColumns returns every stored item, including dead ones, with its front page history.
+// Text posts have a null url, items that never returned have a null returned_at and live items a null dead_at.
+func itemsParquetColumns(db *sql.DB) ([]parquetColumn, error) {
+	rows, err := db.Query("SELECT " + itemColumns + " ORDER BY items.created_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns := []parquetColumn{
+		{Name: "hn_id", Kind: parquetInt64},
+		{Name: "title", Kind: parquetString},
+		{Name: "url", Kind: parquetString, Optional: true},
+		{Name: "comments_url", Kind: parquetString},
+		{Name: "domain", Kind: parquetString, Optional: true},
+		{Name: "points", Kind: parquetInt64},
+		{Name: "comments", Kind: parquetInt64},
+		{Name: "author", Kind: parquetString},
+		{Name: "created_at", Kind: parquetTimestamp},
+		{Name: "updated_at", Kind: parquetTimestamp},
+		{Name: "returned_at", Kind: parquetTimestamp, Optional: true},
+		{Name: "dead_at", Kind: parquetTimestamp, Optional: true},
+	}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		id, err := strconv.ParseInt(item.ItemID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("item %q has a non-numeric ID: %w", item.ItemID, err)
+		}
+		var link, domain, returnedAt, deadAt any
+		if !isTextPost(item) {
+			link = item.Link
+			if d := extractDomain(item.Link); d != "" {
+				domain = d
+			}
+		}
+		if !item.ReturnedAt.IsZero() {
+			returnedAt = item.ReturnedAt
+		}
+		if !item.DeadAt.IsZero() {
+			deadAt = item.DeadAt
+		}
+		row := []any{id, item.Title, link, item.CommentsLink, domain, int64(item.Points), int64(item.CommentCount),
+			item.Author, item.CreatedAt, item.UpdatedAt, returnedAt, deadAt}
+		for i := range columns {
+			columns[i].Values = append(columns[i].Values, row[i])
+		}
+	}
+	return columns, rows.Err()
+}
+
+// runsParquetColumns returns the history of fetch runs, oldest first
+func runsParquetColumns(db *sql.DB) ([]parquetColumn, error) {
+	runs, err := listRuns(db, -1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []parquetColumn{
+		{Name: "id", Kind: parquetInt64},
+		{Name: "source", Kind: parquetString},
+		{Name: "started_at", Kind: parquetTimestamp},
+		{Name: "finished_at", Kind: parquetTimestamp},
+		{Name: "fetched", Kind: parquetInt64},
+		{Name: "updated", Kind: parquetInt64},
+		{Name: "feed_items", Kind: parquetInt64},
+		{Name: "error", Kind: parquetString, Optional: true},
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		var runError any
+		if run.Error != "" {
+			runError = run.Error
+		}
+		row := []any{run.ID, run.Source, run.StartedAt, run.FinishedAt, int64(run.Fetched), int64(run.Updated), int64(run.FeedItems), runError}
+		for j := range columns {
+			columns[j].Values = append(columns[j].Values, row[j])
+		}
+	}
+	return columns, nil
+}
+
+// statsHistoryParquetColumns returns the stats snapshot of every item at every run, by item and time
+func statsHistoryParquetColumns(db *sql.DB) ([]parquetColumn, error) {
+	rows, err := db.Query("SELECT item_hn_id, recorded_at, points, comment_count FROM item_stats_history ORDER BY CAST(item_hn_id AS INTEGER), recorded_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns := []parquetColumn{
+		{Name: "hn_id", Kind: parquetInt64},
+		{Name: "recorded_at", Kind: parquetTimestamp},
+		{Name: "points", Kind: parquetInt64},
+		{Name: "comments", Kind: parquetInt64},
+	}
+	for rows.Next() {
+		var itemID string
+		var snapshot statsSnapshot
+		if err := rows.Scan(&itemID, &snapshot.RecordedAt, &snapshot.Points, &snapshot.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan stats snapshot: %w", err)
+		}
+		id, err := strconv.ParseInt(itemID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("item %q has a non-numeric ID: %w", itemID, err)
+		}
+		row := []any{id, snapshot.RecordedAt, int64(snapshot.Points), int64(snapshot.Comments)}
+		for i := range columns {
+			columns[i].Values = append(columns[i].Values, row[i])
+		}
+	}
+	return columns, rows.Err()
+}
+
+// writeParquetExport writes every exported table to outDir, returning the paths written
+func writeParquetExport(db *sql.DB, outDir string) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var paths []string
+	for _, file := range parquetExportFiles {
+		columns, err := file.Columns(db)
+		if err != nil {
+			return paths, fmt.Errorf("failed to export %s: %w", file.Name, err)
+		}
+
+		// Write to a temporary file so readers never see a partial export
+		path := filepath.Join(outDir, file.Name+".parquet")
+		f, err := os.Create(path + ".tmp")
+		if err != nil {
+			return paths, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		w := bufio.NewWriter(f)
+		if err := writeParquet(w, columns); err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			return paths, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := w.Flush(); err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+			return paths, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			_ = os.Remove(f.Name())
+			return paths, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := os.Rename(f.Name(), path); err != nil {
+			return paths, fmt.Errorf("failed to rename %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// runExportData handles export -format, which writes the tables for analysis tools
+func runExportData(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "parquet", "export format: parquet")
+	outDir := fs.String("outdir", ".", "directory where the exported files will be saved")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+
+	if *format != "parquet" {
+		fmt.Fprintf(os.Stderr, "export: unsupported format %q\n", *format)
+		os.Exit(2)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	paths, err := writeParquetExport(db, *outDir)
+	if err != nil {
+		slog.Error("Failed to export Parquet files", "error", err)
+		os.Exit(1)
+	}
+	for _, path := range paths {
+		slog.Info("Exported table", "filename", path)
+	}
+}
+
+// parquetKind is the type of a column
+type parquetKind int
+
+const (
+	parquetInt64 parquetKind = iota
+	parquetDouble
+	parquetString
+	parquetTimestamp // microseconds since the Unix epoch, UTC
+	parquetBool
+)
+
+// parquetColumn is a column of values; nil values are nulls and require Optional
+type parquetColumn struct {
+	Name     string
+	Kind     parquetKind
+	Optional bool
+	Values   []any // int64, float64, string, time.Time or bool depending on Kind
+}
+
+// node returns the schema node of a column
+func (c parquetColumn) node() parquet.Node {
+	var node parquet.Node
+	switch c.Kind {
+	case parquetDouble:
+		node = parquet.Leaf(parquet.DoubleType)
+	case parquetString:
+		node = parquet.String()
+	case parquetTimestamp:
+		node = parquet.Timestamp(parquet.Microsecond)
+	case parquetBool:
+		node = parquet.Leaf(parquet.BooleanType)
+	default:
+		node = parquet.Int(64)
+	}
+	if c.Optional {
+		node = parquet.Optional(node)
+	}
+	return node
+}
+
+// value converts the i-th value of a column to a Parquet value at column index index
+func (c parquetColumn) value(i, index int) (parquet.Value, error) {
+	value := c.Values[i]
+	if value == nil {
+		if !c.Optional {
+			return parquet.Value{}, fmt.Errorf("null value in required column")
+		}
+		return parquet.NullValue().Level(0, 0, index), nil
+	}
+
+	var v parquet.Value
+	switch c.Kind {
+	case parquetInt64:
+		n, ok := value.(int64)
+		if !ok {
+			return v, fmt.Errorf("expected int64, got %T", value)
+		}
+		v = parquet.Int64Value(n)
+	case parquetTimestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			return v, fmt.Errorf("expected time.Time, got %T", value)
+		}
+		v = parquet.Int64Value(t.UnixMicro())
+	case parquetDouble:
+		f, ok := value.(float64)
+		if !ok {
+			return v, fmt.Errorf("expected float64, got %T", value)
+		}
+		v = parquet.DoubleValue(f)
+	case parquetString:
+		s, ok := value.(string)
+		if !ok {
+			return v, fmt.Errorf("expected string, got %T", value)
+		}
+		v = parquet.ByteArrayValue([]byte(s))
+	case parquetBool:
+		b, ok := value.(bool)
+		if !ok {
+			return v, fmt.Errorf("expected bool, got %T", value)
+		}
+		v = parquet.BooleanValue(b)
+	}
+
+	definitionLevel := 0
+	if c.Optional {
+		definitionLevel = 1
+	}
+	return v.Level(0, definitionLevel, index), nil
+}
+
+// parquetGroup is a schema root that keeps the columns in the order they were given;
+// parquet.Group would sort them by name
+type parquetGroup struct {
+	parquet.Group
+	fields []parquet.Field
+}
+
+func (g parquetGroup) Fields() []parquet.Field { return g.fields }
+
+// parquetField names a column in a parquetGroup
+type parquetField struct {
+	parquet.Node
+	name string
+}
+
+func (f parquetField) Name() string { return f.name }
+
+// Value is only used when writing Go structs; rows are written as parquet.Row values
+func (f parquetField) Value(reflect.Value) reflect.Value { return reflect.Value{} }
+
+// parquetSchema returns the schema of a flat table of columns
+func parquetSchema(columns []parquetColumn) *parquet.Schema {
+	group := parquetGroup{Group: parquet.Group{}}
+	for _, column := range columns {
+		node := column.node()
+		group.Group[column.Name] = node
+		group.fields = append(group.fields, parquetField{Node: node, name: column.Name})
+	}
+	return parquet.NewSchema("schema", group)
+}
+
+// writeParquet writes the columns as a Parquet file. All columns must have the same number of values.
+func writeParquet(w io.Writer, columns []parquetColumn) error {
+	numRows := 0
+	if len(columns) > 0 {
+		numRows = len(columns[0].Values)
+	}
+	for _, column := range columns {
+		if len(column.Values) != numRows {
+			return fmt.Errorf("column %s has %d values, expected %d", column.Name, len(column.Values), numRows)
+		}
+	}
+
+	rows := make([]parquet.Row, numRows)
+	for i := range rows {
+		row := make(parquet.Row, len(columns))
+		for j, column := range columns {
+			value, err := column.value(i, j)
+			if err != nil {
+				return fmt.Errorf("failed to write column %s: %w", column.Name, err)
+			}
+			row[j] = value
+		}
+		rows[i] = row
+	}
+
+	writer := parquet.NewWriter(w, parquetSchema(columns), parquet.Compression(&parquet.Gzip))
+	if _, err := writer.WriteRows(rows); err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return writer.Close()
+}
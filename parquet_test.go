@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// readParquetColumns opens a file written by writeParquet, returning it and its values by column name
+func readParquetColumns(t *testing.T, data []byte) (*parquet.File, map[string][]any) {
+	t.Helper()
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open Parquet file: %v", err)
+	}
+
+	leaves := file.Schema().Fields()
+	values := make(map[string][]any)
+	for _, group := range file.RowGroups() {
+		rows := group.Rows()
+		buf := make([]parquet.Row, 16)
+		for {
+			n, err := rows.ReadRows(buf)
+			for _, row := range buf[:n] {
+				for _, value := range row {
+					leaf := leaves[value.Column()]
+					var v any
+					switch {
+					case value.IsNull():
+					case leaf.Type().Kind() == parquet.Int64:
+						v = value.Int64()
+					case leaf.Type().Kind() == parquet.Double:
+						v = value.Double()
+					case leaf.Type().Kind() == parquet.ByteArray:
+						v = string(value.ByteArray())
+					case leaf.Type().Kind() == parquet.Boolean:
+						v = value.Boolean()
+					}
+					values[leaf.Name()] = append(values[leaf.Name()], v)
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read rows: %v", err)
+			}
+		}
+		_ = rows.Close()
+	}
+	return file, values
+}
+
+func TestWriteParquet_RoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	long := make([]any, 20)
+	for i := range long {
+		long[i] = int64(i * 1000)
+	}
+	columns := []parquetColumn{
+		{Name: "id", Kind: parquetInt64, Values: long},
+		{Name: "title", Kind: parquetString, Values: repeatValues(20, "Ünïcode ✓")},
+		{Name: "url", Kind: parquetString, Optional: true, Values: repeatValues(20, nil, "https://example.com", "https://example.org")},
+		{Name: "created_at", Kind: parquetTimestamp, Values: repeatValues(20, created)},
+		{Name: "ratio", Kind: parquetDouble, Values: repeatValues(20, 0.25)},
+		{Name: "dead", Kind: parquetBool, Optional: true, Values: repeatValues(20, true, false, nil)},
+	}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, columns); err != nil {
+		t.Fatalf("writeParquet failed: %v", err)
+	}
+	file, values := readParquetColumns(t, buf.Bytes())
+
+	if file.NumRows() != 20 {
+		t.Errorf("Expected 20 rows, got %d", file.NumRows())
+	}
+	fields := file.Schema().Fields()
+	if len(fields) != len(columns) {
+		t.Fatalf("Expected %d columns, got %d", len(columns), len(fields))
+	}
+	for i, column := range columns {
+		if fields[i].Name() != column.Name || fields[i].Optional() != column.Optional {
+			t.Errorf("Column %d: expected %s (optional %v), got %s (optional %v)", i, column.Name, column.Optional, fields[i].Name(), fields[i].Optional())
+		}
+	}
+	timestamp := fields[3].Type().LogicalType()
+	if timestamp == nil || timestamp.Timestamp == nil || !timestamp.Timestamp.IsAdjustedToUTC || timestamp.Timestamp.Unit.Micros == nil {
+		t.Errorf("Expected UTC microsecond timestamps, got %v", timestamp)
+	}
+	if title := fields[1].Type().LogicalType(); title == nil || title.UTF8 == nil {
+		t.Errorf("Expected a UTF-8 string column, got %v", title)
+	}
+	for _, chunk := range file.Metadata().RowGroups[0].Columns {
+		if chunk.MetaData.Codec != format.Gzip {
+			t.Errorf("Expected gzip compression for %v, got %v", chunk.MetaData.PathInSchema, chunk.MetaData.Codec)
+		}
+	}
+
+	if values["id"][19] != int64(19000) {
+		t.Errorf("Expected id 19000, got %v", values["id"][19])
+	}
+	if values["title"][0] != "Ünïcode ✓" {
+		t.Errorf("Expected unicode title, got %v", values["title"][0])
+	}
+	if values["url"][0] != nil || values["url"][1] != "https://example.com" || values["url"][5] != "https://example.org" {
+		t.Errorf("Unexpected urls: %v", values["url"][:6])
+	}
+	if values["created_at"][3] != created.UnixMicro() {
+		t.Errorf("Expected %d microseconds, got %v", created.UnixMicro(), values["created_at"][3])
+	}
+	if values["ratio"][7] != 0.25 {
+		t.Errorf("Expected ratio 0.25, got %v", values["ratio"][7])
+	}
+	if values["dead"][0] != true || values["dead"][1] != false || values["dead"][2] != nil || values["dead"][3] != true {
+		t.Errorf("Unexpected booleans: %v", values["dead"][:4])
+	}
+}
+
+// repeatValues returns n values cycling through pattern
+func repeatValues(n int, pattern ...any) []any {
+	values := make([]any, n)
+	for i := range values {
+		values[i] = pattern[i%len(pattern)]
+	}
+	return values
+}
+
+func TestWriteParquet_Errors(t *testing.T) {
+	mismatched := []parquetColumn{
+		{Name: "a", Kind: parquetInt64, Values: []any{int64(1)}},
+		{Name: "b", Kind: parquetInt64, Values: []any{}},
+	}
+	if err := writeParquet(io.Discard, mismatched); err == nil {
+		t.Error("Expected an error for columns of different lengths")
+	}
+
+	nullInRequired := []parquetColumn{{Name: "a", Kind: parquetString, Values: []any{nil}}}
+	if err := writeParquet(io.Discard, nullInRequired); err == nil {
+		t.Error("Expected an error for a null in a required column")
+	}
+
+	wrongType := []parquetColumn{{Name: "a", Kind: parquetInt64, Values: []any{1}}}
+	if err := writeParquet(io.Discard, wrongType); err == nil {
+		t.Error("Expected an error for an int in an int64 column")
+	}
+}
+
+func TestWriteParquet_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, []parquetColumn{{Name: "a", Kind: parquetInt64}}); err != nil {
+		t.Fatalf("writeParquet failed: %v", err)
+	}
+	file, values := readParquetColumns(t, buf.Bytes())
+	if file.NumRows() != 0 || len(values) != 0 {
+		t.Errorf("Expected an empty file, got %d rows", file.NumRows())
+	}
+}
+
+func TestWriteParquetExport(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	day := time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC)
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "101", Title: "Article", Link: "https://www.example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=101", Points: 150, CommentCount: 42, Author: "pg", CreatedAt: day, UpdatedAt: day},
+		{ItemID: "102", Title: "Ask HN: Text", CommentsLink: "https://news.ycombinator.com/item?id=102", Points: 80, Author: "dang", CreatedAt: day.Add(time.Hour), UpdatedAt: day},
+	})
+	if err := recordRun(db, &RunRecord{Source: "cli", StartedAt: day, FinishedAt: day.Add(time.Second), Fetched: 30, Error: "boom"}); err != nil {
+		t.Fatal(err)
+	}
+	recordStatsSnapshot(db, []HackerNewsItem{{ItemID: "102"}, {ItemID: "101"}}, day)
+	if err := markItemDead(db, "102", day.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	paths, err := writeParquetExport(db, outDir)
+	if err != nil {
+		t.Fatalf("writeParquetExport failed: %v", err)
+	}
+	if len(paths) != 3 || paths[0] != filepath.Join(outDir, "items.parquet") || paths[1] != filepath.Join(outDir, "runs.parquet") || paths[2] != filepath.Join(outDir, "item_stats_history.parquet") {
+		t.Fatalf("Unexpected paths: %v", paths)
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, items := readParquetColumns(t, data)
+	if items["hn_id"][0] != int64(101) || items["hn_id"][1] != int64(102) {
+		t.Errorf("Expected items ordered by creation, got %v", items["hn_id"])
+	}
+	if items["domain"][0] != "www.example.com" || items["url"][1] != nil || items["domain"][1] != nil {
+		t.Errorf("Unexpected url/domain columns: %v %v", items["url"], items["domain"])
+	}
+	if items["returned_at"][0] != nil {
+		t.Errorf("Expected null returned_at, got %v", items["returned_at"][0])
+	}
+	if items["dead_at"][0] != nil || items["dead_at"][1] != day.Add(2*time.Hour).UnixMicro() {
+		t.Errorf("Expected dead_at only on the dead item, got %v", items["dead_at"])
+	}
+	if items["created_at"][0] != day.UnixMicro() {
+		t.Errorf("Expected created_at %d, got %v", day.UnixMicro(), items["created_at"][0])
+	}
+
+	data, err = os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, runs := readParquetColumns(t, data)
+	if runs["fetched"][0] != int64(30) || runs["error"][0] != "boom" || runs["source"][0] != "cli" {
+		t.Errorf("Unexpected runs export: %v", runs)
+	}
+
+	data, err = os.ReadFile(paths[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, history := readParquetColumns(t, data)
+	if len(history["hn_id"]) != 2 || history["hn_id"][0] != int64(101) || history["points"][0] != int64(150) || history["comments"][0] != int64(42) {
+		t.Errorf("Unexpected stats history export: %v", history)
+	}
+	if history["recorded_at"][1] != day.UnixMicro() {
+		t.Errorf("Expected recorded_at %d, got %v", day.UnixMicro(), history["recorded_at"][1])
+	}
+}
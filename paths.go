@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appName names the per-user data and config directories
+const appName = "hntop-rss"
+
+// databaseFileName is the name of the SQLite database in the data directory
+const databaseFileName = "hackernews.db"
+
+// dataDir returns the per-user data directory: $XDG_DATA_HOME/hntop-rss (~/.local/share/hntop-rss) on
+// Linux and BSD, ~/Library/Application Support/hntop-rss on macOS and %LocalAppData%\hntop-rss on Windows
+func dataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		dir := os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not set")
+		}
+		return filepath.Join(dir, appName), nil
+	case "darwin", "ios":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support", appName), nil
+	}
+
+	// Relative paths are invalid per the XDG spec and are ignored
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", appName), nil
+}
+
+// configDir returns the per-user config directory: $XDG_CONFIG_HOME/hntop-rss (~/.config/hntop-rss) on
+// Linux and BSD, ~/Library/Application Support/hntop-rss on macOS and %AppData%\hntop-rss on Windows
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// resolveConfigPath returns configPath, or config.json in the config directory if no path was given
+// and that file exists. It returns an empty string when there is no local config to load.
+func resolveConfigPath(configPath string) string {
+	if configPath != "" {
+		return configPath
+	}
+	dir, err := configDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, "config.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// databasePath returns the database location in the data directory, creating the directory and moving
+// a database left next to the executable by earlier versions. Without a usable data directory the
+// database stays next to the executable.
+func databasePath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	legacyPath := filepath.Join(filepath.Dir(exePath), databaseFileName)
+
+	dir, err := dataDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		slog.Warn("No usable data directory, keeping the database next to the executable", "error", err)
+		return legacyPath, nil
+	}
+
+	path := filepath.Join(dir, databaseFileName)
+	if err := migrateLegacyDatabase(legacyPath, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// migrateLegacyDatabase moves the database and its SQLite sidecar files from legacyPath to path,
+// unless there is no legacy database or path already exists
+func migrateLegacyDatabase(legacyPath, path string) error {
+	if legacyPath == path {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(legacyPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	// The write-ahead log may hold committed changes, so it moves along with the database
+	for _, suffix := range []string{"-wal", "-shm", "-journal", ""} {
+		err := moveFile(legacyPath+suffix, path+suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move database to %s: %w", path, err)
+		}
+	}
+	slog.Info("Moved database to the data directory", "from", legacyPath, "to", path)
+	return nil
+}
+
+// moveFile renames src to dst, copying it when they are on different file systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil || errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDataDir_XDG(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG directories only apply on Linux and BSD")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_DATA_HOME", "/srv/data")
+	if dir, err := dataDir(); err != nil || dir != filepath.Join("/srv/data", appName) {
+		t.Errorf("Expected $XDG_DATA_HOME/hntop-rss, got %s (%v)", dir, err)
+	}
+
+	// Relative XDG paths are ignored
+	t.Setenv("XDG_DATA_HOME", "relative")
+	if dir, err := dataDir(); err != nil || dir != filepath.Join(home, ".local", "share", appName) {
+		t.Errorf("Expected ~/.local/share/hntop-rss, got %s (%v)", dir, err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/srv/config")
+	if dir, err := configDir(); err != nil || dir != filepath.Join("/srv/config", appName) {
+		t.Errorf("Expected $XDG_CONFIG_HOME/hntop-rss, got %s (%v)", dir, err)
+	}
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG directories only apply on Linux and BSD")
+	}
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	if got := resolveConfigPath("/etc/custom.json"); got != "/etc/custom.json" {
+		t.Errorf("Expected an explicit path to win, got %s", got)
+	}
+	if got := resolveConfigPath(""); got != "" {
+		t.Errorf("Expected no config without a file in the config directory, got %s", got)
+	}
+
+	path := filepath.Join(configHome, appName, "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveConfigPath(""); got != path {
+		t.Errorf("Expected %s, got %s", path, got)
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	legacyDir, newDir := t.TempDir(), t.TempDir()
+	legacy := filepath.Join(legacyDir, databaseFileName)
+	path := filepath.Join(newDir, databaseFileName)
+	for name, content := range map[string]string{legacy: "db", legacy + "-wal": "wal"} {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := migrateLegacyDatabase(legacy, path); err != nil {
+		t.Fatalf("migrateLegacyDatabase failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "db" {
+		t.Errorf("Expected the database to be moved, got %q", data)
+	}
+	if data, _ := os.ReadFile(path + "-wal"); string(data) != "wal" {
+		t.Errorf("Expected the write-ahead log to move along, got %q", data)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("Expected the legacy database to be gone")
+	}
+
+	// A database in the data directory is never overwritten
+	if err := os.WriteFile(legacy, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrateLegacyDatabase(legacy, path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "db" {
+		t.Errorf("Expected the existing database to be kept, got %q", data)
+	}
+
+	// Nothing to migrate
+	if err := migrateLegacyDatabase(filepath.Join(legacyDir, "missing.db"), filepath.Join(newDir, "other.db")); err != nil {
+		t.Errorf("Expected no error without a legacy database, got %v", err)
+	}
+}
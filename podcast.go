@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/feeds"
+)
+
+const (
+	podcastEpisodePrefix = "hn-digest-"
+	podcastFeedFile      = "podcast.xml"
+	podcastTTSTimeout    = 10 * time.Minute
+	maxTTSChunkLength    = 4000 // characters per OpenAI speech request
+)
+
+// PodcastConfig configures the text-to-speech engine used for the audio digest
+type PodcastConfig struct {
+	Engine  string   `json:"engine"`  // "command" or "openai"
+	Command []string `json:"command"` // TTS command reading text on stdin; "{output}" is replaced with the audio file path
+	URL     string   `json:"url"`     // OpenAI-compatible API base URL (default api.openai.com/v1)
+	APIKey  string   `json:"api_key"` // API key for the openai engine (defaults to $OPENAI_API_KEY)
+	Model   string   `json:"model"`   // speech model for the openai engine (default "tts-1")
+	Voice   string   `json:"voice"`   // voice for the openai engine (default "alloy")
+	Format  string   `json:"format"`  // audio file extension (default "mp3")
+}
+
+// ttsEngine turns a script into an audio file
+type ttsEngine interface {
+	Synthesize(ctx context.Context, text, outputPath string) error
+}
+
+// newTTSEngine creates the configured text-to-speech engine
+func newTTSEngine(config PodcastConfig) (ttsEngine, error) {
+	switch config.Engine {
+	case "command":
+		if len(config.Command) == 0 {
+			return nil, fmt.Errorf("command engine requires a command")
+		}
+		return &commandTTS{args: config.Command}, nil
+	case "openai":
+		baseURL := config.URL
+		if baseURL == "" {
+			baseURL = defaultOpenAIURL
+		}
+		apiKey := config.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		model := config.Model
+		if model == "" {
+			model = "tts-1"
+		}
+		voice := config.Voice
+		if voice == "" {
+			voice = "alloy"
+		}
+		return &openAITTS{
+			baseURL: strings.TrimSuffix(baseURL, "/"),
+			apiKey:  apiKey,
+			model:   model,
+			voice:   voice,
+			format:  podcastFormat(config),
+			client:  &http.Client{},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown TTS engine: %q", config.Engine)
+	}
+}
+
+// podcastFormat returns the audio file extension
+func podcastFormat(config PodcastConfig) string {
+	if config.Format == "" {
+		return "mp3"
+	}
+	return strings.TrimPrefix(strings.ToLower(config.Format), ".")
+}
+
+// audioMIMEType returns the enclosure MIME type for an audio file extension
+func audioMIMEType(format string) string {
+	switch format {
+	case "mp3":
+		return "audio/mpeg"
+	case "m4a", "aac":
+		return "audio/mp4"
+	case "ogg", "opus":
+		return "audio/ogg"
+	case "wav":
+		return "audio/wav"
+	case "flac":
+		return "audio/flac"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// commandTTS runs an external program such as piper or espeak-ng
+type commandTTS struct {
+	args []string
+}
+
+// Synthesize implements ttsEngine. The script is passed on stdin; if no argument contains
+// "{output}" the command's stdout is written to the output file instead.
+func (c *commandTTS) Synthesize(ctx context.Context, text, outputPath string) error {
+	args := make([]string, len(c.args))
+	writesFile := false
+	for i, arg := range c.args {
+		if strings.Contains(arg, "{output}") {
+			writesFile = true
+		}
+		args[i] = strings.ReplaceAll(arg, "{output}", outputPath)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if !writesFile {
+		out, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create audio file: %w", err)
+		}
+		defer func() { _ = out.Close() }()
+		cmd.Stdout = out
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("TTS command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// openAITTS uses an OpenAI-compatible speech endpoint
+type openAITTS struct {
+	baseURL string
+	apiKey  string
+	model   string
+	voice   string
+	format  string
+	client  *http.Client
+}
+
+// Synthesize implements ttsEngine. Long scripts are split into chunks whose audio is concatenated.
+func (o *openAITTS) Synthesize(ctx context.Context, text, outputPath string) error {
+	var audio bytes.Buffer
+	for _, chunk := range splitTTSChunks(text, maxTTSChunkLength) {
+		if err := o.synthesizeChunk(ctx, chunk, &audio); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(outputPath, audio.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write audio file: %w", err)
+	}
+	return nil
+}
+
+// synthesizeChunk requests speech for a single chunk and appends it to the buffer
+func (o *openAITTS) synthesizeChunk(ctx context.Context, chunk string, audio *bytes.Buffer) error {
+	body, err := json.Marshal(map[string]string{
+		"model":           o.model,
+		"voice":           o.voice,
+		"input":           chunk,
+		"response_format": o.format,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/audio/speech", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range bearerHeader(o.apiKey) {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("speech request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if _, err := io.Copy(audio, resp.Body); err != nil {
+		return fmt.Errorf("failed to read audio: %w", err)
+	}
+	return nil
+}
+
+// splitTTSChunks splits text into chunks of at most maxLen bytes, breaking after sentences
+func splitTTSChunks(text string, maxLen int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, sentence := range strings.SplitAfter(text, ". ") {
+		if current.Len() > 0 && current.Len()+len(sentence) > maxLen {
+			chunks = append(chunks, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		// A single oversized sentence is cut hard
+		for len(sentence) > maxLen {
+			cut := maxLen
+			for cut > 0 && !utf8.RuneStart(sentence[cut]) {
+				cut--
+			}
+			chunks = append(chunks, sentence[:cut])
+			sentence = sentence[cut:]
+		}
+		current.WriteString(sentence)
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		chunks = append(chunks, strings.TrimSpace(current.String()))
+	}
+	return chunks
+}
+
+// podcastScript builds the spoken text of a digest episode, including cached summaries
+func podcastScript(db *sql.DB, items []HackerNewsItem, date time.Time) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Hacker News digest for %s. Here are the top %d stories.\n\n", date.Format("Monday, January 2, 2006"), len(items))
+	for i, item := range items {
+		fmt.Fprintf(&sb, "Story %d. %s. %d points and %d comments.", i+1, strings.TrimSuffix(item.Title, "."), item.Points, item.CommentCount)
+		if !isTextPost(item) {
+			if summary, err := getSummary(db, item.Link); err == nil && summary != "" {
+				sb.WriteString(" " + summary)
+			}
+		}
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("That's all for today. Thanks for listening.\n")
+	return sb.String()
+}
+
+// podcastEpisodeItems returns the top stories of the last day, highest score first
+func podcastEpisodeItems(db *sql.DB, filter ItemFilter, categoryMapper *CategoryMapper) ([]HackerNewsItem, error) {
+	items, err := getFilteredItems(db, ItemFilter{Limit: apiScanLimit, MinPoints: filter.MinPoints, MaxAge: 24 * time.Hour, BlockedAuthors: filter.BlockedAuthors, AllowedAuthors: filter.AllowedAuthors})
+	if err != nil {
+		return nil, err
+	}
+	items = prepareFeedItems(db, items, categoryMapper)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Points > items[j].Points })
+	if len(items) > filter.Limit {
+		items = items[:filter.Limit]
+	}
+	return items, nil
+}
+
+// generatePodcastEpisode synthesizes the digest for the given day unless it already exists.
+// Returns true if a new episode was created.
+func generatePodcastEpisode(db *sql.DB, dir string, filter ItemFilter, categoryMapper *CategoryMapper, date time.Time, force bool) (bool, error) {
+	config := categoryMapper.Config().Podcast
+	engine, err := newTTSEngine(config)
+	if err != nil {
+		return false, err
+	}
+	date = localTime(date)
+
+	base := filepath.Join(dir, podcastEpisodePrefix+date.Format("2006-01-02"))
+	audioPath := base + "." + podcastFormat(config)
+	if _, err := os.Stat(audioPath); err == nil && !force {
+		slog.Info("Podcast episode already exists", "path", audioPath)
+		return false, nil
+	}
+
+	items, err := podcastEpisodeItems(db, filter, categoryMapper)
+	if err != nil {
+		return false, err
+	}
+	if len(items) == 0 {
+		return false, fmt.Errorf("no stories for the episode")
+	}
+	script := podcastScript(db, items, date)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create podcast directory: %w", err)
+	}
+
+	// Synthesize into a temporary file so a failed run never leaves a truncated episode
+	tmpPath := base + ".tmp." + podcastFormat(config)
+	ctx, cancel := context.WithTimeout(context.Background(), podcastTTSTimeout)
+	defer cancel()
+	if err := engine.Synthesize(ctx, script, tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return false, err
+	}
+	if err := os.Rename(tmpPath, audioPath); err != nil {
+		return false, fmt.Errorf("failed to move episode into place: %w", err)
+	}
+	if err := os.WriteFile(base+".txt", []byte(script), 0644); err != nil {
+		return false, fmt.Errorf("failed to write episode script: %w", err)
+	}
+
+	slog.Info("Podcast episode created", "path", audioPath, "stories", len(items))
+	return true, nil
+}
+
+// podcastEpisode is an audio file found in the podcast directory
+type podcastEpisode struct {
+	date time.Time
+	file string
+	size int64
+}
+
+// listPodcastEpisodes returns the episodes in the directory, newest first
+func listPodcastEpisodes(dir, format string) ([]podcastEpisode, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read podcast directory: %w", err)
+	}
+
+	var episodes []podcastEpisode
+	for _, entry := range entries {
+		name := entry.Name()
+		dateStr, ok := strings.CutPrefix(strings.TrimSuffix(name, "."+format), podcastEpisodePrefix)
+		if !ok || !strings.HasSuffix(name, "."+format) {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", dateStr, displayLocation)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		episodes = append(episodes, podcastEpisode{date: date, file: name, size: info.Size()})
+	}
+
+	sort.Slice(episodes, func(i, j int) bool { return episodes[i].date.After(episodes[j].date) })
+	return episodes, nil
+}
+
+// prunePodcastEpisodes deletes all but the newest keep episodes and their scripts
+func prunePodcastEpisodes(dir string, episodes []podcastEpisode, keep int) []podcastEpisode {
+	if keep <= 0 || len(episodes) <= keep {
+		return episodes
+	}
+	for _, episode := range episodes[keep:] {
+		base := strings.TrimSuffix(episode.file, filepath.Ext(episode.file))
+		for _, name := range []string{episode.file, base + ".txt"} {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				slog.Warn("Failed to remove old podcast file", "file", name, "error", err)
+			}
+		}
+		slog.Info("Removed old podcast episode", "file", episode.file)
+	}
+	return episodes[:keep]
+}
+
+// generatePodcastFeed renders the podcast RSS feed with an enclosure for each episode
+func generatePodcastFeed(dir, baseURL, format string, episodes []podcastEpisode) (string, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	now := time.Now()
+	feed := &feeds.Feed{
+		Title:       "Hacker News Daily Digest",
+		Description: "A daily audio digest of the top Hacker News stories",
+		Link:        &feeds.Link{Href: baseURL + "/podcast/" + podcastFeedFile},
+		Id:          "tag:news.ycombinator.com,2024:podcast",
+		Created:     now,
+		Updated:     now,
+	}
+
+	for _, episode := range episodes {
+		url := baseURL + "/podcast/" + episode.file
+		script, err := os.ReadFile(filepath.Join(dir, strings.TrimSuffix(episode.file, filepath.Ext(episode.file))+".txt"))
+		if err != nil {
+			slog.Debug("No script for podcast episode", "file", episode.file, "error", err)
+		}
+		feed.Items = append(feed.Items, &feeds.Item{
+			Title:       "HN Digest – " + episode.date.Format("Monday, January 2, 2006"),
+			Link:        &feeds.Link{Href: url},
+			Id:          url,
+			Description: string(script),
+			Created:     episode.date,
+			Enclosure:   &feeds.Enclosure{Url: url, Length: strconv.FormatInt(episode.size, 10), Type: audioMIMEType(format)},
+		})
+	}
+
+	rss, err := feed.ToRss()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate podcast feed: %w", err)
+	}
+	return rss, nil
+}
+
+// runPodcast parses podcast subcommand flags, creates today's episode and rewrites the podcast feed
+func runPodcast(args []string) {
+	fs := flag.NewFlagSet("podcast", flag.ExitOnError)
+	outDir := fs.String("outdir", ".", "directory where the podcast/ directory is written")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	minPoints := fs.Int("min-points", 50, "minimum points threshold for stories in the digest")
+	limit := fs.Int("limit", 10, "number of stories per episode")
+	keep := fs.Int("keep", 14, "number of episodes to keep (0 keeps all)")
+	baseURL := fs.String("base-url", "", "public URL the podcast/ directory is served under, e.g. https://example.com (required)")
+	force := fs.Bool("force", false, "regenerate today's episode even if it exists")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	timezone := addTimezoneFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+	setupTimezone(*timezone)
+	if *baseURL == "" {
+		fmt.Fprintln(os.Stderr, "podcast: -base-url is required for enclosure links")
+		os.Exit(2)
+	}
+
+	categoryMapper := LoadConfig(*configPath, *configURL)
+	config := categoryMapper.Config().Podcast
+	if config.Engine == "" {
+		fmt.Fprintln(os.Stderr, "podcast: no podcast engine configured")
+		os.Exit(2)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	dir := filepath.Join(*outDir, "podcast")
+	filter := categoryMapper.withAuthorLists(ItemFilter{Limit: *limit, MinPoints: *minPoints})
+	if _, err := generatePodcastEpisode(db, dir, filter, categoryMapper, time.Now(), *force); err != nil {
+		slog.Error("Failed to create podcast episode", "error", err)
+		os.Exit(1)
+	}
+
+	format := podcastFormat(config)
+	episodes, err := listPodcastEpisodes(dir, format)
+	if err != nil {
+		slog.Error("Failed to list podcast episodes", "error", err)
+		os.Exit(1)
+	}
+	episodes = prunePodcastEpisodes(dir, episodes, *keep)
+
+	rss, err := generatePodcastFeed(dir, *baseURL, format, episodes)
+	if err != nil {
+		slog.Error("Failed to generate podcast feed", "error", err)
+		os.Exit(1)
+	}
+	filename := filepath.Join(dir, podcastFeedFile)
+	if err := os.WriteFile(filename, []byte(rss), 0644); err != nil {
+		slog.Error("Failed to write podcast feed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Podcast feed saved", "episodes", len(episodes), "filename", filename)
+}
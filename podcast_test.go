@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitTTSChunks(t *testing.T) {
+	text := "One sentence here. Another one here. And a third sentence."
+	chunks := splitTTSChunks(text, 40)
+	if len(chunks) != 2 || chunks[0] != "One sentence here. Another one here." || chunks[1] != "And a third sentence." {
+		t.Errorf("Unexpected chunks: %q", chunks)
+	}
+
+	long := strings.Repeat("ä", 30) // 60 bytes without sentence breaks
+	for _, chunk := range splitTTSChunks(long, 25) {
+		if len(chunk) > 25 || !strings.HasPrefix(chunk, "ä") {
+			t.Errorf("Expected chunks cut on rune boundaries within the limit, got %q", chunk)
+		}
+	}
+}
+
+func seedPodcastDB(t *testing.T) *feedServer {
+	t.Helper()
+	server := setupTestServer(t)
+	if err := cacheSummary(server.db, "https://github.com/user/repo", "A handy tool for repos.", "m"); err != nil {
+		t.Fatalf("Failed to cache summary: %v", err)
+	}
+	return server
+}
+
+func TestPodcastScript(t *testing.T) {
+	server := seedPodcastDB(t)
+	items, err := podcastEpisodeItems(server.db, ItemFilter{Limit: 2, MinPoints: 50}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Title != "GitHub Project" || items[1].Title != "A Tweet" {
+		t.Fatalf("Expected top 2 stories by points, got %+v", items)
+	}
+
+	script := podcastScript(server.db, items, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+	for _, want := range []string{"Thursday, October 15, 2026", "Story 1. GitHub Project. 250 points", "A handy tool for repos.", "Story 2. A Tweet."} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestGeneratePodcastEpisode_CommandEngine(t *testing.T) {
+	server := seedPodcastDB(t)
+	dir := t.TempDir()
+	date := time.Date(2026, 10, 15, 8, 0, 0, 0, time.Local)
+
+	testCases := []struct {
+		name    string
+		command []string
+	}{
+		{"stdout", []string{"cat"}},
+		{"output placeholder", []string{"sh", "-c", `cat > "$0"`, "{output}"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mapper := NewCategoryMapper(&DomainConfig{Podcast: PodcastConfig{Engine: "command", Command: tc.command}})
+			created, err := generatePodcastEpisode(server.db, dir, ItemFilter{Limit: 3, MinPoints: 50}, mapper, date, true)
+			if err != nil || !created {
+				t.Fatalf("Expected episode to be created, got %v (err %v)", created, err)
+			}
+
+			audio, err := os.ReadFile(filepath.Join(dir, "hn-digest-2026-10-15.mp3"))
+			if err != nil || !strings.Contains(string(audio), "Story 1. GitHub Project") {
+				t.Errorf("Expected the script to be piped through the command, got %q (err %v)", audio, err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "hn-digest-2026-10-15.txt")); err != nil {
+				t.Errorf("Expected script file next to the episode: %v", err)
+			}
+
+			created, _ = generatePodcastEpisode(server.db, dir, ItemFilter{Limit: 3, MinPoints: 50}, mapper, date, false)
+			if created {
+				t.Error("Expected existing episode not to be regenerated")
+			}
+		})
+	}
+}
+
+func TestGeneratePodcastEpisode_FailedEngineLeavesNoEpisode(t *testing.T) {
+	server := seedPodcastDB(t)
+	dir := t.TempDir()
+	mapper := NewCategoryMapper(&DomainConfig{Podcast: PodcastConfig{Engine: "command", Command: []string{"false"}}})
+
+	if _, err := generatePodcastEpisode(server.db, dir, ItemFilter{Limit: 3, MinPoints: 50}, mapper, time.Now(), false); err == nil {
+		t.Fatal("Expected error from failing TTS command")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no files after a failed run, got %d", len(entries))
+	}
+}
+
+func TestOpenAITTS_ConcatenatesChunks(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/speech" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		requests++
+		_, _ = w.Write([]byte("AUDIO"))
+	}))
+	defer server.Close()
+
+	engine, err := newTTSEngine(PodcastConfig{Engine: "openai", URL: server.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	output := filepath.Join(t.TempDir(), "episode.mp3")
+	text := strings.Repeat("A sentence of reasonable length goes here. ", 200)
+	if err := engine.Synthesize(t.Context(), text, output); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	audio, _ := os.ReadFile(output)
+	if requests < 2 || string(audio) != strings.Repeat("AUDIO", requests) {
+		t.Errorf("Expected concatenated audio from %d chunks, got %q", requests, audio)
+	}
+}
+
+func TestPodcastFeed_PrunesAndListsEpisodes(t *testing.T) {
+	dir := t.TempDir()
+	for _, day := range []string{"2026-10-13", "2026-10-14", "2026-10-15"} {
+		_ = os.WriteFile(filepath.Join(dir, "hn-digest-"+day+".mp3"), []byte("audio-"+day), 0644)
+		_ = os.WriteFile(filepath.Join(dir, "hn-digest-"+day+".txt"), []byte("Script for "+day), 0644)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "unrelated.mp3"), []byte("x"), 0644)
+
+	episodes, err := listPodcastEpisodes(dir, "mp3")
+	if err != nil || len(episodes) != 3 || episodes[0].file != "hn-digest-2026-10-15.mp3" {
+		t.Fatalf("Unexpected episodes: %+v (err %v)", episodes, err)
+	}
+
+	episodes = prunePodcastEpisodes(dir, episodes, 2)
+	if len(episodes) != 2 {
+		t.Fatalf("Expected 2 episodes after pruning, got %d", len(episodes))
+	}
+	for _, name := range []string{"hn-digest-2026-10-13.mp3", "hn-digest-2026-10-13.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", name)
+		}
+	}
+
+	rss, err := generatePodcastFeed(dir, "https://example.com/", "mp3", episodes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`<enclosure url="https://example.com/podcast/hn-digest-2026-10-15.mp3" length="16" type="audio/mpeg">`,
+		"Script for 2026-10-14",
+		"Hacker News Daily Digest",
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("Expected podcast feed to contain %q:\n%s", want, rss)
+		}
+	}
+}
+
+func TestServer_PodcastDir(t *testing.T) {
+	server := setupTestServer(t)
+	server.podcastDir = t.TempDir()
+	_ = os.WriteFile(filepath.Join(server.podcastDir, "podcast.xml"), []byte("<rss></rss>"), 0644)
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/podcast/podcast.xml", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "<rss></rss>" {
+		t.Errorf("Expected podcast feed to be served, got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// hnAPIItemURL is the official Hacker News API endpoint for a single item
+var hnAPIItemURL = "https://hacker-news.firebaseio.com/v0/item/"
+
+// hnAPIItem is the subset of an official Hacker News API item used for polls and top comments
+type hnAPIItem struct {
+	Type    string `json:"type"`
+	By      string `json:"by"`
+	Text    string `json:"text"`
+	Score   int    `json:"score"`
+	Parts   []int  `json:"parts"` // poll option IDs, in display order
+	Kids    []int  `json:"kids"`  // comment IDs, in ranked order
+	Deleted bool   `json:"deleted"`
+	Dead    bool   `json:"dead"`
+}
+
+// pollOption is a poll choice and the number of votes it got
+type pollOption struct {
+	Text  string `json:"text"`
+	Votes int    `json:"votes"`
+}
+
+// hnPoll is the cached poll state of a text post
+type hnPoll struct {
+	IsPoll    bool
+	Options   []pollOption
+	FetchedAt time.Time
+}
+
+// fetchHNAPIItem fetches an item from the official Hacker News API
+func fetchHNAPIItem(ctx context.Context, client *http.Client, itemID string) (*hnAPIItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, hnAPIItemURL+itemID+".json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, recordFetchError(fetchSourceHNAPI, fmt.Errorf("HTTP request failed: %w", err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, recordFetchError(fetchSourceHNAPI, httpStatusError(resp.StatusCode))
+	}
+
+	// Unknown items come back as a JSON null
+	var item *hnAPIItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("item %s not found", itemID)
+	}
+	return item, nil
+}
+
+// fetchPoll checks whether an item is a poll and fetches its options with their vote counts
+func fetchPoll(ctx context.Context, client *http.Client, itemID string, now time.Time) (*hnPoll, error) {
+	item, err := fetchHNAPIItem(ctx, client, itemID)
+	if err != nil {
+		return nil, err
+	}
+	poll := &hnPoll{IsPoll: item.Type == "poll", FetchedAt: now}
+	if !poll.IsPoll {
+		return poll, nil
+	}
+
+	for _, part := range item.Parts {
+		option, err := fetchHNAPIItem(ctx, client, fmt.Sprint(part))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch poll option %d: %w", part, err)
+		}
+		if option.Deleted || option.Dead {
+			continue
+		}
+		poll.Options = append(poll.Options, pollOption{Text: commentPlainText(option.Text), Votes: option.Score})
+	}
+	return poll, nil
+}
+
+// refreshPolls caches poll options for text posts. Text posts are checked once; polls have
+// their vote counts refreshed on every run while they are in the feed.
+func refreshPolls(db *sql.DB, items []HackerNewsItem, timeout time.Duration) {
+	client := &http.Client{Timeout: timeout}
+	for _, item := range items {
+		// Items typed from the Algolia tags need no check; untyped text posts might be polls
+		if !isTextPost(item) || (item.Type != "" && item.Type != StoryTypePoll) {
+			continue
+		}
+		cached, err := getPoll(db, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to load poll", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if cached != nil && !cached.IsPoll {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var poll *hnPoll
+		err = errPanicked
+		safely("poll fetch", func() {
+			poll, err = fetchPoll(ctx, client, item.ItemID, time.Now())
+		}, itemLogAttrs(item)...)
+		cancel()
+		if err != nil {
+			slog.Debug("Failed to fetch poll", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+
+		if err := cachePoll(db, item.ItemID, poll); err != nil {
+			slog.Warn("Failed to cache poll", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if poll.IsPoll {
+			slog.Debug("Fetched poll options", "hn_id", item.ItemID, "options", len(poll.Options))
+		}
+	}
+}
+
+// loadPoll returns the cached poll of a text post for rendering, or nil if it isn't a known poll
+func loadPoll(db *sql.DB, item HackerNewsItem) *hnPoll {
+	if db == nil || !isTextPost(item) {
+		return nil
+	}
+	poll, err := getPoll(db, item.ItemID)
+	if err != nil {
+		slog.Debug("Failed to load poll", "hn_id", item.ItemID, "error", err)
+		return nil
+	}
+	if poll == nil || !poll.IsPoll || len(poll.Options) == 0 {
+		return nil
+	}
+	return poll
+}
+
+// renderPoll renders poll options as an ordered list with vote counts and shares
+func renderPoll(poll *hnPoll, format FeedFormat) string {
+	total := 0
+	for _, option := range poll.Options {
+		total += option.Votes
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<section aria-label="Poll" style="margin-bottom: 16px; padding: 12px; background: #f6f6ef; border-radius: 6px; border-left: 3px solid #ff6600;">
+				<h4 style="margin: 0 0 8px 0; color: #ff6600; font-size: 14px;">%sPoll</h4>
+				<ol style="margin: 0; padding-left: 20px;">`, format.icon("📊"))
+	for _, option := range poll.Options {
+		share := 0
+		if total > 0 {
+			share = option.Votes * 100 / total
+		}
+		votes := "votes"
+		if option.Votes == 1 {
+			votes = "vote"
+		}
+		fmt.Fprintf(&b, `<li style="margin-bottom: 4px;">%s <span style="color: #828282;">– %d %s (%d%%)</span></li>`,
+			html.EscapeString(option.Text), option.Votes, votes, share)
+	}
+	b.WriteString(`</ol>
+			</section>`)
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useTestHNAPI points the official HN API at a test server answering with the given items by ID
+func useTestHNAPI(t *testing.T, items map[string]string) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/item/"), ".json")
+		body, ok := items[id]
+		if !ok {
+			body = "null"
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	original := hnAPIItemURL
+	hnAPIItemURL = server.URL + "/item/"
+	t.Cleanup(func() {
+		hnAPIItemURL = original
+		server.Close()
+	})
+	return &requests
+}
+
+var testPollItems = map[string]string{
+	"500": `{"id": 500, "type": "poll", "title": "Poll: Tabs or spaces?", "parts": [501, 502, 503]}`,
+	"501": `{"id": 501, "type": "pollopt", "text": "Tabs &amp; more tabs", "score": 30}`,
+	"502": `{"id": 502, "type": "pollopt", "text": "Spaces", "score": 10}`,
+	"503": `{"id": 503, "type": "pollopt", "deleted": true}`,
+	"600": `{"id": 600, "type": "story", "title": "Ask HN: Anything?"}`,
+}
+
+func TestFetchPoll(t *testing.T) {
+	useTestHNAPI(t, testPollItems)
+
+	poll, err := fetchPoll(t.Context(), http.DefaultClient, "500", time.Now())
+	if err != nil {
+		t.Fatalf("fetchPoll failed: %v", err)
+	}
+	if !poll.IsPoll || len(poll.Options) != 2 || poll.Options[0] != (pollOption{Text: "Tabs & more tabs", Votes: 30}) {
+		t.Errorf("Unexpected poll: %+v", poll)
+	}
+
+	story, err := fetchPoll(t.Context(), http.DefaultClient, "600", time.Now())
+	if err != nil || story.IsPoll {
+		t.Errorf("Expected a story not to be a poll, got %+v (err %v)", story, err)
+	}
+
+	if _, err := fetchPoll(t.Context(), http.DefaultClient, "999", time.Now()); err == nil {
+		t.Error("Expected an error for an unknown item")
+	}
+}
+
+func TestRefreshPolls(t *testing.T) {
+	requests := useTestHNAPI(t, testPollItems)
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	items := []HackerNewsItem{
+		{ItemID: "500", Title: "Poll: Tabs or spaces?", CommentsLink: "https://news.ycombinator.com/item?id=500", Points: 120, CommentCount: 80, CreatedAt: now},
+		{ItemID: "600", Title: "Ask HN: Anything?", CommentsLink: "https://news.ycombinator.com/item?id=600", Points: 90, CreatedAt: now},
+		{ItemID: "700", Title: "Article", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=700", Points: 200, CreatedAt: now},
+	}
+	refreshPolls(db, items, time.Second)
+	if got := requests.Load(); got != 5 {
+		t.Errorf("Expected 5 API requests for the poll, its options and the text post, got %d", got)
+	}
+
+	// Only the poll is checked again on the next run
+	refreshPolls(db, items, time.Second)
+	if got := requests.Load(); got != 9 {
+		t.Errorf("Expected the text post to be checked only once, got %d requests", got)
+	}
+
+	entry, _ := feedEntry(db, items[0], 50, nil, nil, FeedFormat{})
+	for _, want := range []string{`<section aria-label="Poll"`, "Tabs &amp; more tabs", "30 votes (75%)", "10 votes (25%)", "news.ycombinator.com (poll)", "Vote on Hacker News"} {
+		if !strings.Contains(entry.Description, want) {
+			t.Errorf("Expected poll entry to contain %q", want)
+		}
+	}
+
+	entry, _ = feedEntry(db, items[1], 50, nil, nil, FeedFormat{})
+	if strings.Contains(entry.Description, "Poll") || !strings.Contains(entry.Description, "(text post)") {
+		t.Error("Expected a plain text post to render without a poll")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresBackend stores everything in a PostgreSQL database, which instances on different
+// hosts can share
+type postgresBackend struct{}
+
+// postgresTimestampRegex matches the TIMESTAMP type in schema statements, but not CURRENT_TIMESTAMP
+var postgresTimestampRegex = regexp.MustCompile(`\bTIMESTAMP\b`)
+
+func (postgresBackend) name() string { return "postgres" }
+
+// ddl maps SQLite's auto-incrementing keys to identity columns and stores times with their time zone
+func (postgresBackend) ddl(statement string) string {
+	statement = strings.ReplaceAll(statement, "INTEGER PRIMARY KEY AUTOINCREMENT", "BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY")
+	return postgresTimestampRegex.ReplaceAllString(statement, "TIMESTAMPTZ")
+}
+
+func (b postgresBackend) addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, b.ddl(definition))); err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
+	}
+	slog.Info("Migrated database schema", "table", table, "column", column)
+	return nil
+}
+
+func (postgresBackend) snapshot(*sql.DB, string) error {
+	return fmt.Errorf("snapshots copy the SQLite file; back up PostgreSQL with pg_dump instead")
+}
+
+// openPostgres opens a PostgreSQL database through pgx, rewriting the ? placeholders the
+// queries are written with to PostgreSQL's $1, $2, ...
+func openPostgres(url string) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PostgreSQL URL: %w", err)
+	}
+	return sql.OpenDB(rebindConnector{stdlib.GetConnector(*config)}), nil
+}
+
+// rebindConnector hands out connections that rebind placeholders
+type rebindConnector struct {
+	driver.Connector
+}
+
+func (c rebindConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rebindConn{conn.(*stdlib.Conn)}, nil
+}
+
+// rebindConn is a pgx connection that rebinds placeholders in every statement
+type rebindConn struct {
+	*stdlib.Conn
+}
+
+func (c rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebindPlaceholders(query))
+}
+
+func (c rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.PrepareContext(ctx, rebindPlaceholders(query))
+}
+
+func (c rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.ExecContext(ctx, rebindPlaceholders(query), args)
+}
+
+func (c rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.QueryContext(ctx, rebindPlaceholders(query), args)
+}
+
+// rebindPlaceholders rewrites ? and ?N placeholders to $N, leaving string literals, quoted
+// identifiers and comments alone
+func rebindPlaceholders(query string) string {
+	var out strings.Builder
+	next := 1
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				out.WriteString(query[i:])
+				return out.String()
+			}
+			out.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				out.WriteString(query[i:])
+				return out.String()
+			}
+			out.WriteString(query[i : i+end+1])
+			i += end
+		case c == '?':
+			digits := i + 1
+			for digits < len(query) && query[digits] >= '0' && query[digits] <= '9' {
+				digits++
+			}
+			if digits > i+1 {
+				out.WriteString("$" + query[i+1:digits])
+				i = digits - 1
+				continue
+			}
+			out.WriteString("$" + strconv.Itoa(next))
+			next++
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRebindPlaceholders(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM items WHERE item_hn_id = ?", "SELECT * FROM items WHERE item_hn_id = $1"},
+		{"INSERT INTO t (a, b) VALUES (?, ?)", "INSERT INTO t (a, b) VALUES ($1, $2)"},
+		{"DELETE FROM items WHERE created_at < ?1 AND updated_at < ?1", "DELETE FROM items WHERE created_at < $1 AND updated_at < $1"},
+		{"SELECT '?' FROM t WHERE a = ?", "SELECT '?' FROM t WHERE a = $1"},
+		{"SELECT 'it''s ?' FROM t WHERE a = ?", "SELECT 'it''s ?' FROM t WHERE a = $1"},
+		{`SELECT "col?" FROM t WHERE a = ?`, `SELECT "col?" FROM t WHERE a = $1`},
+		{"SELECT a -- why?\nFROM t WHERE a = ?", "SELECT a -- why?\nFROM t WHERE a = $1"},
+		{"SELECT 'unterminated ?", "SELECT 'unterminated ?"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			if result := rebindPlaceholders(tc.query); result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPostgresDDL(t *testing.T) {
+	statement := postgresBackend{}.ddl(`
+	CREATE TABLE IF NOT EXISTS runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	for _, expected := range []string{
+		"id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY",
+		"started_at TIMESTAMPTZ NOT NULL",
+		"created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP",
+	} {
+		if !strings.Contains(statement, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, statement)
+		}
+	}
+}
+
+func TestBackendOf(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	expected := "sqlite"
+	if os.Getenv(testDatabaseURLEnv) != "" {
+		expected = "postgres"
+	}
+	if name := backendOf(db).name(); name != expected {
+		t.Errorf("Expected %s backend, got %s", expected, name)
+	}
+
+	pg, err := openPostgres("postgres://user@localhost/hntop")
+	if err != nil {
+		t.Fatalf("openPostgres failed: %v", err)
+	}
+	defer func() { _ = pg.Close() }()
+	if name := backendOf(pg).name(); name != "postgres" {
+		t.Errorf("Expected postgres backend, got %s", name)
+	}
+}
+
+func TestOpenDatabaseRejectsNonPostgresURL(t *testing.T) {
+	t.Setenv(databaseURLEnv, "mysql://localhost/hntop")
+	if _, err := openDatabase(); err == nil {
+		t.Error("Expected an error for a non-PostgreSQL URL")
+	}
+}
+
+func TestPostgresSnapshotUnsupported(t *testing.T) {
+	if err := (postgresBackend{}).snapshot((*sql.DB)(nil), "out.db"); err == nil {
+		t.Error("Expected PostgreSQL snapshots to be refused")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+)
+
+// profilingFlags holds the runtime profiling options of a long-running command
+type profilingFlags struct {
+	addr          *string
+	blockRate     *int
+	mutexFraction *int
+}
+
+// addProfilingFlags registers the -pprof, -block-profile-rate and -mutex-profile-fraction flags
+func addProfilingFlags(fs *flag.FlagSet) profilingFlags {
+	return profilingFlags{
+		addr:          fs.String("pprof", "", "serve runtime profiles under /debug/pprof/ on this address, e.g. localhost:6060 (optional)"),
+		blockRate:     fs.Int("block-profile-rate", 0, "record one blocking event per this many nanoseconds blocked (0 = off, 1 = every event)"),
+		mutexFraction: fs.Int("mutex-profile-fraction", 0, "record one in this many mutex contention events (0 = off)"),
+	}
+}
+
+// pprofHandler serves the runtime profiles on its own mux, keeping them off the public routes
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// startProfiling applies the profile rates and starts the pprof listener in the background
+func startProfiling(flags profilingFlags) {
+	if *flags.blockRate > 0 {
+		runtime.SetBlockProfileRate(*flags.blockRate)
+	}
+	if *flags.mutexFraction > 0 {
+		runtime.SetMutexProfileFraction(*flags.mutexFraction)
+	}
+	if *flags.addr == "" {
+		return
+	}
+
+	go func() {
+		slog.Info("Starting pprof server", "addr", *flags.addr, "block_profile_rate", *flags.blockRate, "mutex_profile_fraction", *flags.mutexFraction)
+		if err := http.ListenAndServe(*flags.addr, pprofHandler()); err != nil {
+			slog.Error("pprof server failed", "error", err)
+		}
+	}()
+}
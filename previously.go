@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PreviouslyConfig configures linking earlier Hacker News submissions of the same article
+type PreviouslyConfig struct {
+	Enabled   bool `json:"enabled"`    // search Algolia once per new item for earlier submissions
+	MinPoints int  `json:"min_points"` // ignore earlier submissions below this score, which rarely had a discussion
+	Limit     int  `json:"limit"`      // at most this many links per entry (default 5)
+}
+
+// defaultPreviouslyLimit is how many earlier submissions are linked when limit is not set
+const defaultPreviouslyLimit = 5
+
+// previousDiscussion is an earlier submission of the same article
+type previousDiscussion struct {
+	ItemID    string    `json:"id"`
+	Points    int       `json:"points"`
+	Comments  int       `json:"comments"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// findPreviousDiscussions searches Algolia for submissions of the item's article made before it, newest first
+func findPreviousDiscussions(ctx context.Context, client *http.Client, item HackerNewsItem, minPoints int) ([]previousDiscussion, error) {
+	hits, err := searchStories(ctx, client, url.Values{
+		"query":                        {item.Link},
+		"restrictSearchableAttributes": {"url"},
+		"tags":                         {"story"},
+		"hitsPerPage":                  {"50"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The search is fuzzy, so only submissions of the same article count
+	article := map[string]bool{
+		normalizeSubmissionURL(item.Link):        true,
+		normalizeSubmissionURL(articleURL(item)): true,
+	}
+	var previous []previousDiscussion
+	for _, hit := range hits {
+		other, err := itemFromHit(hit, item.CreatedAt)
+		if err != nil {
+			continue
+		}
+		if other.ItemID == item.ItemID || !other.CreatedAt.Before(item.CreatedAt) || other.Points < minPoints {
+			continue
+		}
+		if !article[normalizeSubmissionURL(other.Link)] {
+			continue
+		}
+		previous = append(previous, previousDiscussion{ItemID: other.ItemID, Points: other.Points, Comments: other.CommentCount, CreatedAt: other.CreatedAt})
+	}
+	slices.SortFunc(previous, func(a, b previousDiscussion) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return previous, nil
+}
+
+// refreshPreviousDiscussions looks up earlier submissions of new items' articles. Each item is searched
+// once, since submissions made before it never change.
+func refreshPreviousDiscussions(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	config := categoryMapper.Config().Previously
+	if !config.Enabled {
+		return
+	}
+
+	timeout := categoryMapper.Timeouts().Algolia
+	client := &http.Client{Timeout: timeout}
+	for _, item := range items {
+		if isTextPost(item) {
+			continue
+		}
+		fetchedAt, err := getPreviousDiscussionsFetchedAt(db, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to load previous discussions", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if !fetchedAt.IsZero() {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var previous []previousDiscussion
+		err = errPanicked
+		safely("previous discussions search", func() {
+			previous, err = findPreviousDiscussions(ctx, client, item, config.MinPoints)
+		}, itemLogAttrs(item)...)
+		cancel()
+		if err != nil {
+			slog.Debug("Failed to search previous discussions", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+
+		if err := cachePreviousDiscussions(db, item.ItemID, previous, time.Now()); err != nil {
+			slog.Warn("Failed to cache previous discussions", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if len(previous) > 0 {
+			slog.Debug("Found previous discussions", "hn_id", item.ItemID, "count", len(previous))
+		}
+	}
+}
+
+// loadPreviousDiscussions returns the cached earlier submissions of an item for rendering, leaving out
+// submissions already shown as duplicates of the entry
+func loadPreviousDiscussions(db *sql.DB, item HackerNewsItem, categoryMapper *CategoryMapper) []previousDiscussion {
+	config := categoryMapper.Config().Previously
+	if db == nil || !config.Enabled || isTextPost(item) {
+		return nil
+	}
+	previous, err := getPreviousDiscussions(db, item.ItemID)
+	if err != nil {
+		slog.Debug("Failed to load previous discussions", "hn_id", item.ItemID, "error", err)
+		return nil
+	}
+
+	previous = slices.DeleteFunc(previous, func(p previousDiscussion) bool {
+		return slices.ContainsFunc(item.Duplicates, func(dup HackerNewsItem) bool { return dup.ItemID == p.ItemID })
+	})
+	limit := config.Limit
+	if limit <= 0 {
+		limit = defaultPreviouslyLimit
+	}
+	if len(previous) > limit {
+		previous = previous[:limit]
+	}
+	return previous
+}
+
+// renderPreviousDiscussions links earlier submissions as "Previously: 2019 (412 points), ..."
+func renderPreviousDiscussions(previous []previousDiscussion) string {
+	if len(previous) == 0 {
+		return ""
+	}
+	links := make([]string, len(previous))
+	for i, p := range previous {
+		links[i] = fmt.Sprintf(`<a href="https://news.ycombinator.com/item?id=%s">%s (%d points)</a>`,
+			html.EscapeString(p.ItemID), previousDiscussionDate(p.CreatedAt), p.Points)
+	}
+	return fmt.Sprintf(`<p style="margin: 0 0 12px 0;"><strong>Previously:</strong> %s</p>`, strings.Join(links, ", "))
+}
+
+// previousDiscussionDate shows the year of an earlier submission, or the month and year for recent ones
+// so several submissions in the same year can be told apart
+func previousDiscussionDate(createdAt time.Time) string {
+	if time.Since(createdAt) < 365*24*time.Hour {
+		return createdAt.Format("Jan 2006")
+	}
+	return createdAt.Format("2006")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testPreviousStories = []AlgoliaHit{
+	{ObjectID: "10", Title: "Classic Essay", URL: "http://www.example.com/essay/", Points: 412, NumComments: 150, CreatedAt: "2019-05-01T08:00:00Z"},
+	{ObjectID: "11", Title: "Classic Essay", URL: "https://example.com/essay", Points: 3, CreatedAt: "2020-05-01T08:00:00Z"},
+	{ObjectID: "12", Title: "Classic Essay", URL: "https://example.com/essay", Points: 88, NumComments: 40, CreatedAt: "2021-05-01T08:00:00Z"},
+	{ObjectID: "13", Title: "Another Essay", URL: "https://example.com/essay/other", Points: 500, CreatedAt: "2018-05-01T08:00:00Z"},
+	{ObjectID: "20", Title: "Classic Essay", URL: "https://example.com/essay", Points: 200, CreatedAt: "2024-05-01T08:00:00Z"},
+	{ObjectID: "21", Title: "Classic Essay", URL: "https://example.com/essay", Points: 50, CreatedAt: "2024-06-01T08:00:00Z"},
+}
+
+func TestRefreshPreviousDiscussions(t *testing.T) {
+	useTestAlgoliaSearch(t, testPreviousStories)
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	item := HackerNewsItem{
+		ItemID:       "20",
+		Title:        "Classic Essay",
+		Link:         "https://example.com/essay",
+		CommentsLink: "https://news.ycombinator.com/item?id=20",
+		Points:       200,
+		CreatedAt:    time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+	}
+	mapper := NewCategoryMapper(&DomainConfig{Previously: PreviouslyConfig{Enabled: true, MinPoints: 10}})
+	refreshPreviousDiscussions(db, []HackerNewsItem{item}, mapper)
+
+	previous := loadPreviousDiscussions(db, item, mapper)
+	if len(previous) != 2 || previous[0].ItemID != "12" || previous[1].ItemID != "10" || previous[1].Comments != 150 {
+		t.Fatalf("Expected earlier submissions of the article above min_points, newest first, got %+v", previous)
+	}
+
+	entry, _ := feedEntry(db, item, 50, mapper, nil, FeedFormat{})
+	expected := `<strong>Previously:</strong> <a href="https://news.ycombinator.com/item?id=12">2021 (88 points)</a>, <a href="https://news.ycombinator.com/item?id=10">2019 (412 points)</a>`
+	if !strings.Contains(entry.Description, expected) {
+		t.Errorf("Expected previous discussion links in the entry, got %s", entry.Description)
+	}
+
+	// Submissions already shown as duplicates are not repeated, and the limit applies
+	item.Duplicates = []HackerNewsItem{{ItemID: "12"}}
+	limited := NewCategoryMapper(&DomainConfig{Previously: PreviouslyConfig{Enabled: true, Limit: 1}})
+	if previous := loadPreviousDiscussions(db, item, limited); len(previous) != 1 || previous[0].ItemID != "10" {
+		t.Errorf("Expected only the 2019 submission, got %+v", previous)
+	}
+
+	// Items are searched only once
+	item.Duplicates = nil
+	useTestAlgoliaSearch(t, nil)
+	refreshPreviousDiscussions(db, []HackerNewsItem{item}, mapper)
+	if previous := loadPreviousDiscussions(db, item, mapper); len(previous) != 2 {
+		t.Errorf("Expected the cached result to be kept, got %+v", previous)
+	}
+
+	if previous := loadPreviousDiscussions(db, item, nil); previous != nil {
+		t.Errorf("Expected nothing unless enabled, got %+v", previous)
+	}
+}
+
+func TestPreviousDiscussionDate(t *testing.T) {
+	if got := previousDiscussionDate(time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)); got != "2019" {
+		t.Errorf("Expected the year for old submissions, got %q", got)
+	}
+	recent := time.Now().AddDate(0, -2, 0)
+	if got := previousDiscussionDate(recent); got != recent.Format("Jan 2006") {
+		t.Errorf("Expected the month for recent submissions, got %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// generateToken returns a random, URL-safe feed token
+func generateToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createFeedProfile stores a new feed profile, generating its token if not set
+func createFeedProfile(db *sql.DB, profile *FeedProfile) error {
+	if profile.Token == "" {
+		token, err := generateToken()
+		if err != nil {
+			return err
+		}
+		profile.Token = token
+	}
+	if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = time.Now()
+	}
+
+	keywords, _ := json.Marshal(profile.Keywords)
+	categories, _ := json.Marshal(profile.Categories)
+	exclude, _ := json.Marshal(profile.Exclude)
+	format, _ := json.Marshal(profile.Format)
+
+	_, err := execWithRetry(db, `
+		INSERT INTO feed_profiles (token, name, keywords, categories, exclude, min_points, created_at, format)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		profile.Token, profile.Name, string(keywords), string(categories), string(exclude), profile.MinPoints, profile.CreatedAt, string(format))
+	if err != nil {
+		return fmt.Errorf("failed to create feed profile: %w", err)
+	}
+
+	slog.Debug("Created feed profile", "name", profile.Name, "token", profile.Token)
+	return nil
+}
+
+// scanFeedProfile scans a feed_profiles row into a FeedProfile
+func scanFeedProfile(scanner interface{ Scan(...any) error }) (*FeedProfile, error) {
+	var profile FeedProfile
+	var keywords, categories, exclude, format sql.NullString
+	err := scanner.Scan(&profile.Token, &profile.Name, &keywords, &categories, &exclude, &profile.MinPoints, &profile.CreatedAt, &format)
+	if err != nil {
+		return nil, err
+	}
+	if format.Valid && format.String != "" {
+		if err := json.Unmarshal([]byte(format.String), &profile.Format); err != nil {
+			return nil, fmt.Errorf("failed to decode profile format: %w", err)
+		}
+	}
+
+	for _, field := range []struct {
+		raw  sql.NullString
+		dest *[]string
+	}{{keywords, &profile.Keywords}, {categories, &profile.Categories}, {exclude, &profile.Exclude}} {
+		if field.raw.Valid && field.raw.String != "" {
+			if err := json.Unmarshal([]byte(field.raw.String), field.dest); err != nil {
+				return nil, fmt.Errorf("failed to decode profile filters: %w", err)
+			}
+		}
+	}
+
+	return &profile, nil
+}
+
+// getFeedProfile retrieves a feed profile by token, returning nil if it doesn't exist
+func getFeedProfile(db *sql.DB, token string) (*FeedProfile, error) {
+	row := db.QueryRow(`
+		SELECT token, name, keywords, categories, exclude, min_points, created_at, format
+		FROM feed_profiles WHERE token = ?`, token)
+
+	profile, err := scanFeedProfile(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed profile: %w", err)
+	}
+	return profile, nil
+}
+
+// listFeedProfiles returns all feed profiles ordered by creation time
+func listFeedProfiles(db *sql.DB) ([]FeedProfile, error) {
+	rows, err := db.Query(`
+		SELECT token, name, keywords, categories, exclude, min_points, created_at, format
+		FROM feed_profiles ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed profiles: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var profiles []FeedProfile
+	for rows.Next() {
+		profile, err := scanFeedProfile(rows)
+		if err != nil {
+			slog.Error("Error scanning feed profile", "error", err)
+			continue
+		}
+		profiles = append(profiles, *profile)
+	}
+	return profiles, nil
+}
+
+// revokeFeedProfile deletes a feed profile, reporting whether it existed
+func revokeFeedProfile(db *sql.DB, token string) (bool, error) {
+	result, err := execWithRetry(db, "DELETE FROM feed_profiles WHERE token = ?", token)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke feed profile: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// filterItemsByKeywords keeps items whose title contains any of the keywords (case-insensitive)
+func filterItemsByKeywords(items []HackerNewsItem, keywords []string) []HackerNewsItem {
+	if len(keywords) == 0 {
+		return items
+	}
+
+	var filtered []HackerNewsItem
+	for _, item := range items {
+		title := strings.ToLower(item.Title)
+		for _, keyword := range keywords {
+			if strings.Contains(title, strings.ToLower(keyword)) {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// runProfile implements the profile subcommand: create, list and revoke feed tokens
+func runProfile(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss profile <create|list|revoke> [options]")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("profile create", flag.ExitOnError)
+		name := fs.String("name", "", "name of the profile (required)")
+		keywords := fs.String("keywords", "", "comma-separated title keywords")
+		categories := fs.String("categories", "", "comma-separated categories to include")
+		exclude := fs.String("exclude", "", "comma-separated categories to exclude")
+		minPoints := fs.Int("min-points", 0, "minimum points threshold (0 uses the server default)")
+		noEmoji := fs.Bool("no-emoji", false, "strip emoji from titles and content")
+		noEngagement := fs.Bool("no-engagement", false, "drop the points, comments and engagement line")
+		plainLinks := fs.Bool("plain-links", false, "use plain links instead of styled buttons")
+		titleTemplate := fs.String("title-template", "", `entry title template, e.g. "[{{.Points}}p] {{.Title}} ({{.Domain}})"`)
+		_ = fs.Parse(args[1:])
+
+		if *name == "" {
+			fmt.Fprintln(os.Stderr, "profile create: -name is required")
+			os.Exit(2)
+		}
+		if *titleTemplate != "" {
+			if _, err := parseTitleTemplate(*titleTemplate); err != nil {
+				fmt.Fprintf(os.Stderr, "profile create: invalid -title-template: %v\n", err)
+				os.Exit(2)
+			}
+		}
+
+		db := initDB()
+		defer func() { _ = db.Close() }()
+
+		profile := &FeedProfile{
+			Name:       *name,
+			Keywords:   splitQueryList([]string{*keywords}),
+			Categories: splitQueryList([]string{*categories}),
+			Exclude:    splitQueryList([]string{*exclude}),
+			MinPoints:  *minPoints,
+			Format:     FeedFormat{NoEmoji: *noEmoji, NoEngagement: *noEngagement, PlainLinks: *plainLinks, TitleTemplate: *titleTemplate},
+		}
+		if err := createFeedProfile(db, profile); err != nil {
+			slog.Error("Failed to create profile", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created profile %q: /feed/%s.xml\n", profile.Name, profile.Token)
+
+	case "list":
+		db := initDB()
+		defer func() { _ = db.Close() }()
+
+		profiles, err := listFeedProfiles(db)
+		if err != nil {
+			slog.Error("Failed to list profiles", "error", err)
+			os.Exit(1)
+		}
+		for _, p := range profiles {
+			fmt.Printf("%s\t%s\tkeywords=%s categories=%s exclude=%s min-points=%d format=%s\n",
+				p.Token, p.Name, strings.Join(p.Keywords, ","), strings.Join(p.Categories, ","), strings.Join(p.Exclude, ","), p.MinPoints, p.Format)
+		}
+
+	case "revoke":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "usage: hntop-rss profile revoke <token>")
+			os.Exit(2)
+		}
+
+		db := initDB()
+		defer func() { _ = db.Close() }()
+
+		revoked, err := revokeFeedProfile(db, args[1])
+		if err != nil {
+			slog.Error("Failed to revoke profile", "error", err)
+			os.Exit(1)
+		}
+		if !revoked {
+			fmt.Fprintf(os.Stderr, "no profile with token %s\n", args[1])
+			os.Exit(1)
+		}
+		fmt.Printf("Revoked profile %s\n", args[1])
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown profile command %q\n", args[0])
+		os.Exit(2)
+	}
+}
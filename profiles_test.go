@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFeedProfile_CreateGetRevoke(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	profile := &FeedProfile{
+		Name:       "friend",
+		Keywords:   []string{"rust", "go"},
+		Categories: []string{"GitHub"},
+		Exclude:    []string{"Twitter"},
+		MinPoints:  100,
+	}
+	if err := createFeedProfile(db, profile); err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+	if len(profile.Token) != 32 {
+		t.Errorf("Expected 32 character token, got %q", profile.Token)
+	}
+
+	loaded, err := getFeedProfile(db, profile.Token)
+	if err != nil {
+		t.Fatalf("Failed to get profile: %v", err)
+	}
+	if loaded == nil {
+		t.Fatal("Expected profile, got nil")
+	}
+	if loaded.Name != "friend" || loaded.MinPoints != 100 {
+		t.Errorf("Unexpected profile fields: %+v", loaded)
+	}
+	if strings.Join(loaded.Keywords, ",") != "rust,go" {
+		t.Errorf("Expected keywords rust,go, got %v", loaded.Keywords)
+	}
+	if strings.Join(loaded.Exclude, ",") != "Twitter" {
+		t.Errorf("Expected exclude Twitter, got %v", loaded.Exclude)
+	}
+
+	profiles, err := listFeedProfiles(db)
+	if err != nil {
+		t.Fatalf("Failed to list profiles: %v", err)
+	}
+	if len(profiles) != 1 {
+		t.Errorf("Expected 1 profile, got %d", len(profiles))
+	}
+
+	revoked, err := revokeFeedProfile(db, profile.Token)
+	if err != nil || !revoked {
+		t.Fatalf("Expected profile to be revoked, got %v, %v", revoked, err)
+	}
+
+	loaded, err = getFeedProfile(db, profile.Token)
+	if err != nil {
+		t.Fatalf("Unexpected error after revoke: %v", err)
+	}
+	if loaded != nil {
+		t.Error("Expected revoked profile to be gone")
+	}
+
+	revoked, _ = revokeFeedProfile(db, profile.Token)
+	if revoked {
+		t.Error("Revoking a missing profile should report false")
+	}
+}
+
+func TestFilterItemsByKeywords(t *testing.T) {
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Rust 2.0 released"},
+		{ItemID: "2", Title: "Gardening tips"},
+		{ItemID: "3", Title: "Why I love SQLite"},
+	}
+
+	filtered := filterItemsByKeywords(items, []string{"rust", "SQLITE"})
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(filtered))
+	}
+
+	if len(filterItemsByKeywords(items, nil)) != 3 {
+		t.Error("No keywords should keep all items")
+	}
+}
+
+func TestHandleProfileFeed(t *testing.T) {
+	server := setupTestServer(t)
+
+	profile := &FeedProfile{Name: "github-only", Categories: []string{"GitHub"}}
+	if err := createFeedProfile(server.db, profile); err != nil {
+		t.Fatalf("Failed to create profile: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed/"+profile.Token+".xml", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "GitHub Project") || strings.Contains(body, "Blog Post") {
+		t.Error("Profile feed should only contain GitHub items")
+	}
+
+	for _, path := range []string{"/feed/unknown.xml", "/feed/" + profile.Token} {
+		rec = httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// addProxyFlag registers the -proxy flag on a subcommand's flag set
+func addProxyFlag(fs *flag.FlagSet) *string {
+	return fs.String("proxy", "", "proxy for outbound requests, e.g. http://proxy:3128 or socks5://127.0.0.1:1080 (defaults to $HTTPS_PROXY/$HTTP_PROXY)")
+}
+
+// setupProxy routes all outbound requests through the given proxy, exiting on an invalid proxy URL.
+// Without a proxy the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+func setupProxy(proxy string) {
+	if proxy == "" {
+		return
+	}
+	if err := configureProxy(http.DefaultTransport.(*http.Transport), proxy, noProxyEnv()); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -proxy: %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// configureProxy sets the proxy of a transport. Every HTTP client in the application uses the
+// default transport, so configuring it covers Algolia, config, OpenGraph and enricher requests.
+func configureProxy(transport *http.Transport, proxy, noProxy string) error {
+	proxyURL, err := parseProxyURL(proxy)
+	if err != nil {
+		return err
+	}
+	transport.Proxy = proxyFunc(proxyURL, noProxy)
+	slog.Info("Using outbound proxy", "proxy", proxyURL.Redacted(), "no_proxy", noProxy)
+	return nil
+}
+
+// parseProxyURL validates a proxy URL; http, https, socks5 and socks5h proxies are supported
+func parseProxyURL(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL has no host")
+	}
+	return proxyURL, nil
+}
+
+// noProxyEnv returns the NO_PROXY environment variable, accepting the lowercase spelling too
+func noProxyEnv() string {
+	if value := os.Getenv("NO_PROXY"); value != "" {
+		return value
+	}
+	return os.Getenv("no_proxy")
+}
+
+// proxyFunc returns a transport Proxy function sending requests through proxyURL,
+// except for loopback hosts and hosts matching the NO_PROXY list
+func proxyFunc(proxyURL *url.URL, noProxy string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// bypassProxy reports whether a host should be contacted directly. NO_PROXY entries are
+// comma-separated host names (matching subdomains too), IP addresses, CIDR ranges or "*".
+func bypassProxy(host, noProxy string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.TrimPrefix(entry, "*")
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keptItemsQuery selects the items a prune keeps; ?1 is the cutoff time
+const keptItemsQuery = "SELECT item_hn_id FROM items WHERE COALESCE(pinned_at, created_at) >= ?1"
+
+// pruneTarget is a table and the condition selecting the rows a prune deletes, with ?1 as the cutoff
+type pruneTarget struct {
+	Table string
+	Where string
+}
+
+// pruneTargets are pruned in order. Items count as old by when they were pinned or submitted, and
+// the per-item tables lose the rows of every item that isn't kept, including ones deleted earlier.
+var pruneTargets = []pruneTarget{
+	{"items", "COALESCE(pinned_at, created_at) < ?1"},
+	{"item_stats_history", "recorded_at < ?1 OR item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"item_sightings", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"discussion_summaries", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"top_comments", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"polls", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"previous_discussions", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"watchlist_alerts", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"threshold_alerts", "item_hn_id NOT IN (" + keptItemsQuery + ")"},
+	{"opengraph_cache", "fetched_at < ?1"},
+}
+
+// pruneCount is the number of rows pruned, or that would be pruned, from a table
+type pruneCount struct {
+	Table string
+	Rows  int64
+}
+
+// parseRetention parses a retention period such as "90d", or a Go duration such as "2160h"
+func parseRetention(value string) (time.Duration, error) {
+	var period time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid retention period %q", value)
+		}
+		period = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if period, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid retention period %q", value)
+		}
+	}
+	if period <= 0 {
+		return 0, fmt.Errorf("retention period %q must be positive", value)
+	}
+	return period, nil
+}
+
+// pruneDatabase deletes items older than the cutoff along with their per-item rows, stats snapshots
+// and OpenGraph cache entries fetched before it. With dryRun nothing is deleted and the counts are
+// what would be pruned.
+func pruneDatabase(db *sql.DB, cutoff time.Time, dryRun bool) ([]pruneCount, error) {
+	var counts []pruneCount
+	err := withBusyRetry(func() error {
+		counts = nil
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		for _, target := range pruneTargets {
+			var rows int64
+			if dryRun {
+				err = tx.QueryRow("SELECT COUNT(*) FROM "+target.Table+" WHERE "+target.Where, cutoff.UTC()).Scan(&rows)
+			} else {
+				var result sql.Result
+				if result, err = tx.Exec("DELETE FROM "+target.Table+" WHERE "+target.Where, cutoff.UTC()); err == nil {
+					rows, err = result.RowsAffected()
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to prune %s: %w", target.Table, err)
+			}
+			counts = append(counts, pruneCount{Table: target.Table, Rows: rows})
+		}
+		if dryRun {
+			return nil
+		}
+		return tx.Commit()
+	})
+	return counts, err
+}
+
+// runPrune handles the prune subcommand
+func runPrune(args []string) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	olderThan := fs.String("older-than", "", "delete items, stats history and OpenGraph cache entries older than this, e.g. 90d or 2160h (required)")
+	dryRun := fs.Bool("dry-run", false, "only report how many rows would be deleted")
+	vacuum := fs.Bool("vacuum", false, "compact the database file after pruning")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+
+	if *olderThan == "" {
+		fmt.Fprintln(os.Stderr, "usage: hntop-rss prune -older-than <period> [-dry-run] [-vacuum]")
+		os.Exit(2)
+	}
+	period, err := parseRetention(*olderThan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prune: %v\n", err)
+		os.Exit(2)
+	}
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	cutoff := time.Now().Add(-period)
+	counts, err := pruneDatabase(db, cutoff, *dryRun)
+	if err != nil {
+		slog.Error("Failed to prune database", "error", err)
+		os.Exit(1)
+	}
+
+	verb := "Deleted"
+	if *dryRun {
+		verb = "Would delete"
+	}
+	for _, count := range counts {
+		fmt.Printf("%s %d rows from %s\n", verb, count.Rows, count.Table)
+	}
+
+	// Deleted pages are reused by SQLite, so compacting is only needed to shrink the file
+	if *vacuum && !*dryRun {
+		if _, err := execWithRetry(db, "VACUUM"); err != nil {
+			slog.Error("Failed to vacuum database", "error", err)
+			os.Exit(1)
+		}
+		fmt.Println("Compacted the database")
+	}
+}
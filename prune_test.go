@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2160h", 2160 * time.Hour, false},
+		{"1d", 24 * time.Hour, false},
+		{"0d", 0, true},
+		{"-5d", 0, true},
+		{"ninety days", 0, true},
+		{"d", 0, true},
+	}
+	for _, tc := range testCases {
+		got, err := parseRetention(tc.value)
+		if (err != nil) != tc.wantErr || got != tc.expected {
+			t.Errorf("parseRetention(%q) = %v, %v; expected %v, error %v", tc.value, got, err, tc.expected, tc.wantErr)
+		}
+	}
+}
+
+func TestPruneDatabase(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now().UTC()
+	old := now.Add(-120 * 24 * time.Hour)
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Old", Link: "https://example.com/old", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, Author: "a", CreatedAt: old, UpdatedAt: old},
+		{ItemID: "2", Title: "Recent", Link: "https://example.com/recent", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 100, Author: "b", CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "3", Title: "Old but pinned", Link: "https://example.com/classic", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 10, Author: "c", CreatedAt: old, UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+	if err := pinItem(db, "3", now); err != nil {
+		t.Fatal(err)
+	}
+	recordStatsSnapshot(db, items, old)
+	recordStatsSnapshot(db, items, now)
+	recordFrontPageSightings(db, items, now)
+
+	count := func(table string) int {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	cutoff := now.Add(-90 * 24 * time.Hour)
+
+	// A dry run reports what would go without deleting anything
+	counts, err := pruneDatabase(db, cutoff, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{"items": 1, "item_stats_history": 4, "item_sightings": 1}
+	for _, c := range counts {
+		if c.Rows != expected[c.Table] {
+			t.Errorf("Dry run: expected %d rows from %s, got %d", expected[c.Table], c.Table, c.Rows)
+		}
+	}
+	if count("items") != 3 || count("item_stats_history") != 6 {
+		t.Fatal("Expected a dry run to keep every row")
+	}
+
+	if _, err := pruneDatabase(db, cutoff, false); err != nil {
+		t.Fatal(err)
+	}
+	if count("items") != 2 || count("item_stats_history") != 2 || count("item_sightings") != 2 {
+		t.Errorf("Unexpected rows after pruning: items=%d history=%d sightings=%d", count("items"), count("item_stats_history"), count("item_sightings"))
+	}
+	if item, err := getItemByID(db, "1"); err != nil || item != nil {
+		t.Errorf("Expected the old item to be deleted, got %+v (%v)", item, err)
+	}
+}
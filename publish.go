@@ -0,0 +1,164 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultPublishMaxPerRun bounds how many stories a run posts to one service, so enabling
+// posting doesn't flood followers with the whole feed at once
+const defaultPublishMaxPerRun = 5
+
+// storyPost holds the fields available to status text templates
+type storyPost struct {
+	Title       string
+	URL         string // article URL, empty for text posts
+	CommentsURL string
+	Domain      string // article domain without www., empty for text posts
+	Points      int
+	Comments    int
+	Author      string
+}
+
+// newStoryPost returns the template fields of an item
+func newStoryPost(item HackerNewsItem) storyPost {
+	return storyPost{
+		Title:       item.Title,
+		URL:         item.Link,
+		CommentsURL: item.CommentsLink,
+		Domain:      strings.TrimPrefix(extractDomain(item.Link), "www."),
+		Points:      item.Points,
+		Comments:    item.CommentCount,
+		Author:      item.Author,
+	}
+}
+
+// parseStatusTemplate compiles a status text template, falling back to the default when text is empty
+func parseStatusTemplate(text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New("status").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Execute once against sample data so unknown fields fail before anything is posted
+	if err := tmpl.Execute(&strings.Builder{}, storyPost{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderStatus renders a story through a status template, trimming surrounding whitespace
+func renderStatus(tmpl *template.Template, item HackerNewsItem) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, newStoryPost(item)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// storiesToPublish returns the feed items in the order they reached the feed, oldest first,
+// so followers see stories in the order they were submitted
+func storiesToPublish(items []HackerNewsItem) []HackerNewsItem {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b HackerNewsItem) int { return cmp.Compare(a.CreatedAt.Unix(), b.CreatedAt.Unix()) })
+	return sorted
+}
+
+// fetchPreviewImage downloads an article's preview image for services that host their own copy,
+// rejecting non-image responses and images over maxSize bytes
+func fetchPreviewImage(ctx context.Context, client *http.Client, imageURL string, maxSize int) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "HNTop-RSS/1.0 (link previews)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code fetching image: %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("not an image: %q", contentType)
+	}
+	image, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(image) > maxSize {
+		return nil, "", fmt.Errorf("image is larger than %d bytes", maxSize)
+	}
+	return image, contentType, nil
+}
+
+// storyPosted reports whether an item has been posted to a service, recorded in the given items column
+func storyPosted(db *sql.DB, column, itemID string) (bool, error) {
+	var posted bool
+	err := db.QueryRow("SELECT "+column+" IS NOT NULL FROM items WHERE item_hn_id = ?", itemID).Scan(&posted)
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", column, err)
+	}
+	return posted, nil
+}
+
+// markStoryPosted records when an item was posted to a service
+func markStoryPosted(db *sql.DB, column, itemID string, now time.Time) error {
+	if _, err := execWithRetry(db, "UPDATE items SET "+column+" = ? WHERE item_hn_id = ?", now.UTC(), itemID); err != nil {
+		return fmt.Errorf("failed to record %s: %w", column, err)
+	}
+	return nil
+}
+
+// postNewStories posts the stories not posted to a service before, up to the per-run limit. Posting
+// stops at the first failure, such as a rate limit, and the remaining stories are tried on the next run.
+func postNewStories(db *sql.DB, items []HackerNewsItem, service, column string, maxPerRun int, now time.Time, post func(HackerNewsItem) error) {
+	limit := cmp.Or(maxPerRun, defaultPublishMaxPerRun)
+	posted := 0
+	for _, item := range items {
+		if posted == limit {
+			break
+		}
+		done, err := storyPosted(db, column, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to check earlier post", "service", service, "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if done {
+			continue
+		}
+
+		if err := post(item); err != nil {
+			slog.Warn("Failed to post story", "service", service, "hn_id", item.ItemID, "error", err)
+			return
+		}
+		if err := markStoryPosted(db, column, item.ItemID, now); err != nil {
+			slog.Warn("Failed to record post", "service", service, "hn_id", item.ItemID, "error", err)
+		}
+		slog.Info("Posted story", "service", service, "title", item.Title, "hn_id", item.ItemID)
+		posted++
+	}
+}
+
+// publishStories posts the feed's stories to the configured social accounts and bookmark services
+func publishStories(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper, now time.Time) {
+	config := categoryMapper.Config()
+	stories := storiesToPublish(items)
+	postToMastodon(db, stories, config.Mastodon, now)
+	postToBluesky(db, stories, config.Bluesky, now)
+	saveToLinkding(db, stories, config.Linkding, categoryMapper, now)
+	saveToKarakeep(db, stories, config.Karakeep, now)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"runtime/debug"
+)
+
+// errPanicked is reported for work that was abandoned after a recovered panic
+var errPanicked = errors.New("recovered from panic")
+
+// safely runs fn, recovering from a panic so one malformed page or unexpected nil can't abort
+// a whole run. The panic is logged with the stage and the given attributes. It reports whether
+// fn completed without panicking.
+func safely(stage string, fn func(), attrs ...any) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			args := append([]any{"stage", stage, "panic", r}, attrs...)
+			args = append(args, "stack", string(debug.Stack()))
+			slog.Error("Recovered from panic, skipping", args...)
+			ok = false
+		}
+	}()
+	fn()
+	return true
+}
+
+// itemLogAttrs returns the log attributes identifying an item
+func itemLogAttrs(item HackerNewsItem) []any {
+	return []any{"hn_id", item.ItemID, "title", item.Title, "url", item.Link}
+}
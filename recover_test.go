@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSafely(t *testing.T) {
+	ran := false
+	if !safely("test", func() { ran = true }) || !ran {
+		t.Error("Expected safely to run fn and report success")
+	}
+
+	var m map[string]int
+	if safely("test", func() { m["boom"] = 1 }, "hn_id", "1") {
+		t.Error("Expected safely to report a recovered panic")
+	}
+}
+
+func TestProcessOpenGraphQueue_SurvivesPanics(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	// A nil fetcher panics on every URL; each worker must recover and keep going
+	now := time.Now()
+	urls := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}
+	for _, url := range urls {
+		if err := queueOpenGraphURL(db, url, 100, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	processOpenGraphQueue(db, nil, 2, now)
+
+	// Every URL was attempted once and is waiting for a retry
+	if due, _ := getDueOpenGraphQueue(db, now); len(due) != 0 {
+		t.Errorf("Expected no URL due right after a failure, got %+v", due)
+	}
+	retries, err := getDueOpenGraphQueue(db, now.Add(ogRetryBackoff(1)+time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retries) != len(urls) {
+		t.Fatalf("Expected every URL to be rescheduled, got %+v", retries)
+	}
+	for _, entry := range retries {
+		if entry.Attempts != 1 {
+			t.Errorf("Expected one failed attempt for %s, got %d", entry.URL, entry.Attempts)
+		}
+	}
+}
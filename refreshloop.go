@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// defaultServeRefreshInterval is how often serve mode fetches the front page in the background
+const defaultServeRefreshInterval = 15 * time.Minute
+
+// refresh fetches the front page, updates stats and enrichments with the current settings,
+// records the run and drops cached feeds
+func (s *feedServer) refresh(source string) {
+	filter, categoryMapper := s.settings()
+	fetchErrorsBefore := fetchErrorSnapshot()
+	run := refreshItems(s.db, filter, categoryMapper, source)
+	if items, err := getFilteredItems(s.db, filter); err != nil {
+		slog.Error("Failed to load stored items", "error", err)
+		if run.Error == "" {
+			run.Error = err.Error()
+		}
+	} else {
+		items = prepareFeedItems(s.db, items, categoryMapper)
+		enrichItems(s.db, items, categoryMapper)
+		publishStories(s.db, items, categoryMapper, time.Now())
+	}
+	run.FetchErrors = fetchErrorsSince(fetchErrorsBefore)
+	if err := recordRun(s.db, &run); err != nil {
+		slog.Warn("Failed to record run", "error", err)
+	}
+	s.dataChanged()
+}
+
+// scheduledRefresh runs a background refresh unless one is already running or another instance
+// holds the refresh lease. It reports whether a refresh ran.
+func (s *feedServer) scheduledRefresh(now time.Time) bool {
+	_, categoryMapper := s.settings()
+	if !isRefreshLeader(s.db, categoryMapper.Config().Coordination, now) {
+		return false
+	}
+	if !s.refreshing.CompareAndSwap(false, true) {
+		slog.Debug("Skipping scheduled refresh, a refresh is already running")
+		return false
+	}
+	defer s.refreshing.Store(false)
+
+	slog.Debug("Scheduled refresh started")
+	s.refresh("serve")
+	slog.Debug("Scheduled refresh completed")
+	return true
+}
+
+// startRefreshLoop refreshes right away and then on every interval until the process exits,
+// so serve mode keeps the feed current without a separate cron job
+func (s *feedServer) startRefreshLoop(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		s.scheduledRefresh(time.Now())
+		for now := range ticker.C {
+			s.scheduledRefresh(now)
+		}
+	}()
+	slog.Info("Background refresh enabled", "interval", interval)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledRefresh_SkipsWhileRefreshing(t *testing.T) {
+	server := setupTestServer(t)
+	server.refreshing.Store(true)
+
+	if server.scheduledRefresh(time.Now()) {
+		t.Error("Expected the scheduled refresh to skip while another refresh is running")
+	}
+	if !server.refreshing.Load() {
+		t.Error("Expected the running refresh to keep its flag")
+	}
+}
+
+func TestScheduledRefresh_SkipsWithoutLease(t *testing.T) {
+	server := setupTestServer(t)
+	now := time.Now()
+
+	// Another instance holds the lease, so this one leaves the fetch to it
+	other := CoordinationConfig{Enabled: true, InstanceID: "other", LeaseSeconds: 60}
+	if !isRefreshLeader(server.db, other, now) {
+		t.Fatal("Expected the other instance to take the lease")
+	}
+	server.updateSettings(server.defaults, NewCategoryMapper(&DomainConfig{
+		Coordination: CoordinationConfig{Enabled: true, InstanceID: "serve", LeaseSeconds: 60},
+	}))
+
+	if server.scheduledRefresh(now.Add(time.Second)) {
+		t.Error("Expected the scheduled refresh to skip without the lease")
+	}
+	if server.refreshing.Load() {
+		t.Error("Expected no refresh to be marked as running")
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// replicationTimeout bounds a single snapshot upload
+	replicationTimeout = 10 * time.Minute
+	// defaultReplicationInterval is how often serve mode replicates when no interval is configured
+	defaultReplicationInterval = time.Hour
+	// snapshotName is the object name of the latest snapshot
+	snapshotName = "hackernews.db.gz"
+)
+
+// ReplicationConfig configures uploading database snapshots after each run
+type ReplicationConfig struct {
+	Destination     string `json:"destination"`       // s3://bucket/prefix or a local directory, e.g. on another disk; empty disables
+	Endpoint        string `json:"endpoint"`          // S3-compatible endpoint such as https://minio.lan:9000 (default AWS)
+	Region          string `json:"region"`            // S3 region (default $AWS_REGION or us-east-1)
+	AccessKeyID     string `json:"access_key_id"`     // defaults to $AWS_ACCESS_KEY_ID
+	SecretAccessKey string `json:"secret_access_key"` // defaults to $AWS_SECRET_ACCESS_KEY
+	Daily           bool   `json:"daily"`             // also keep one dated snapshot per day next to the latest one
+	IntervalMinutes int    `json:"interval_minutes"`  // how often serve mode replicates (default 60)
+}
+
+// snapshotStore stores a compressed snapshot file under a name
+type snapshotStore interface {
+	store(ctx context.Context, name, file string) error
+}
+
+// dirSnapshotStore writes snapshots to a local directory
+type dirSnapshotStore struct {
+	dir string
+}
+
+// store copies the snapshot into the directory, replacing any previous one atomically
+func (d *dirSnapshotStore) store(_ context.Context, name, file string) error {
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp, err := os.CreateTemp(d.dir, "."+name+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to copy snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(d.dir, name))
+}
+
+// s3SnapshotStore uploads snapshots to an S3 bucket under a key prefix
+type s3SnapshotStore struct {
+	client *s3Client
+	bucket string
+	prefix string
+}
+
+// store uploads the snapshot as prefix/name
+func (s *s3SnapshotStore) store(ctx context.Context, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return s.client.putObject(ctx, s.bucket, path.Join(s.prefix, name), f, info.Size(), "application/gzip", "")
+}
+
+// newSnapshotStore creates the store for a replication destination
+func newSnapshotStore(config ReplicationConfig) (snapshotStore, error) {
+	if !strings.HasPrefix(config.Destination, "s3://") {
+		return &dirSnapshotStore{dir: config.Destination}, nil
+	}
+
+	destination, err := url.Parse(config.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination: %w", err)
+	}
+	if destination.Host == "" {
+		return nil, fmt.Errorf("destination %q has no bucket", config.Destination)
+	}
+	client, err := newS3Client(config.Endpoint, config.Region, config.AccessKeyID, config.SecretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	return &s3SnapshotStore{client: client, bucket: destination.Host, prefix: strings.Trim(destination.Path, "/")}, nil
+}
+
+// snapshotDatabase writes a consistent, gzip-compressed copy of the database to a temporary file.
+// Only the SQLite backend can snapshot; the caller removes the file.
+func snapshotDatabase(db *sql.DB) (string, error) {
+	dir, err := os.MkdirTemp("", "hntop-snapshot-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	raw := filepath.Join(dir, "hackernews.db")
+	if err := backendOf(db).snapshot(db, raw); err != nil {
+		return "", err
+	}
+
+	compressed, err := os.CreateTemp("", "hntop-snapshot-*.db.gz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	if err := gzipFile(raw, compressed); err != nil {
+		_ = compressed.Close()
+		_ = os.Remove(compressed.Name())
+		return "", err
+	}
+	if err := compressed.Close(); err != nil {
+		_ = os.Remove(compressed.Name())
+		return "", fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+	return compressed.Name(), nil
+}
+
+// gzipFile compresses the file at src into dst
+func gzipFile(src string, dst io.Writer) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, in); err != nil {
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	return nil
+}
+
+// replicateDatabase uploads a snapshot of the database to the configured destination.
+// It does nothing when replication isn't configured.
+func replicateDatabase(db *sql.DB, config ReplicationConfig, now time.Time) error {
+	if config.Destination == "" {
+		return nil
+	}
+	store, err := newSnapshotStore(config)
+	if err != nil {
+		return fmt.Errorf("invalid replication destination: %w", err)
+	}
+
+	file, err := snapshotDatabase(db)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), replicationTimeout)
+	defer cancel()
+
+	names := []string{snapshotName}
+	if config.Daily {
+		names = append(names, "hackernews-"+localTime(now).Format("2006-01-02")+".db.gz")
+	}
+	for _, name := range names {
+		if err := store.store(ctx, name, file); err != nil {
+			return fmt.Errorf("failed to store snapshot %s: %w", name, err)
+		}
+	}
+	slog.Info("Database replicated", "destination", config.Destination, "snapshots", names)
+	return nil
+}
+
+// startReplication replicates the database periodically until the process exits
+func startReplication(db *sql.DB, config ReplicationConfig) {
+	if config.Destination == "" {
+		return
+	}
+	if backend := backendOf(db); backend.name() != "sqlite" {
+		slog.Warn("Database replication copies the SQLite file; skipping it", "backend", backend.name())
+		return
+	}
+	interval := defaultReplicationInterval
+	if config.IntervalMinutes > 0 {
+		interval = time.Duration(config.IntervalMinutes) * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := replicateDatabase(db, config, now); err != nil {
+				slog.Error("Database replication failed", "error", err)
+			}
+		}
+	}()
+	slog.Info("Database replication enabled", "destination", config.Destination, "interval", interval)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openSnapshot decompresses a snapshot and opens it as a database
+func openSnapshot(t *testing.T, compressed io.Reader) *sql.DB {
+	t.Helper()
+	zr, err := gzip.NewReader(compressed)
+	if err != nil {
+		t.Fatalf("Snapshot is not gzip-compressed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "restored.db")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(out, zr); err != nil {
+		t.Fatal(err)
+	}
+	_ = out.Close()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestReplicateDatabase_Directory(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+	updateStoredItems(db, []HackerNewsItem{{ItemID: "42", Title: "Kept", Link: "https://example.com", CreatedAt: time.Now(), UpdatedAt: time.Now()}})
+
+	dir := filepath.Join(t.TempDir(), "backup")
+	now := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+	if err := replicateDatabase(db, ReplicationConfig{Destination: dir, Daily: true}, now); err != nil {
+		t.Fatalf("replicateDatabase failed: %v", err)
+	}
+
+	for _, name := range []string{snapshotName, "hackernews-2024-05-06.db.gz"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected snapshot %s: %v", name, err)
+		}
+		restored := openSnapshot(t, f)
+		_ = f.Close()
+
+		item, err := getItemByID(restored, "42")
+		if err != nil || item == nil || item.Title != "Kept" {
+			t.Errorf("Expected the item in snapshot %s, got %v, %v", name, item, err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected only the two snapshots in the directory, got %d entries", len(entries))
+	}
+}
+
+func TestReplicateDatabase_S3(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var uploadedPath string
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploadedPath = r.URL.Path
+		uploaded, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	config := ReplicationConfig{Destination: "s3://backups/hntop/", Endpoint: server.URL, AccessKeyID: "key", SecretAccessKey: "secret"}
+	if err := replicateDatabase(db, config, time.Now()); err != nil {
+		t.Fatalf("replicateDatabase failed: %v", err)
+	}
+	if uploadedPath != "/backups/hntop/"+snapshotName {
+		t.Errorf("Unexpected upload path %q", uploadedPath)
+	}
+	openSnapshot(t, bytes.NewReader(uploaded))
+}
+
+func TestReplicateDatabase_Disabled(t *testing.T) {
+	if err := replicateDatabase(nil, ReplicationConfig{}, time.Now()); err != nil {
+		t.Errorf("Expected no error when replication is not configured, got %v", err)
+	}
+}
+
+func TestNewSnapshotStore_InvalidS3(t *testing.T) {
+	if _, err := newSnapshotStore(ReplicationConfig{Destination: "s3:///prefix", AccessKeyID: "k", SecretAccessKey: "s"}); err == nil {
+		t.Error("Expected an error for a destination without a bucket")
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportTextPosts is the domain row text posts are counted under
+const reportTextPosts = "(text posts)"
+
+// reportRow aggregates the stories sharing a domain, author or category
+type reportRow struct {
+	Name     string
+	Stories  int
+	Points   int
+	Comments int
+}
+
+// AveragePoints returns the mean points per story
+func (r reportRow) AveragePoints() float64 {
+	if r.Stories == 0 {
+		return 0
+	}
+	return float64(r.Points) / float64(r.Stories)
+}
+
+// weeklyReport summarizes the stories stored for a period
+type weeklyReport struct {
+	Start, End time.Time
+	Total      reportRow
+	Domains    []reportRow
+	Authors    []reportRow
+	Categories []reportRow
+}
+
+// buildReport aggregates items into a report, keeping the top rows of the domain and author tables.
+// Categories are the newsletter sections, so every story is counted in exactly one.
+func buildReport(items []HackerNewsItem, categoryMapper *CategoryMapper, start, end time.Time, top int) weeklyReport {
+	domains := make(map[string]*reportRow)
+	authors := make(map[string]*reportRow)
+	categories := make(map[string]*reportRow)
+
+	report := weeklyReport{Start: localTime(start), End: localTime(end), Total: reportRow{Name: "All stories"}}
+	for _, item := range items {
+		report.Total.Stories++
+		report.Total.Points += item.Points
+		report.Total.Comments += item.CommentCount
+
+		addReportRow(domains, reportDomain(item), item)
+		if item.Author != "" {
+			addReportRow(authors, item.Author, item)
+		}
+		addReportRow(categories, newsletterSectionName(item, categoryMapper), item)
+	}
+
+	report.Domains = sortedReportRows(domains, top)
+	report.Authors = sortedReportRows(authors, top)
+	report.Categories = sortedReportRows(categories, 0)
+	return report
+}
+
+// reportDomain returns the domain row an item is counted under
+func reportDomain(item HackerNewsItem) string {
+	domain := strings.TrimPrefix(extractDomain(item.Link), "www.")
+	if isTextPost(item) || domain == "" {
+		return reportTextPosts
+	}
+	return domain
+}
+
+// addReportRow counts an item in the named row, creating it on first use
+func addReportRow(rows map[string]*reportRow, name string, item HackerNewsItem) {
+	row, ok := rows[name]
+	if !ok {
+		row = &reportRow{Name: name}
+		rows[name] = row
+	}
+	row.Stories++
+	row.Points += item.Points
+	row.Comments += item.CommentCount
+}
+
+// sortedReportRows orders rows by story count, then points, then name, keeping at most top rows (0 keeps all)
+func sortedReportRows(rows map[string]*reportRow, top int) []reportRow {
+	sorted := make([]reportRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, *row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Stories != sorted[j].Stories {
+			return sorted[i].Stories > sorted[j].Stories
+		}
+		if sorted[i].Points != sorted[j].Points {
+			return sorted[i].Points > sorted[j].Points
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if top > 0 && len(sorted) > top {
+		sorted = sorted[:top]
+	}
+	return sorted
+}
+
+// renderReportMarkdown renders a report as Markdown tables
+func renderReportMarkdown(report weeklyReport, title string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "%s – %s\n\n", report.Start.Format("2006-01-02"), report.End.Format("2006-01-02"))
+	fmt.Fprintf(&b, "- Stories: %d\n", report.Total.Stories)
+	fmt.Fprintf(&b, "- Average points: %.1f\n", report.Total.AveragePoints())
+	if report.Total.Stories > 0 {
+		fmt.Fprintf(&b, "- Average comments: %.1f\n", float64(report.Total.Comments)/float64(report.Total.Stories))
+	}
+
+	table := func(heading, column string, rows []reportRow) {
+		fmt.Fprintf(&b, "\n## %s\n\n", heading)
+		if len(rows) == 0 {
+			b.WriteString("No stories.\n")
+			return
+		}
+		fmt.Fprintf(&b, "| %s | Stories | Share | Points | Avg points |\n", column)
+		b.WriteString("| --- | ---: | ---: | ---: | ---: |\n")
+		for _, row := range rows {
+			share := 100 * float64(row.Stories) / float64(report.Total.Stories)
+			fmt.Fprintf(&b, "| %s | %d | %.0f%% | %d | %.1f |\n", markdownCell(row.Name), row.Stories, share, row.Points, row.AveragePoints())
+		}
+	}
+	table("Top Domains", "Domain", report.Domains)
+	table("Top Authors", "Author", report.Authors)
+	table("Categories", "Category", report.Categories)
+	return b.String()
+}
+
+// markdownCell escapes text for use in a Markdown table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+// renderReportCSV renders a report as a single CSV table with a section column
+func renderReportCSV(report weeklyReport) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	write := func(section string, row reportRow) {
+		_ = w.Write([]string{
+			report.Start.Format("2006-01-02"),
+			report.End.Format("2006-01-02"),
+			section,
+			row.Name,
+			strconv.Itoa(row.Stories),
+			strconv.Itoa(row.Points),
+			strconv.Itoa(row.Comments),
+			strconv.FormatFloat(row.AveragePoints(), 'f', 1, 64),
+		})
+	}
+
+	_ = w.Write([]string{"period_start", "period_end", "section", "name", "stories", "points", "comments", "average_points"})
+	write("total", report.Total)
+	for _, row := range report.Domains {
+		write("domain", row)
+	}
+	for _, row := range report.Authors {
+		write("author", row)
+	}
+	for _, row := range report.Categories {
+		write("category", row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// runReport handles the report subcommand
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	outDir := fs.String("outdir", ".", "directory where the report files will be saved")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	days := fs.Int("days", 7, "number of days the report covers")
+	top := fs.Int("top", 10, "number of domains and authors listed")
+	minPoints := fs.Int("min-points", 0, "only count stories with more points than this")
+	format := fs.String("format", "md,csv", "comma-separated report formats: md, csv")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	timezone := addTimezoneFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+	setupTimezone(*timezone)
+
+	formats := splitQueryList([]string{*format})
+	for _, f := range formats {
+		if f != "md" && f != "csv" {
+			fmt.Fprintf(os.Stderr, "report: unsupported format %q\n", f)
+			os.Exit(2)
+		}
+	}
+
+	categoryMapper := LoadConfig(*configPath, *configURL)
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	end := time.Now()
+	start := end.Add(-time.Duration(*days) * 24 * time.Hour)
+	items, err := getTopItemsBetween(db, start, end, *minPoints, -1)
+	if err != nil {
+		slog.Error("Failed to load items", "error", err)
+		os.Exit(1)
+	}
+	report := buildReport(collapseDuplicateSubmissions(items), categoryMapper, start, end, *top)
+
+	year, week := end.ISOWeek()
+	title := fmt.Sprintf("Hacker News Weekly Report – Week %d, %d", week, year)
+	if *days != 7 {
+		title = fmt.Sprintf("Hacker News Report – the last %d days", *days)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		slog.Error("Error creating output directory", "error", err)
+		os.Exit(1)
+	}
+	base := filepath.Join(*outDir, fmt.Sprintf("report-%d-W%02d", year, week))
+	for _, f := range formats {
+		content := renderReportMarkdown(report, title)
+		if f == "csv" {
+			if content, err = renderReportCSV(report); err != nil {
+				slog.Error("Failed to render report", "error", err)
+				os.Exit(1)
+			}
+		}
+		filename := base + "." + f
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			slog.Error("Error writing report", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Report saved", "stories", report.Total.Stories, "filename", filename)
+	}
+}
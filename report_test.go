@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportTestItems() []HackerNewsItem {
+	return []HackerNewsItem{
+		{ItemID: "1", Title: "Repo one", Link: "https://github.com/a/one", Points: 300, CommentCount: 30, Author: "alice"},
+		{ItemID: "2", Title: "Repo two", Link: "https://www.github.com/b/two", Points: 100, CommentCount: 10, Author: "bob"},
+		{ItemID: "3", Title: "Ask HN: Why?", Points: 50, CommentCount: 80, Author: "alice"},
+		{ItemID: "4", Title: "Show HN: A | pipe", Link: "https://example.com/", Points: 150, CommentCount: 20, Author: "carol"},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{CategoryDomains: map[string][]string{"Code": {"github.com"}}})
+	report := buildReport(reportTestItems(), mapper, time.Time{}, time.Time{}, 10)
+
+	if report.Total.Stories != 4 || report.Total.Points != 600 || report.Total.AveragePoints() != 150 {
+		t.Errorf("Unexpected totals: %+v", report.Total)
+	}
+
+	if len(report.Domains) != 3 || report.Domains[0].Name != "github.com" || report.Domains[0].Stories != 2 {
+		t.Errorf("Expected github.com (with www stripped) to lead the domains, got %+v", report.Domains)
+	}
+	foundTextPosts := false
+	for _, row := range report.Domains {
+		if row.Name == reportTextPosts {
+			foundTextPosts = true
+		}
+	}
+	if !foundTextPosts {
+		t.Error("Expected text posts to be counted under their own domain row")
+	}
+
+	if report.Authors[0].Name != "alice" || report.Authors[0].Stories != 2 || report.Authors[0].Points != 350 {
+		t.Errorf("Expected alice to lead the authors, got %+v", report.Authors[0])
+	}
+
+	categories := map[string]int{}
+	for _, row := range report.Categories {
+		categories[row.Name] = row.Stories
+	}
+	if categories["Code"] != 2 || categories["Ask HN"] != 1 || categories["Show HN"] != 1 {
+		t.Errorf("Unexpected category distribution %v", categories)
+	}
+}
+
+func TestBuildReport_Top(t *testing.T) {
+	report := buildReport(reportTestItems(), nil, time.Time{}, time.Time{}, 1)
+	if len(report.Domains) != 1 || len(report.Authors) != 1 {
+		t.Errorf("Expected one domain and one author, got %d and %d", len(report.Domains), len(report.Authors))
+	}
+	if len(report.Categories) < 2 {
+		t.Error("Expected the category distribution not to be truncated")
+	}
+}
+
+func TestRenderReportMarkdown(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := buildReport(reportTestItems(), nil, start, start.AddDate(0, 0, 7), 10)
+	markdown := renderReportMarkdown(report, "Weekly")
+
+	for _, want := range []string{"# Weekly", "2024-01-01 – 2024-01-08", "- Stories: 4", "- Average points: 150.0", "## Top Domains", "| github.com | 2 | 50% | 400 | 200.0 |", "## Top Authors", "## Categories"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected markdown to contain %q:\n%s", want, markdown)
+		}
+	}
+
+	empty := renderReportMarkdown(buildReport(nil, nil, start, start, 10), "Empty")
+	if !strings.Contains(empty, "No stories.") {
+		t.Errorf("Expected an empty report to say so, got:\n%s", empty)
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	if got := markdownCell("a|b\nc"); got != `a\|b c` {
+		t.Errorf("markdownCell = %q", got)
+	}
+}
+
+func TestRenderReportCSV(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := buildReport(reportTestItems(), nil, start, start.AddDate(0, 0, 7), 10)
+	data, err := renderReportCSV(report)
+	if err != nil {
+		t.Fatalf("renderReportCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if strings.Join(records[0], ",") != "period_start,period_end,section,name,stories,points,comments,average_points" {
+		t.Errorf("Unexpected header %v", records[0])
+	}
+	if strings.Join(records[1], ",") != "2024-01-01,2024-01-08,total,All stories,4,600,140,150.0" {
+		t.Errorf("Unexpected total row %v", records[1])
+	}
+	want := 1 + 1 + len(report.Domains) + len(report.Authors) + len(report.Categories)
+	if len(records) != want {
+		t.Errorf("Expected %d rows, got %d", want, len(records))
+	}
+}
+
+func TestReportItemsFromDatabase(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	updateStoredItems(db, []HackerNewsItem{
+		{ItemID: "1", Title: "Recent", Link: "https://a.example/", Points: 100, CreatedAt: now.Add(-24 * time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "Old", Link: "https://b.example/", Points: 100, CreatedAt: now.Add(-10 * 24 * time.Hour), UpdatedAt: now},
+	})
+
+	items, err := getTopItemsBetween(db, now.Add(-7*24*time.Hour), now, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ItemID != "1" {
+		t.Errorf("Expected only the recent item without a limit, got %v", items)
+	}
+}
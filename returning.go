@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// returningAbsence is how long a story must have been off the front page before reappearing counts as a return
+const returningAbsence = 6 * time.Hour
+
+// frontPageSighting is the last time an item was seen on the front page and its stats at that time
+type frontPageSighting struct {
+	LastSeenAt   time.Time
+	Points       int
+	CommentCount int
+}
+
+// isReturning reports whether an item reappearing on the front page at now has come back:
+// it was away for at least returningAbsence and its stats grew in the meantime
+func isReturning(previous *frontPageSighting, item HackerNewsItem, now time.Time) bool {
+	if previous == nil || now.Sub(previous.LastSeenAt) < returningAbsence {
+		return false
+	}
+	return item.Points > previous.Points || item.CommentCount > previous.CommentCount
+}
+
+// recordFrontPageSightings records the current front page items and marks the ones that returned
+// after dropping off. Returned items get their updated timestamp bumped so feed readers resurface them.
+// It returns the IDs of the returning items.
+func recordFrontPageSightings(db *sql.DB, items []HackerNewsItem, now time.Time) []string {
+	var returning []string
+	for _, item := range items {
+		previous, err := getFrontPageSighting(db, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to load front page sighting", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+
+		returned := isReturning(previous, item, now)
+		if err := saveFrontPageSighting(db, item, now, returned); err != nil {
+			slog.Warn("Failed to record front page sighting", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if returned {
+			slog.Info("Story returned to the front page", "title", item.Title, "hn_id", item.ItemID, "away", now.Sub(previous.LastSeenAt).Round(time.Minute), "points", item.Points, "previous_points", previous.Points)
+			returning = append(returning, item.ItemID)
+		}
+	}
+	return returning
+}
+
+// getFrontPageSighting returns the last front page sighting of an item, or nil if it was never seen
+func getFrontPageSighting(db *sql.DB, itemID string) (*frontPageSighting, error) {
+	var sighting frontPageSighting
+	err := db.QueryRow("SELECT last_seen_at, last_points, last_comment_count FROM item_sightings WHERE item_hn_id = ?", itemID).
+		Scan(&sighting.LastSeenAt, &sighting.Points, &sighting.CommentCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sighting, nil
+}
+
+// saveFrontPageSighting stores an item's latest front page sighting, setting its return time if it returned
+func saveFrontPageSighting(db *sql.DB, item HackerNewsItem, now time.Time, returned bool) error {
+	var returnedAt any
+	if returned {
+		returnedAt = now
+	}
+	_, err := execWithRetry(db, `
+		INSERT INTO item_sightings (item_hn_id, last_seen_at, last_points, last_comment_count, returned_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(item_hn_id) DO UPDATE SET
+			last_seen_at = excluded.last_seen_at,
+			last_points = excluded.last_points,
+			last_comment_count = excluded.last_comment_count,
+			returned_at = COALESCE(excluded.returned_at, item_sightings.returned_at)`,
+		item.ItemID, now, item.Points, item.CommentCount, returnedAt)
+	return err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsReturning(t *testing.T) {
+	now := time.Now()
+	item := HackerNewsItem{Points: 150, CommentCount: 40}
+
+	testCases := []struct {
+		name     string
+		previous *frontPageSighting
+		expected bool
+	}{
+		{"never seen", nil, false},
+		{"still on front page", &frontPageSighting{LastSeenAt: now.Add(-30 * time.Minute), Points: 100}, false},
+		{"back with more points", &frontPageSighting{LastSeenAt: now.Add(-8 * time.Hour), Points: 100, CommentCount: 40}, true},
+		{"back with more comments", &frontPageSighting{LastSeenAt: now.Add(-8 * time.Hour), Points: 150, CommentCount: 10}, true},
+		{"back without new activity", &frontPageSighting{LastSeenAt: now.Add(-8 * time.Hour), Points: 150, CommentCount: 40}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isReturning(tc.previous, item, now); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecordFrontPageSightings(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	start := time.Now().Add(-12 * time.Hour)
+	item := HackerNewsItem{
+		ItemID:       "42",
+		Title:        "Second wind",
+		Link:         "https://example.com/second-wind",
+		CommentsLink: "https://news.ycombinator.com/item?id=42",
+		Points:       80,
+		CommentCount: 20,
+		CreatedAt:    start,
+		UpdatedAt:    start,
+	}
+	updateStoredItems(db, []HackerNewsItem{item})
+
+	if returning := recordFrontPageSightings(db, []HackerNewsItem{item}, start); len(returning) != 0 {
+		t.Fatalf("Expected no returning items on first sighting, got %v", returning)
+	}
+
+	// Seen again shortly after: still the same front page run
+	item.Points = 90
+	if returning := recordFrontPageSightings(db, []HackerNewsItem{item}, start.Add(time.Hour)); len(returning) != 0 {
+		t.Fatalf("Expected no returning items while on the front page, got %v", returning)
+	}
+
+	// Back after dropping off with more points
+	item.Points = 200
+	returnedAt := start.Add(10 * time.Hour)
+	returning := recordFrontPageSightings(db, []HackerNewsItem{item}, returnedAt)
+	if len(returning) != 1 || returning[0] != "42" {
+		t.Fatalf("Expected item 42 to be returning, got %v", returning)
+	}
+
+	// The return time survives later sightings
+	recordFrontPageSightings(db, []HackerNewsItem{item}, returnedAt.Add(time.Hour))
+	stored, err := getItemByID(db, "42")
+	if err != nil || stored == nil {
+		t.Fatalf("Failed to load item: %v", err)
+	}
+	if !stored.ReturnedAt.Equal(returnedAt) {
+		t.Errorf("Expected returned_at %v, got %v", returnedAt, stored.ReturnedAt)
+	}
+
+	feed := generateRSSFeed(nil, []HackerNewsItem{*stored}, 50, nil)
+	if !strings.Contains(feed, `term="Returning"`) {
+		t.Error("Expected returning item to be tagged Returning")
+	}
+	if !strings.Contains(feed, "<updated>"+returnedAt.Format(time.RFC3339)+"</updated>") {
+		t.Error("Expected entry updated time to be the return time")
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// atomNamespace is the XML namespace of Atom 1.0 documents
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// Atom structures used to validate generated feeds. Required elements are slices so that
+// missing and repeated elements can both be detected.
+type checkAtomFeed struct {
+	XMLName xml.Name          `xml:"feed"`
+	IDs     []string          `xml:"id"`
+	Titles  []string          `xml:"title"`
+	Updated []string          `xml:"updated"`
+	Links   []checkAtomLink   `xml:"link"`
+	Authors []checkAtomPerson `xml:"author"`
+	Entries []checkAtomEntry  `xml:"entry"`
+}
+
+type checkAtomEntry struct {
+	IDs        []string            `xml:"id"`
+	Titles     []checkAtomText     `xml:"title"`
+	Updated    []string            `xml:"updated"`
+	Published  []string            `xml:"published"`
+	Links      []checkAtomLink     `xml:"link"`
+	Authors    []checkAtomPerson   `xml:"author"`
+	Categories []checkAtomCategory `xml:"category"`
+	Content    []checkAtomText     `xml:"content"`
+	Summary    []checkAtomText     `xml:"summary"`
+}
+
+type checkAtomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type checkAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type checkAtomPerson struct {
+	Names []string `xml:"name"`
+}
+
+type checkAtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// selfTestConfig is the configuration used for the self-test when no local config is given
+var selfTestConfig = DomainConfig{
+	CategoryDomains: map[string][]string{
+		"Code":     {"github.com"},
+		"Research": {"arxiv.org"},
+	},
+}
+
+// selfTestItems returns fixture items covering the cases that have broken feed readers before:
+// text posts, non-ASCII and HTML-special titles, duplicate submissions, flamewars and returning stories
+func selfTestItems(now time.Time) []HackerNewsItem {
+	item := func(id, title, link string, points, comments int, age time.Duration) HackerNewsItem {
+		return HackerNewsItem{
+			ItemID:       id,
+			Title:        title,
+			Link:         link,
+			CommentsLink: "https://news.ycombinator.com/item?id=" + id,
+			Points:       points,
+			CommentCount: comments,
+			Author:       "user" + id,
+			CreatedAt:    now.Add(-age),
+			UpdatedAt:    now.Add(-age / 2),
+		}
+	}
+
+	returning := item("1000006", "A story that came back", "https://example.com/back", 300, 120, 30*time.Hour)
+	returning.ReturnedAt = now.Add(-time.Hour)
+
+	return []HackerNewsItem{
+		item("1000001", "Plain article", "https://example.com/article", 120, 40, 2*time.Hour),
+		item("1000002", "Ask HN: How do you test feeds?", "", 80, 60, 3*time.Hour),
+		item("1000003", "Ünïcödé, 日本語 and emoji 🚀 in titles", "https://github.com/example/unicode", 95, 10, 4*time.Hour),
+		item("1000004", `<script>alert("x")</script> & "quotes" <b>tags</b>`, "https://example.com/escape?a=1&b=2", 70, 5, 5*time.Hour),
+		item("1000005", "Same article, first submission", "https://arxiv.org/abs/2401.00001", 200, 90, 10*time.Hour),
+		item("1000007", "Same article, second submission", "https://arxiv.org/abs/2401.00001", 60, 30, 6*time.Hour),
+		item("1000008", "Flamewar with control\vcharacters", "https://example.com/flame", 55, 400, time.Hour),
+		returning,
+		item("1000009", "No comments yet", "https://example.com/quiet", 51, 0, 30*time.Minute),
+	}
+}
+
+// runSelfTest generates a feed from fixture data and validates it, writing any problems to out.
+// It returns the process exit code: 0 if the feed passed, 1 otherwise.
+func runSelfTest(out io.Writer, categoryMapper *CategoryMapper) int {
+	if categoryMapper == nil {
+		config := selfTestConfig
+		categoryMapper = NewCategoryMapper(&config)
+	}
+
+	items := prepareFeedItems(nil, selfTestItems(time.Now()), categoryMapper)
+
+	var feed string
+	var problems []string
+	if !safely("self-test feed", func() {
+		feed = generateRSSFeed(nil, items, 50, categoryMapper)
+	}) {
+		problems = append(problems, "feed generation panicked")
+	} else {
+		problems = validateAtomFeed(feed, len(items))
+	}
+
+	if len(problems) > 0 {
+		_, _ = fmt.Fprintf(out, "Self-test failed with %d problem(s):\n", len(problems))
+		for _, problem := range problems {
+			_, _ = fmt.Fprintf(out, "  - %s\n", problem)
+		}
+		return 1
+	}
+
+	_, _ = fmt.Fprintf(out, "Self-test passed: %d entries validated\n", len(items))
+	return 0
+}
+
+// validateAtomFeed checks a feed against the RFC 4287 rules and common feed reader expectations.
+// It returns a description of every problem found, or nil for a valid feed.
+func validateAtomFeed(data string, wantEntries int) []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Readers reject invalid UTF-8 and documents that don't declare their encoding
+	if !utf8.ValidString(data) {
+		addf("feed is not valid UTF-8")
+	}
+	if !strings.HasPrefix(data, `<?xml version="1.0" encoding="UTF-8"?>`) {
+		addf("feed does not start with an XML declaration specifying UTF-8")
+	}
+
+	// Well-formedness, checked separately so the error points at the offending position
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			addf("feed is not well-formed XML: %v", err)
+			return problems
+		}
+	}
+
+	var feed checkAtomFeed
+	if err := xml.Unmarshal([]byte(data), &feed); err != nil {
+		addf("failed to parse feed: %v", err)
+		return problems
+	}
+	if feed.XMLName.Space != atomNamespace {
+		addf("root element is not in the Atom namespace (got %q)", feed.XMLName.Space)
+	}
+
+	// RFC 4287 4.1.1: the feed and each entry have exactly one id, title and updated
+	requireOne := func(context, element string, values []string) (string, bool) {
+		if len(values) != 1 {
+			addf("%s must have exactly one <%s>, found %d", context, element, len(values))
+			return "", false
+		}
+		return strings.TrimSpace(values[0]), true
+	}
+	checkID := func(context, id string) {
+		// RFC 4287 4.2.6: the id is an absolute IRI
+		parsed, err := url.Parse(id)
+		if err != nil || parsed.Scheme == "" {
+			addf("%s id %q is not an absolute IRI", context, id)
+		}
+	}
+	checkDate := func(context, element, value string) time.Time {
+		// RFC 4287 3.3: dates are RFC 3339 timestamps
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			addf("%s <%s> %q is not an RFC 3339 timestamp", context, element, value)
+		}
+		return parsed
+	}
+	checkLinks := func(context string, links []checkAtomLink) int {
+		alternates := map[string]bool{}
+		count := 0
+		for _, link := range links {
+			// RFC 4287 4.2.7.1: href is required. Readers don't resolve relative links reliably.
+			parsed, err := url.Parse(link.Href)
+			if link.Href == "" || err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				addf("%s has a link with a missing or relative href %q", context, link.Href)
+			}
+			if link.Rel == "" || link.Rel == "alternate" {
+				// RFC 4287 4.1.1: at most one alternate link per type
+				if alternates[link.Type] {
+					addf("%s has more than one alternate link of type %q", context, link.Type)
+				}
+				alternates[link.Type] = true
+				count++
+			}
+		}
+		return count
+	}
+	checkText := func(context, element string, text checkAtomText) {
+		// RFC 4287 3.1.1: text constructs are text, html or xhtml
+		switch text.Type {
+		case "", "text", "html", "xhtml":
+		default:
+			addf("%s <%s> has invalid type %q", context, element, text.Type)
+		}
+	}
+
+	if id, ok := requireOne("feed", "id", feed.IDs); ok {
+		checkID("feed", id)
+	}
+	if title, ok := requireOne("feed", "title", feed.Titles); ok && title == "" {
+		addf("feed title is empty")
+	}
+	if updated, ok := requireOne("feed", "updated", feed.Updated); ok {
+		checkDate("feed", "updated", updated)
+	}
+	checkLinks("feed", feed.Links)
+	for _, author := range feed.Authors {
+		if len(author.Names) != 1 || strings.TrimSpace(author.Names[0]) == "" {
+			addf("feed author must have exactly one non-empty name")
+		}
+	}
+
+	if len(feed.Entries) != wantEntries {
+		addf("feed has %d entries, expected %d", len(feed.Entries), wantEntries)
+	}
+
+	seenIDs := map[string]int{}
+	for i, entry := range feed.Entries {
+		context := fmt.Sprintf("entry %d", i+1)
+
+		if id, ok := requireOne(context, "id", entry.IDs); ok {
+			checkID(context, id)
+			// Readers deduplicate by id, so a repeated id hides an entry
+			if previous, exists := seenIDs[id]; exists {
+				addf("%s repeats the id %q of entry %d", context, id, previous)
+			}
+			seenIDs[id] = i + 1
+		}
+		if len(entry.Titles) != 1 {
+			addf("%s must have exactly one <title>, found %d", context, len(entry.Titles))
+		} else {
+			checkText(context, "title", entry.Titles[0])
+			if strings.TrimSpace(entry.Titles[0].Body) == "" {
+				addf("%s title is empty", context)
+			}
+		}
+
+		var updated time.Time
+		if value, ok := requireOne(context, "updated", entry.Updated); ok {
+			updated = checkDate(context, "updated", value)
+		}
+		if len(entry.Published) > 1 {
+			addf("%s must have at most one <published>, found %d", context, len(entry.Published))
+		} else if len(entry.Published) == 1 {
+			published := checkDate(context, "published", strings.TrimSpace(entry.Published[0]))
+			if !published.IsZero() && !updated.IsZero() && published.After(updated) {
+				addf("%s is published after it was updated", context)
+			}
+		}
+
+		// RFC 4287 4.1.1: authors are required when the feed has none
+		if len(feed.Authors) == 0 && len(entry.Authors) == 0 {
+			addf("%s has no author and the feed has none either", context)
+		}
+		for _, author := range entry.Authors {
+			if len(author.Names) != 1 || strings.TrimSpace(author.Names[0]) == "" {
+				addf("%s author must have exactly one non-empty name", context)
+			}
+		}
+
+		// RFC 4287 4.1.1: at most one content and summary; without content an alternate link is required
+		alternates := checkLinks(context, entry.Links)
+		if len(entry.Content) > 1 {
+			addf("%s must have at most one <content>, found %d", context, len(entry.Content))
+		}
+		if len(entry.Summary) > 1 {
+			addf("%s must have at most one <summary>, found %d", context, len(entry.Summary))
+		}
+		if len(entry.Content) == 0 && alternates == 0 {
+			addf("%s has neither content nor an alternate link", context)
+		}
+		for _, content := range entry.Content {
+			checkText(context, "content", content)
+			if strings.TrimSpace(content.Body) == "" {
+				addf("%s content is empty", context)
+			}
+		}
+		for _, summary := range entry.Summary {
+			checkText(context, "summary", summary)
+		}
+		if len(entry.Content) == 0 && len(entry.Summary) == 0 {
+			addf("%s has no content or summary for readers to display", context)
+		}
+
+		// RFC 4287 4.2.2.1: term is required
+		for _, category := range entry.Categories {
+			if strings.TrimSpace(category.Term) == "" {
+				addf("%s has a category without a term", context)
+			}
+		}
+	}
+
+	return problems
+}
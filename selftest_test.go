@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSelfTest_Passes(t *testing.T) {
+	var out bytes.Buffer
+	if code := runSelfTest(&out, nil); code != 0 {
+		t.Fatalf("Expected self-test to pass, got exit code %d:\n%s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "Self-test passed") {
+		t.Errorf("Expected pass message, got %q", out.String())
+	}
+}
+
+func TestRunSelfTest_ExcludedFlamewars(t *testing.T) {
+	config := selfTestConfig
+	config.Flamewar = FlamewarConfig{Exclude: true}
+
+	var out bytes.Buffer
+	if code := runSelfTest(&out, NewCategoryMapper(&config)); code != 0 {
+		t.Fatalf("Expected self-test to pass with flamewars excluded, got:\n%s", out.String())
+	}
+}
+
+func TestValidateAtomFeed_GeneratedFeed(t *testing.T) {
+	items := collapseDuplicateSubmissions(selfTestItems(time.Now()))
+	feed := generateRSSFeed(nil, items, 50, nil)
+
+	if problems := validateAtomFeed(feed, len(items)); len(problems) > 0 {
+		t.Errorf("Expected generated feed to be valid, got: %v", problems)
+	}
+}
+
+func TestValidateAtomFeed_Problems(t *testing.T) {
+	const header = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+	valid := `<entry><id>tag:x,2024:1</id><title>T</title><updated>2024-01-01T00:00:00Z</updated><author><name>a</name></author><link href="https://example.com/"/><content type="html">c</content></entry>`
+
+	tests := []struct {
+		name    string
+		feed    string
+		entries int
+		want    string
+	}{
+		{"missing declaration", `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`, 0, "XML declaration"},
+		{"malformed", header + `<feed xmlns="http://www.w3.org/2005/Atom"><title>x</feed>`, 0, "not well-formed"},
+		{"wrong namespace", header + `<feed><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated></feed>`, 0, "Atom namespace"},
+		{"missing feed id", header + `<feed xmlns="http://www.w3.org/2005/Atom"><title>x</title><updated>2024-01-01T00:00:00Z</updated></feed>`, 0, "feed must have exactly one <id>"},
+		{"relative id", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>feed</id><title>x</title><updated>2024-01-01T00:00:00Z</updated></feed>`, 0, "absolute IRI"},
+		{"bad date", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>Mon, 01 Jan 2024</updated></feed>`, 0, "RFC 3339"},
+		{"entry count", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated>` + valid + `</feed>`, 2, "expected 2"},
+		{"duplicate ids", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated>` + valid + valid + `</feed>`, 2, "repeats the id"},
+		{"no author", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated><entry><id>tag:x,2024:1</id><title>T</title><updated>2024-01-01T00:00:00Z</updated><content>c</content></entry></feed>`, 1, "no author"},
+		{"empty href", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated><link href=""/></feed>`, 0, "missing or relative href"},
+		{"no content or link", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated><entry><id>tag:x,2024:1</id><title>T</title><updated>2024-01-01T00:00:00Z</updated><author><name>a</name></author></entry></feed>`, 1, "neither content nor an alternate link"},
+		{"empty category", header + `<feed xmlns="http://www.w3.org/2005/Atom"><id>tag:x,2024:f</id><title>x</title><updated>2024-01-01T00:00:00Z</updated><entry><id>tag:x,2024:1</id><title>T</title><updated>2024-01-01T00:00:00Z</updated><author><name>a</name></author><category term=""/><content>c</content></entry></feed>`, 1, "category without a term"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateAtomFeed(tt.feed, tt.entries)
+			if !strings.Contains(strings.Join(problems, "\n"), tt.want) {
+				t.Errorf("Expected a problem containing %q, got %v", tt.want, problems)
+			}
+		})
+	}
+}
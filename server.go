@@ -0,0 +1,445 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// feedServer serves feeds rendered on the fly from the database
+type feedServer struct {
+	db         *sql.DB
+	cache      *feedCache
+	admin      *adminConfig // nil disables the admin UI
+	graphQL    bool         // enables the /graphql endpoint
+	podcastDir string       // directory served under /podcast/, empty disables
+	auth       authRules    // per route group protection
+	refreshing atomic.Bool  // set while a manual or scheduled refresh is running
+	// dataVersion is bumped whenever this server changes the stored items or its settings; cached
+	// feeds and their ETags are keyed on it, so requests never query the database to validate them
+	dataVersion atomic.Uint64
+	// healthMaxAge makes /healthz unhealthy when no update succeeded within it, 0 only checks the database
+	healthMaxAge time.Duration
+
+	settingsMutex  sync.RWMutex
+	categoryMapper *CategoryMapper
+	defaults       ItemFilter
+}
+
+// newFeedServer creates a feed server using the given default filter for unparameterized requests
+func newFeedServer(db *sql.DB, categoryMapper *CategoryMapper, defaults ItemFilter, cacheTTL time.Duration) *feedServer {
+	return &feedServer{
+		db:             db,
+		categoryMapper: categoryMapper,
+		defaults:       categoryMapper.withAuthorLists(defaults),
+		cache:          newFeedCache(cacheTTL),
+		auth:           make(authRules),
+	}
+}
+
+// routes returns the HTTP handler for all server endpoints. Only the feed, API and admin
+// responses are compressed; podcast audio, health checks and metrics are served as they are.
+func (s *feedServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /feed.xml", compressHandler(s.auth.requireAuth("feed", http.HandlerFunc(s.handleFeed))))
+	mux.Handle("GET /watchlist.xml", compressHandler(s.auth.requireAuth("feed", http.HandlerFunc(s.handleWatchlistFeed))))
+	mux.Handle("GET /graveyard.xml", compressHandler(s.auth.requireAuth("feed", http.HandlerFunc(s.handleGraveyardFeed))))
+	mux.Handle("GET /feed/{file}", compressHandler(s.auth.requireAuth("feed", http.HandlerFunc(s.handleProfileFeed))))
+	if feedStylesheet != "" {
+		mux.HandleFunc("GET /"+feedStylesheetFile, handleFeedStylesheet)
+	}
+	s.registerAPIRoutes(mux)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.Handle("GET /metrics", s.auth.requireAuth("api", http.HandlerFunc(s.handleMetrics)))
+	if s.podcastDir != "" {
+		mux.Handle("GET /podcast/", s.auth.requireAuth("feed", http.StripPrefix("/podcast/", http.FileServer(http.Dir(s.podcastDir)))))
+	}
+	if s.graphQL {
+		mux.Handle("GET /graphql", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleGraphQL))))
+		mux.Handle("POST /graphql", compressHandler(s.auth.requireAuth("api", http.HandlerFunc(s.handleGraphQL))))
+	}
+	if s.admin != nil {
+		s.registerAdminRoutes(mux)
+	}
+	return mux
+}
+
+// settings returns the current default filter and category mapper
+func (s *feedServer) settings() (ItemFilter, *CategoryMapper) {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+	return s.defaults, s.categoryMapper
+}
+
+// updateSettings replaces the default filter and category mapper and drops cached feeds
+func (s *feedServer) updateSettings(defaults ItemFilter, categoryMapper *CategoryMapper) {
+	s.settingsMutex.Lock()
+	s.defaults = categoryMapper.withAuthorLists(defaults)
+	s.categoryMapper = categoryMapper
+	s.settingsMutex.Unlock()
+
+	s.dataChanged()
+}
+
+// dataChanged bumps the data version after the stored items or settings changed, so feeds are
+// rendered anew. Writes by other processes, such as a cron job, show up when cached feeds expire.
+func (s *feedServer) dataChanged() {
+	s.dataVersion.Add(1)
+	s.cache.invalidate()
+}
+
+// feedQuery holds the per-request feed filters parsed from query parameters
+type feedQuery struct {
+	filter    ItemFilter
+	include   []string
+	exclude   []string
+	keywords  []string
+	types     []StoryType
+	watchlist bool       // only items matching the watchlist, regardless of points
+	dead      bool       // only items that died or were flagged, most recently died first
+	format    FeedFormat // rendering toggles of personalized feeds
+}
+
+// parseFeedQuery parses min_points, limit, category, exclude and type query parameters
+func (s *feedServer) parseFeedQuery(r *http.Request) (feedQuery, error) {
+	values := r.URL.Query()
+	defaults, _ := s.settings()
+	q := feedQuery{
+		filter:  defaults,
+		include: splitQueryList(values["category"]),
+		exclude: splitQueryList(values["exclude"]),
+	}
+
+	if v := values.Get("min_points"); v != "" {
+		minPoints, err := strconv.Atoi(v)
+		if err != nil || minPoints < 0 {
+			return q, fmt.Errorf("invalid min_points: %q", v)
+		}
+		q.filter.MinPoints = minPoints
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > 100 {
+			return q, fmt.Errorf("invalid limit: %q", v)
+		}
+		q.filter.Limit = limit
+	}
+
+	for _, name := range splitQueryList(values["type"]) {
+		storyType, err := parseStoryType(name)
+		if err != nil {
+			return q, fmt.Errorf("invalid type: %q", name)
+		}
+		q.types = append(q.types, storyType)
+	}
+
+	return q, nil
+}
+
+// splitQueryList flattens repeated and comma-separated query values
+func splitQueryList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// handleFeed renders the feed, optionally filtered by query parameters, with short-lived caching
+func (s *feedServer) handleFeed(w http.ResponseWriter, r *http.Request) {
+	query, err := s.parseFeedQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeFeed(w, r, query)
+}
+
+// handleWatchlistFeed renders the feed of items matching the watchlist
+func (s *feedServer) handleWatchlistFeed(w http.ResponseWriter, r *http.Request) {
+	query, err := s.parseFeedQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query.watchlist = true
+	s.writeFeed(w, r, query)
+}
+
+// handleGraveyardFeed renders the feed of items that died or were flagged, filtered by query parameters
+func (s *feedServer) handleGraveyardFeed(w http.ResponseWriter, r *http.Request) {
+	query, err := s.parseFeedQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query.dead = true
+	s.writeFeed(w, r, query)
+}
+
+// handleProfileFeed renders the personalized feed for a profile token at /feed/<token>.xml
+func (s *feedServer) handleProfileFeed(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".xml")
+	if !ok || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	profile, err := getFeedProfile(s.db, token)
+	if err != nil {
+		slog.Error("Failed to load feed profile", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if profile == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	defaults, _ := s.settings()
+	query := feedQuery{
+		filter:   defaults,
+		include:  profile.Categories,
+		exclude:  profile.Exclude,
+		keywords: profile.Keywords,
+		format:   profile.Format,
+	}
+	if profile.MinPoints > 0 {
+		query.filter.MinPoints = profile.MinPoints
+	}
+
+	s.writeFeed(w, r, query)
+}
+
+// hasItemFilters reports whether the query drops items after they are loaded from the database
+func (q feedQuery) hasItemFilters() bool {
+	return len(q.include) > 0 || len(q.exclude) > 0 || len(q.keywords) > 0 || len(q.types) > 0
+}
+
+// queryFeedItems returns up to the query's limit of items for a feed. Category, keyword and type
+// filters are applied in Go, so a filtered query scans more items and applies the limit afterwards.
+func (s *feedServer) queryFeedItems(query feedQuery, categoryMapper *CategoryMapper) ([]HackerNewsItem, error) {
+	scan := query.filter
+	if query.hasItemFilters() {
+		scan.Limit = apiScanLimit
+	}
+
+	var items []HackerNewsItem
+	var err error
+	switch {
+	case query.watchlist:
+		items, err = getWatchlistItems(s.db, scan, categoryMapper)
+	case query.dead:
+		items, err = getDeadItems(s.db, scan.Limit)
+	default:
+		items, err = getFilteredItems(s.db, scan)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items = filterItemsByCategory(items, query.include, query.exclude, query.filter.MinPoints, categoryMapper)
+	items = filterItemsByKeywords(items, query.keywords)
+	items = filterItemsByType(items, query.types)
+	if !query.watchlist && !query.dead {
+		items = prepareFeedItems(s.db, items, categoryMapper)
+	}
+	if len(items) > query.filter.Limit {
+		items = items[:query.filter.Limit]
+	}
+	return items, nil
+}
+
+// writeFeed renders the feed for a query, using the cache when the data hasn't changed
+func (s *feedServer) writeFeed(w http.ResponseWriter, r *http.Request, query feedQuery) {
+	version := strconv.FormatUint(s.dataVersion.Load(), 10)
+	cacheKey := query.cacheKey()
+	entry, ok := s.cache.get(cacheKey, version)
+	if !ok {
+		_, categoryMapper := s.settings()
+		items, queryErr := s.queryFeedItems(query, categoryMapper)
+		if queryErr != nil {
+			slog.Error("Failed to load feed items", "error", queryErr)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		info := defaultFeedInfo
+		if query.watchlist {
+			info = watchlistFeedInfo
+		} else if query.dead {
+			info = graveyardFeedInfo
+		}
+		info.Format = query.format
+		entry = s.cache.put(cacheKey, version, generateFeed(s.db, items, query.filter.MinPoints, categoryMapper, info))
+	}
+
+	if entry.etag != "" {
+		w.Header().Set("ETag", entry.etag)
+		if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if feedStylesheet != "" {
+		w.Header().Add("Vary", "Accept")
+	}
+	w.Header().Set("Content-Type", feedContentType(r))
+	_, _ = w.Write([]byte(entry.body))
+}
+
+// filterItemsByCategory keeps items having any of the included categories and none of the excluded ones.
+// Category names are compared case-insensitively, and aliased names select the category they are merged into.
+func filterItemsByCategory(items []HackerNewsItem, include, exclude []string, minPoints int, categoryMapper *CategoryMapper) []HackerNewsItem {
+	if len(include) == 0 && len(exclude) == 0 {
+		return items
+	}
+	include = categoryMapper.AliasCategories(include)
+	exclude = categoryMapper.AliasCategories(exclude)
+
+	var filtered []HackerNewsItem
+	for _, item := range items {
+		categories := itemCategoryList(item, minPoints, categoryMapper)
+		if len(include) > 0 && !hasAnyCategory(categories, include) {
+			continue
+		}
+		if hasAnyCategory(categories, exclude) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+// hasAnyCategory reports whether any of the wanted categories is present
+func hasAnyCategory(categories, wanted []string) bool {
+	for _, category := range categories {
+		for _, w := range wanted {
+			if strings.EqualFold(category, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runServe parses serve subcommand flags and starts the HTTP server
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	minPoints := fs.Int("min-points", 50, "default minimum points threshold for items")
+	limit := fs.Int("limit", 30, "default maximum number of items per feed")
+	cacheTTL := fs.Duration("cache-ttl", time.Minute, "how long rendered feeds are cached")
+	refreshInterval := fs.Duration("refresh-interval", defaultServeRefreshInterval, "how often to fetch the front page in the background (0 disables, e.g. when cron runs the fetch)")
+	configPath := fs.String("config", "", "path to local configuration file (optional)")
+	configURL := fs.String("config-url", "", "URL to remote configuration file (defaults to GitHub)")
+	proxy := addProxyFlag(fs)
+	timezone := addTimezoneFlag(fs)
+	adminUser := fs.String("admin-user", "admin", "username for the admin UI")
+	adminPassword := fs.String("admin-password", os.Getenv("HNTOP_ADMIN_PASSWORD"), "password for the admin UI (defaults to $HNTOP_ADMIN_PASSWORD)")
+	auth := make(authRules)
+	fs.Var(auth, "auth", "protect a route group (feed, api, admin) as group=basic:user:password or group=bearer:token; repeatable")
+	graphQL := fs.Bool("graphql", false, "enable the read-only GraphQL endpoint at /graphql")
+	podcastDir := fs.String("podcast-dir", "", "serve podcast episodes and podcast.xml from this directory under /podcast/ (optional)")
+	tlsCert := fs.String("tls-cert", "", "TLS certificate file; serves HTTPS when set together with -tls-key")
+	tlsKey := fs.String("tls-key", "", "TLS private key file")
+	tlsDomain := fs.String("tls-domain", "", "domain the certificate is issued for (optional, rejects other server names)")
+	httpAddr := fs.String("http-addr", "", "address for a plain HTTP listener that answers ACME challenges and redirects to HTTPS, e.g. :80 (optional)")
+	acmeWebroot := fs.String("acme-webroot", "", "directory served at /.well-known/acme-challenge/ on -http-addr for ACME HTTP-01 renewals")
+	healthMaxAge := fs.Duration("health-max-age", 0, "report /healthz unhealthy when the last successful update is older than this (0 only checks the database)")
+	xslt := fs.Bool("xslt", false, "link the feeds to a stylesheet served at /feed.xsl so browsers show a readable story list")
+	profiling := addProfilingFlags(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupProxy(*proxy)
+	setupTimezone(*timezone)
+	startProfiling(profiling)
+	categoryMapper := LoadConfig(*configPath, *configURL)
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+	startReplication(db, categoryMapper.Config().Replication)
+
+	server := newFeedServer(db, categoryMapper, ItemFilter{Limit: *limit, MinPoints: *minPoints}, *cacheTTL)
+	if *xslt {
+		feedStylesheet = "/" + feedStylesheetFile
+	}
+	server.graphQL = *graphQL
+	server.healthMaxAge = *healthMaxAge
+	server.podcastDir = *podcastDir
+	server.auth = auth
+	if _, ok := auth["admin"]; !ok && *adminPassword != "" {
+		auth["admin"] = authRule{scheme: "basic", username: *adminUser, password: *adminPassword}
+	}
+	// The admin UI is only enabled when it is protected
+	if _, ok := auth["admin"]; ok {
+		// Settings are only saved back to a file the running config was actually loaded from,
+		// never over a local file that failed to parse with a remote or empty config
+		server.admin = &adminConfig{configPath: categoryMapper.ConfigFile()}
+	}
+	server.startRefreshLoop(*refreshInterval)
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		slog.Error("Both -tls-cert and -tls-key are required for HTTPS")
+		os.Exit(1)
+	}
+	if acme := categoryMapper.Config().ACME; acme.Domain != "" {
+		if *tlsCert != "" {
+			slog.Error("Use either acme.domain in the config or -tls-cert and -tls-key, not both")
+			os.Exit(1)
+		}
+		serveACME(server.routes(), acme, *addr, *httpAddr)
+		return
+	}
+	if *tlsCert == "" {
+		slog.Info("Starting HTTP server", "addr", *addr)
+		if err := http.ListenAndServe(*addr, server.routes()); err != nil {
+			slog.Error("HTTP server failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	reloader, err := newCertReloader(*tlsCert, *tlsKey)
+	if err != nil {
+		slog.Error("Failed to load TLS certificate", "error", err)
+		os.Exit(1)
+	}
+	if *httpAddr != "" {
+		go func() {
+			slog.Info("Starting HTTP redirect server", "addr", *httpAddr, "acme_webroot", *acmeWebroot)
+			if err := http.ListenAndServe(*httpAddr, httpRedirectHandler(*acmeWebroot, *tlsDomain, *addr)); err != nil {
+				slog.Error("HTTP redirect server failed", "error", err)
+			}
+		}()
+	}
+
+	httpsServer := &http.Server{
+		Addr:      *addr,
+		Handler:   server.routes(),
+		TLSConfig: newTLSConfig(reloader, *tlsDomain),
+	}
+	slog.Info("Starting HTTPS server", "addr", *addr, "domain", *tlsDomain)
+	if err := httpsServer.ListenAndServeTLS("", ""); err != nil {
+		slog.Error("HTTPS server failed", "error", err)
+		os.Exit(1)
+	}
+}
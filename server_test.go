@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupTestServer(t *testing.T) *feedServer {
+	db := setupTestDB()
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Now().UTC()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "GitHub Project", Link: "https://github.com/user/repo", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 250, CommentCount: 40, Author: "user1", CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+		{ItemID: "2", Title: "A Tweet", Link: "https://twitter.com/user/status/1", CommentsLink: "https://news.ycombinator.com/item?id=2", Points: 120, CommentCount: 30, Author: "user2", CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now},
+		{ItemID: "3", Title: "Blog Post", Link: "https://example.com/post", CommentsLink: "https://news.ycombinator.com/item?id=3", Points: 80, CommentCount: 10, Author: "user3", CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+
+	// Cache failed OpenGraph lookups so rendering doesn't hit the network
+	for _, item := range items {
+		if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link}, false); err != nil {
+			t.Fatalf("Failed to seed OpenGraph cache: %v", err)
+		}
+	}
+
+	mapper := NewCategoryMapper(&DomainConfig{CategoryDomains: map[string][]string{
+		"GitHub":  {"github.com"},
+		"Twitter": {"twitter.com"},
+	}})
+
+	return newFeedServer(db, mapper, ItemFilter{Limit: 30, MinPoints: 50}, time.Minute)
+}
+
+func TestHandleFeed_QueryFilters(t *testing.T) {
+	server := setupTestServer(t)
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+		excluded []string
+	}{
+		{"no filters", "", []string{"GitHub Project", "A Tweet", "Blog Post"}, nil},
+		{"min points", "min_points=100", []string{"GitHub Project", "A Tweet"}, []string{"Blog Post"}},
+		{"category", "category=GitHub", []string{"GitHub Project"}, []string{"A Tweet", "Blog Post"}},
+		{"exclude", "exclude=Twitter", []string{"GitHub Project", "Blog Post"}, []string{"A Tweet"}},
+		{"combined", "min_points=200&category=github,twitter&exclude=Twitter", []string{"GitHub Project"}, []string{"A Tweet", "Blog Post"}},
+		{"category beyond the limit", "category=Twitter&limit=1", []string{"A Tweet"}, []string{"GitHub Project", "Blog Post"}},
+		{"exclude with limit", "exclude=GitHub&limit=1", []string{"A Tweet"}, []string{"GitHub Project", "Blog Post"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/feed.xml?"+tc.query, nil)
+			rec := httptest.NewRecorder()
+			server.routes().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", rec.Code)
+			}
+			body := rec.Body.String()
+			for _, title := range tc.expected {
+				if !strings.Contains(body, title) {
+					t.Errorf("Expected feed to contain %q", title)
+				}
+			}
+			for _, title := range tc.excluded {
+				if strings.Contains(body, title) {
+					t.Errorf("Expected feed not to contain %q", title)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleFeed_InvalidParams(t *testing.T) {
+	server := setupTestServer(t)
+
+	for _, query := range []string{"min_points=abc", "limit=0", "limit=1000"} {
+		req := httptest.NewRequest(http.MethodGet, "/feed.xml?"+query, nil)
+		rec := httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHandlers_DatabaseError(t *testing.T) {
+	server := setupTestServer(t)
+	_ = server.db.Close()
+
+	// A failing query answers the request with an error instead of stopping the server
+	for _, path := range []string{"/feed.xml", "/feed.xml?category=GitHub", "/watchlist.xml", "/api/items", "/api/categories"} {
+		rec := httptest.NewRecorder()
+		server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500 for %s, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestHandleFeed_Caching(t *testing.T) {
+	server := setupTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml?min_points=100", nil)
+	server.routes().ServeHTTP(httptest.NewRecorder(), req)
+
+	// Change the data; the cached response should still be served
+	if _, err := server.db.Exec("UPDATE items SET title = 'Renamed' WHERE item_hn_id = '1'"); err != nil {
+		t.Fatalf("Failed to update item: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "GitHub Project") {
+		t.Error("Expected cached feed to be served")
+	}
+
+	// A different query is rendered fresh
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml?min_points=90", nil))
+	if !strings.Contains(rec.Body.String(), "Renamed") {
+		t.Error("Expected uncached query to reflect updated data")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHKeys are tried in order when no ssh_key is configured
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sftpUploadTarget uploads output files to a directory on a remote host over SFTP. The connection
+// is opened on the first upload, so runs with nothing new to upload don't log in.
+type sftpUploadTarget struct {
+	destination *url.URL
+	keyFile     string
+	knownHosts  string
+	conn        *ssh.Client
+	client      *sftp.Client
+}
+
+// newSFTPUploadTarget validates an sftp://user@host:port/path destination
+func newSFTPUploadTarget(destination *url.URL, config UploadConfig) (*sftpUploadTarget, error) {
+	if destination.Hostname() == "" || destination.Path == "" {
+		return nil, fmt.Errorf("destination %q needs a host and a path", config.Destination)
+	}
+	home, _ := os.UserHomeDir()
+	target := &sftpUploadTarget{destination: destination, keyFile: config.SSHKey, knownHosts: config.KnownHosts}
+	if target.knownHosts == "" {
+		target.knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	if target.keyFile == "" {
+		for _, name := range defaultSSHKeys {
+			if file := filepath.Join(home, ".ssh", name); fileExists(file) {
+				target.keyFile = file
+				break
+			}
+		}
+	}
+	if target.keyFile == "" {
+		return nil, fmt.Errorf("no SSH key configured and none found in ~/.ssh")
+	}
+	return target, nil
+}
+
+// fileExists reports whether a regular file exists at name
+func fileExists(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// connect logs in with the private key, verifying the host key against known_hosts
+func (s *sftpUploadTarget) connect(ctx context.Context) error {
+	keyData, err := os.ReadFile(s.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return fmt.Errorf("SSH key %s is passphrase-protected; use a key without a passphrase for uploads", s.keyFile)
+		}
+		return fmt.Errorf("failed to parse SSH key: %w", err)
+	}
+	hostKeyCallback, err := knownhosts.New(s.knownHosts)
+	if err != nil {
+		return fmt.Errorf("failed to load known hosts: %w", err)
+	}
+
+	username := s.destination.User.Username()
+	if username == "" {
+		if current, err := user.Current(); err == nil {
+			username = current.Username
+		}
+	}
+	port := s.destination.Port()
+	if port == "" {
+		port = "22"
+	}
+	addr := net.JoinHostPort(s.destination.Hostname(), port)
+
+	netConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		_ = netConn.Close()
+		return fmt.Errorf("SSH login to %s failed: %w", addr, err)
+	}
+	s.conn = ssh.NewClient(sshConn, chans, reqs)
+	if s.client, err = sftp.NewClient(s.conn); err != nil {
+		_ = s.conn.Close()
+		return fmt.Errorf("failed to start SFTP session: %w", err)
+	}
+	return nil
+}
+
+// upload writes the file next to its destination under a temporary name and renames it into
+// place, so the web server never serves a partly written feed. Content type and caching are up
+// to the web server.
+func (s *sftpUploadTarget) upload(ctx context.Context, key, file, _, _ string) error {
+	if s.client == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	remote := path.Join(s.destination.Path, key)
+	if err := s.client.MkdirAll(path.Dir(remote)); err != nil {
+		return fmt.Errorf("failed to create remote directory: %w", err)
+	}
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	tmp := path.Join(path.Dir(remote), "."+path.Base(remote)+".tmp")
+	dst, err := s.client.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file: %w", err)
+	}
+	if _, err := dst.ReadFrom(src); err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("failed to write remote file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to write remote file: %w", err)
+	}
+
+	// Plain SFTP rename fails when the target exists; OpenSSH's extension replaces it atomically
+	if err := s.client.PosixRename(tmp, remote); err != nil {
+		_ = s.client.Remove(remote)
+		if err := s.client.Rename(tmp, remote); err != nil {
+			return fmt.Errorf("failed to move remote file into place: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close ends the SFTP session, if one was opened
+func (s *sftpUploadTarget) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	_ = s.client.Close()
+	return s.conn.Close()
+}
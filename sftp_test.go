@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startTestSFTPServer runs an SSH server that accepts clientKey and serves SFTP on the local
+// filesystem. It returns the server address and a known_hosts file for it.
+func startTestSFTPServer(t *testing.T, clientKey ssh.PublicKey) (string, string) {
+	t.Helper()
+	_, hostPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "deploy" && bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSFTP(conn, config)
+		}
+	}()
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(listener.Addr().String())}, hostSigner.PublicKey())
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return listener.Addr().String(), knownHostsFile
+}
+
+func serveTestSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					if server, err := sftp.NewServer(channel); err == nil {
+						_ = server.Serve()
+					}
+					_ = channel.Close()
+				}
+			}
+		}()
+	}
+}
+
+// writeTestSSHKey writes a new unencrypted OpenSSH private key and returns its file and public key
+func writeTestSSHKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sshPublic, _ := ssh.NewPublicKey(public)
+	return keyFile, sshPublic
+}
+
+func TestUploadOutput_SFTP(t *testing.T) {
+	keyFile, publicKey := writeTestSSHKey(t)
+	addr, knownHostsFile := startTestSFTPServer(t, publicKey)
+
+	outDir := t.TempDir()
+	remoteDir := filepath.Join(t.TempDir(), "www", "hn")
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed/>")
+	writeOutputFile(t, outDir, "archive/2026-10.xml", "<feed>old</feed>")
+	config := UploadConfig{Destination: "sftp://deploy@" + addr + filepath.ToSlash(remoteDir), SSHKey: keyFile, KnownHosts: knownHostsFile}
+
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+	// Replacing an existing file goes through the rename as well
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed><entry/></feed>")
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+
+	for name, expected := range map[string]string{"hackernews.xml": "<feed><entry/></feed>", "archive/2026-10.xml": "<feed>old</feed>"} {
+		if data, err := os.ReadFile(filepath.Join(remoteDir, name)); err != nil || string(data) != expected {
+			t.Errorf("Remote %s = %q, %v; want %q", name, data, err, expected)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, ".hackernews.xml.tmp")); !os.IsNotExist(err) {
+		t.Error("Expected no temporary file left behind")
+	}
+}
+
+func TestUploadOutput_SFTPUnknownHost(t *testing.T) {
+	keyFile, publicKey := writeTestSSHKey(t)
+	addr, _ := startTestSFTPServer(t, publicKey)
+	emptyKnownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(emptyKnownHosts, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	outDir := t.TempDir()
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed/>")
+	config := UploadConfig{Destination: "sftp://deploy@" + addr + "/tmp/hn", SSHKey: keyFile, KnownHosts: emptyKnownHosts}
+	err := uploadOutput(outDir, config)
+	if err == nil || !strings.Contains(err.Error(), "knownhosts") {
+		t.Errorf("Expected an unknown host key to be rejected, got %v", err)
+	}
+}
+
+func TestNewSFTPUploadTarget_Invalid(t *testing.T) {
+	for _, destination := range []string{"sftp://host", "sftp:///var/www"} {
+		parsed, _ := url.Parse(destination)
+		if _, err := newSFTPUploadTarget(parsed, UploadConfig{Destination: destination, SSHKey: "key"}); err == nil {
+			t.Errorf("Expected %q to be rejected", destination)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// statsRecentDays is the number of days listed in the per-day story counts
+const statsRecentDays = 7
+
+// dayCount is the number of stories submitted on a day in the display time zone
+type dayCount struct {
+	Day     time.Time
+	Stories int
+}
+
+// instanceStats summarizes what the database has collected
+type instanceStats struct {
+	Total       reportRow
+	First, Last time.Time
+	Days        int
+	Domains     []reportRow
+	Authors     []reportRow
+	Recent      []dayCount
+}
+
+// ItemsPerDay returns the mean number of stories per day between the first and last story
+func (s instanceStats) ItemsPerDay() float64 {
+	if s.Days == 0 {
+		return 0
+	}
+	return float64(s.Total.Stories) / float64(s.Days)
+}
+
+// startOfDay returns midnight of t's day in the display time zone
+func startOfDay(t time.Time) time.Time {
+	t = localTime(t)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// buildStats aggregates items into instance statistics, keeping the top domains and authors and
+// counting stories per day for the statsRecentDays days up to now
+func buildStats(items []HackerNewsItem, top int, now time.Time) instanceStats {
+	domains := make(map[string]*reportRow)
+	authors := make(map[string]*reportRow)
+	perDay := make(map[time.Time]int)
+
+	stats := instanceStats{Total: reportRow{Name: "All stories"}}
+	for _, item := range items {
+		stats.Total.Stories++
+		stats.Total.Points += item.Points
+		stats.Total.Comments += item.CommentCount
+		if stats.First.IsZero() || item.CreatedAt.Before(stats.First) {
+			stats.First = item.CreatedAt
+		}
+		if item.CreatedAt.After(stats.Last) {
+			stats.Last = item.CreatedAt
+		}
+
+		addReportRow(domains, reportDomain(item), item)
+		if item.Author != "" {
+			addReportRow(authors, item.Author, item)
+		}
+		perDay[startOfDay(item.CreatedAt)]++
+	}
+
+	if stats.Total.Stories > 0 {
+		// Rounded so days shortened or lengthened by a DST change still count as one
+		stats.Days = int(math.Round(startOfDay(stats.Last).Sub(startOfDay(stats.First)).Hours()/24)) + 1
+	}
+	today := startOfDay(now)
+	for i := statsRecentDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		stats.Recent = append(stats.Recent, dayCount{Day: day, Stories: perDay[day]})
+	}
+
+	stats.Domains = sortedReportRows(domains, top)
+	stats.Authors = sortedReportRows(authors, top)
+	return stats
+}
+
+// printStats writes the statistics as plain text with aligned tables
+func printStats(w io.Writer, stats instanceStats) {
+	if stats.Total.Stories == 0 {
+		fmt.Fprintln(w, "No stories in the database")
+		return
+	}
+
+	fmt.Fprintf(w, "Stories:        %d (%s to %s)\n", stats.Total.Stories,
+		localTime(stats.First).Format("2006-01-02"), localTime(stats.Last).Format("2006-01-02"))
+	fmt.Fprintf(w, "Average points: %.1f\n", stats.Total.AveragePoints())
+	fmt.Fprintf(w, "Items per day:  %.1f\n", stats.ItemsPerDay())
+
+	printTable := func(title string, rows []reportRow) {
+		fmt.Fprintf(w, "\n%s\n", title)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  name\tstories\tavg points\t")
+		for _, row := range rows {
+			fmt.Fprintf(tw, "  %s\t%d\t%.1f\t\n", row.Name, row.Stories, row.AveragePoints())
+		}
+		_ = tw.Flush()
+	}
+	printTable("Top domains", stats.Domains)
+	printTable("Top authors", stats.Authors)
+
+	fmt.Fprintf(w, "\nLast %d days\n", len(stats.Recent))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, day := range stats.Recent {
+		fmt.Fprintf(tw, "  %s\t%d\t\n", day.Day.Format("Mon 2006-01-02"), day.Stories)
+	}
+	_ = tw.Flush()
+}
+
+// runStats handles the stats subcommand
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "enable debug logging")
+	top := fs.Int("top", 10, "number of domains and authors listed")
+	days := fs.Int("days", 0, "only count stories from the last N days (0 counts every stored story)")
+	minPoints := fs.Int("min-points", 0, "only count stories with more points than this")
+	timezone := addTimezoneFlag(fs)
+	_ = fs.Parse(args)
+
+	setupLogging(*debug)
+	setupTimezone(*timezone)
+
+	db := initDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	var start time.Time
+	if *days > 0 {
+		start = now.Add(-time.Duration(*days) * 24 * time.Hour)
+	}
+	items, err := getTopItemsBetween(db, start, now.Add(time.Minute), *minPoints, -1)
+	if err != nil {
+		slog.Error("Failed to load items", "error", err)
+		os.Exit(1)
+	}
+	printStats(os.Stdout, buildStats(collapseDuplicateSubmissions(items), *top, now))
+}
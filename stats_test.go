@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildStats(t *testing.T) {
+	useDisplayLocation(t, "UTC")
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	items := reportTestItems()
+	items[0].CreatedAt = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	items[1].CreatedAt = time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)
+	items[2].CreatedAt = time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	items[3].CreatedAt = time.Date(2024, 3, 10, 11, 0, 0, 0, time.UTC)
+
+	stats := buildStats(items, 2, now)
+
+	if stats.Total.Stories != 4 || stats.Total.AveragePoints() != 150 {
+		t.Errorf("Unexpected totals: %+v", stats.Total)
+	}
+	if stats.Days != 10 || stats.ItemsPerDay() != 0.4 {
+		t.Errorf("Expected 4 stories over 10 days, got %d days and %.2f per day", stats.Days, stats.ItemsPerDay())
+	}
+	if len(stats.Domains) != 2 || stats.Domains[0].Name != "github.com" || stats.Domains[0].Stories != 2 {
+		t.Errorf("Expected the top 2 domains led by github.com, got %+v", stats.Domains)
+	}
+	if len(stats.Authors) != 2 || stats.Authors[0].Name != "alice" {
+		t.Errorf("Expected the top 2 authors led by alice, got %+v", stats.Authors)
+	}
+
+	if len(stats.Recent) != statsRecentDays {
+		t.Fatalf("Expected %d recent days, got %d", statsRecentDays, len(stats.Recent))
+	}
+	last := stats.Recent[len(stats.Recent)-1]
+	if !last.Day.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)) || last.Stories != 2 {
+		t.Errorf("Expected 2 stories today, got %+v", last)
+	}
+	if stats.Recent[len(stats.Recent)-2].Stories != 1 || stats.Recent[0].Stories != 0 {
+		t.Errorf("Unexpected recent day counts: %+v", stats.Recent)
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	useDisplayLocation(t, "UTC")
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	items := reportTestItems()
+	for i := range items {
+		items[i].CreatedAt = now.Add(-time.Duration(i) * time.Hour)
+	}
+
+	var buf bytes.Buffer
+	printStats(&buf, buildStats(items, 10, now))
+	out := buf.String()
+	for _, want := range []string{"Stories:        4 (2024-03-10 to 2024-03-10)", "Average points: 150.0", "Items per day:  4.0", "Top domains", "github.com", "Top authors", "alice", "Sun 2024-03-10"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printStats(&buf, buildStats(nil, 10, now))
+	if !strings.Contains(buf.String(), "No stories") {
+		t.Errorf("Expected an empty database message, got %q", buf.String())
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sparklineSnapshots is how many of the latest snapshots the points sparkline covers
+const sparklineSnapshots = 12
+
+// sparklineLevels are the bar characters of a sparkline, lowest first
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// statsDelta is how much an item's stats changed since the previous run
+type statsDelta struct {
+	Points   int
+	Comments int
+	Since    time.Time // when the previous snapshot was taken
+}
+
+// statsSnapshot is an item's stats as recorded by one run
+type statsSnapshot struct {
+	RecordedAt time.Time
+	Points     int
+	Comments   int
+}
+
+// recordStatsSnapshot stores the current stats of the given items as taken by the run started at now
+func recordStatsSnapshot(db *sql.DB, items []HackerNewsItem, now time.Time) {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.ItemID == "" || seen[item.ItemID] {
+			continue
+		}
+		seen[item.ItemID] = true
+
+		// Stats are copied from the items table, which holds the values refreshed during the run
+		var points, comments int
+		err := db.QueryRow("SELECT points, comment_count FROM items WHERE item_hn_id = ?", item.ItemID).Scan(&points, &comments)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err == nil {
+			_, err = execWithRetry(db, `
+				INSERT INTO item_stats_history (item_hn_id, recorded_at, points, comment_count)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT(item_hn_id, recorded_at) DO UPDATE SET
+					points = excluded.points, comment_count = excluded.comment_count`,
+				item.ItemID, now, points, comments)
+		}
+		if err != nil {
+			slog.Warn("Failed to record stats snapshot", "hn_id", item.ItemID, "error", err)
+		}
+	}
+}
+
+// getStatsHistory returns the recorded stats of an item, oldest first
+func getStatsHistory(db *sql.DB, itemID string) ([]statsSnapshot, error) {
+	rows, err := db.Query(`
+		SELECT recorded_at, points, comment_count FROM item_stats_history
+		WHERE item_hn_id = ? ORDER BY recorded_at`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var history []statsSnapshot
+	for rows.Next() {
+		var snapshot statsSnapshot
+		if err := rows.Scan(&snapshot.RecordedAt, &snapshot.Points, &snapshot.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan stats snapshot: %w", err)
+		}
+		history = append(history, snapshot)
+	}
+	return history, rows.Err()
+}
+
+// getStatsDelta returns the change in an item's stats since the snapshot before the latest one.
+// It reports false when the item has fewer than two snapshots.
+func getStatsDelta(db *sql.DB, item HackerNewsItem) (statsDelta, bool, error) {
+	var points, comments int
+	var since time.Time
+	err := db.QueryRow(`
+		SELECT points, comment_count, recorded_at FROM item_stats_history
+		WHERE item_hn_id = ? ORDER BY recorded_at DESC LIMIT 1 OFFSET 1`, item.ItemID).Scan(&points, &comments, &since)
+	if err == sql.ErrNoRows {
+		return statsDelta{}, false, nil
+	}
+	if err != nil {
+		return statsDelta{}, false, fmt.Errorf("failed to load stats history: %w", err)
+	}
+	return statsDelta{Points: item.Points - points, Comments: item.CommentCount - comments, Since: since}, true, nil
+}
+
+// statsVelocity returns how many points per hour an item gained between its two latest snapshots.
+// It reports false when the item has fewer than two snapshots.
+func statsVelocity(history []statsSnapshot) (float64, bool) {
+	if len(history) < 2 {
+		return 0, false
+	}
+	previous, latest := history[len(history)-2], history[len(history)-1]
+	hours := latest.RecordedAt.Sub(previous.RecordedAt).Hours()
+	if hours <= 0 {
+		return 0, false
+	}
+	return float64(latest.Points-previous.Points) / hours, true
+}
+
+// renderSparkline renders the points of the latest snapshots as a sparkline such as ▁▃▅▇, with the
+// progression as its tooltip. It returns an empty string for fewer than three snapshots or flat points.
+func renderSparkline(history []statsSnapshot) string {
+	if len(history) > sparklineSnapshots {
+		history = history[len(history)-sparklineSnapshots:]
+	}
+	if len(history) < 3 {
+		return ""
+	}
+
+	low, high := history[0].Points, history[0].Points
+	for _, snapshot := range history {
+		low, high = min(low, snapshot.Points), max(high, snapshot.Points)
+	}
+	if low == high {
+		return ""
+	}
+
+	var bars strings.Builder
+	progression := make([]string, len(history))
+	for i, snapshot := range history {
+		level := ((snapshot.Points-low)*(len(sparklineLevels)-1) + (high-low)/2) / (high - low)
+		bars.WriteRune(sparklineLevels[level])
+		progression[i] = strconv.Itoa(snapshot.Points)
+	}
+	label := html.EscapeString(strings.Join(progression, " → ") + " points")
+	return fmt.Sprintf(`<span role="img" aria-label="Points trend: %s" title="%s" style="color: #ff6600; letter-spacing: 1px;">%s</span>`, label, label, bars.String())
+}
+
+// loadSparkline returns the points sparkline of an item for a feed entry
+func loadSparkline(db *sql.DB, item HackerNewsItem) string {
+	if db == nil {
+		return ""
+	}
+	history, err := getStatsHistory(db, item.ItemID)
+	if err != nil {
+		slog.Debug("Failed to load stats history", "hn_id", item.ItemID, "error", err)
+	}
+	return renderSparkline(history)
+}
+
+// formatDelta renders a change as " (+57)" or " (-3)", or nothing when there was no change
+func formatDelta(n int) string {
+	switch {
+	case n > 0:
+		return fmt.Sprintf(" (+%d)", n)
+	case n < 0:
+		return fmt.Sprintf(" (%d)", n)
+	}
+	return ""
+}
+
+// renderDeltaLine renders the points change since the previous run as a line such as
+// "▲ +45 since last update", or nothing when the points didn't change
+func renderDeltaLine(delta statsDelta, hasDelta bool) string {
+	if !hasDelta || delta.Points == 0 {
+		return ""
+	}
+	marker, color := "▲", "#2e7d32"
+	if delta.Points < 0 {
+		marker, color = "▼", "#c62828"
+	}
+	since := ""
+	if !delta.Since.IsZero() {
+		since = fmt.Sprintf(` <time datetime="%s" style="color: #828282;">(%s)</time>`, delta.Since.UTC().Format(time.RFC3339), calculatePostAge(delta.Since))
+	}
+	return fmt.Sprintf(`<p style="margin: 0 0 12px 0; color: %s; font-size: 13px;">%s %+d since last update%s</p>`, color, marker, delta.Points, since)
+}
+
+// loadStatsDelta returns the change in an item's stats since the previous run for a feed entry
+func loadStatsDelta(db *sql.DB, item HackerNewsItem) (statsDelta, bool) {
+	if db == nil {
+		return statsDelta{}, false
+	}
+	delta, ok, err := getStatsDelta(db, item)
+	if err != nil {
+		slog.Debug("Failed to load stats delta", "hn_id", item.ItemID, "error", err)
+	}
+	return delta, ok
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDelta(t *testing.T) {
+	testCases := []struct {
+		input    int
+		expected string
+	}{
+		{57, " (+57)"},
+		{-3, " (-3)"},
+		{0, ""},
+	}
+	for _, tc := range testCases {
+		if got := formatDelta(tc.input); got != tc.expected {
+			t.Errorf("formatDelta(%d) = %q, expected %q", tc.input, got, tc.expected)
+		}
+	}
+}
+
+func TestStatsVelocity(t *testing.T) {
+	start := time.Now()
+	history := []statsSnapshot{
+		{RecordedAt: start, Points: 10},
+		{RecordedAt: start.Add(time.Hour), Points: 40},
+		{RecordedAt: start.Add(90 * time.Minute), Points: 100},
+	}
+	if velocity, ok := statsVelocity(history); !ok || velocity != 120 {
+		t.Errorf("Expected 120 points per hour, got %v (ok=%v)", velocity, ok)
+	}
+	if _, ok := statsVelocity(history[:1]); ok {
+		t.Error("Expected no velocity for a single snapshot")
+	}
+}
+
+func TestStatsDelta(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	start := time.Now().Add(-time.Hour)
+	item := HackerNewsItem{
+		ItemID:       "42",
+		Title:        "Still going",
+		Link:         "https://example.com/still-going",
+		CommentsLink: "https://news.ycombinator.com/item?id=42",
+		Points:       150,
+		CommentCount: 255,
+		CreatedAt:    start,
+		UpdatedAt:    start,
+	}
+	updateStoredItems(db, []HackerNewsItem{item})
+	recordStatsSnapshot(db, []HackerNewsItem{item, item}, start)
+
+	// A single snapshot has nothing to compare against
+	if _, ok, err := getStatsDelta(db, item); ok || err != nil {
+		t.Fatalf("Expected no delta after the first run, got ok=%v err=%v", ok, err)
+	}
+
+	item.Points, item.CommentCount = 160, 312
+	updateStoredItems(db, []HackerNewsItem{item})
+	recordStatsSnapshot(db, []HackerNewsItem{item}, start.Add(30*time.Minute))
+
+	delta, ok, err := getStatsDelta(db, item)
+	if err != nil || !ok {
+		t.Fatalf("Expected a delta, got ok=%v err=%v", ok, err)
+	}
+	if delta.Points != 10 || delta.Comments != 57 {
+		t.Errorf("Expected +10 points and +57 comments, got %+v", delta)
+	}
+
+	entry, _ := feedEntry(db, item, 50, nil, nil, FeedFormat{})
+	if !strings.Contains(entry.Description, "312 comments (+57)") || !strings.Contains(entry.Description, "160 points</strong>") {
+		t.Error("Expected the comment delta in the entry stats line and the points delta left to its own line")
+	}
+	if strings.Count(entry.Description, "+10") != 1 || !strings.Contains(entry.Description, "▲ +10 since last update") || !strings.Contains(entry.Description, "(1 hours ago)") {
+		t.Error("Expected the points change since the last update")
+	}
+
+	// Items that were never snapshotted render without deltas
+	other := HackerNewsItem{ItemID: "43", Title: "New", Link: "https://example.com/new", CommentsLink: "https://news.ycombinator.com/item?id=43", Points: 80, CommentCount: 5, CreatedAt: start}
+	entry, _ = feedEntry(db, other, 50, nil, nil, FeedFormat{})
+	if strings.Contains(entry.Description, "(+") || strings.Contains(entry.Description, "since last update") || !strings.Contains(entry.Description, "5 comments</strong>") {
+		t.Error("Expected no delta for an item without history")
+	}
+}
+
+func TestRenderDeltaLine(t *testing.T) {
+	since := time.Now().Add(-30 * time.Minute)
+	if got := renderDeltaLine(statsDelta{Points: 45, Since: since}, true); !strings.Contains(got, "▲ +45 since last update") || !strings.Contains(got, "30 minutes ago") {
+		t.Errorf("Unexpected rising line: %s", got)
+	}
+	if got := renderDeltaLine(statsDelta{Points: -3}, true); !strings.Contains(got, "▼ -3 since last update</p>") {
+		t.Errorf("Unexpected falling line: %s", got)
+	}
+	if got := renderDeltaLine(statsDelta{Comments: 5}, true); got != "" {
+		t.Errorf("Expected no line without a points change, got %s", got)
+	}
+	if got := renderDeltaLine(statsDelta{Points: 10}, false); got != "" {
+		t.Errorf("Expected no line without history, got %s", got)
+	}
+}
+
+func TestGetStatsHistory(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	start := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	item := HackerNewsItem{ItemID: "44", Title: "Climbing", Link: "https://example.com/climbing", CommentsLink: "https://news.ycombinator.com/item?id=44", Points: 50, CommentCount: 4, CreatedAt: start, UpdatedAt: start}
+	for i, points := range []int{50, 120, 340} {
+		item.Points, item.CommentCount = points, points/10
+		updateStoredItems(db, []HackerNewsItem{item})
+		recordStatsSnapshot(db, []HackerNewsItem{item}, start.Add(time.Duration(i)*15*time.Minute))
+	}
+
+	history, err := getStatsHistory(db, "44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 snapshots, got %d", len(history))
+	}
+	for i, expected := range []int{50, 120, 340} {
+		if history[i].Points != expected || history[i].Comments != expected/10 {
+			t.Errorf("Snapshot %d: expected %d points, got %+v", i, expected, history[i])
+		}
+	}
+	if !history[2].RecordedAt.Equal(start.Add(30 * time.Minute)) {
+		t.Errorf("Expected the last snapshot at %v, got %v", start.Add(30*time.Minute), history[2].RecordedAt)
+	}
+
+	if history, err := getStatsHistory(db, "45"); err != nil || len(history) != 0 {
+		t.Errorf("Expected no history for an unknown item, got %v (%v)", history, err)
+	}
+
+	entry, _ := feedEntry(db, item, 50, nil, nil, FeedFormat{})
+	if !strings.Contains(entry.Description, "▁▃█") {
+		t.Error("Expected the points sparkline in the entry stats line")
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	snapshots := func(points ...int) []statsSnapshot {
+		history := make([]statsSnapshot, len(points))
+		for i, p := range points {
+			history[i] = statsSnapshot{Points: p}
+		}
+		return history
+	}
+
+	sparkline := renderSparkline(snapshots(50, 120, 340))
+	if !strings.Contains(sparkline, ">▁▃█<") || !strings.Contains(sparkline, `title="50 → 120 → 340 points"`) {
+		t.Errorf("Unexpected sparkline: %s", sparkline)
+	}
+	if got := renderSparkline(snapshots(50, 120)); got != "" {
+		t.Errorf("Expected no sparkline for two snapshots, got %s", got)
+	}
+	if got := renderSparkline(snapshots(80, 80, 80)); got != "" {
+		t.Errorf("Expected no sparkline for flat points, got %s", got)
+	}
+
+	// Only the latest snapshots are drawn
+	long := make([]int, 20)
+	for i := range long {
+		long[i] = i
+	}
+	if got := renderSparkline(snapshots(long...)); !strings.Contains(got, "8 → 9 → ") || strings.Contains(got, "7 → 8") {
+		t.Errorf("Expected the last %d snapshots, got %s", sparklineSnapshots, got)
+	}
+}
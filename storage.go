@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// databaseURLEnv selects a PostgreSQL database instead of the local SQLite file
+const databaseURLEnv = "HNTOP_DATABASE_URL"
+
+// storageBackend is the database engine the data is stored in. Queries are written in the SQL
+// both SQLite and PostgreSQL accept, with ? placeholders; a backend covers what differs.
+type storageBackend interface {
+	// name identifies the engine in logs and errors
+	name() string
+	// ddl adapts a CREATE TABLE statement or column definition written for SQLite to the engine
+	ddl(statement string) string
+	// addColumnIfMissing adds a column to a table created by an older version of the schema
+	addColumnIfMissing(db *sql.DB, table, column, definition string) error
+	// snapshot writes a consistent copy of the database to a new SQLite file at path
+	snapshot(db *sql.DB, path string) error
+}
+
+// backendOf returns the engine behind db
+func backendOf(db *sql.DB) storageBackend {
+	if _, ok := db.Driver().(*stdlib.Driver); ok {
+		return postgresBackend{}
+	}
+	return sqliteBackend{}
+}
+
+// isPostgresURL reports whether a database URL names a PostgreSQL database
+func isPostgresURL(url string) bool {
+	return strings.HasPrefix(url, "postgres://") || strings.HasPrefix(url, "postgresql://")
+}
+
+// openDatabase opens the database named by $HNTOP_DATABASE_URL, or the SQLite file in the data directory
+func openDatabase() (*sql.DB, error) {
+	if url := os.Getenv(databaseURLEnv); url != "" {
+		if !isPostgresURL(url) {
+			return nil, fmt.Errorf("%s must be a postgres:// URL", databaseURLEnv)
+		}
+		slog.Debug("Opening PostgreSQL database")
+		return openPostgres(url)
+	}
+
+	// The database lives in the per-user data directory
+	dbPath, err := databasePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate database: %w", err)
+	}
+	slog.Debug("Opening SQLite database", "path", dbPath)
+	return sql.Open("sqlite", dbPath)
+}
+
+// sqliteBackend stores everything in a local SQLite file
+type sqliteBackend struct{}
+
+func (sqliteBackend) name() string { return "sqlite" }
+
+func (sqliteBackend) ddl(statement string) string { return statement }
+
+func (sqliteBackend) addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to inspect %s table: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	_ = rows.Close()
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
+	}
+	slog.Info("Migrated database schema", "table", table, "column", column)
+	return nil
+}
+
+// snapshot uses VACUUM INTO, which works while other connections keep using the database
+func (sqliteBackend) snapshot(db *sql.DB, path string) error {
+	if _, err := db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
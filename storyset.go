@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// algoliaAPIURL is the base of the Algolia endpoints the story set is fetched from
+var algoliaAPIURL = "https://hn.algolia.com/api/v1"
+
+// storySetHits is how many stories a run fetches
+const storySetHits = 100
+
+const (
+	defaultStorySetTags     = "front_page"
+	defaultStorySetEndpoint = "search_by_date"
+)
+
+// algoliaStoryTags are the Algolia tags that select a story set
+var algoliaStoryTags = []string{"front_page", "story", "show_hn", "ask_hn", "poll"}
+
+// algoliaSearchEndpoints are the Algolia search endpoints: newest first, or by relevance and points
+var algoliaSearchEndpoints = []string{"search_by_date", "search"}
+
+// FetchConfig selects the slice of Hacker News tracked on each run; empty values keep the defaults
+type FetchConfig struct {
+	Tags     string `json:"tags"`     // front_page (default), story, show_hn, ask_hn or poll
+	Endpoint string `json:"endpoint"` // search_by_date (default) for the newest stories, or search for the most popular
+}
+
+// validateFetch checks the story set against the tags and endpoints Algolia supports
+func validateFetch(config FetchConfig) error {
+	if config.Tags != "" && !slices.Contains(algoliaStoryTags, config.Tags) {
+		return fmt.Errorf("tags must be one of %v, got %q", algoliaStoryTags, config.Tags)
+	}
+	if config.Endpoint != "" && !slices.Contains(algoliaSearchEndpoints, config.Endpoint) {
+		return fmt.Errorf("endpoint must be one of %v, got %q", algoliaSearchEndpoints, config.Endpoint)
+	}
+	return nil
+}
+
+// resolveFetch applies defaults to the configured story set
+func resolveFetch(config FetchConfig) FetchConfig {
+	if config.Tags == "" {
+		config.Tags = defaultStorySetTags
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = defaultStorySetEndpoint
+	}
+	return config
+}
+
+// isFrontPage reports whether the story set is the front page, the only set where stories
+// dropping off and returning means something
+func (f FetchConfig) isFrontPage() bool {
+	return resolveFetch(f).Tags == "front_page"
+}
+
+// url returns the Algolia request for the story set
+func (f FetchConfig) url() string {
+	f = resolveFetch(f)
+	params := url.Values{
+		"tags":        {f.Tags},
+		"hitsPerPage": {fmt.Sprint(storySetHits)},
+	}
+	return algoliaAPIURL + "/" + f.Endpoint + "?" + params.Encode()
+}
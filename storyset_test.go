@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestValidateFetch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  FetchConfig
+		wantErr bool
+	}{
+		{"defaults", FetchConfig{}, false},
+		{"show hn by relevance", FetchConfig{Tags: "show_hn", Endpoint: "search"}, false},
+		{"polls", FetchConfig{Tags: "poll"}, false},
+		{"unknown tags", FetchConfig{Tags: "jobs"}, true},
+		{"unknown endpoint", FetchConfig{Endpoint: "items"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFetch(tc.config)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFetchConfigURL(t *testing.T) {
+	testCases := []struct {
+		config FetchConfig
+		want   string
+	}{
+		{FetchConfig{}, "https://hn.algolia.com/api/v1/search_by_date?hitsPerPage=100&tags=front_page"},
+		{FetchConfig{Tags: "ask_hn"}, "https://hn.algolia.com/api/v1/search_by_date?hitsPerPage=100&tags=ask_hn"},
+		{FetchConfig{Tags: "story", Endpoint: "search"}, "https://hn.algolia.com/api/v1/search?hitsPerPage=100&tags=story"},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.config.url(); got != tc.want {
+			t.Errorf("url() for %+v = %q, want %q", tc.config, got, tc.want)
+		}
+	}
+}
+
+func TestCategoryMapperFetch(t *testing.T) {
+	var nilMapper *CategoryMapper
+	if fetch := nilMapper.Fetch(); fetch.Tags != "front_page" || fetch.Endpoint != "search_by_date" || !fetch.isFrontPage() {
+		t.Errorf("Unexpected default story set: %+v", fetch)
+	}
+
+	mapper := NewCategoryMapper(&DomainConfig{Fetch: FetchConfig{Tags: "show_hn"}})
+	if fetch := mapper.Fetch(); fetch.Tags != "show_hn" || fetch.Endpoint != "search_by_date" || fetch.isFrontPage() {
+		t.Errorf("Unexpected configured story set: %+v", fetch)
+	}
+}
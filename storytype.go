@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StoryType is the kind of a Hacker News item as tagged by Algolia
+type StoryType string
+
+const (
+	StoryTypeStory  StoryType = "story"
+	StoryTypeShowHN StoryType = "show_hn"
+	StoryTypeAskHN  StoryType = "ask_hn"
+	StoryTypePoll   StoryType = "poll"
+	StoryTypeJob    StoryType = "job"
+)
+
+// storyTypes lists the known types, most specific first: a Show HN is also tagged as a story
+var storyTypes = []StoryType{StoryTypePoll, StoryTypeJob, StoryTypeShowHN, StoryTypeAskHN, StoryTypeStory}
+
+// storyTypeFromTags returns the most specific type in an Algolia _tags array, or "" if it has none
+func storyTypeFromTags(tags []string) StoryType {
+	for _, storyType := range storyTypes {
+		for _, tag := range tags {
+			if tag == string(storyType) {
+				return storyType
+			}
+		}
+	}
+	return ""
+}
+
+// parseStoryType validates a story type given by name, e.g. in a query parameter
+func parseStoryType(name string) (StoryType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, storyType := range storyTypes {
+		if name == string(storyType) {
+			return storyType, nil
+		}
+	}
+	return "", fmt.Errorf("unknown story type %q", name)
+}
+
+// itemStoryType returns the type of an item. Items stored before types were recorded fall back
+// to their title prefix.
+func itemStoryType(item HackerNewsItem) StoryType {
+	if item.Type != "" {
+		return item.Type
+	}
+	title := strings.ToLower(item.Title)
+	switch {
+	case strings.HasPrefix(title, "show hn:"):
+		return StoryTypeShowHN
+	case strings.HasPrefix(title, "ask hn:"):
+		return StoryTypeAskHN
+	}
+	return StoryTypeStory
+}
+
+// storyTypeCategory returns the category label of a story type, or "" for plain stories
+func storyTypeCategory(storyType StoryType) string {
+	switch storyType {
+	case StoryTypeShowHN:
+		return "Show HN"
+	case StoryTypeAskHN:
+		return "Ask HN"
+	case StoryTypePoll:
+		return "Poll"
+	case StoryTypeJob:
+		return "Job"
+	}
+	return ""
+}
+
+// filterItemsByType keeps items of any of the given types; no types keeps everything
+func filterItemsByType(items []HackerNewsItem, types []StoryType) []HackerNewsItem {
+	if len(types) == 0 {
+		return items
+	}
+
+	var filtered []HackerNewsItem
+	for _, item := range items {
+		storyType := itemStoryType(item)
+		for _, t := range types {
+			if storyType == t {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
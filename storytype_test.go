@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoryTypeFromTags(t *testing.T) {
+	testCases := []struct {
+		tags     []string
+		expected StoryType
+	}{
+		{[]string{"story", "author_pg", "story_1", "front_page"}, StoryTypeStory},
+		{[]string{"story", "author_pg", "story_2", "show_hn", "front_page"}, StoryTypeShowHN},
+		{[]string{"story", "ask_hn"}, StoryTypeAskHN},
+		{[]string{"poll", "author_pg", "poll_3"}, StoryTypePoll},
+		{[]string{"job", "story_4"}, StoryTypeJob},
+		{[]string{"comment"}, ""},
+		{nil, ""},
+	}
+	for _, tc := range testCases {
+		if got := storyTypeFromTags(tc.tags); got != tc.expected {
+			t.Errorf("storyTypeFromTags(%v) = %q, expected %q", tc.tags, got, tc.expected)
+		}
+	}
+
+	var hit AlgoliaHit
+	if err := json.Unmarshal([]byte(`{"objectID": "5", "title": "Launch", "_tags": ["story", "show_hn"], "created_at": "2024-01-01T00:00:00Z"}`), &hit); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := itemFromHit(hit, time.Now()); err != nil || item.Type != StoryTypeShowHN {
+		t.Errorf("Expected the item typed from its tags, got %q", item.Type)
+	}
+}
+
+func TestItemStoryType(t *testing.T) {
+	testCases := []struct {
+		item     HackerNewsItem
+		expected StoryType
+	}{
+		// Typed items ignore the title
+		{HackerNewsItem{Title: "Show HN: not really", Type: StoryTypeStory}, StoryTypeStory},
+		{HackerNewsItem{Title: "Tabs or spaces?", Type: StoryTypePoll}, StoryTypePoll},
+		// Untyped items fall back to the title prefix
+		{HackerNewsItem{Title: "Show HN: My project"}, StoryTypeShowHN},
+		{HackerNewsItem{Title: "ask hn: anyone?"}, StoryTypeAskHN},
+		{HackerNewsItem{Title: "An article"}, StoryTypeStory},
+	}
+	for _, tc := range testCases {
+		if got := itemStoryType(tc.item); got != tc.expected {
+			t.Errorf("itemStoryType(%+v) = %q, expected %q", tc.item, got, tc.expected)
+		}
+	}
+}
+
+func TestStoryTypeStorage(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	item := HackerNewsItem{ItemID: "1", Title: "We are hiring", Link: "https://example.com/jobs", Points: 100, CreatedAt: now, UpdatedAt: now, Type: StoryTypeJob}
+	updateStoredItems(db, []HackerNewsItem{item})
+
+	// Stats refreshes carry no tags and keep the stored type
+	item.Type = ""
+	item.Points = 120
+	updateStoredItems(db, []HackerNewsItem{item})
+
+	stored, err := getItemByID(db, "1")
+	if err != nil || stored == nil || stored.Type != StoryTypeJob {
+		t.Fatalf("Expected the stored type to be kept, got %+v (err %v)", stored, err)
+	}
+	if categories := itemCategoryList(*stored, 50, nil); !hasAnyCategory(categories, []string{"Job"}) {
+		t.Errorf("Expected a Job category, got %v", categories)
+	}
+}
+
+func TestHandleFeed_TypeFilter(t *testing.T) {
+	server := setupTestServer(t)
+	now := time.Now().UTC()
+	launch := HackerNewsItem{ItemID: "10", Title: "My weekend project", Link: "https://example.com/launch", CommentsLink: "https://news.ycombinator.com/item?id=10", Points: 90, CreatedAt: now.Add(-time.Hour), UpdatedAt: now, Type: StoryTypeShowHN}
+	updateStoredItems(server.db, []HackerNewsItem{launch})
+	if err := cacheOpenGraphData(server.db, &OpenGraphData{URL: launch.Link}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml?type=show_hn", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "My weekend project") || strings.Contains(body, "GitHub Project") {
+		t.Errorf("Expected only the Show HN story, got %d", rec.Code)
+	}
+	if !strings.Contains(body, `term="Show HN"`) {
+		t.Error("Expected the Show HN category from the story type")
+	}
+
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml?type=story,ask_hn", nil))
+	if body := rec.Body.String(); strings.Contains(body, "My weekend project") || !strings.Contains(body, "GitHub Project") {
+		t.Error("Expected the Show HN story to be filtered out")
+	}
+
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml?type=comment", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown type, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// feedStylesheetFile is the name of the XSL stylesheet written next to the feeds
+const feedStylesheetFile = "feed.xsl"
+
+// feedStylesheet is the href of the XSL stylesheet linked from every generated feed, or empty
+// for plain feeds. Files link the stylesheet written next to them, serve mode its own route.
+var feedStylesheet string
+
+// feedStylesheetXSL renders an Atom feed as a story list in browsers, styled like the year-in-review page
+const feedStylesheetXSL = `<?xml version="1.0" encoding="utf-8"?>
+<xsl:stylesheet version="1.0" xmlns:xsl="http://www.w3.org/1999/XSL/Transform" xmlns:atom="http://www.w3.org/2005/Atom">
+<xsl:output method="html" encoding="utf-8" indent="yes" doctype-system="about:legacy-compat"/>
+<xsl:template match="/atom:feed">
+<html lang="en">
+<head>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1"/>
+<title><xsl:value-of select="atom:title"/></title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 860px; margin: 0 auto; padding: 1rem; color: #222; }
+h1 { border-bottom: 3px solid #ff6600; padding-bottom: .3rem; }
+.about { background: #f6f6ef; padding: .6rem .8rem; }
+ol { padding-left: 1.5rem; }
+li { margin: .5rem 0; }
+a { color: #000; }
+.meta { color: #828282; font-size: .85rem; }
+.meta a { color: #828282; }
+.category { border: 1px solid #ddd; border-radius: 3px; padding: 0 .3rem; margin-right: .3rem; }
+nav a { margin-right: .5rem; }
+</style>
+</head>
+<body>
+<h1><xsl:value-of select="atom:title"/></h1>
+<p class="about">This is an Atom feed. Copy this page's address into a feed reader to subscribe. <xsl:value-of select="atom:subtitle"/></p>
+<nav>
+<xsl:for-each select="atom:link[@rel!='self' and @rel!='alternate']">
+<a href="{@href}"><xsl:value-of select="@rel"/></a>
+</xsl:for-each>
+</nav>
+<ol>
+<xsl:for-each select="atom:entry">
+<li>
+<a href="{atom:link[@rel='alternate']/@href}"><xsl:value-of select="atom:title"/></a>
+<br/>
+<span class="meta">
+by <xsl:value-of select="atom:author/atom:name"/> · <xsl:value-of select="substring(atom:published, 1, 10)"/>
+<xsl:for-each select="atom:category"> <span class="category"><xsl:value-of select="@term"/></span></xsl:for-each>
+</span>
+</li>
+</xsl:for-each>
+</ol>
+</body>
+</html>
+</xsl:template>
+</xsl:stylesheet>
+`
+
+// stylesheetInstruction returns the xml-stylesheet processing instruction linking feedStylesheet,
+// or an empty string when no stylesheet is configured
+func stylesheetInstruction() string {
+	if feedStylesheet == "" {
+		return ""
+	}
+	href := strings.NewReplacer("&", "&amp;", `"`, "&quot;", "<", "&lt;").Replace(feedStylesheet)
+	return fmt.Sprintf(`<?xml-stylesheet type="text/xsl" href="%s"?>`, href) + "\n"
+}
+
+// writeFeedStylesheet writes the XSL stylesheet to outDir
+func writeFeedStylesheet(outDir string) error {
+	if _, err := writeFeedFile(filepath.Join(outDir, feedStylesheetFile), feedStylesheetXSL); err != nil {
+		return fmt.Errorf("failed to write feed stylesheet: %w", err)
+	}
+	return nil
+}
+
+// handleFeedStylesheet serves the XSL stylesheet linked from the feeds in serve mode
+func handleFeedStylesheet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/xsl; charset=utf-8")
+	_, _ = w.Write([]byte(feedStylesheetXSL))
+}
+
+// feedContentType returns the content type a feed is served with. Browsers download Atom documents
+// instead of applying their stylesheet, so with a stylesheet they get the feed as plain XML.
+func feedContentType(r *http.Request) string {
+	if feedStylesheet != "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return "application/xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
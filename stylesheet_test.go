@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useFeedStylesheet links generated feeds to the given stylesheet href for the duration of a test
+func useFeedStylesheet(t *testing.T, href string) {
+	t.Helper()
+	original := feedStylesheet
+	feedStylesheet = href
+	t.Cleanup(func() { feedStylesheet = original })
+}
+
+// checkWellFormed fails the test if document isn't well-formed XML
+func checkWellFormed(t *testing.T, document string) {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	for {
+		_, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Document is not well-formed XML: %v", err)
+		}
+	}
+}
+
+func TestFeedStylesheetXSL_WellFormed(t *testing.T) {
+	checkWellFormed(t, feedStylesheetXSL)
+}
+
+func TestStylesheetInstruction(t *testing.T) {
+	if got := stylesheetInstruction(); got != "" {
+		t.Errorf("Expected no instruction without a stylesheet, got %q", got)
+	}
+
+	useFeedStylesheet(t, "feed.xsl")
+	if got := stylesheetInstruction(); got != `<?xml-stylesheet type="text/xsl" href="feed.xsl"?>`+"\n" {
+		t.Errorf("Unexpected instruction %q", got)
+	}
+}
+
+func TestWriteFeedStylesheet(t *testing.T) {
+	outDir := t.TempDir()
+	if err := writeFeedStylesheet(outDir); err != nil {
+		t.Fatalf("writeFeedStylesheet failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, feedStylesheetFile))
+	if err != nil || string(data) != feedStylesheetXSL {
+		t.Errorf("Expected the stylesheet to be written, got %v", err)
+	}
+}
+
+func TestHandleFeed_Stylesheet(t *testing.T) {
+	useFeedStylesheet(t, "/"+feedStylesheetFile)
+	server := setupTestServer(t)
+	handler := server.routes()
+
+	// Browsers get the feed as plain XML so they apply the stylesheet
+	req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Errorf("Expected application/xml for a browser, got %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `<?xml-stylesheet type="text/xsl" href="/feed.xsl"?>`) {
+		t.Errorf("Expected the stylesheet instruction in the feed:\n%s", body[:min(len(body), 300)])
+	}
+	checkWellFormed(t, body)
+
+	// Feed readers keep getting Atom
+	req = httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+	req.Header.Set("Accept", "application/atom+xml")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("Expected application/atom+xml for a feed reader, got %q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xsl", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != feedStylesheetXSL {
+		t.Errorf("Expected the stylesheet to be served, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/xsl") {
+		t.Errorf("Expected text/xsl, got %q", ct)
+	}
+}
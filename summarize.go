@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	defaultOllamaURL        = "http://localhost:11434"
+	defaultOpenAIURL        = "https://api.openai.com/v1"
+	maxSummaryTokens        = 200 // completion tokens requested per summary
+	defaultSummaryTimeout   = 60 * time.Second
+	defaultSummaryMaxItems  = 10
+	maxArticleTextLength    = 8000 // characters of article text sent to the model
+	minArticleTextLength    = 200  // pages with less text than this are not worth summarizing
+	maxArticleResponseBytes = 2 * 1024 * 1024
+)
+
+// SummarizerConfig configures the optional LLM article summarizer
+type SummarizerConfig struct {
+	Provider       string `json:"provider"`        // "ollama" or "openai"; empty disables summarization
+	URL            string `json:"url"`             // API base URL, defaults to a local Ollama or api.openai.com
+	APIKey         string `json:"api_key"`         // API key for OpenAI-compatible endpoints (defaults to $OPENAI_API_KEY)
+	Model          string `json:"model"`           // model name, required
+	TimeoutSeconds int    `json:"timeout_seconds"` // per-item timeout for fetching and summarizing (0 = 60)
+	MaxItems       int    `json:"max_items"`       // new summaries generated per run (0 = 10)
+	TokenBudget    int    `json:"token_budget"`    // total tokens spent per run before stopping (0 = unlimited)
+}
+
+// summarizer sends a prompt to an LLM and returns its reply along with the tokens used
+type summarizer interface {
+	Complete(ctx context.Context, prompt string) (reply string, tokens int, err error)
+	Model() string
+}
+
+// newSummarizer creates the summarizer for the configured provider
+func newSummarizer(config SummarizerConfig) (summarizer, error) {
+	if config.Model == "" {
+		return nil, fmt.Errorf("summarizer requires a model")
+	}
+
+	switch config.Provider {
+	case "ollama":
+		baseURL := config.URL
+		if baseURL == "" {
+			baseURL = defaultOllamaURL
+		}
+		return &ollamaSummarizer{baseURL: strings.TrimSuffix(baseURL, "/"), model: config.Model, client: &http.Client{}}, nil
+	case "openai":
+		baseURL := config.URL
+		if baseURL == "" {
+			baseURL = defaultOpenAIURL
+		}
+		apiKey := config.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		return &openAISummarizer{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey, model: config.Model, client: &http.Client{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown summarizer provider: %q", config.Provider)
+	}
+}
+
+// summaryPrompt builds the instruction sent to the model
+func summaryPrompt(title, text string) string {
+	return fmt.Sprintf("Summarize the following article in 2-3 plain sentences. Reply with the summary only.\n\nTitle: %s\n\n%s", title, text)
+}
+
+// ollamaSummarizer summarizes articles using a local Ollama server
+type ollamaSummarizer struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// Model implements summarizer
+func (o *ollamaSummarizer) Model() string {
+	return o.model
+}
+
+// Complete implements summarizer using the Ollama generate API
+func (o *ollamaSummarizer) Complete(ctx context.Context, prompt string) (string, int, error) {
+	var result struct {
+		Response        string `json:"response"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+	request := map[string]any{
+		"model":   o.model,
+		"prompt":  prompt,
+		"stream":  false,
+		"options": map[string]any{"num_predict": maxSummaryTokens},
+	}
+	if err := postJSON(ctx, o.client, o.baseURL+"/api/generate", nil, request, &result); err != nil {
+		return "", 0, fmt.Errorf("ollama request failed: %w", err)
+	}
+
+	tokens := result.PromptEvalCount + result.EvalCount
+	summary := strings.TrimSpace(result.Response)
+	if summary == "" {
+		return "", tokens, fmt.Errorf("empty reply")
+	}
+	return summary, tokens, nil
+}
+
+// openAISummarizer summarizes articles using any OpenAI-compatible chat completions endpoint
+type openAISummarizer struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// Model implements summarizer
+func (o *openAISummarizer) Model() string {
+	return o.model
+}
+
+// Complete implements summarizer using the chat completions API
+func (o *openAISummarizer) Complete(ctx context.Context, prompt string) (string, int, error) {
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	request := map[string]any{
+		"model":      o.model,
+		"max_tokens": maxSummaryTokens,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+	if err := postJSON(ctx, o.client, o.baseURL+"/chat/completions", bearerHeader(o.apiKey), request, &result); err != nil {
+		return "", 0, fmt.Errorf("chat completions request failed: %w", err)
+	}
+
+	tokens := result.Usage.TotalTokens
+	if len(result.Choices) == 0 {
+		return "", tokens, fmt.Errorf("no choices in response")
+	}
+	summary := strings.TrimSpace(result.Choices[0].Message.Content)
+	if summary == "" {
+		return "", tokens, fmt.Errorf("empty reply")
+	}
+	return summary, tokens, nil
+}
+
+// bearerHeader returns an Authorization header for a token, or nil if the token is empty
+func bearerHeader(token string) map[string]string {
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// postJSON sends a JSON request with extra headers and decodes the JSON response
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, request, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// fetchArticleText downloads an article and extracts its readable text
+func fetchArticleText(ctx context.Context, client *http.Client, targetURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "HNTop-RSS/1.0 (summarizer)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP error: %d %s", resp.StatusCode, resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.Contains(strings.ToLower(contentType), "text/html") {
+		return "", fmt.Errorf("not an HTML page: %s", contentType)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxArticleResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	return extractArticleText(doc), nil
+}
+
+// extractArticleText collects paragraph and heading text, skipping page chrome and scripts
+func extractArticleText(doc *html.Node) string {
+	var parts []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "nav", "header", "footer", "aside", "form":
+				return
+			case "p", "h1", "h2", "h3", "li", "blockquote", "pre":
+				if text := strings.Join(strings.Fields(nodeText(n)), " "); text != "" {
+					parts = append(parts, text)
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	text := strings.Join(parts, "\n")
+	if len(text) > maxArticleTextLength {
+		text = text[:maxArticleTextLength]
+	}
+	return strings.ToValidUTF8(text, "")
+}
+
+// nodeText returns the concatenated text content of a node
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.Data == "script" || c.Data == "style") {
+			continue
+		}
+		sb.WriteString(nodeText(c))
+		sb.WriteString(" ")
+	}
+	return sb.String()
+}
+
+// summarizeItems generates summaries for items that don't have one cached yet.
+// It does nothing unless a summarizer is configured, and each item is bounded by
+// the configured timeout so a slow model cannot stall feed generation for long.
+// The number of new summaries and the tokens spent per run are capped to bound costs.
+func summarizeItems(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	config := categoryMapper.Config().Summarizer
+	if config.Provider == "" {
+		return
+	}
+
+	s, err := newSummarizer(config)
+	if err != nil {
+		slog.Warn("Invalid summarizer configuration, skipping summaries", "error", err)
+		return
+	}
+
+	timeout := defaultSummaryTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	maxItems := defaultSummaryMaxItems
+	if config.MaxItems > 0 {
+		maxItems = config.MaxItems
+	}
+
+	client := &http.Client{Timeout: timeout}
+	generated, tokensUsed := 0, 0
+	for _, item := range items {
+		if generated >= maxItems {
+			slog.Debug("Summary limit reached for this run", "limit", maxItems)
+			break
+		}
+		if config.TokenBudget > 0 && tokensUsed >= config.TokenBudget {
+			slog.Info("Summary token budget exhausted for this run", "budget", config.TokenBudget, "used", tokensUsed)
+			break
+		}
+		if isTextPost(item) {
+			continue
+		}
+		if summary, err := getSummary(db, item.Link); err != nil || summary != "" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var summary string
+		var tokens int
+		err := errPanicked
+		safely("article summary", func() {
+			summary, tokens, err = summarizeArticle(ctx, client, s, item)
+		}, itemLogAttrs(item)...)
+		cancel()
+		tokensUsed += tokens
+		if err != nil {
+			slog.Debug("Failed to summarize article", "url", item.Link, "error", err)
+			continue
+		}
+
+		if err := cacheSummary(db, item.Link, summary, s.Model()); err != nil {
+			slog.Warn("Failed to cache summary", "url", item.Link, "error", err)
+			continue
+		}
+		generated++
+		slog.Info("Summarized article", "title", item.Title, "model", s.Model(), "tokens", tokens)
+	}
+}
+
+// summarizeArticle fetches an item's article and asks the summarizer for a summary
+func summarizeArticle(ctx context.Context, client *http.Client, s summarizer, item HackerNewsItem) (string, int, error) {
+	text, err := fetchArticleText(ctx, client, item.Link)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(text) < minArticleTextLength {
+		return "", 0, fmt.Errorf("not enough article text (%d characters)", len(text))
+	}
+	return s.Complete(ctx, summaryPrompt(item.Title, text))
+}
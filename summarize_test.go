@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractArticleText(t *testing.T) {
+	page := `<html><head><title>T</title><script>var x = 1;</script></head><body>
+		<nav><p>Home | About</p></nav>
+		<h1>Headline</h1>
+		<p>First <b>paragraph</b>
+		   text.</p>
+		<p>Second paragraph.</p>
+		<footer><p>Copyright</p></footer>
+	</body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	text := extractArticleText(doc)
+	expected := "Headline\nFirst paragraph text.\nSecond paragraph."
+	if text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestNewSummarizer(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    SummarizerConfig
+		shouldErr bool
+	}{
+		{"ollama", SummarizerConfig{Provider: "ollama", Model: "llama3.2"}, false},
+		{"missing model", SummarizerConfig{Provider: "ollama"}, true},
+		{"unknown provider", SummarizerConfig{Provider: "magic", Model: "x"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newSummarizer(tc.config)
+			if tc.shouldErr != (err != nil) {
+				t.Errorf("Expected error=%v, got %v", tc.shouldErr, err)
+			}
+		})
+	}
+}
+
+// newSummaryTestServer serves an article at /article and a fake Ollama API at /api/generate
+func newSummaryTestServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	article := "<html><body><p>" + strings.Repeat("This is a long article sentence. ", 20) + "</p></body></html>"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/generate":
+			calls.Add(1)
+			var req struct {
+				Model  string `json:"model"`
+				Prompt string `json:"prompt"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "test-model" || !strings.Contains(req.Prompt, "long article") {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": " A <short> summary. "})
+		case "/short":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<p>Too short</p>"))
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(article))
+		}
+	}))
+}
+
+func TestSummarizeItems(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var calls atomic.Int32
+	server := newSummaryTestServer(t, &calls)
+	defer server.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{Summarizer: SummarizerConfig{
+		Provider: "ollama",
+		URL:      server.URL,
+		Model:    "test-model",
+		MaxItems: 2,
+	}})
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Short", Link: server.URL + "/short"},
+		{ItemID: "2", Title: "One", Link: server.URL + "/one"},
+		{ItemID: "3", Title: "Two", Link: server.URL + "/two"},
+		{ItemID: "4", Title: "Three", Link: server.URL + "/three"},
+	}
+
+	summarizeItems(db, items, mapper)
+	if calls.Load() != 2 {
+		t.Fatalf("Expected 2 summaries within the per-run limit, got %d", calls.Load())
+	}
+
+	summary, err := getSummary(db, server.URL+"/one")
+	if err != nil || summary != "A <short> summary." {
+		t.Errorf("Expected cached summary, got %q (err %v)", summary, err)
+	}
+	if summary, _ := getSummary(db, server.URL+"/short"); summary != "" {
+		t.Errorf("Expected no summary for short article, got %q", summary)
+	}
+
+	// Cached summaries are not regenerated; the remaining item is picked up next run
+	summarizeItems(db, items, mapper)
+	if calls.Load() != 3 {
+		t.Errorf("Expected only the remaining item to be summarized, got %d calls", calls.Load())
+	}
+}
+
+func TestSummarizeItems_Disabled(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var calls atomic.Int32
+	server := newSummaryTestServer(t, &calls)
+	defer server.Close()
+
+	summarizeItems(db, []HackerNewsItem{{ItemID: "1", Link: server.URL + "/one"}}, NewCategoryMapper(&DomainConfig{}))
+	summarizeItems(db, []HackerNewsItem{{ItemID: "1", Link: server.URL + "/one"}}, nil)
+	if calls.Load() != 0 {
+		t.Errorf("Expected no summarizer calls when disabled, got %d", calls.Load())
+	}
+}
+
+func TestSummarizeItems_Timeout(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{Summarizer: SummarizerConfig{Provider: "ollama", URL: slow.URL, Model: "m", TimeoutSeconds: 1}})
+	start := time.Now()
+	summarizeItems(db, []HackerNewsItem{{ItemID: "1", Link: slow.URL + "/article"}}, mapper)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected summarization to give up after the timeout, took %s", elapsed)
+	}
+}
+
+func TestGenerateRSSFeed_IncludesSummary(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	item := HackerNewsItem{ItemID: "1", Title: "Article", Link: "https://example.com/a", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CreatedAt: time.Now()}
+	if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link}, false); err != nil {
+		t.Fatalf("Failed to seed OpenGraph cache: %v", err)
+	}
+	if err := cacheSummary(db, item.Link, "Short & sweet.", "m"); err != nil {
+		t.Fatalf("Failed to cache summary: %v", err)
+	}
+
+	rss := generateRSSFeed(db, []HackerNewsItem{item}, 50, nil)
+	if !strings.Contains(rss, "Summary") || !strings.Contains(rss, "Short &amp;amp; sweet.") {
+		t.Error("Expected feed entry to include the escaped summary")
+	}
+}
+
+func TestOllamaSummarizer_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	s, _ := newSummarizer(SummarizerConfig{Provider: "ollama", URL: server.URL, Model: "missing"})
+	if _, _, err := s.Complete(context.Background(), "Prompt"); err == nil {
+		t.Error("Expected error for failed Ollama request")
+	}
+}
+
+func TestOpenAISummarizer(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		var req struct {
+			Model     string `json:"model"`
+			MaxTokens int    `json:"max_tokens"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-test" || req.MaxTokens != maxSummaryTokens {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Compact summary."}}],"usage":{"total_tokens":321}}`))
+	}))
+	defer server.Close()
+
+	s, err := newSummarizer(SummarizerConfig{Provider: "openai", URL: server.URL + "/v1/", APIKey: "sk-test", Model: "gpt-test"})
+	if err != nil {
+		t.Fatalf("Failed to create summarizer: %v", err)
+	}
+	summary, tokens, err := s.Complete(context.Background(), "Prompt")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary != "Compact summary." || tokens != 321 {
+		t.Errorf("Unexpected result: %q, %d tokens", summary, tokens)
+	}
+	if gotAuth != "Bearer sk-test" {
+		t.Errorf("Expected API key as bearer token, got %q", gotAuth)
+	}
+}
+
+func TestSummarizeItems_TokenBudget(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var calls atomic.Int32
+	article := "<html><body><p>" + strings.Repeat("Plenty of article text here. ", 20) + "</p></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat/completions" {
+			calls.Add(1)
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Summary."}}],"usage":{"total_tokens":600}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(article))
+	}))
+	defer server.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{Summarizer: SummarizerConfig{
+		Provider:    "openai",
+		URL:         server.URL,
+		Model:       "gpt-test",
+		TokenBudget: 1000,
+	}})
+	items := []HackerNewsItem{
+		{ItemID: "1", Link: server.URL + "/a"},
+		{ItemID: "2", Link: server.URL + "/b"},
+		{ItemID: "3", Link: server.URL + "/c"},
+	}
+
+	summarizeItems(db, items, mapper)
+	if calls.Load() != 2 {
+		t.Errorf("Expected summarization to stop once the token budget is spent, got %d calls", calls.Load())
+	}
+}
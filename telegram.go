@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// telegramAPIURL is the Telegram Bot API base URL, a variable so tests can point it at a fake server
+var telegramAPIURL = "https://api.telegram.org"
+
+// telegramNotifier posts notifications as messages through a Telegram bot
+type telegramNotifier struct {
+	token          string
+	chatID         string
+	disablePreview bool
+	client         *http.Client
+}
+
+// telegramMessage is the sendMessage request body
+type telegramMessage struct {
+	ChatID             string                     `json:"chat_id"`
+	Text               string                     `json:"text"`
+	ParseMode          string                     `json:"parse_mode"`
+	LinkPreviewOptions telegramLinkPreviewOptions `json:"link_preview_options"`
+}
+
+type telegramLinkPreviewOptions struct {
+	IsDisabled bool   `json:"is_disabled"`
+	URL        string `json:"url,omitempty"` // the link to preview, the article rather than the discussion
+}
+
+// telegramResponse is the envelope of every Bot API response
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// renderTelegramMessage formats a notification as a Telegram HTML message: the reason, the linked
+// title, the stats line and a link to the discussion
+func renderTelegramMessage(n notification) string {
+	item := n.Item
+	var b strings.Builder
+	fmt.Fprintf(&b, "<i>%s</i>\n", html.EscapeString(notificationReason(n)))
+	link := item.URL
+	if link == "" {
+		link = item.CommentsURL
+	}
+	fmt.Fprintf(&b, "<b><a href=\"%s\">%s</a></b>\n", html.EscapeString(link), html.EscapeString(item.Title))
+	fmt.Fprintf(&b, "%d points · %d comments · by %s\n", item.Points, item.CommentCount, html.EscapeString(item.Author))
+	fmt.Fprintf(&b, "<a href=\"%s\">Discussion</a>", html.EscapeString(item.CommentsURL))
+	return b.String()
+}
+
+// Notify implements notifier
+func (t *telegramNotifier) Notify(ctx context.Context, n notification) error {
+	message := telegramMessage{
+		ChatID:             t.chatID,
+		Text:               renderTelegramMessage(n),
+		ParseMode:          "HTML",
+		LinkPreviewOptions: telegramLinkPreviewOptions{IsDisabled: t.disablePreview},
+	}
+	if !t.disablePreview {
+		message.LinkPreviewOptions.URL = n.Item.URL
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPIURL+"/bot"+t.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The request URL contains the bot token, so only the underlying cause is reported
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send Telegram message: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.OK {
+		if result.Description != "" {
+			return fmt.Errorf("telegram rejected the message: %s", result.Description)
+		}
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
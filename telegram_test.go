@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// useTestTelegramAPI points the Telegram Bot API at a test server and returns the received messages
+func useTestTelegramAPI(t *testing.T, handler func(w http.ResponseWriter, message telegramMessage)) *[]string {
+	t.Helper()
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var message telegramMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode message: %v", err)
+		}
+		handler(w, message)
+	}))
+	original := telegramAPIURL
+	telegramAPIURL = server.URL
+	t.Cleanup(func() {
+		telegramAPIURL = original
+		server.Close()
+	})
+	return &paths
+}
+
+func TestRenderTelegramMessage(t *testing.T) {
+	n := notification{Event: "threshold", Threshold: 500, Item: apiItem{
+		Title: "Rust & <Go>", URL: "https://example.com/a?x=1&y=2", CommentsURL: "https://news.ycombinator.com/item?id=1",
+		Points: 512, CommentCount: 87, Author: "alice",
+	}}
+	got := renderTelegramMessage(n)
+	for _, want := range []string{
+		"<i>Crossed 500 points</i>",
+		`<b><a href="https://example.com/a?x=1&amp;y=2">Rust &amp; &lt;Go&gt;</a></b>`,
+		"512 points · 87 comments · by alice",
+		`<a href="https://news.ycombinator.com/item?id=1">Discussion</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in message:\n%s", want, got)
+		}
+	}
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	var received []telegramMessage
+	paths := useTestTelegramAPI(t, func(w http.ResponseWriter, message telegramMessage) {
+		received = append(received, message)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	})
+
+	n := notification{Event: "watchlist", Matches: []string{"hntop"}, Item: apiItem{Title: "hntop-rss", URL: "https://example.com/", CommentsURL: "https://news.ycombinator.com/item?id=1"}}
+	for _, disablePreview := range []bool{false, true} {
+		target, err := newNotifier(NotifierConfig{Type: "telegram", BotToken: "123:abc", ChatID: "@hn", DisablePreview: disablePreview})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := target.Notify(context.Background(), n); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+
+	if len(received) != 2 || (*paths)[0] != "/bot123:abc/sendMessage" {
+		t.Fatalf("Expected two messages sent through the bot, got %d to %v", len(received), *paths)
+	}
+	if received[0].ChatID != "@hn" || received[0].ParseMode != "HTML" || !strings.Contains(received[0].Text, "Watchlist match: hntop") {
+		t.Errorf("Unexpected message: %+v", received[0])
+	}
+	if received[0].LinkPreviewOptions.IsDisabled || received[0].LinkPreviewOptions.URL != "https://example.com/" {
+		t.Errorf("Expected an article preview, got %+v", received[0].LinkPreviewOptions)
+	}
+	if !received[1].LinkPreviewOptions.IsDisabled {
+		t.Errorf("Expected the preview to be disabled, got %+v", received[1].LinkPreviewOptions)
+	}
+}
+
+func TestTelegramNotifier_Rejected(t *testing.T) {
+	useTestTelegramAPI(t, func(w http.ResponseWriter, message telegramMessage) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request: chat not found"}`))
+	})
+
+	target, err := newNotifier(NotifierConfig{Type: "telegram", BotToken: "123:abc", ChatID: "@missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = target.Notify(context.Background(), notification{Event: "threshold", Threshold: 100})
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("Expected Telegram's error description, got %v", err)
+	}
+}
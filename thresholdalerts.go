@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// ThresholdConfig sends notifications when a story first reaches a number of points
+type ThresholdConfig struct {
+	Points    int              `json:"points"`
+	Notifiers []NotifierConfig `json:"notifiers"`
+}
+
+// alertThresholdCrossings notifies about items whose stored points reached a configured threshold.
+// Points are read from the database, which holds the values refreshed during the run, and each
+// item is alerted at most once per threshold.
+func alertThresholdCrossings(db *sql.DB, items []HackerNewsItem, minPoints int, categoryMapper *CategoryMapper) {
+	alerts := categoryMapper.Config().ThresholdAlerts
+	if len(alerts) == 0 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, listed := range items {
+		if listed.ItemID == "" || seen[listed.ItemID] {
+			continue
+		}
+		seen[listed.ItemID] = true
+
+		item, err := getItemByID(db, listed.ItemID)
+		if err != nil || item == nil || !item.DeadAt.IsZero() {
+			continue
+		}
+		for _, alert := range alerts {
+			if alert.Points <= 0 || item.Points < alert.Points {
+				continue
+			}
+			isNew, err := markThresholdAlerted(db, item.ItemID, alert.Points)
+			if err != nil {
+				slog.Warn("Failed to record threshold alert", "hn_id", item.ItemID, "threshold", alert.Points, "error", err)
+				continue
+			}
+			if !isNew {
+				continue
+			}
+			slog.Info("Threshold crossed", "title", item.Title, "hn_id", item.ItemID, "points", item.Points, "threshold", alert.Points)
+			sendNotification(alert.Notifiers, notification{
+				Event:     "threshold",
+				Threshold: alert.Points,
+				Item:      toAPIItem(*item, minPoints, categoryMapper),
+			})
+		}
+	}
+}
+
+// markThresholdAlerted records that an item has been alerted on for a threshold.
+// Returns false if it had already been alerted before.
+func markThresholdAlerted(db *sql.DB, itemID string, points int) (bool, error) {
+	result, err := execWithRetry(db, "INSERT INTO threshold_alerts (item_hn_id, points) VALUES (?, ?) ON CONFLICT DO NOTHING", itemID, points)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert threshold alert: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check threshold alert: %w", err)
+	}
+	return affected > 0, nil
+}
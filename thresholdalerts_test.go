@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAlertThresholdCrossings(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var mu sync.Mutex
+	var received []notification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	notifiers := []NotifierConfig{{Type: "webhook", URL: webhook.URL}}
+	mapper := NewCategoryMapper(&DomainConfig{ThresholdAlerts: []ThresholdConfig{
+		{Points: 300, Notifiers: notifiers},
+		{Points: 1000, Notifiers: notifiers},
+	}})
+
+	now := time.Now()
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Rising story", Link: "https://example.com/a", Points: 120, CreatedAt: now, UpdatedAt: now},
+		{ItemID: "2", Title: "Quiet story", Link: "https://example.com/b", Points: 40, CreatedAt: now, UpdatedAt: now},
+	}
+	updateStoredItems(db, items)
+	alertThresholdCrossings(db, items, 50, mapper)
+	if len(received) != 0 {
+		t.Fatalf("Expected no alerts below the thresholds, got %+v", received)
+	}
+
+	// The listed items carry stale points; the refreshed ones in the database decide
+	if _, err := db.Exec("UPDATE items SET points = 450 WHERE item_hn_id = '1'"); err != nil {
+		t.Fatal(err)
+	}
+	alertThresholdCrossings(db, append(items, items[0]), 50, mapper)
+	alertThresholdCrossings(db, items, 50, mapper)
+	if len(received) != 1 {
+		t.Fatalf("Expected exactly one alert, got %d", len(received))
+	}
+	if n := received[0]; n.Event != "threshold" || n.Threshold != 300 || n.Item.ID != "1" || n.Item.Points != 450 {
+		t.Errorf("Unexpected notification: %+v", n)
+	}
+
+	// A higher threshold alerts again, once
+	if _, err := db.Exec("UPDATE items SET points = 1200 WHERE item_hn_id = '1'"); err != nil {
+		t.Fatal(err)
+	}
+	alertThresholdCrossings(db, items, 50, mapper)
+	if len(received) != 2 || received[1].Threshold != 1000 {
+		t.Errorf("Expected a second alert for the 1000 point threshold, got %+v", received)
+	}
+
+	// Dead items don't alert
+	if _, err := db.Exec("UPDATE items SET points = 5000 WHERE item_hn_id = '2'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := markItemDead(db, "2", now); err != nil {
+		t.Fatal(err)
+	}
+	alertThresholdCrossings(db, items, 50, mapper)
+	if len(received) != 2 {
+		t.Errorf("Expected no alerts for a dead item, got %+v", received[2:])
+	}
+}
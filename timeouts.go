@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	defaultAlgoliaTimeout          = 30 * time.Second
+	defaultOpenGraphRequestTimeout = 10 * time.Second
+	defaultOpenGraphTimeout        = 15 * time.Second
+	defaultConfigTimeout           = 10 * time.Second
+	maxRequestTimeoutSeconds       = 3600
+	maxRunDeadlineSeconds          = 24 * 3600
+)
+
+// configTimeoutEnv names the environment variable overriding the remote config fetch timeout.
+// It can't live in the config file because it applies before the config is loaded.
+const configTimeoutEnv = "HNTOP_CONFIG_TIMEOUT"
+
+// TimeoutsConfig overrides network timeouts in seconds; zero keeps the default
+type TimeoutsConfig struct {
+	AlgoliaSeconds          int `json:"algolia_seconds"`           // Algolia front page and item requests (default 30)
+	OpenGraphRequestSeconds int `json:"opengraph_request_seconds"` // a single OpenGraph HTTP request (default 10)
+	OpenGraphSeconds        int `json:"opengraph_seconds"`         // an OpenGraph fetch including rate-limit waits (default 15)
+	RunDeadlineSeconds      int `json:"run_deadline_seconds"`      // a whole feed run before it is aborted (default none)
+}
+
+// networkTimeouts holds resolved timeouts
+type networkTimeouts struct {
+	Algolia          time.Duration
+	OpenGraphRequest time.Duration
+	OpenGraph        time.Duration
+	RunDeadline      time.Duration // zero means no deadline
+}
+
+// defaultNetworkTimeouts returns the timeouts used when none are configured
+func defaultNetworkTimeouts() networkTimeouts {
+	return resolveTimeouts(TimeoutsConfig{})
+}
+
+// validateTimeouts checks configured timeouts for negative or unreasonably large values
+func validateTimeouts(config TimeoutsConfig) error {
+	requests := map[string]int{
+		"algolia_seconds":           config.AlgoliaSeconds,
+		"opengraph_request_seconds": config.OpenGraphRequestSeconds,
+		"opengraph_seconds":         config.OpenGraphSeconds,
+	}
+	for name, seconds := range requests {
+		if seconds < 0 || seconds > maxRequestTimeoutSeconds {
+			return fmt.Errorf("%s must be between 0 and %d, got %d", name, maxRequestTimeoutSeconds, seconds)
+		}
+	}
+	if config.RunDeadlineSeconds < 0 || config.RunDeadlineSeconds > maxRunDeadlineSeconds {
+		return fmt.Errorf("run_deadline_seconds must be between 0 and %d, got %d", maxRunDeadlineSeconds, config.RunDeadlineSeconds)
+	}
+
+	resolved := resolveTimeouts(config)
+	if resolved.OpenGraphRequest > resolved.OpenGraph {
+		return fmt.Errorf("opengraph_request_seconds (%s) must not exceed opengraph_seconds (%s)", resolved.OpenGraphRequest, resolved.OpenGraph)
+	}
+	return nil
+}
+
+// resolveTimeouts applies defaults to the configured timeouts
+func resolveTimeouts(config TimeoutsConfig) networkTimeouts {
+	seconds := func(value int, fallback time.Duration) time.Duration {
+		if value > 0 {
+			return time.Duration(value) * time.Second
+		}
+		return fallback
+	}
+	return networkTimeouts{
+		Algolia:          seconds(config.AlgoliaSeconds, defaultAlgoliaTimeout),
+		OpenGraphRequest: seconds(config.OpenGraphRequestSeconds, defaultOpenGraphRequestTimeout),
+		OpenGraph:        seconds(config.OpenGraphSeconds, defaultOpenGraphTimeout),
+		RunDeadline:      seconds(config.RunDeadlineSeconds, 0),
+	}
+}
+
+// configFetchTimeout returns the remote config fetch timeout, honoring $HNTOP_CONFIG_TIMEOUT
+func configFetchTimeout() time.Duration {
+	value := os.Getenv(configTimeoutEnv)
+	if value == "" {
+		return defaultConfigTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 || timeout > maxRequestTimeoutSeconds*time.Second {
+		slog.Warn("Invalid config fetch timeout, using default", "env", configTimeoutEnv, "value", value, "default", defaultConfigTimeout)
+		return defaultConfigTimeout
+	}
+	return timeout
+}
+
+// startRunDeadline aborts the process if a run is still going after the deadline.
+// The returned function stops the watchdog; it does nothing when no deadline is set.
+func startRunDeadline(deadline time.Duration) func() {
+	if deadline <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(deadline, func() {
+		slog.Error("Run deadline exceeded, aborting", "deadline", deadline)
+		os.Exit(1)
+	})
+	return func() { timer.Stop() }
+}
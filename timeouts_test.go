@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateTimeouts(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  TimeoutsConfig
+		wantErr bool
+	}{
+		{"defaults", TimeoutsConfig{}, false},
+		{"slow connection", TimeoutsConfig{AlgoliaSeconds: 90, OpenGraphRequestSeconds: 30, OpenGraphSeconds: 45, RunDeadlineSeconds: 900}, false},
+		{"negative", TimeoutsConfig{AlgoliaSeconds: -1}, true},
+		{"too large", TimeoutsConfig{OpenGraphSeconds: 7200}, true},
+		{"negative deadline", TimeoutsConfig{RunDeadlineSeconds: -5}, true},
+		{"request longer than fetch", TimeoutsConfig{OpenGraphRequestSeconds: 20}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTimeouts(tc.config)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResolveTimeouts(t *testing.T) {
+	var mapper *CategoryMapper
+	defaults := mapper.Timeouts()
+	if defaults.Algolia != 30*time.Second || defaults.OpenGraphRequest != 10*time.Second || defaults.OpenGraph != 15*time.Second || defaults.RunDeadline != 0 {
+		t.Errorf("Unexpected defaults: %+v", defaults)
+	}
+
+	mapper = NewCategoryMapper(&DomainConfig{Timeouts: TimeoutsConfig{AlgoliaSeconds: 5, RunDeadlineSeconds: 600}})
+	got := mapper.Timeouts()
+	if got.Algolia != 5*time.Second || got.OpenGraph != 15*time.Second || got.RunDeadline != 10*time.Minute {
+		t.Errorf("Unexpected resolved timeouts: %+v", got)
+	}
+}
+
+func TestConfigFetchTimeout(t *testing.T) {
+	t.Setenv(configTimeoutEnv, "")
+	if got := configFetchTimeout(); got != defaultConfigTimeout {
+		t.Errorf("Expected default %v, got %v", defaultConfigTimeout, got)
+	}
+
+	t.Setenv(configTimeoutEnv, "45s")
+	if got := configFetchTimeout(); got != 45*time.Second {
+		t.Errorf("Expected 45s, got %v", got)
+	}
+
+	for _, invalid := range []string{"soon", "-3s", "0s"} {
+		t.Setenv(configTimeoutEnv, invalid)
+		if got := configFetchTimeout(); got != defaultConfigTimeout {
+			t.Errorf("Expected default for %q, got %v", invalid, got)
+		}
+	}
+}
+
+func TestLoadConfig_InvalidTimeoutsFallBackToDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"timeouts": {"algolia_seconds": -10, "opengraph_seconds": 60}}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	mapper := LoadConfig(path, "")
+	if got := mapper.Timeouts(); got != defaultNetworkTimeouts() {
+		t.Errorf("Expected invalid timeouts to be replaced by defaults, got %+v", got)
+	}
+}
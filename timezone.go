@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+	_ "time/tzdata" // zone names resolve in minimal containers without a zoneinfo database
+)
+
+// displayLocation is the time zone used for dates shown to readers: posted-at times, digest days
+// and grouping by day, month or year. Stored timestamps are unaffected.
+var displayLocation = time.Local
+
+// addTimezoneFlag registers the -timezone flag on a flag set
+func addTimezoneFlag(fs *flag.FlagSet) *string {
+	return fs.String("timezone", "", "IANA time zone for displayed dates and day boundaries, e.g. Europe/Helsinki (defaults to $TZ or the system zone)")
+}
+
+// setupTimezone sets the display time zone, exiting on an unknown zone
+func setupTimezone(name string) {
+	if name == "" {
+		return
+	}
+	loc, err := loadDisplayLocation(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -timezone: %v\n", err)
+		os.Exit(2)
+	}
+	displayLocation = loc
+	slog.Debug("Using display time zone", "timezone", loc.String())
+}
+
+// loadDisplayLocation loads a time zone by IANA name; "Local" and "UTC" are accepted as well
+func loadDisplayLocation(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// localTime returns t in the display time zone
+func localTime(t time.Time) time.Time {
+	return t.In(displayLocation)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// useDisplayLocation sets the display time zone for the duration of a test
+func useDisplayLocation(t *testing.T, name string) {
+	t.Helper()
+	loc, err := loadDisplayLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := displayLocation
+	displayLocation = loc
+	t.Cleanup(func() { displayLocation = original })
+}
+
+func TestLoadDisplayLocation(t *testing.T) {
+	for _, name := range []string{"Europe/Helsinki", "UTC", "Local"} {
+		if _, err := loadDisplayLocation(name); err != nil {
+			t.Errorf("loadDisplayLocation(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := loadDisplayLocation("Mars/Olympus_Mons"); err == nil {
+		t.Error("Expected an error for an unknown zone")
+	}
+}
+
+func TestFeedEntry_PostedInDisplayZone(t *testing.T) {
+	useDisplayLocation(t, "Europe/Helsinki")
+
+	// 22:30 UTC is already the next day in Helsinki
+	item := HackerNewsItem{ItemID: "1", Title: "Late story", Link: "https://example.com", CommentsLink: "https://news.ycombinator.com/item?id=1",
+		Points: 100, CreatedAt: time.Date(2024, 1, 31, 22, 30, 0, 0, time.UTC)}
+	entry, _ := feedEntry(nil, item, 50, nil, nil, FeedFormat{})
+	if !strings.Contains(entry.Description, `<time datetime="2024-02-01T00:30:00+02:00" title="Posted 2024-02-01 00:30 EET"`) {
+		t.Errorf("Expected the posted time in EET, got %s", entry.Description)
+	}
+}
+
+func TestDayBoundariesInDisplayZone(t *testing.T) {
+	useDisplayLocation(t, "Europe/Helsinki")
+
+	start, end := yearBounds(2024)
+	if !start.Equal(time.Date(2023, 12, 31, 22, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 12, 31, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the year to start at Helsinki midnight, got %v – %v", start, end)
+	}
+
+	items := []HackerNewsItem{{ItemID: "1", Title: "Late story", Points: 100, CreatedAt: time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)}}
+	page, err := renderBestOf(items, nil, 2024, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "February") || strings.Contains(page, "January") {
+		t.Error("Expected the story grouped under its Helsinki month")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+)
+
+// titleData holds the fields available to entry title templates
+type titleData struct {
+	Title    string // story title, translated when translation is enabled
+	Points   int
+	Comments int
+	Domain   string // article domain without www., empty for text posts
+	Trend    string // ▲ or ▼ with the current points, e.g. "▲340"
+}
+
+// parseTitleTemplate compiles an entry title template, rejecting unknown fields
+func parseTitleTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("title").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Execute once against sample data so unknown fields fail at load time instead of per entry
+	if err := tmpl.Execute(&strings.Builder{}, titleData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderTitle renders an entry title through the template, falling back to the plain title on errors.
+// Runs of whitespace left by empty fields are collapsed.
+func renderTitle(tmpl *template.Template, data titleData) string {
+	if tmpl == nil {
+		return data.Title
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		slog.Debug("Failed to render title template", "title", data.Title, "error", err)
+		return data.Title
+	}
+	title := strings.Join(strings.Fields(b.String()), " ")
+	if title == "" {
+		return data.Title
+	}
+	return title
+}
+
+// trendMarker returns the current points prefixed with ▲ or ▼ when they changed since the previous run
+func trendMarker(points int, delta statsDelta, hasDelta bool) string {
+	switch {
+	case hasDelta && delta.Points > 0:
+		return fmt.Sprintf("▲%d", points)
+	case hasDelta && delta.Points < 0:
+		return fmt.Sprintf("▼%d", points)
+	}
+	return fmt.Sprintf("%d", points)
+}
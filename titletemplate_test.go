@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTitleTemplate(t *testing.T) {
+	if _, err := parseTitleTemplate("{{.Trend}} {{.Title}} ({{.Domain}})"); err != nil {
+		t.Errorf("Expected a valid template, got %v", err)
+	}
+	for _, text := range []string{"{{.Title", "{{.Karma}} {{.Title}}"} {
+		if _, err := parseTitleTemplate(text); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+
+	mapper := NewCategoryMapper(&DomainConfig{TitleTemplate: "{{.Nope}}"})
+	if mapper.TitleTemplate() != nil {
+		t.Error("Expected an invalid template to be ignored")
+	}
+}
+
+func TestRenderTitle(t *testing.T) {
+	tmpl, err := parseTitleTemplate("{{.Trend}} {{.Title}} {{if .Domain}}({{.Domain}}){{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		data     titleData
+		expected string
+	}{
+		{titleData{Title: "Rust 2.0", Domain: "example.com", Trend: "▲340"}, "▲340 Rust 2.0 (example.com)"},
+		{titleData{Title: "Ask HN: Why?", Trend: "80"}, "80 Ask HN: Why?"},
+	}
+	for _, tc := range testCases {
+		if got := renderTitle(tmpl, tc.data); got != tc.expected {
+			t.Errorf("renderTitle(%+v) = %q, expected %q", tc.data, got, tc.expected)
+		}
+	}
+
+	if got := renderTitle(nil, titleData{Title: "Plain"}); got != "Plain" {
+		t.Errorf("Expected the plain title without a template, got %q", got)
+	}
+}
+
+func TestTrendMarker(t *testing.T) {
+	testCases := []struct {
+		delta    statsDelta
+		hasDelta bool
+		expected string
+	}{
+		{statsDelta{Points: 45}, true, "▲340"},
+		{statsDelta{Points: -2}, true, "▼340"},
+		{statsDelta{}, true, "340"},
+		{statsDelta{}, false, "340"},
+	}
+	for _, tc := range testCases {
+		if got := trendMarker(340, tc.delta, tc.hasDelta); got != tc.expected {
+			t.Errorf("trendMarker(%+v, %v) = %q, expected %q", tc.delta, tc.hasDelta, got, tc.expected)
+		}
+	}
+}
+
+func TestFeedEntry_TitleTemplate(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	start := time.Now().Add(-time.Hour)
+	item := HackerNewsItem{
+		ItemID:       "42",
+		Title:        "Climbing",
+		Link:         "https://www.example.com/climbing",
+		CommentsLink: "https://news.ycombinator.com/item?id=42",
+		Points:       295,
+		CommentCount: 10,
+		CreatedAt:    start,
+		UpdatedAt:    start,
+	}
+	updateStoredItems(db, []HackerNewsItem{item})
+	recordStatsSnapshot(db, []HackerNewsItem{item}, start)
+	item.Points = 340
+	updateStoredItems(db, []HackerNewsItem{item})
+	recordStatsSnapshot(db, []HackerNewsItem{item}, start.Add(30*time.Minute))
+
+	mapper := NewCategoryMapper(&DomainConfig{TitleTemplate: "{{.Trend}} {{.Title}} ({{.Domain}})"})
+	entry, _ := feedEntry(db, item, 50, mapper, nil, FeedFormat{})
+	if entry.Title != "▲340 Climbing (example.com)" {
+		t.Errorf("Unexpected title: %q", entry.Title)
+	}
+
+	entry, _ = feedEntry(db, item, 50, nil, nil, FeedFormat{})
+	if entry.Title != "Climbing" || strings.Contains(entry.Title, "▲") {
+		t.Errorf("Expected a plain title without a template, got %q", entry.Title)
+	}
+}
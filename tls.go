@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeChallengePath is where ACME HTTP-01 challenge tokens are requested
+const acmeChallengePath = "/.well-known/acme-challenge/"
+
+// ACMEConfig enables HTTPS with automatic Let's Encrypt certificates in serve mode
+type ACMEConfig struct {
+	Domain   string `json:"domain"`    // host name certificates are requested for, e.g. hn.example.com
+	Email    string `json:"email"`     // contact address for expiry notices (optional)
+	CacheDir string `json:"cache_dir"` // where certificates and the account key are kept (default: acme in the data directory)
+}
+
+// acmeCacheDir returns the configured certificate cache, or acme in the data directory
+func acmeCacheDir(config ACMEConfig) string {
+	if config.CacheDir != "" {
+		return config.CacheDir
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "acme"
+	}
+	return filepath.Join(dir, "acme")
+}
+
+// newACMEManager returns a certificate manager that obtains and renews certificates for the
+// configured domain only. Certificates are cached on disk so restarts don't hit the rate limits.
+func newACMEManager(config ACMEConfig) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(config.Domain),
+		Cache:      autocert.DirCache(acmeCacheDir(config)),
+		Email:      config.Email,
+	}
+}
+
+// certReloader serves a certificate from disk and reloads it when the files change,
+// so renewals by an external ACME client are picked up without a restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// newCertReloader loads the initial certificate and key pair
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load returns the current certificate, re-reading it if either file was modified
+func (r *certReloader) load() (*tls.Certificate, error) {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && !modTime.After(r.modTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	if r.cert != nil {
+		slog.Info("Reloaded TLS certificate", "cert", r.certFile)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return r.cert, nil
+}
+
+// getCertificate implements tls.Config.GetCertificate. If reloading fails the
+// previously loaded certificate keeps being served.
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, err := r.load()
+	if err != nil {
+		slog.Warn("Failed to reload TLS certificate, serving previous one", "error", err)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.cert, nil
+	}
+	return cert, nil
+}
+
+// latestModTime returns the most recent modification time of the given files
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// newTLSConfig builds the TLS configuration for serving certificate files. When domain is set,
+// handshakes for other server names are rejected.
+func newTLSConfig(reloader *certReloader, domain string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if domain != "" && hello.ServerName != "" && !strings.EqualFold(hello.ServerName, domain) {
+				return nil, fmt.Errorf("unknown server name %q", hello.ServerName)
+			}
+			return reloader.getCertificate(hello)
+		},
+	}
+}
+
+// httpRedirectHandler answers ACME HTTP-01 challenges from webroot and redirects
+// everything else to HTTPS. An empty webroot disables challenge serving.
+func httpRedirectHandler(webroot, domain, httpsAddr string) http.Handler {
+	mux := http.NewServeMux()
+	if webroot != "" {
+		mux.Handle("GET "+acmeChallengePath, http.FileServer(http.Dir(webroot)))
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		host := domain
+		if host == "" {
+			host = r.Host
+		}
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if _, port, err := net.SplitHostPort(httpsAddr); err == nil && port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+	return mux
+}
+
+// serveACME serves handler over HTTPS with certificates from Let's Encrypt. The HTTP listener on
+// httpAddr answers HTTP-01 challenges and redirects everything else; without it, certificates
+// are obtained with the TLS-ALPN-01 challenge on the HTTPS port, which must then be 443.
+func serveACME(handler http.Handler, config ACMEConfig, addr, httpAddr string) {
+	manager := newACMEManager(config)
+	if httpAddr != "" {
+		go func() {
+			slog.Info("Starting HTTP redirect server", "addr", httpAddr)
+			if err := http.ListenAndServe(httpAddr, manager.HTTPHandler(httpRedirectHandler("", config.Domain, addr))); err != nil {
+				slog.Error("HTTP redirect server failed", "error", err)
+			}
+		}()
+	}
+
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	httpsServer := &http.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	slog.Info("Starting HTTPS server with Let's Encrypt certificates", "addr", addr, "domain", config.Domain, "cache", acmeCacheDir(config))
+	if err := httpsServer.ListenAndServeTLS("", ""); err != nil {
+		slog.Error("HTTPS server failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// writeTestCertificate writes a self-signed certificate for commonName and returns the file paths
+func writeTestCertificate(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "old.example.com")
+
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to create reloader: %v", err)
+	}
+	cert, _ := reloader.getCertificate(nil)
+	if name := leafCommonName(t, cert); name != "old.example.com" {
+		t.Fatalf("Expected initial certificate, got %s", name)
+	}
+
+	writeTestCertificate(t, dir, "new.example.com")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+
+	cert, _ = reloader.getCertificate(nil)
+	if name := leafCommonName(t, cert); name != "new.example.com" {
+		t.Errorf("Expected renewed certificate, got %s", name)
+	}
+
+	// A broken renewal keeps serving the previous certificate
+	_ = os.WriteFile(certFile, []byte("garbage"), 0600)
+	later := future.Add(time.Minute)
+	_ = os.Chtimes(certFile, later, later)
+	cert, err = reloader.getCertificate(nil)
+	if err != nil || cert == nil {
+		t.Fatalf("Expected previous certificate on reload failure, got %v", err)
+	}
+	if name := leafCommonName(t, cert); name != "new.example.com" {
+		t.Errorf("Expected previous certificate, got %s", name)
+	}
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	if _, err := newCertReloader("/nonexistent/cert.pem", "/nonexistent/key.pem"); err == nil {
+		t.Error("Expected error for missing certificate files")
+	}
+}
+
+func TestNewTLSConfig_DomainRestriction(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir(), "hn.example.com")
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to create reloader: %v", err)
+	}
+	config := newTLSConfig(reloader, "hn.example.com")
+
+	if _, err := config.GetCertificate(&tls.ClientHelloInfo{ServerName: "HN.example.com"}); err != nil {
+		t.Errorf("Expected configured domain to be accepted, got %v", err)
+	}
+	if _, err := config.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"}); err == nil {
+		t.Error("Expected other server names to be rejected")
+	}
+}
+
+func TestHTTPRedirectHandler(t *testing.T) {
+	webroot := t.TempDir()
+	challengeDir := filepath.Join(webroot, ".well-known", "acme-challenge")
+	if err := os.MkdirAll(challengeDir, 0755); err != nil {
+		t.Fatalf("Failed to create challenge dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(challengeDir, "token123"), []byte("token123.thumbprint"), 0600); err != nil {
+		t.Fatalf("Failed to write challenge: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		httpsAddr string
+		path      string
+		status    int
+		location  string
+		body      string
+	}{
+		{"challenge served", ":443", "/.well-known/acme-challenge/token123", http.StatusOK, "", "token123.thumbprint"},
+		{"redirect default port", ":443", "/feed.xml?min_points=100", http.StatusMovedPermanently, "https://hn.example.com/feed.xml?min_points=100", ""},
+		{"redirect custom port", ":8443", "/feed.xml", http.StatusMovedPermanently, "https://hn.example.com:8443/feed.xml", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://hn.example.com:80"+tc.path, nil)
+			httpRedirectHandler(webroot, "", tc.httpsAddr).ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, rec.Code)
+			}
+			if tc.location != "" && rec.Header().Get("Location") != tc.location {
+				t.Errorf("Expected Location %q, got %q", tc.location, rec.Header().Get("Location"))
+			}
+			if tc.body != "" && rec.Body.String() != tc.body {
+				t.Errorf("Expected body %q, got %q", tc.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewACMEManager(t *testing.T) {
+	cacheDir := t.TempDir()
+	manager := newACMEManager(ACMEConfig{Domain: "hn.example.com", Email: "ops@example.com", CacheDir: cacheDir})
+
+	if err := manager.HostPolicy(context.Background(), "hn.example.com"); err != nil {
+		t.Errorf("Expected the configured domain to be allowed, got %v", err)
+	}
+	if err := manager.HostPolicy(context.Background(), "other.example.com"); err == nil {
+		t.Error("Expected other host names to be rejected")
+	}
+	if cache, ok := manager.Cache.(autocert.DirCache); !ok || string(cache) != cacheDir {
+		t.Errorf("Expected the certificates cached in %s, got %v", cacheDir, manager.Cache)
+	}
+	if manager.Email != "ops@example.com" {
+		t.Errorf("Expected the contact email to be passed on, got %q", manager.Email)
+	}
+
+	// Requests that aren't challenges are redirected to HTTPS
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://hn.example.com/feed.xml", nil)
+	manager.HTTPHandler(httpRedirectHandler("", "hn.example.com", ":443")).ServeHTTP(rec, req)
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://hn.example.com/feed.xml" {
+		t.Errorf("Expected a redirect to HTTPS, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestACMECacheDir_Default(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if dir := acmeCacheDir(ACMEConfig{}); filepath.Base(dir) != "acme" || filepath.Base(filepath.Dir(dir)) != appName {
+		t.Errorf("Expected the cache in the data directory, got %s", dir)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultTopCommentLength is the excerpt length in characters when top_comment.length is not set
+const defaultTopCommentLength = 280
+
+// TopCommentConfig configures quoting the current top comment of each story
+type TopCommentConfig struct {
+	Enabled bool `json:"enabled"` // fetch and quote the top comment
+	Length  int  `json:"length"`  // excerpt length in characters (0 = 280)
+}
+
+// topComment is the cached top comment of a story and the comment count it was fetched at
+type topComment struct {
+	CommentID    string
+	Author       string
+	Text         string // plain text of the whole comment
+	CommentCount int
+	FetchedAt    time.Time
+}
+
+// fetchTopComment returns the highest ranked live top-level comment of a story, or nil if it has none.
+// The official API lists an item's kids in ranked order, the same order as on the HN page.
+func fetchTopComment(ctx context.Context, client *http.Client, itemID string) (*topComment, error) {
+	story, err := fetchHNAPIItem(ctx, client, itemID)
+	if err != nil {
+		return nil, err
+	}
+	for _, kid := range story.Kids {
+		commentID := fmt.Sprint(kid)
+		comment, err := fetchHNAPIItem(ctx, client, commentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch comment %s: %w", commentID, err)
+		}
+		if comment.Deleted || comment.Dead {
+			continue
+		}
+		if text := commentPlainText(comment.Text); text != "" {
+			return &topComment{CommentID: commentID, Author: comment.By, Text: text}, nil
+		}
+	}
+	return nil, nil
+}
+
+// refreshTopComments fetches the top comment of stories whose comment count changed since it was last fetched
+func refreshTopComments(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	if !categoryMapper.Config().TopComment.Enabled {
+		return
+	}
+
+	timeout := categoryMapper.Timeouts().Algolia
+	client := &http.Client{Timeout: timeout}
+	for _, item := range items {
+		if item.CommentCount == 0 {
+			continue
+		}
+		cached, err := getTopComment(db, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to load top comment", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if cached != nil && cached.CommentCount == item.CommentCount {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		var comment *topComment
+		err = errPanicked
+		safely("top comment fetch", func() {
+			comment, err = fetchTopComment(ctx, client, item.ItemID)
+		}, itemLogAttrs(item)...)
+		cancel()
+		if err != nil {
+			slog.Debug("Failed to fetch top comment", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if comment == nil {
+			continue
+		}
+
+		comment.CommentCount = item.CommentCount
+		comment.FetchedAt = time.Now()
+		if err := cacheTopComment(db, item.ItemID, comment); err != nil {
+			slog.Warn("Failed to cache top comment", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		slog.Debug("Fetched top comment", "hn_id", item.ItemID, "comment_id", comment.CommentID, "author", comment.Author)
+	}
+}
+
+// loadTopComment returns the cached top comment of an item for rendering, or nil if there is none
+func loadTopComment(db *sql.DB, item HackerNewsItem, categoryMapper *CategoryMapper) *topComment {
+	if db == nil || !categoryMapper.Config().TopComment.Enabled {
+		return nil
+	}
+	comment, err := getTopComment(db, item.ItemID)
+	if err != nil {
+		slog.Debug("Failed to load top comment", "hn_id", item.ItemID, "error", err)
+		return nil
+	}
+	return comment
+}
+
+// commentExcerpt shortens comment text to at most length characters, cutting at a word boundary
+func commentExcerpt(text string, length int) string {
+	if length <= 0 {
+		length = defaultTopCommentLength
+	}
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	cut := string(runes[:length-1])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+// renderTopComment quotes the top comment with a link to it and its author
+func renderTopComment(comment *topComment, length int, format FeedFormat) string {
+	if comment == nil {
+		return ""
+	}
+	return fmt.Sprintf(`<section aria-label="Top comment" style="margin-bottom: 16px;">
+				<h4 style="margin: 0 0 8px 0; color: #666; font-size: 14px;">%[1]sTop comment</h4>
+				<blockquote cite="https://news.ycombinator.com/item?id=%[2]s" style="margin: 0; padding: 8px 12px; border-left: 3px solid #ccc; color: #333; font-size: 13px; line-height: 1.4;">
+					<p style="margin: 0 0 6px 0;">%[3]s</p>
+					<footer style="color: #828282;">— <a href="https://news.ycombinator.com/item?id=%[2]s">%[4]s</a></footer>
+				</blockquote>
+			</section>`, format.icon("💭"), html.EscapeString(comment.CommentID), html.EscapeString(commentExcerpt(comment.Text, length)), html.EscapeString(comment.Author))
+}
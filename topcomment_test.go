@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTopCommentItems = map[string]string{
+	"700": `{"id": 700, "type": "story", "kids": [701, 702, 703]}`,
+	"701": `{"id": 701, "type": "comment", "deleted": true}`,
+	"702": `{"id": 702, "type": "comment", "by": "tptacek", "text": "This is <i>exactly</i> right &amp; well put.<p>Second paragraph."}`,
+	"703": `{"id": 703, "type": "comment", "by": "someone", "text": "Lower ranked"}`,
+}
+
+func TestRefreshTopComments(t *testing.T) {
+	requests := useTestHNAPI(t, testTopCommentItems)
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	now := time.Now()
+	item := HackerNewsItem{ItemID: "700", Title: "Story", Link: "https://example.com/story", CommentsLink: "https://news.ycombinator.com/item?id=700", Points: 100, CommentCount: 3, CreatedAt: now, UpdatedAt: now}
+	quiet := HackerNewsItem{ItemID: "800", Title: "No comments", Points: 100, CreatedAt: now, UpdatedAt: now}
+	mapper := NewCategoryMapper(&DomainConfig{TopComment: TopCommentConfig{Enabled: true}})
+
+	refreshTopComments(db, []HackerNewsItem{item, quiet}, mapper)
+	comment := loadTopComment(db, item, mapper)
+	if comment == nil || comment.CommentID != "702" || comment.Author != "tptacek" || comment.Text != "This is exactly right & well put. Second paragraph." {
+		t.Fatalf("Expected the first live comment, got %+v", comment)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected 3 requests (story and the first two comments), got %d", got)
+	}
+
+	// Unchanged stats don't trigger a refetch, a new comment count does
+	refreshTopComments(db, []HackerNewsItem{item}, mapper)
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected no requests for unchanged stats, got %d", got-3)
+	}
+	item.CommentCount = 10
+	refreshTopComments(db, []HackerNewsItem{item}, mapper)
+	if got := requests.Load(); got != 6 {
+		t.Errorf("Expected a refetch after the comment count changed, got %d requests", got)
+	}
+
+	entry, _ := feedEntry(db, item, 50, mapper, nil, FeedFormat{})
+	if !strings.Contains(entry.Description, `<p style="margin: 0 0 6px 0;">This is exactly right &amp; well put. Second paragraph.</p>`) ||
+		!strings.Contains(entry.Description, `— <a href="https://news.ycombinator.com/item?id=702">tptacek</a>`) {
+		t.Errorf("Expected the quoted top comment in the entry, got %s", entry.Description)
+	}
+
+	if comment := loadTopComment(db, item, nil); comment != nil {
+		t.Errorf("Expected no top comment unless enabled, got %+v", comment)
+	}
+}
+
+func TestCommentExcerpt(t *testing.T) {
+	testCases := []struct {
+		text     string
+		length   int
+		expected string
+	}{
+		{"Short comment", 20, "Short comment"},
+		{"The quick brown fox jumps over the lazy dog", 20, "The quick brown…"},
+		{"Supercalifragilisticexpialidocious words", 10, "Supercali…"},
+		{"Ünïcödé characters count as one", 8, "Ünïcödé…"},
+	}
+	for _, tc := range testCases {
+		if got := commentExcerpt(tc.text, tc.length); got != tc.expected {
+			t.Errorf("commentExcerpt(%q, %d) = %q, expected %q", tc.text, tc.length, got, tc.expected)
+		}
+	}
+	if got := commentExcerpt(strings.Repeat("a ", 200), 0); len([]rune(got)) > defaultTopCommentLength {
+		t.Errorf("Expected the default length to apply, got %d characters", len([]rune(got)))
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultTranslationTarget   = "en"
+	defaultTranslationMaxItems = 30
+	defaultTranslationTimeout  = 15 * time.Second
+	defaultDeepLURL            = "https://api-free.deepl.com"
+	llmSameLanguageReply       = "SAME"
+)
+
+// TranslationConfig configures the optional title translation enricher
+type TranslationConfig struct {
+	Provider       string `json:"provider"`        // "libretranslate", "deepl" or "llm" (uses the summarizer); empty disables
+	URL            string `json:"url"`             // API base URL; required for libretranslate
+	APIKey         string `json:"api_key"`         // API key for libretranslate or deepl
+	TargetLanguage string `json:"target_language"` // language code to translate into (default "en")
+	All            bool   `json:"all"`             // translate every title instead of only ones that look non-English
+	MaxItems       int    `json:"max_items"`       // new translations per run (0 = 30)
+}
+
+// translator translates text into a target language and reports the detected source language
+type translator interface {
+	Translate(ctx context.Context, text, target string) (translated, sourceLanguage string, err error)
+}
+
+// newTranslator creates the translator for the configured provider
+func newTranslator(config DomainConfig) (translator, error) {
+	t := config.Translation
+	client := &http.Client{Timeout: defaultTranslationTimeout}
+	switch t.Provider {
+	case "libretranslate":
+		if t.URL == "" {
+			return nil, fmt.Errorf("libretranslate requires a url")
+		}
+		return &libreTranslator{baseURL: strings.TrimSuffix(t.URL, "/"), apiKey: t.APIKey, client: client}, nil
+	case "deepl":
+		if t.APIKey == "" {
+			return nil, fmt.Errorf("deepl requires an api_key")
+		}
+		baseURL := t.URL
+		if baseURL == "" {
+			baseURL = defaultDeepLURL
+		}
+		return &deepLTranslator{baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: t.APIKey, client: client}, nil
+	case "llm":
+		llm, err := newSummarizer(config.Summarizer)
+		if err != nil {
+			return nil, fmt.Errorf("llm translation needs a configured summarizer: %w", err)
+		}
+		return &llmTranslator{llm: llm}, nil
+	default:
+		return nil, fmt.Errorf("unknown translation provider: %q", t.Provider)
+	}
+}
+
+// libreTranslator uses a LibreTranslate server
+type libreTranslator struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// Translate implements translator
+func (l *libreTranslator) Translate(ctx context.Context, text, target string) (string, string, error) {
+	var result struct {
+		TranslatedText   string `json:"translatedText"`
+		DetectedLanguage struct {
+			Language string `json:"language"`
+		} `json:"detectedLanguage"`
+	}
+	request := map[string]string{"q": text, "source": "auto", "target": target, "format": "text"}
+	if l.apiKey != "" {
+		request["api_key"] = l.apiKey
+	}
+	if err := postJSON(ctx, l.client, l.baseURL+"/translate", nil, request, &result); err != nil {
+		return "", "", fmt.Errorf("libretranslate request failed: %w", err)
+	}
+	return strings.TrimSpace(result.TranslatedText), result.DetectedLanguage.Language, nil
+}
+
+// deepLTranslator uses the DeepL API
+type deepLTranslator struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// Translate implements translator
+func (d *deepLTranslator) Translate(ctx context.Context, text, target string) (string, string, error) {
+	var result struct {
+		Translations []struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		} `json:"translations"`
+	}
+	request := map[string]any{"text": []string{text}, "target_lang": strings.ToUpper(target)}
+	headers := map[string]string{"Authorization": "DeepL-Auth-Key " + d.apiKey}
+	if err := postJSON(ctx, d.client, d.baseURL+"/v2/translate", headers, request, &result); err != nil {
+		return "", "", fmt.Errorf("deepl request failed: %w", err)
+	}
+	if len(result.Translations) == 0 {
+		return "", "", fmt.Errorf("no translations in response")
+	}
+	translation := result.Translations[0]
+	return strings.TrimSpace(translation.Text), strings.ToLower(translation.DetectedSourceLanguage), nil
+}
+
+// llmTranslator asks the configured LLM to translate
+type llmTranslator struct {
+	llm summarizer
+}
+
+// Translate implements translator. The model replies with a marker when the text
+// is already in the target language, which is reported as the source language.
+func (l *llmTranslator) Translate(ctx context.Context, text, target string) (string, string, error) {
+	prompt := fmt.Sprintf("Translate this Hacker News title into the language with code %q. Reply with the translation only. If it is already in that language, reply with exactly %s.\n\n%s", target, llmSameLanguageReply, text)
+	reply, _, err := l.llm.Complete(ctx, prompt)
+	if err != nil {
+		return "", "", err
+	}
+	if reply == llmSameLanguageReply {
+		return "", target, nil
+	}
+	return strings.Trim(reply, "\"“” "), "", nil
+}
+
+// looksEnglish reports whether a title contains no non-ASCII letters, which is used as a
+// cheap guess that it does not need translating into English
+func looksEnglish(title string) bool {
+	for _, r := range title {
+		if unicode.IsLetter(r) && r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// translationTarget returns the configured target language
+func translationTarget(config TranslationConfig) string {
+	if config.TargetLanguage == "" {
+		return defaultTranslationTarget
+	}
+	return strings.ToLower(config.TargetLanguage)
+}
+
+// translateTitles translates item titles that don't have a cached translation yet.
+// It does nothing unless a translation provider is configured.
+func translateTitles(db *sql.DB, items []HackerNewsItem, categoryMapper *CategoryMapper) {
+	fullConfig := categoryMapper.Config()
+	config := fullConfig.Translation
+	if config.Provider == "" {
+		return
+	}
+
+	t, err := newTranslator(fullConfig)
+	if err != nil {
+		slog.Warn("Invalid translation configuration, skipping translations", "error", err)
+		return
+	}
+
+	target := translationTarget(config)
+	maxItems := config.MaxItems
+	if maxItems <= 0 {
+		maxItems = defaultTranslationMaxItems
+	}
+
+	translated := 0
+	for _, item := range items {
+		if translated >= maxItems {
+			slog.Debug("Translation limit reached for this run", "limit", maxItems)
+			break
+		}
+		if !config.All && target == defaultTranslationTarget && looksEnglish(item.Title) {
+			continue
+		}
+		if _, found, err := getTranslation(db, item.Title, target); err != nil || found {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTranslationTimeout)
+		var text, source string
+		err := errPanicked
+		safely("title translation", func() {
+			text, source, err = t.Translate(ctx, item.Title, target)
+		}, itemLogAttrs(item)...)
+		cancel()
+		if err != nil {
+			slog.Debug("Failed to translate title", "title", item.Title, "error", err)
+			continue
+		}
+
+		// Titles already in the target language are cached with an empty translation
+		if strings.EqualFold(source, target) || strings.EqualFold(text, item.Title) {
+			text = ""
+		}
+		if err := cacheTranslation(db, item.Title, target, text, source); err != nil {
+			slog.Warn("Failed to cache translation", "title", item.Title, "error", err)
+			continue
+		}
+		translated++
+		slog.Debug("Translated title", "title", item.Title, "translation", text, "source", source)
+	}
+}
+
+// translatedTitle returns the title to show for an item and the original title if it was
+// replaced. Without "all", translations are appended to the original title instead.
+func translatedTitle(db *sql.DB, title string, categoryMapper *CategoryMapper) (string, string) {
+	config := categoryMapper.Config().Translation
+	if db == nil || config.Provider == "" {
+		return title, ""
+	}
+
+	translation, _, err := getTranslation(db, title, translationTarget(config))
+	if err != nil {
+		slog.Debug("Failed to load translation", "title", title, "error", err)
+	}
+	if translation == "" {
+		return title, ""
+	}
+	if config.All {
+		return translation, title
+	}
+	return title + " (" + translation + ")", ""
+}
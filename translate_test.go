@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLooksEnglish(t *testing.T) {
+	testCases := []struct {
+		title    string
+		expected bool
+	}{
+		{"Show HN: A new database", true},
+		{"Rust 1.80 – what's new", true},
+		{"Pääkaupunkiseudun liikenne uudistuu", false},
+		{"日本語のタイトル", false},
+		{"Über die Sprache", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			if got := looksEnglish(tc.title); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// newLibreTranslateServer fakes LibreTranslate, translating the known German title
+func newLibreTranslateServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["source"] != "auto" || req["target"] != "en" || req["api_key"] != "key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		switch req["q"] {
+		case "Über die Sprache":
+			_, _ = w.Write([]byte(`{"translatedText": "About the language", "detectedLanguage": {"language": "de", "confidence": 90}}`))
+		default:
+			_, _ = w.Write([]byte(`{"translatedText": "` + req["q"] + `", "detectedLanguage": {"language": "en", "confidence": 90}}`))
+		}
+	}))
+}
+
+func TestTranslateTitles_AppendsTranslation(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var calls atomic.Int32
+	server := newLibreTranslateServer(t, &calls)
+	defer server.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{Translation: TranslationConfig{Provider: "libretranslate", URL: server.URL, APIKey: "key"}})
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "Über die Sprache"},
+		{ItemID: "2", Title: "An English title"},
+		{ItemID: "3", Title: "Café culture"},
+	}
+
+	translateTitles(db, items, mapper)
+	if calls.Load() != 2 {
+		t.Fatalf("Expected only non-ASCII titles to be translated, got %d calls", calls.Load())
+	}
+
+	if title, original := translatedTitle(db, "Über die Sprache", mapper); title != "Über die Sprache (About the language)" || original != "" {
+		t.Errorf("Unexpected translated title: %q (original %q)", title, original)
+	}
+	if title, _ := translatedTitle(db, "Café culture", mapper); title != "Café culture" {
+		t.Errorf("Expected English title with accents to stay unchanged, got %q", title)
+	}
+
+	// Cached results, including "already English", are not requested again
+	translateTitles(db, items, mapper)
+	if calls.Load() != 2 {
+		t.Errorf("Expected cached translations to be reused, got %d calls", calls.Load())
+	}
+}
+
+func TestTranslateTitles_AllReplacesTitle(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var calls atomic.Int32
+	server := newLibreTranslateServer(t, &calls)
+	defer server.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{Translation: TranslationConfig{Provider: "libretranslate", URL: server.URL, APIKey: "key", All: true}})
+	item := HackerNewsItem{ItemID: "1", Title: "Über die Sprache", Link: "https://example.de/a", CommentsLink: "https://news.ycombinator.com/item?id=1", Points: 100, CreatedAt: time.Now()}
+	translateTitles(db, []HackerNewsItem{item, {ItemID: "2", Title: "Plain English"}}, mapper)
+	if calls.Load() != 2 {
+		t.Errorf("Expected every title to be sent in all mode, got %d calls", calls.Load())
+	}
+
+	if err := cacheOpenGraphData(db, &OpenGraphData{URL: item.Link}, false); err != nil {
+		t.Fatalf("Failed to seed OpenGraph cache: %v", err)
+	}
+	rss := generateRSSFeed(db, []HackerNewsItem{item}, 50, mapper)
+	if !strings.Contains(rss, "<title>About the language</title>") {
+		t.Error("Expected translated entry title")
+	}
+	if !strings.Contains(rss, "Original title:") {
+		t.Error("Expected original title in the entry description")
+	}
+}
+
+func TestDeepLTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/translate" || r.Header.Get("Authorization") != "DeepL-Auth-Key secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var req struct {
+			Text       []string `json:"text"`
+			TargetLang string   `json:"target_lang"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.TargetLang != "FI" || len(req.Text) != 1 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"translations":[{"detected_source_language":"EN","text":"Hei maailma"}]}`))
+	}))
+	defer server.Close()
+
+	tr, err := newTranslator(DomainConfig{Translation: TranslationConfig{Provider: "deepl", URL: server.URL, APIKey: "secret"}})
+	if err != nil {
+		t.Fatalf("Failed to create translator: %v", err)
+	}
+	text, source, err := tr.Translate(t.Context(), "Hello world", "fi")
+	if err != nil || text != "Hei maailma" || source != "en" {
+		t.Errorf("Unexpected result: %q %q %v", text, source, err)
+	}
+}
+
+func TestLLMTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		reply := "\"Ein Titel\""
+		if strings.HasSuffix(req.Messages[0].Content, "Schon Deutsch") {
+			reply = llmSameLanguageReply
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"choices": []any{map[string]any{"message": map[string]string{"content": reply}}}})
+	}))
+	defer server.Close()
+
+	tr, err := newTranslator(DomainConfig{
+		Summarizer:  SummarizerConfig{Provider: "openai", URL: server.URL, Model: "m"},
+		Translation: TranslationConfig{Provider: "llm"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create translator: %v", err)
+	}
+
+	if text, _, err := tr.Translate(t.Context(), "A title", "de"); err != nil || text != "Ein Titel" {
+		t.Errorf("Expected unquoted translation, got %q (err %v)", text, err)
+	}
+	if text, source, _ := tr.Translate(t.Context(), "Schon Deutsch", "de"); text != "" || source != "de" {
+		t.Errorf("Expected same-language marker to report the target language, got %q %q", text, source)
+	}
+}
+
+func TestNewTranslator_Errors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config DomainConfig
+	}{
+		{"libretranslate without url", DomainConfig{Translation: TranslationConfig{Provider: "libretranslate"}}},
+		{"deepl without key", DomainConfig{Translation: TranslationConfig{Provider: "deepl"}}},
+		{"llm without summarizer", DomainConfig{Translation: TranslationConfig{Provider: "llm"}}},
+		{"unknown provider", DomainConfig{Translation: TranslationConfig{Provider: "babelfish"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := newTranslator(tc.config); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
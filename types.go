@@ -4,15 +4,60 @@ import "time"
 
 // HackerNewsItem represents a single Hacker News story with metadata
 type HackerNewsItem struct {
-	ItemID       string
-	Title        string
-	Link         string
-	CommentsLink string
-	Points       int
-	CommentCount int
-	Author       string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ItemID          string
+	Title           string
+	Link            string
+	CommentsLink    string
+	Points          int
+	CommentCount    int
+	Author          string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	ReturnedAt      time.Time        // when the item came back to the front page, zero if it never left
+	PinnedAt        time.Time        // when the item was manually added to the feed, zero if it wasn't
+	CanonicalURL    string           // article URL after redirects and rel=canonical, empty until resolved
+	Type            StoryType        // from the Algolia tags, empty for items stored before types were recorded
+	AuthorKarma     int              // submitter karma, when author enrichment is enabled
+	AuthorCreatedAt time.Time        // when the submitter's account was created, zero if unknown
+	DeadAt          time.Time        // when the item died or was flagged, zero while it is alive
+	Duplicates      []HackerNewsItem // other submissions of the same article URL
+}
+
+// ItemFilter holds the criteria used to select items from the database for the feed
+type ItemFilter struct {
+	Limit     int
+	MinPoints int
+	MinAge    time.Duration // items younger than this are held back until they stabilize
+	MaxAge    time.Duration // items older than this are dropped from the feed entirely
+
+	BlockedAuthors []string // submitters whose items are left out, see withAuthorLists
+	AllowedAuthors []string // when set, only items from these submitters are included
+}
+
+// FeedProfile is a named set of filters exposed as a personalized feed at /feed/<token>.xml
+type FeedProfile struct {
+	Token      string
+	Name       string
+	Keywords   []string // title keywords, any of which must match
+	Categories []string // categories to include, any of which must match
+	Exclude    []string // categories to exclude
+	MinPoints  int      // 0 uses the server default
+	Format     FeedFormat
+	CreatedAt  time.Time
+}
+
+// RunRecord describes a single fetch/update run
+type RunRecord struct {
+	ID         int64
+	Source     string // what triggered the run: cli, serve or admin
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Fetched    int // items returned by the front page query
+	Updated    int // items added or updated in the database
+	FeedItems  int // items written to the generated feed
+	Error      string
+	// FetchErrors counts failed OpenGraph and API fetches during the run by "source/class"
+	FetchErrors map[string]int
 }
 
 // AlgoliaResponse represents the response structure from Algolia API
@@ -22,13 +67,14 @@ type AlgoliaResponse struct {
 
 // AlgoliaHit represents a single hit from Algolia search results
 type AlgoliaHit struct {
-	ObjectID    string `json:"objectID"`
-	Title       string `json:"title"`
-	URL         string `json:"url"`
-	Author      string `json:"author"`
-	Points      int    `json:"points"`
-	NumComments int    `json:"num_comments"`
-	CreatedAt   string `json:"created_at"`
+	ObjectID    string   `json:"objectID"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Author      string   `json:"author"`
+	Points      int      `json:"points"`
+	NumComments int      `json:"num_comments"`
+	CreatedAt   string   `json:"created_at"`
+	Tags        []string `json:"_tags"` // e.g. story, show_hn, ask_hn, poll, job, author_pg, story_123
 }
 
 // statsUpdate represents the result of updating an item's statistics
@@ -42,11 +88,13 @@ type statsUpdate struct {
 
 // OpenGraphData represents extracted OpenGraph metadata from a webpage
 type OpenGraphData struct {
-	URL         string
-	Title       string
-	Description string
-	Image       string
-	SiteName    string
+	URL          string
+	Title        string
+	Description  string
+	Image        string
+	SiteName     string
+	FinalURL     string // URL the fetch ended up at after redirects
+	CanonicalURL string // rel=canonical of the page, or FinalURL when it has none
 }
 
 // OpenGraphCache represents cached OpenGraph data in the database
@@ -57,7 +105,10 @@ type OpenGraphCache struct {
 	Description  string
 	Image        string
 	SiteName     string
+	FinalURL     string
+	CanonicalURL string
 	FetchedAt    time.Time
 	ExpiresAt    time.Time
 	FetchSuccess bool
+	ErrorClass   fetchErrorClass // why the fetch failed, when FetchSuccess is false
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// uploadTimeout bounds uploading the output directory after a run
+	uploadTimeout = 5 * time.Minute
+	// uploadManifestFile records what was last uploaded, so unchanged files are skipped
+	uploadManifestFile = ".upload-manifest.json"
+	// defaultUploadCacheControl lets caches and CDNs keep feeds for a few minutes
+	defaultUploadCacheControl = "public, max-age=300"
+)
+
+// UploadConfig configures publishing the output directory to static hosting after each run
+type UploadConfig struct {
+	Destination     string `json:"destination"`       // s3://bucket/prefix, sftp://user@host:port/path or webdav+https://host/path; empty disables
+	Endpoint        string `json:"endpoint"`          // S3-compatible endpoint such as https://<account>.r2.cloudflarestorage.com (default AWS)
+	Region          string `json:"region"`            // S3 region (default $AWS_REGION or us-east-1)
+	AccessKeyID     string `json:"access_key_id"`     // defaults to $AWS_ACCESS_KEY_ID
+	SecretAccessKey string `json:"secret_access_key"` // defaults to $AWS_SECRET_ACCESS_KEY
+	CacheControl    string `json:"cache_control"`     // Cache-Control of uploaded files (default "public, max-age=300")
+	SSHKey          string `json:"ssh_key"`           // SFTP private key (default ~/.ssh/id_ed25519, id_ecdsa or id_rsa)
+	KnownHosts      string `json:"known_hosts"`       // SFTP known_hosts file (default ~/.ssh/known_hosts)
+	Username        string `json:"username"`          // WebDAV basic auth user
+	Password        string `json:"password"`          // WebDAV password, defaults to $HNTOP_WEBDAV_PASSWORD
+}
+
+// uploadTarget stores output files under a path relative to the destination. Targets holding a
+// connection also implement io.Closer.
+type uploadTarget interface {
+	upload(ctx context.Context, key, file, contentType, cacheControl string) error
+}
+
+// s3UploadTarget uploads output files to an S3 bucket under a key prefix
+type s3UploadTarget struct {
+	client *s3Client
+	bucket string
+	prefix string
+}
+
+// upload stores the file as prefix/key
+func (s *s3UploadTarget) upload(ctx context.Context, key, file, contentType, cacheControl string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return s.client.putObject(ctx, s.bucket, path.Join(s.prefix, key), f, info.Size(), contentType, cacheControl)
+}
+
+// newUploadTarget creates the target for an upload destination
+func newUploadTarget(config UploadConfig) (uploadTarget, error) {
+	destination, err := url.Parse(config.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination: %w", err)
+	}
+	switch destination.Scheme {
+	case "s3":
+		if destination.Host == "" {
+			return nil, fmt.Errorf("destination %q has no bucket", config.Destination)
+		}
+		client, err := newS3Client(config.Endpoint, config.Region, config.AccessKeyID, config.SecretAccessKey)
+		if err != nil {
+			return nil, err
+		}
+		return &s3UploadTarget{client: client, bucket: destination.Host, prefix: strings.Trim(destination.Path, "/")}, nil
+	case "sftp":
+		return newSFTPUploadTarget(destination, config)
+	case "webdav+https", "webdav+http":
+		return newWebDAVUploadTarget(destination, config)
+	default:
+		return nil, fmt.Errorf("unsupported destination %q, expected s3://, sftp:// or webdav+https://", config.Destination)
+	}
+}
+
+// uploadContentTypes are the content types of the files the generator writes
+var uploadContentTypes = map[string]string{
+	".xml":  "application/atom+xml; charset=utf-8",
+	".xsl":  "text/xsl; charset=utf-8",
+	".opml": "text/x-opml; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".json": "application/json; charset=utf-8",
+	".gz":   "application/gzip",
+}
+
+// uploadContentType returns the content type a file is uploaded with. Static hosts can't vary
+// it by Accept header like serve mode does, so with a stylesheet feeds are served as plain XML
+// for browsers to apply it; feed readers accept either.
+func uploadContentType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".xml" && feedStylesheet != "" {
+		return "application/xml; charset=utf-8"
+	}
+	if contentType, ok := uploadContentTypes[ext]; ok {
+		return contentType
+	}
+	return cmp.Or(mime.TypeByExtension(ext), "application/octet-stream")
+}
+
+// uploadManifest maps the relative path of each uploaded file to its SHA-256, for one destination
+type uploadManifest struct {
+	Destination string            `json:"destination"`
+	Files       map[string]string `json:"files"`
+}
+
+// loadUploadManifest reads the manifest of the last upload, or an empty one if it is missing,
+// unreadable or for another destination
+func loadUploadManifest(outDir, destination string) uploadManifest {
+	manifest := uploadManifest{Destination: destination, Files: make(map[string]string)}
+	data, err := os.ReadFile(filepath.Join(outDir, uploadManifestFile))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("Failed to read upload manifest, uploading everything", "error", err)
+		}
+		return manifest
+	}
+	var stored uploadManifest
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Destination != destination || stored.Files == nil {
+		return manifest
+	}
+	return stored
+}
+
+// fileSHA256 returns the hex SHA-256 of a file
+func fileSHA256(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// uploadOutputFiles uploads the files of outDir that changed since the last upload. Hidden files,
+// such as the manifest and temporary files, are skipped. The manifest is saved even when an
+// upload fails, so the files that made it aren't uploaded again and the rest are retried next run.
+func uploadOutputFiles(ctx context.Context, outDir string, target uploadTarget, config UploadConfig) (int, error) {
+	manifest := loadUploadManifest(outDir, config.Destination)
+	cacheControl := cmp.Or(config.CacheControl, defaultUploadCacheControl)
+
+	uploaded := 0
+	seen := make(map[string]bool)
+	walkErr := filepath.WalkDir(outDir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && file != outDir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(outDir, file)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		seen[key] = true
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", key, err)
+		}
+		if manifest.Files[key] == sum {
+			return nil
+		}
+		if err := target.upload(ctx, key, file, uploadContentType(key), cacheControl); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		manifest.Files[key] = sum
+		uploaded++
+		return nil
+	})
+
+	if walkErr == nil {
+		// Files removed locally, such as pages of a shorter feed, stay in the bucket but leave the manifest
+		for key := range manifest.Files {
+			if !seen[key] {
+				delete(manifest.Files, key)
+			}
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(outDir, uploadManifestFile), append(data, '\n'), 0644)
+	}
+	if err != nil {
+		slog.Warn("Failed to save upload manifest", "error", err)
+	}
+	return uploaded, walkErr
+}
+
+// uploadOutput publishes the output directory to the configured destination
+func uploadOutput(outDir string, config UploadConfig) error {
+	if config.Destination == "" {
+		return nil
+	}
+	target, err := newUploadTarget(config)
+	if err != nil {
+		return fmt.Errorf("invalid upload destination: %w", err)
+	}
+	if closer, ok := target.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+	uploaded, err := uploadOutputFiles(ctx, outDir, target, config)
+	if err != nil {
+		return err
+	}
+	slog.Info("Output uploaded", "destination", config.Destination, "files", uploaded)
+	return nil
+}
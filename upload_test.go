@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 records the objects uploaded to it, failing uploads of keys listed in fail
+type fakeS3 struct {
+	mu      sync.Mutex
+	puts    []string
+	headers map[string]http.Header
+	fail    map[string]bool
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail[r.URL.Path] {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.puts = append(f.puts, r.URL.Path)
+	f.headers[r.URL.Path] = r.Header.Clone()
+}
+
+func writeOutputFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUploadOutput_S3(t *testing.T) {
+	s3 := &fakeS3{headers: make(map[string]http.Header), fail: make(map[string]bool)}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	outDir := t.TempDir()
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed/>")
+	writeOutputFile(t, outDir, "feeds.opml", "<opml/>")
+	writeOutputFile(t, outDir, "archive/2026-10.xml", "<feed/>")
+	writeOutputFile(t, outDir, ".hackernews.xml.tmp", "partial")
+	config := UploadConfig{Destination: "s3://site/hn/", Endpoint: server.URL, AccessKeyID: "key", SecretAccessKey: "secret"}
+
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+	slices.Sort(s3.puts)
+	if strings.Join(s3.puts, ",") != "/site/hn/archive/2026-10.xml,/site/hn/feeds.opml,/site/hn/hackernews.xml" {
+		t.Fatalf("Unexpected uploads %v", s3.puts)
+	}
+	feed := s3.headers["/site/hn/hackernews.xml"]
+	if feed.Get("Content-Type") != "application/atom+xml; charset=utf-8" || feed.Get("Cache-Control") != defaultUploadCacheControl {
+		t.Errorf("Unexpected feed headers %v", feed)
+	}
+	if got := s3.headers["/site/hn/feeds.opml"].Get("Content-Type"); got != "text/x-opml; charset=utf-8" {
+		t.Errorf("Unexpected OPML content type %q", got)
+	}
+
+	// Only changed files are uploaded again
+	s3.puts = nil
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed><entry/></feed>")
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+	if strings.Join(s3.puts, ",") != "/site/hn/hackernews.xml" {
+		t.Errorf("Expected only the changed feed to be uploaded, got %v", s3.puts)
+	}
+
+	// A failed upload is retried on the next run, while the files that made it are not
+	s3.puts = nil
+	s3.fail["/site/hn/hackernews.xml"] = true
+	writeOutputFile(t, outDir, "feeds.opml", "<opml><body/></opml>")
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed></feed>")
+	if err := uploadOutput(outDir, config); err == nil {
+		t.Fatal("Expected the rejected upload to fail")
+	}
+	delete(s3.fail, "/site/hn/hackernews.xml")
+	s3.puts = nil
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+	if strings.Join(s3.puts, ",") != "/site/hn/hackernews.xml" {
+		t.Errorf("Expected only the failed file to be retried, got %v", s3.puts)
+	}
+
+	// Another destination gets everything
+	s3.puts = nil
+	config.Destination = "s3://other"
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+	if len(s3.puts) != 3 {
+		t.Errorf("Expected a new destination to get every file, got %v", s3.puts)
+	}
+}
+
+func TestUploadContentType(t *testing.T) {
+	testCases := map[string]string{
+		"hackernews.xml":    "application/atom+xml; charset=utf-8",
+		"hackernews.xml.gz": "application/gzip",
+		"feed.xsl":          "text/xsl; charset=utf-8",
+		"best-of-2025.html": "text/html; charset=utf-8",
+		"unknown.bin":       "application/octet-stream",
+	}
+	for name, expected := range testCases {
+		if got := uploadContentType(name); got != expected {
+			t.Errorf("uploadContentType(%q) = %q, want %q", name, got, expected)
+		}
+	}
+
+	useFeedStylesheet(t, "feed.xsl")
+	if got := uploadContentType("hackernews.xml"); got != "application/xml; charset=utf-8" {
+		t.Errorf("Expected feeds to be plain XML with a stylesheet, got %q", got)
+	}
+}
+
+func TestNewUploadTarget_Invalid(t *testing.T) {
+	for _, destination := range []string{"s3:///prefix", "ftp://host/dir"} {
+		if _, err := newUploadTarget(UploadConfig{Destination: destination, AccessKeyID: "k", SecretAccessKey: "s"}); err == nil {
+			t.Errorf("Expected %q to be rejected", destination)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxHostnameLength = 253
+	maxLabelLength    = 63
+)
+
+// normalizeArticleURL validates an article URL and returns its canonical form: only http and https
+// are allowed, the host is lowercased and converted to punycode, and any fragment is removed
+func normalizeArticleURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("empty URL")
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme %q", parsed.Scheme)
+	}
+	if parsed.Opaque != "" {
+		return "", fmt.Errorf("URL has no host")
+	}
+
+	host, err := normalizeHostname(parsed.Hostname())
+	if err != nil {
+		return "", err
+	}
+	if port := parsed.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	parsed.Host = host
+	parsed.User = nil
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String(), nil
+}
+
+// normalizeHostname lowercases a hostname and converts internationalized labels to punycode
+// with the IDNA lookup profile, which also rejects characters hostnames can't contain.
+// IP addresses are returned unchanged.
+func normalizeHostname(hostname string) (string, error) {
+	hostname = strings.TrimSuffix(hostname, ".")
+	if hostname == "" {
+		return "", fmt.Errorf("URL has no host")
+	}
+	if net.ParseIP(hostname) != nil {
+		return strings.ToLower(hostname), nil
+	}
+
+	host, err := idna.Lookup.ToASCII(hostname)
+	if err != nil {
+		return "", fmt.Errorf("invalid host %q: %w", hostname, err)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" {
+			return "", fmt.Errorf("invalid host %q: empty label", hostname)
+		}
+		if len(label) > maxLabelLength {
+			return "", fmt.Errorf("invalid host %q: label too long", hostname)
+		}
+	}
+	if len(host) > maxHostnameLength {
+		return "", fmt.Errorf("invalid host %q: too long", hostname)
+	}
+	return host, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// watchlistScanLimit bounds how many stored items are searched for watchlist matches
+const watchlistScanLimit = 1000
+
+// watchlistFeedInfo describes the watchlist-only feed
+var watchlistFeedInfo = feedInfo{
+	Title:       "Hacker News Watchlist",
+	Description: "Hacker News stories matching the watchlist, regardless of score",
+	ID:          "tag:news.ycombinator.com,2024:watchlist",
+}
+
+// getWatchlistItems returns stored items matching the watchlist, ignoring the points
+// threshold and minimum age so matches show up as soon as they are fetched
+func getWatchlistItems(db *sql.DB, filter ItemFilter, categoryMapper *CategoryMapper) ([]HackerNewsItem, error) {
+	items, err := getFilteredItems(db, ItemFilter{Limit: watchlistScanLimit, MaxAge: filter.MaxAge})
+	if err != nil {
+		return nil, err
+	}
+	var matched []HackerNewsItem
+	for _, item := range items {
+		if len(categoryMapper.MatchWatchlist(item.Title, item.Link)) == 0 {
+			continue
+		}
+		matched = append(matched, item)
+		if len(matched) == filter.Limit {
+			break
+		}
+	}
+	return collapseDuplicateSubmissions(matched), nil
+}
+
+// alertWatchlistMatches notifies about fetched items matching the watchlist.
+// Each item is alerted at most once, no matter how often it is fetched again.
+func alertWatchlistMatches(db *sql.DB, items []HackerNewsItem, minPoints int, categoryMapper *CategoryMapper) {
+	notifiers := categoryMapper.Notifiers()
+	for _, item := range items {
+		matches := categoryMapper.MatchWatchlist(item.Title, item.Link)
+		if len(matches) == 0 {
+			continue
+		}
+
+		isNew, err := markWatchlistAlerted(db, item.ItemID)
+		if err != nil {
+			slog.Warn("Failed to record watchlist alert", "hn_id", item.ItemID, "error", err)
+			continue
+		}
+		if !isNew {
+			continue
+		}
+
+		slog.Info("Watchlist match", "title", item.Title, "hn_id", item.ItemID, "matches", matches)
+		sendNotification(notifiers, notification{
+			Event:   "watchlist",
+			Matches: matches,
+			Item:    toAPIItem(item, minPoints, categoryMapper),
+		})
+	}
+}
+
+// markWatchlistAlerted records that an item has been alerted on.
+// Returns false if it had already been alerted before.
+func markWatchlistAlerted(db *sql.DB, itemID string) (bool, error) {
+	result, err := execWithRetry(db, "INSERT INTO watchlist_alerts (item_hn_id) VALUES (?) ON CONFLICT DO NOTHING", itemID)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert watchlist alert: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check watchlist alert: %w", err)
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMatchWatchlist(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{Watchlist: []string{"hntop-rss", `\bsqlite\b`, "c++", ""}})
+
+	testCases := []struct {
+		name     string
+		title    string
+		url      string
+		expected []string
+	}{
+		{"keyword in title", "Show HN: HNTOP-RSS, a better feed", "https://example.com", []string{"hntop-rss"}},
+		{"keyword in url", "A feed generator", "https://github.com/lepinkainen/hntop-rss", []string{"hntop-rss"}},
+		{"regex word boundary", "SQLite is fast", "https://example.com", []string{`\bsqlite\b`}},
+		{"regex no match", "sqliteish things", "https://example.com", nil},
+		{"invalid regex matched literally", "Modern C++ tips", "https://example.com", []string{"c++"}},
+		{"no match", "Something else", "https://example.com", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches := mapper.MatchWatchlist(tc.title, tc.url)
+			if !slices.Equal(matches, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, matches)
+			}
+		})
+	}
+
+	var nilMapper *CategoryMapper
+	if nilMapper.MatchWatchlist("hntop-rss", "") != nil {
+		t.Error("Expected nil mapper to match nothing")
+	}
+}
+
+func TestCategorizeContent_Watchlist(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{Watchlist: []string{"rust"}})
+	categories := categorizeContent("Rewriting it in Rust", "example.com", "https://example.com", StoryTypeStory, mapper)
+	if !slices.Contains(categories, "Watchlist") {
+		t.Errorf("Expected Watchlist category, got %v", categories)
+	}
+}
+
+func TestAlertWatchlistMatches(t *testing.T) {
+	db := setupTestDB()
+	defer func() { _ = db.Close() }()
+
+	var mu sync.Mutex
+	var received []notification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	mapper := NewCategoryMapper(&DomainConfig{
+		Watchlist: []string{"hntop"},
+		Notifiers: []NotifierConfig{{Type: "webhook", URL: webhook.URL}},
+	})
+	items := []HackerNewsItem{
+		{ItemID: "1", Title: "hntop-rss released", Link: "https://example.com/a", Points: 5, CreatedAt: time.Now()},
+		{ItemID: "2", Title: "Unrelated story", Link: "https://example.com/b", Points: 500, CreatedAt: time.Now()},
+	}
+
+	alertWatchlistMatches(db, items, 50, mapper)
+	alertWatchlistMatches(db, items, 50, mapper)
+
+	if len(received) != 1 {
+		t.Fatalf("Expected exactly one notification, got %d", len(received))
+	}
+	n := received[0]
+	if n.Event != "watchlist" || n.Item.ID != "1" || !slices.Equal(n.Matches, []string{"hntop"}) {
+		t.Errorf("Unexpected notification: %+v", n)
+	}
+	if !slices.Contains(n.Item.Categories, "Watchlist") {
+		t.Errorf("Expected notified item to carry the Watchlist category, got %v", n.Item.Categories)
+	}
+}
+
+func TestHandleWatchlistFeed_IgnoresThreshold(t *testing.T) {
+	server := setupTestServer(t)
+	filter, _ := server.settings()
+	filter.MinPoints = 200
+	server.updateSettings(filter, NewCategoryMapper(&DomainConfig{Watchlist: []string{"blog", "tweet"}}))
+
+	rec := httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/watchlist.xml", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{"Hacker News Watchlist", "Blog Post", "A Tweet"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected watchlist feed to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "GitHub Project") {
+		t.Error("Expected non-matching item to be left out of the watchlist feed")
+	}
+
+	// The main feed still applies the threshold and has its own cache entry
+	rec = httptest.NewRecorder()
+	server.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.xml", nil))
+	if strings.Contains(rec.Body.String(), "Blog Post") || strings.Contains(rec.Body.String(), "Hacker News Watchlist") {
+		t.Error("Expected main feed to keep applying the points threshold")
+	}
+}
+
+func TestGetWatchlistItems_Limit(t *testing.T) {
+	server := setupTestServer(t)
+	mapper := NewCategoryMapper(&DomainConfig{Watchlist: []string{"."}})
+
+	items, err := getWatchlistItems(server.db, ItemFilter{Limit: 2, MinPoints: 1000}, mapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Errorf("Expected limit of 2 items, got %d", len(items))
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// webdavUploadTarget uploads output files to a WebDAV collection, such as a Nextcloud folder
+type webdavUploadTarget struct {
+	base     *url.URL // collection URL with an http or https scheme
+	username string
+	password string
+	client   *http.Client
+	created  map[string]bool // collections known to exist
+}
+
+// newWebDAVUploadTarget validates a webdav+https://host/path destination
+func newWebDAVUploadTarget(destination *url.URL, config UploadConfig) (*webdavUploadTarget, error) {
+	base := *destination
+	base.Scheme = strings.TrimPrefix(destination.Scheme, "webdav+")
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("destination %q should start with webdav+https:// or webdav+http://", config.Destination)
+	}
+	if base.Host == "" {
+		return nil, fmt.Errorf("destination %q has no host", config.Destination)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	base.RawPath = ""
+	base.User = nil
+	return &webdavUploadTarget{
+		base:     &base,
+		username: cmp.Or(config.Username, destination.User.Username()),
+		password: cmp.Or(config.Password, os.Getenv("HNTOP_WEBDAV_PASSWORD")),
+		client:   &http.Client{},
+		created:  make(map[string]bool),
+	}, nil
+}
+
+// request sends an authenticated request for a path relative to the collection
+func (w *webdavUploadTarget) request(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (int, error) {
+	target := *w.base
+	target.Path = w.base.Path + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Type", contentType)
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%s %s failed: %w", method, key, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// makeCollections creates the parent collections of key that aren't known to exist. WebDAV has
+// no recursive MKCOL, so each level is created in turn; 405 means the collection already exists.
+func (w *webdavUploadTarget) makeCollections(ctx context.Context, key string) error {
+	dir := path.Dir(key)
+	if dir == "." || w.created[dir] {
+		return nil
+	}
+	if err := w.makeCollections(ctx, dir); err != nil {
+		return err
+	}
+	status, err := w.request(ctx, "MKCOL", dir+"/", nil, 0, "")
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated && status != http.StatusMethodNotAllowed {
+		return fmt.Errorf("failed to create folder %s: status code %d", dir, status)
+	}
+	w.created[dir] = true
+	return nil
+}
+
+// upload PUTs the file into the collection, creating missing folders first
+func (w *webdavUploadTarget) upload(ctx context.Context, key, file, contentType, _ string) error {
+	if err := w.makeCollections(ctx, key); err != nil {
+		return err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	status, err := w.request(ctx, http.MethodPut, key, f, info.Size(), contentType)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("WebDAV server rejected the credentials")
+	default:
+		return fmt.Errorf("unexpected status code: %d", status)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWebDAV is an in-memory WebDAV server that, like real ones, refuses to PUT into missing folders
+type fakeWebDAV struct {
+	mu          sync.Mutex
+	files       map[string]string
+	contentType map[string]string
+	folders     map[string]bool
+	mkcols      int
+}
+
+func newFakeWebDAV() *fakeWebDAV {
+	return &fakeWebDAV{files: make(map[string]string), contentType: make(map[string]string), folders: map[string]bool{"/dav/files/me/hn": true}}
+}
+
+func (f *fakeWebDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, password, ok := r.BasicAuth(); !ok || user != "me" || password != "app-password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	name := strings.TrimSuffix(r.URL.Path, "/")
+	if !f.folders[path.Dir(name)] {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	switch r.Method {
+	case "MKCOL":
+		f.mkcols++
+		if f.folders[name] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		f.folders[name] = true
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.files[name] = string(body)
+		f.contentType[name] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestUploadOutput_WebDAV(t *testing.T) {
+	dav := newFakeWebDAV()
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	outDir := t.TempDir()
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed/>")
+	writeOutputFile(t, outDir, "archive/2026/10.xml", "<feed>old</feed>")
+	writeOutputFile(t, outDir, "archive/2026/09.xml", "<feed>older</feed>")
+	destination := strings.Replace(server.URL, "http://", "webdav+http://me@", 1) + "/dav/files/me/hn/"
+	config := UploadConfig{Destination: destination, Password: "app-password"}
+
+	if err := uploadOutput(outDir, config); err != nil {
+		t.Fatalf("uploadOutput failed: %v", err)
+	}
+	expected := map[string]string{
+		"/dav/files/me/hn/hackernews.xml":      "<feed/>",
+		"/dav/files/me/hn/archive/2026/10.xml": "<feed>old</feed>",
+		"/dav/files/me/hn/archive/2026/09.xml": "<feed>older</feed>",
+	}
+	for name, content := range expected {
+		if dav.files[name] != content {
+			t.Errorf("%s = %q, want %q", name, dav.files[name], content)
+		}
+	}
+	if dav.mkcols != 2 {
+		t.Errorf("Expected each missing folder to be created once, got %d MKCOL requests", dav.mkcols)
+	}
+	if got := dav.contentType["/dav/files/me/hn/hackernews.xml"]; got != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Unexpected content type %q", got)
+	}
+}
+
+func TestUploadOutput_WebDAVBadCredentials(t *testing.T) {
+	server := httptest.NewServer(newFakeWebDAV())
+	defer server.Close()
+
+	outDir := t.TempDir()
+	writeOutputFile(t, outDir, "hackernews.xml", "<feed/>")
+	config := UploadConfig{Destination: strings.Replace(server.URL, "http://", "webdav+http://", 1) + "/dav/files/me/hn", Username: "me", Password: "wrong"}
+	if err := uploadOutput(outDir, config); err == nil || !strings.Contains(err.Error(), "credentials") {
+		t.Errorf("Expected rejected credentials to be reported, got %v", err)
+	}
+}
+
+func TestNewWebDAVUploadTarget_Invalid(t *testing.T) {
+	for _, destination := range []string{"webdav+ftp://host/dir", "webdav+https:///dir"} {
+		if _, err := newUploadTarget(UploadConfig{Destination: destination}); err == nil {
+			t.Errorf("Expected %q to be rejected", destination)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/feeds"
+)
+
+// websubPingTimeout bounds how long a single hub may take to accept a publish ping
+const websubPingTimeout = 10 * time.Second
+
+// WebSubConfig lists the WebSub hubs readers can subscribe to for push updates of the main feed
+type WebSubConfig struct {
+	Hubs []string `json:"hubs"` // hub URLs, e.g. https://pubsubhubbub.appspot.com/
+}
+
+// websubLinks returns the feed-level hub links and the self link naming the topic readers subscribe to
+func websubLinks(hubs []string, topic string) []feeds.AtomLink {
+	var links []feeds.AtomLink
+	for _, hub := range hubs {
+		links = append(links, feeds.AtomLink{Href: hub, Rel: "hub"})
+	}
+	return append(links, feeds.AtomLink{Href: topic, Rel: "self", Type: "application/atom+xml"})
+}
+
+// isWebSubLink reports whether a feed-level link is a hub or self link, which only the topic document carries
+func isWebSubLink(link feeds.AtomLink) bool {
+	return link.Rel == "hub" || link.Rel == "self"
+}
+
+// pingWebSubHub tells a hub that the topic changed, so it fetches the feed and pushes it to subscribers
+func pingWebSubHub(ctx context.Context, client *http.Client, hub, topic string) error {
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to ping hub: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pingWebSubHubs pings every hub about the topic, logging failures
+func pingWebSubHubs(hubs []string, topic string) {
+	client := &http.Client{Timeout: websubPingTimeout}
+	for _, hub := range hubs {
+		ctx, cancel := context.WithTimeout(context.Background(), websubPingTimeout)
+		err := pingWebSubHub(ctx, client, hub, topic)
+		cancel()
+		if err != nil {
+			slog.Warn("Failed to ping WebSub hub", "hub", hub, "topic", topic, "error", err)
+			continue
+		}
+		slog.Info("Pinged WebSub hub", "hub", hub, "topic", topic)
+	}
+}
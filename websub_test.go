@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebSubLinks_InPagedFeed(t *testing.T) {
+	var items []HackerNewsItem
+	for i := range 3 {
+		id := fmt.Sprint(100 + i)
+		items = append(items, HackerNewsItem{ItemID: id, Title: "Story " + id, CommentsLink: "https://news.ycombinator.com/item?id=" + id, Points: 100, CreatedAt: time.Now()})
+	}
+	info := defaultFeedInfo
+	info.Links = websubLinks([]string{"https://hub.example.com/"}, "https://hn.example.com/hackernews.xml")
+
+	pages := generatePagedFeed(nil, "hackernews.xml", items, 50, nil, info, 2)
+	first := pagedFeedLinks(t, pages[0])
+	if first["hub"] != "https://hub.example.com/" || first["self"] != "https://hn.example.com/hackernews.xml" {
+		t.Errorf("Expected hub and topic self links on the first page, got %v", first)
+	}
+	if first["alternate"] != "https://news.ycombinator.com/" {
+		t.Errorf("Expected the front page link to become alternate, got %v", first)
+	}
+
+	second := pagedFeedLinks(t, pages[1])
+	if second["hub"] != "" || second["self"] == "https://hn.example.com/hackernews.xml" {
+		t.Errorf("Expected later pages not to claim the WebSub topic, got %v", second)
+	}
+}
+
+func TestPingWebSubHub(t *testing.T) {
+	var mode, topic string
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse ping: %v", err)
+		}
+		mode, topic = r.PostForm.Get("hub.mode"), r.PostForm.Get("hub.url")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hub.Close()
+
+	if err := pingWebSubHub(context.Background(), hub.Client(), hub.URL, "https://hn.example.com/hackernews.xml"); err != nil {
+		t.Fatalf("pingWebSubHub failed: %v", err)
+	}
+	if mode != "publish" || topic != "https://hn.example.com/hackernews.xml" {
+		t.Errorf("Unexpected ping: mode %q, url %q", mode, topic)
+	}
+}
+
+func TestPingWebSubHub_ErrorStatus(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad topic", http.StatusBadRequest)
+	}))
+	defer hub.Close()
+
+	if err := pingWebSubHub(context.Background(), hub.Client(), hub.URL, "https://hn.example.com/hackernews.xml"); err == nil {
+		t.Error("Expected an error for a rejected ping")
+	}
+}
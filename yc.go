@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// YCConfig configures tagging stories about Y Combinator companies
+type YCConfig struct {
+	Companies     []YCCompany `json:"companies"`
+	CompaniesFile string      `json:"companies_file"` // JSON file with more companies in the same format, e.g. generated from a YC directory export
+	TagBatch      bool        `json:"tag_batch"`      // also tag the batch, e.g. "YC W21"
+}
+
+// YCCompany is a Y Combinator company matched by its domains or by its name in titles
+type YCCompany struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+	Batch   string   `json:"batch"` // e.g. "W21"
+}
+
+// ycCompany is a compiled YCCompany
+type ycCompany struct {
+	name    string
+	batch   string
+	domains []string
+	title   *regexp.Regexp // whole-word, case-sensitive name match; company names are often common words
+}
+
+// loadYCCompanies returns the configured companies, including those from the companies file
+func loadYCCompanies(config YCConfig) ([]YCCompany, error) {
+	companies := config.Companies
+	if config.CompaniesFile == "" {
+		return companies, nil
+	}
+
+	data, err := os.ReadFile(config.CompaniesFile)
+	if err != nil {
+		return companies, fmt.Errorf("failed to read YC companies file: %w", err)
+	}
+	var fromFile []YCCompany
+	if err := json.Unmarshal(data, &fromFile); err != nil {
+		return companies, fmt.Errorf("failed to parse YC companies file: %w", err)
+	}
+	return append(companies, fromFile...), nil
+}
+
+// compileYCCompanies prepares companies for matching, skipping unnamed ones
+func compileYCCompanies(companies []YCCompany) []ycCompany {
+	var compiled []ycCompany
+	for _, company := range companies {
+		name := strings.TrimSpace(company.Name)
+		if name == "" {
+			continue
+		}
+		c := ycCompany{
+			name:  name,
+			batch: strings.ToUpper(strings.TrimSpace(company.Batch)),
+			title: regexp.MustCompile(`(^|[^\pL\pN])` + regexp.QuoteMeta(name) + `($|[^\pL\pN])`),
+		}
+		for _, domain := range company.Domains {
+			if domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www."); domain != "" {
+				c.domains = append(c.domains, domain)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled
+}
+
+// matches reports whether a story's domain or title refers to the company
+func (c ycCompany) matches(title, domain string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+	for _, d := range c.domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return c.title.MatchString(title)
+}
+
+// ycCategories returns the "YC Company" tag, and the batch tag if enabled, for a story about a YC company
+func (cm *CategoryMapper) ycCategories(title, domain string) []string {
+	if cm == nil {
+		return nil
+	}
+	for _, company := range cm.ycCompanies {
+		if !company.matches(title, domain) {
+			continue
+		}
+		slog.Debug("Matched YC company", "company", company.name, "title", title)
+		categories := []string{"YC Company"}
+		if cm.config.YC.TagBatch && company.batch != "" {
+			categories = append(categories, "YC "+company.batch)
+		}
+		return categories
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestYCCategories(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{YC: YCConfig{
+		TagBatch: true,
+		Companies: []YCCompany{
+			{Name: "Dropbox", Domains: []string{"dropbox.com"}, Batch: "s07"},
+			{Name: "Stripe", Domains: []string{"www.stripe.com"}},
+			{Name: ""},
+		},
+	}})
+
+	testCases := []struct {
+		name     string
+		title    string
+		domain   string
+		expected []string
+	}{
+		{"other domain", "Our new sync engine", "dropbox.tech", nil},
+		{"exact domain", "Our new sync engine", "www.dropbox.com", []string{"YC Company", "YC S07"}},
+		{"subdomain", "Engineering blog", "blog.dropbox.com", []string{"YC Company", "YC S07"}},
+		{"suffix is not a subdomain", "Something", "notdropbox.com", nil},
+		{"name in title", "Dropbox raises prices", "example.com", []string{"YC Company", "YC S07"}},
+		{"name in title without batch", "How Stripe ships", "example.com", []string{"YC Company"}},
+		{"domain without www", "Payments docs", "stripe.com", []string{"YC Company"}},
+		{"name inside a word", "Stripes and dots", "example.com", nil},
+		{"name is case-sensitive", "stripe patterns in CSS", "example.com", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			categories := mapper.ycCategories(tc.title, tc.domain)
+			if !slices.Equal(categories, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, categories)
+			}
+		})
+	}
+
+	var nilMapper *CategoryMapper
+	if categories := nilMapper.ycCategories("Dropbox", "dropbox.com"); categories != nil {
+		t.Errorf("Expected no categories from a nil mapper, got %v", categories)
+	}
+}
+
+func TestYCCategories_CompaniesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yc.json")
+	if err := os.WriteFile(path, []byte(`[{"name": "Airbnb", "domains": ["airbnb.com"], "batch": "W09"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mapper := NewCategoryMapper(&DomainConfig{YC: YCConfig{
+		CompaniesFile: path,
+		Companies:     []YCCompany{{Name: "Reddit"}},
+	}})
+
+	if categories := mapper.ycCategories("Airbnb IPO", ""); !slices.Equal(categories, []string{"YC Company"}) {
+		t.Errorf("Expected a company from the file without a batch tag, got %v", categories)
+	}
+	if categories := mapper.ycCategories("Reddit API changes", ""); !slices.Equal(categories, []string{"YC Company"}) {
+		t.Errorf("Expected inline companies alongside the file, got %v", categories)
+	}
+
+	// A missing file leaves the inline companies
+	mapper = NewCategoryMapper(&DomainConfig{YC: YCConfig{
+		CompaniesFile: filepath.Join(t.TempDir(), "missing.json"),
+		Companies:     []YCCompany{{Name: "Reddit"}},
+	}})
+	if categories := mapper.ycCategories("Reddit API changes", ""); len(categories) == 0 {
+		t.Error("Expected inline companies despite the missing file")
+	}
+}
+
+func TestCategorizeContent_YCCompany(t *testing.T) {
+	mapper := NewCategoryMapper(&DomainConfig{YC: YCConfig{Companies: []YCCompany{{Name: "Dropbox", Domains: []string{"dropbox.com"}}}}})
+
+	categories := categorizeContent("How we scaled", "dropbox.com", "https://dropbox.com/blog/scale", StoryTypeStory, mapper)
+	if !slices.Contains(categories, "YC Company") {
+		t.Errorf("Expected YC Company category, got %v", categories)
+	}
+}